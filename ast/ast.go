@@ -78,6 +78,7 @@ const (
 	OperatorExtendedAnd                        // and
 	OperatorExtendedOr                         // or
 	OperatorExtendedNot                        // not
+	OperatorTilde                              // ~
 )
 
 // String returns the string representation of the operator type.
@@ -86,7 +87,7 @@ func (op OperatorType) String() string {
 	// compiler.internalOperatorNotZero.
 	return []string{"==", "!=", "<", "<=", ">", ">=", "!", "&", "|", "&&", "||",
 		"+", "-", "*", "/", "%", "^", "&^", "<<", ">>", "contains", "not contains",
-		"<-", "&", "*", "and", "or", "not", "", ""}[op]
+		"<-", "&", "*", "and", "or", "not", "~", "", ""}[op]
 }
 
 // AssignmentType represents a type of assignment.
@@ -811,17 +812,19 @@ func NewForRange(pos *Position, assignment *Assignment, body []Node, els *Block)
 type Func struct {
 	expression
 	*Position
-	Ident    *Identifier // name, nil for function literals.
-	Type     *FuncType   // type.
-	Body     *Block      // body.
-	DistFree bool        // reports whether it is distraction free.
-	Upvars   []Upvar     // Upvars of func.
-	Format   Format      // macro format.
+	Recv       *Parameter  // receiver, nil if it is not a method.
+	Ident      *Identifier // name, nil for function literals.
+	TypeParams []*Field    // type parameters, nil if it is not generic.
+	Type       *FuncType   // type.
+	Body       *Block      // body.
+	DistFree   bool        // reports whether it is distraction free.
+	Upvars     []Upvar     // Upvars of func.
+	Format     Format      // macro format.
 }
 
 // NewFunc returns a new [Func] node.
 func NewFunc(pos *Position, name *Identifier, typ *FuncType, body *Block, distFree bool, format Format) *Func {
-	return &Func{expression{}, pos, name, typ, body, distFree, nil, format}
+	return &Func{expression{}, pos, nil, name, nil, typ, body, distFree, nil, format}
 }
 
 // String returns the string representation of n.
@@ -832,6 +835,9 @@ func (n *Func) String() string {
 	if n.Ident == nil {
 		return "func literal"
 	}
+	if n.Recv != nil {
+		return "method declaration"
+	}
 	return "func declaration"
 }
 
@@ -1003,20 +1009,68 @@ func (n *Index) String() string {
 	return n.Expr.String() + "[" + n.Index.String() + "]"
 }
 
+// IndexList node represents an expression followed by a list of two or more
+// indices, as in the instantiation of a generic function or type.
+type IndexList struct {
+	*expression
+	*Position              // position in the source.
+	Expr      Expression   // expression.
+	Indices   []Expression // indices.
+}
+
+// NewIndexList returns a new [IndexList] node.
+func NewIndexList(pos *Position, expr Expression, indices []Expression) *IndexList {
+	return &IndexList{&expression{}, pos, expr, indices}
+}
+
+// String returns the string representation of n.
+func (n *IndexList) String() string {
+	var s strings.Builder
+	s.WriteString(n.Expr.String())
+	s.WriteByte('[')
+	for i, index := range n.Indices {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(index.String())
+	}
+	s.WriteByte(']')
+	return s.String()
+}
+
 // Interface node represents an interface type.
 type Interface struct {
 	*expression
-	*Position // position in the source.
+	*Position          // position in the source.
+	Elements  []*Field // methods, embedded types and type unions.
 }
 
 // NewInterface returns a new [Interface] node.
 func NewInterface(pos *Position) *Interface {
-	return &Interface{&expression{}, pos}
+	return &Interface{&expression{}, pos, nil}
 }
 
 // String returns the string representation of n.
 func (n *Interface) String() string {
-	return "interface{}"
+	if len(n.Elements) == 0 {
+		return "interface{}"
+	}
+	var s strings.Builder
+	s.WriteString("interface{")
+	for i, elem := range n.Elements {
+		if i > 0 {
+			s.WriteString("; ")
+		}
+		if elem.Idents != nil {
+			// Method.
+			s.WriteString(elem.Idents[0].Name)
+			s.WriteString(strings.TrimPrefix(elem.Type.String(), "func"))
+			continue
+		}
+		s.WriteString(elem.String())
+	}
+	s.WriteString("}")
+	return s.String()
 }
 
 // KeyValue represents a key value pair in a slice, map or struct composite literal.
@@ -1437,13 +1491,14 @@ func (n *TypeAssertion) String() string {
 type TypeDeclaration struct {
 	*Position                      // position in the source.
 	Ident              *Identifier // identifier of the type.
+	TypeParams         []*Field    // type parameters, nil if it is not generic.
 	Type               Expression  // expression representing the type.
 	IsAliasDeclaration bool        // reports whether it is an alias declaration or a type definition.
 }
 
 // NewTypeDeclaration returns a new [TypeDeclaration] node.
 func NewTypeDeclaration(pos *Position, ident *Identifier, typ Expression, isAliasDeclaration bool) *TypeDeclaration {
-	return &TypeDeclaration{pos, ident, typ, isAliasDeclaration}
+	return &TypeDeclaration{pos, ident, nil, typ, isAliasDeclaration}
 }
 
 // String returns the string representation of n.
@@ -1451,6 +1506,16 @@ func (n *TypeDeclaration) String() string {
 	if n.IsAliasDeclaration {
 		return fmt.Sprintf("type %s = %s", n.Ident.Name, n.Type.String())
 	}
+	if n.TypeParams != nil {
+		var s strings.Builder
+		for i, param := range n.TypeParams {
+			if i > 0 {
+				s.WriteString(", ")
+			}
+			s.WriteString(param.String())
+		}
+		return fmt.Sprintf("type %s[%s] %s", n.Ident.Name, s.String(), n.Type.String())
+	}
 	return fmt.Sprintf("type %s %s", n.Ident.Name, n.Type.String())
 }
 
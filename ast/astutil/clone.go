@@ -21,13 +21,16 @@ func CloneNode(node ast.Node) ast.Node {
 	switch n := node.(type) {
 
 	case *ast.Assignment:
-		variables := make([]ast.Expression, len(n.Lhs))
-		for i, v := range n.Lhs {
-			variables[i] = CloneExpression(v)
+		var variables []ast.Expression
+		if n.Lhs != nil {
+			variables = make([]ast.Expression, len(n.Lhs))
+			for i, v := range n.Lhs {
+				variables[i] = CloneExpression(v)
+			}
 		}
 		values := make([]ast.Expression, len(n.Rhs))
 		for i, v := range n.Rhs {
-			variables[i] = CloneExpression(v)
+			values[i] = CloneExpression(v)
 		}
 		return ast.NewAssignment(ClonePosition(n.Position), variables, n.Type, values)
 
@@ -42,7 +45,10 @@ func CloneNode(node ast.Node) ast.Node {
 		return ast.NewBlock(ClonePosition(n.Position), nodes)
 
 	case *ast.Break:
-		label := CloneExpression(n.Label).(*ast.Identifier)
+		var label *ast.Identifier
+		if n.Label != nil {
+			label = CloneExpression(n.Label).(*ast.Identifier)
+		}
 		return ast.NewBreak(ClonePosition(n.Position), label)
 
 	case *ast.Case:
@@ -78,7 +84,10 @@ func CloneNode(node ast.Node) ast.Node {
 		return ast.NewConst(ClonePosition(n.Position), idents, typ, values, n.Index)
 
 	case *ast.Continue:
-		label := CloneExpression(n.Label).(*ast.Identifier)
+		var label *ast.Identifier
+		if n.Label != nil {
+			label = CloneExpression(n.Label).(*ast.Identifier)
+		}
 		return ast.NewContinue(ClonePosition(n.Position), label)
 
 	case *ast.Defer:
@@ -137,12 +146,7 @@ func CloneNode(node ast.Node) ast.Node {
 		return ast.NewForRange(ClonePosition(n.Position), assignment, body, els)
 
 	case *ast.Func:
-		var ident *ast.Identifier
-		if n.Ident != nil {
-			ident = ast.NewIdentifier(ClonePosition(n.Ident.Position), n.Ident.Name)
-		}
-		typ := CloneExpression(n.Type).(*ast.FuncType)
-		return ast.NewFunc(ClonePosition(n.Position), ident, typ, CloneNode(n.Body).(*ast.Block), n.DistFree, n.Format)
+		return CloneExpression(n)
 
 	case *ast.Go:
 		return ast.NewGo(ClonePosition(n.Position), CloneExpression(n.Call))
@@ -184,7 +188,21 @@ func CloneNode(node ast.Node) ast.Node {
 		return imp
 
 	case *ast.Label:
-		return ast.NewLabel(ClonePosition(n.Position), CloneExpression(n.Ident).(*ast.Identifier), CloneNode(n.Statement))
+		var statement ast.Node
+		if n.Statement != nil {
+			statement = CloneNode(n.Statement)
+		}
+		return ast.NewLabel(ClonePosition(n.Position), CloneExpression(n.Ident).(*ast.Identifier), statement)
+
+	case *ast.Return:
+		var values []ast.Expression
+		if n.Values != nil {
+			values = make([]ast.Expression, len(n.Values))
+			for i, v := range n.Values {
+				values[i] = CloneExpression(v)
+			}
+		}
+		return ast.NewReturn(ClonePosition(n.Position), values)
 
 	case *ast.Package:
 		var nn = make([]ast.Node, 0, len(n.Declarations))
@@ -194,7 +212,11 @@ func CloneNode(node ast.Node) ast.Node {
 		return ast.NewPackage(ClonePosition(n.Position), n.Name, nn)
 
 	case *ast.Raw:
-		return ast.NewRaw(ClonePosition(n.Position), n.Marker, n.Tag, CloneNode(n.Text).(*ast.Text))
+		var text *ast.Text
+		if n.Text != nil {
+			text = CloneNode(n.Text).(*ast.Text)
+		}
+		return ast.NewRaw(ClonePosition(n.Position), n.Marker, n.Tag, text)
 
 	case *ast.Select:
 		var text *ast.Text
@@ -245,25 +267,7 @@ func CloneNode(node ast.Node) ast.Node {
 		return ast.NewStatements(ClonePosition(n.Position), nodes)
 
 	case *ast.StructType:
-		var fields []*ast.Field
-		if n.Fields != nil {
-			fields = make([]*ast.Field, len(n.Fields))
-			for i, field := range n.Fields {
-				var idents []*ast.Identifier
-				if field.Idents != nil {
-					idents = make([]*ast.Identifier, len(field.Idents))
-					for j, ident := range field.Idents {
-						idents[j] = CloneExpression(ident).(*ast.Identifier)
-					}
-				}
-				var typ ast.Expression
-				if field.Type != nil {
-					typ = CloneExpression(field.Type)
-				}
-				fields[i] = ast.NewField(idents, typ, field.Tag)
-			}
-		}
-		return ast.NewStructType(ClonePosition(n.Position), fields)
+		return ast.NewStructType(ClonePosition(n.Position), cloneFields(n.Fields))
 
 	case *ast.Switch:
 		var init ast.Node
@@ -291,6 +295,12 @@ func CloneNode(node ast.Node) ast.Node {
 		}
 		return ast.NewText(ClonePosition(n.Position), text, n.Cut)
 
+	case *ast.TypeDeclaration:
+		ident := CloneExpression(n.Ident).(*ast.Identifier)
+		td := ast.NewTypeDeclaration(ClonePosition(n.Position), ident, CloneExpression(n.Type), n.IsAliasDeclaration)
+		td.TypeParams = cloneFields(n.TypeParams)
+		return td
+
 	case *ast.TypeSwitch:
 		var init ast.Node
 		if n.Init != nil {
@@ -400,7 +410,20 @@ func CloneExpression(expr ast.Expression) ast.Expression {
 			ident = ast.NewIdentifier(ClonePosition(e.Ident.Position), e.Ident.Name)
 		}
 		typ := CloneExpression(e.Type).(*ast.FuncType)
-		expr2 = ast.NewFunc(ClonePosition(e.Position), ident, typ, CloneNode(e.Body).(*ast.Block), false, e.Format)
+		var body *ast.Block
+		if e.Body != nil {
+			body = CloneNode(e.Body).(*ast.Block)
+		}
+		fn := ast.NewFunc(ClonePosition(e.Position), ident, typ, body, e.DistFree, e.Format)
+		if e.Recv != nil {
+			var ident *ast.Identifier
+			if e.Recv.Ident != nil {
+				ident = ast.NewIdentifier(ClonePosition(e.Recv.Ident.Position), e.Recv.Ident.Name)
+			}
+			fn.Recv = ast.NewParameter(ident, CloneExpression(e.Recv.Type))
+		}
+		fn.TypeParams = cloneFields(e.TypeParams)
+		expr2 = fn
 
 	case *ast.FuncType:
 		var parameters []*ast.Parameter
@@ -433,8 +456,17 @@ func CloneExpression(expr ast.Expression) ast.Expression {
 	case *ast.Index:
 		expr2 = ast.NewIndex(ClonePosition(e.Position), CloneExpression(e.Expr), CloneExpression(e.Index))
 
+	case *ast.IndexList:
+		indices := make([]ast.Expression, len(e.Indices))
+		for i, index := range e.Indices {
+			indices[i] = CloneExpression(index)
+		}
+		expr2 = ast.NewIndexList(ClonePosition(e.Position), CloneExpression(e.Expr), indices)
+
 	case *ast.Interface:
-		expr2 = ast.NewInterface(ClonePosition(e.Pos()))
+		n := ast.NewInterface(ClonePosition(e.Pos()))
+		n.Elements = cloneFields(e.Elements)
+		expr2 = n
 
 	case *ast.MapType:
 		expr2 = ast.NewMapType(ClonePosition(e.Pos()), CloneExpression(e.KeyType), CloneExpression(e.ValueType))
@@ -452,6 +484,9 @@ func CloneExpression(expr ast.Expression) ast.Expression {
 	case *ast.SliceType:
 		expr2 = ast.NewSliceType(ClonePosition(e.Pos()), CloneExpression(e.ElementType))
 
+	case *ast.StructType:
+		expr2 = ast.NewStructType(ClonePosition(e.Position), cloneFields(e.Fields))
+
 	case *ast.Slicing:
 		expr2 = ast.NewSlicing(ClonePosition(e.Position), CloneExpression(e.Expr), CloneExpression(e.Low),
 			CloneExpression(e.High), CloneExpression(e.Max), e.IsFull)
@@ -473,5 +508,27 @@ func CloneExpression(expr ast.Expression) ast.Expression {
 
 // ClonePosition returns a copy of position pos.
 func ClonePosition(pos *ast.Position) *ast.Position {
+	if pos == nil {
+		return nil
+	}
 	return &ast.Position{Line: pos.Line, Column: pos.Column, Start: pos.Start, End: pos.End}
 }
+
+// cloneFields returns a copy of fields.
+func cloneFields(fields []*ast.Field) []*ast.Field {
+	if fields == nil {
+		return nil
+	}
+	clone := make([]*ast.Field, len(fields))
+	for i, field := range fields {
+		var idents []*ast.Identifier
+		if field.Idents != nil {
+			idents = make([]*ast.Identifier, len(field.Idents))
+			for j, ident := range field.Idents {
+				idents[j] = CloneExpression(ident).(*ast.Identifier)
+			}
+		}
+		clone[i] = ast.NewField(idents, CloneExpression(field.Type), field.Tag)
+	}
+	return clone
+}
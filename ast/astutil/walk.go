@@ -177,10 +177,21 @@ func Walk(v Visitor, node ast.Node) {
 			Walk(v, n.Else)
 		}
 
+	case *ast.Interface:
+		for _, elem := range n.Elements {
+			Walk(v, elem.Type)
+		}
+
 	case *ast.Index:
 		Walk(v, n.Expr)
 		Walk(v, n.Index)
 
+	case *ast.IndexList:
+		Walk(v, n.Expr)
+		for _, index := range n.Indices {
+			Walk(v, index)
+		}
+
 	case *ast.Label:
 		Walk(v, n.Ident)
 		Walk(v, n.Statement)
@@ -242,6 +253,11 @@ func Walk(v Visitor, node ast.Node) {
 			Walk(v, child)
 		}
 
+	case *ast.StructType:
+		for _, field := range n.Fields {
+			Walk(v, field.Type)
+		}
+
 	case *ast.Switch:
 		Walk(v, n.Init)
 		Walk(v, n.Expr)
@@ -256,6 +272,10 @@ func Walk(v Visitor, node ast.Node) {
 
 	case *ast.TypeAssertion:
 		Walk(v, n.Expr)
+		Walk(v, n.Type)
+
+	case *ast.TypeDeclaration:
+		Walk(v, n.Type)
 
 	case *ast.TypeSwitch:
 		Walk(v, n.Init)
@@ -272,6 +292,13 @@ func Walk(v Visitor, node ast.Node) {
 	case *ast.UnaryOperator:
 		Walk(v, n.Expr)
 
+	case *ast.Using:
+		Walk(v, n.Statement)
+		Walk(v, n.Type)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
 	case *ast.Var:
 		for _, ident := range n.Lhs {
 			Walk(v, ident)
@@ -293,7 +320,6 @@ func Walk(v Visitor, node ast.Node) {
 		*ast.Text,
 		*ast.Raw,
 		*ast.Placeholder,
-		*ast.Interface,
 		*ast.Fallthrough:
 		// Nothing to do
 
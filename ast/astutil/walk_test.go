@@ -59,6 +59,7 @@ func TestWalk(t *testing.T) {
 		{`{% x := (getStruct()).field %}`, []int{0, 3, 3, 8, 8}},
 		{`{% x := -5.189 %}`, []int{0, 3, 3, 8, 9}},
 		{`{% x := vect[3:54] %}`, []int{0, 3, 3, 8, 8, 13, 15}},
+		{`{% show itea; using %}ab{% end using %}`, []int{0, 14, 3, 8, 0, 22}},
 	}
 
 	for _, c := range stringCases {
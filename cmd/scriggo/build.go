@@ -0,0 +1,476 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/native"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// siteFlags are the flags of the build command.
+type siteFlags struct {
+	consts   []string // global constants, as passed to the "-const" option
+	partials []string // patterns of the partial files
+	o        string   // output directory
+	j        int      // maximum number of pages rendered in parallel
+	force    bool     // rebuild all the pages and copy all the assets
+	v        bool     // print the names of the written files
+}
+
+// siteManifestName is the name of the manifest file in the output directory.
+const siteManifestName = ".scriggo-build.json"
+
+// siteManifest is the manifest of a build, written in the output directory
+// and read by the next build to rebuild only what has changed.
+type siteManifest struct {
+	Consts []string            // global constants of the build
+	Files  map[string]fileStat // files of the root directory
+	Pages  map[string][]string // built pages and the files they depend on
+	Assets []string            // copied assets
+}
+
+// fileStat is the size and the modification time of a file.
+type fileStat struct {
+	Size    int64
+	ModTime int64 // modification time in nanoseconds since the Unix epoch
+}
+
+// buildSite executes the sub command "build":
+//
+//	scriggo build
+//
+// It renders the pages in the directory root, and in its subdirectories, and
+// copies the assets, writing them to the output directory and the names of
+// the written files to out if flags.v is true.
+func buildSite(root string, flags siteFlags, out io.Writer) error {
+
+	for _, pattern := range flags.partials {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid -partial pattern %q", pattern)
+		}
+	}
+
+	if flags.o == "" {
+		flags.o = "public"
+	}
+	if flags.j <= 0 {
+		flags.j = runtime.NumCPU()
+	}
+
+	// Read the files of the root directory.
+	outAbs, err := filepath.Abs(flags.o)
+	if err != nil {
+		return err
+	}
+	files, err := siteFiles(root, outAbs)
+	if err != nil {
+		return err
+	}
+	pages, assets := sitePages(files, flags.partials)
+
+	// Read the manifest of the previous build and determine the changed files.
+	manifest := readSiteManifest(flags.o)
+	if flags.force || manifest == nil || !slices.Equal(manifest.Consts, flags.consts) {
+		manifest = &siteManifest{}
+	}
+	changed := map[string]bool{}
+	for name, st := range files {
+		if prev, ok := manifest.Files[name]; !ok || prev != st {
+			changed[name] = true
+		}
+	}
+	for name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			changed[name] = true
+		}
+	}
+
+	// dependents maps a file to the pages that depend on it.
+	dependents := map[string]map[string]struct{}{}
+	for page, dependencies := range manifest.Pages {
+		for _, dependency := range dependencies {
+			if _, ok := dependents[dependency]; !ok {
+				dependents[dependency] = map[string]struct{}{}
+			}
+			dependents[dependency][page] = struct{}{}
+		}
+	}
+
+	// Remove the outputs of the pages and assets that no longer exist.
+	for page := range manifest.Pages {
+		if !slices.Contains(pages, page) {
+			err = removeOutput(flags.o, pageOutput(page))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, asset := range manifest.Assets {
+		if !slices.Contains(assets, asset) {
+			err = removeOutput(flags.o, asset)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Determine the pages to render. A page is rendered again if one of the
+	// files it depends on has changed.
+	var render []string
+	built := map[string][]string{}
+	for _, page := range pages {
+		dependencies, ok := manifest.Pages[page]
+		if ok && outputExists(flags.o, pageOutput(page)) {
+			for name := range changed {
+				if _, ok2 := dependents[name][page]; ok2 {
+					ok = false
+					break
+				}
+			}
+		} else {
+			ok = false
+		}
+		if ok {
+			built[page] = dependencies
+		} else {
+			render = append(render, page)
+		}
+	}
+
+	// Copy the assets.
+	for _, asset := range assets {
+		if !changed[asset] && slices.Contains(manifest.Assets, asset) && outputExists(flags.o, asset) {
+			continue
+		}
+		err = copyAsset(root, flags.o, asset)
+		if err != nil {
+			return err
+		}
+		if flags.v {
+			_, _ = fmt.Fprintln(out, asset)
+		}
+	}
+
+	// Render the pages in parallel.
+	b, err := newSiteBuilder(root, flags)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var errs []error
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < flags.j && i < len(render); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range queue {
+				dependencies, err := b.render(page)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					built[page] = dependencies
+					if flags.v {
+						_, _ = fmt.Fprintln(out, pageOutput(page))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, page := range render {
+		queue <- page
+	}
+	close(queue)
+	wg.Wait()
+
+	// Write the manifest. The pages that could not be built are not in the
+	// manifest, so they are rendered again by the next build.
+	manifest = &siteManifest{
+		Consts: flags.consts,
+		Files:  files,
+		Pages:  built,
+		Assets: assets,
+	}
+	err = writeSiteManifest(flags.o, manifest)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		var msg strings.Builder
+		for _, err := range errs {
+			msg.WriteString(err.Error())
+			msg.WriteByte('\n')
+		}
+		_, _ = fmt.Fprintf(&msg, "%d of %d pages could not be built", len(errs), len(render))
+		return errors.New(msg.String())
+	}
+
+	return nil
+}
+
+// siteFiles returns the files in the directory root, and in its
+// subdirectories, with their stats. It ignores the files and the directories
+// whose names start with a period and the output directory, with absolute
+// path outAbs.
+func siteFiles(root, outAbs string) (map[string]fileStat, error) {
+	files := map[string]fileStat{}
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			abs, err := filepath.Abs(name)
+			if err != nil {
+				return err
+			}
+			if abs == outAbs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = fileStat{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		return nil
+	})
+	return files, err
+}
+
+// sitePages returns, sorted by name, the pages to render and the assets to
+// copy among files.
+//
+// The pages are the files with extension ".html" and ".md", excluding a
+// Markdown file if there is also an HTML file with the same name, as the
+// serve command does. The other files are assets.
+//
+// The partial files, the tests, with their fixture and golden files, are
+// neither pages nor assets. A file is partial if its name, or the name of
+// one of its directories, starts with an underscore, or if its path, or the
+// path of one of its directories, matches one of the given patterns. A pattern
+// without a slash is matched against the names instead of the paths.
+func sitePages(files map[string]fileStat, partials []string) (pages, assets []string) {
+	names := scriggo.Files{}
+	for name := range files {
+		names[name] = nil
+	}
+	excluded := map[string]bool{}
+	for _, test := range templateTests(names) {
+		excluded[test.name] = true
+		excluded[test.name+".golden"] = true
+		if test.fixture != "" {
+			excluded[test.fixture] = true
+		}
+	}
+	for name := range files {
+		if excluded[name] || isPartial(name, partials) {
+			continue
+		}
+		switch ext := path.Ext(name); ext {
+		case ".html":
+			pages = append(pages, name)
+		case ".md":
+			if _, ok := files[strings.TrimSuffix(name, ext)+".html"]; !ok {
+				pages = append(pages, name)
+			}
+		default:
+			assets = append(assets, name)
+		}
+	}
+	sort.Strings(pages)
+	sort.Strings(assets)
+	return pages, assets
+}
+
+// isPartial reports whether the file with the given name is a partial file.
+func isPartial(name string, patterns []string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+	for _, pattern := range patterns {
+		for p := name; p != "."; p = path.Dir(p) {
+			elem := p
+			if !strings.Contains(pattern, "/") {
+				elem = path.Base(p)
+			}
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pageOutput returns the name of the output file of a page.
+func pageOutput(page string) string {
+	if ext := path.Ext(page); ext == ".md" {
+		return strings.TrimSuffix(page, ext) + ".html"
+	}
+	return page
+}
+
+// siteBuilder renders the pages of a site.
+type siteBuilder struct {
+	out       string
+	templates *scriggo.TemplateSet
+}
+
+// newSiteBuilder returns a site builder that renders the pages in the
+// directory root.
+func newSiteBuilder(root string, flags siteFlags) (*siteBuilder, error) {
+	md := goldmark.New(
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithExtensions(extension.Footnote))
+	mdConverter := func(src []byte, out io.Writer) error {
+		return md.Convert(src, out)
+	}
+	decls := make(native.Declarations, len(globals))
+	for n, v := range globals {
+		decls[n] = v
+	}
+	for _, c := range flags.consts {
+		err := parseConstants(c, decls)
+		if err != nil {
+			return nil, err
+		}
+	}
+	templates := scriggo.NewTemplateSet(os.DirFS(root), func(name string) *scriggo.BuildOptions {
+		opts := scriggo.BuildOptions{
+			AllowGoStmt:       true,
+			MarkdownConverter: mdConverter,
+			Globals:           make(native.Declarations, len(decls)+1),
+			FrontMatter:       frontMatter,
+		}
+		for n, v := range decls {
+			opts.Globals[n] = v
+		}
+		opts.Globals["filepath"] = strings.TrimSuffix(name, path.Ext(name))
+		return &opts
+	})
+	return &siteBuilder{out: flags.o, templates: templates}, nil
+}
+
+// render renders the page and writes its output file. It returns the names
+// of the files the page depends on. It can be called concurrently.
+func (b *siteBuilder) render(page string) ([]string, error) {
+	template, err := b.templates.Get(page)
+	if err != nil {
+		return nil, buildError(err)
+	}
+	var buf bytes.Buffer
+	err = template.Run(&buf, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", page, err)
+	}
+	err = writeOutput(b.out, pageOutput(page), buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return b.templates.Dependencies(page), nil
+}
+
+// copyAsset copies the asset with the given name from the directory root to
+// the output directory out.
+func copyAsset(root, out, name string) error {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	return writeOutput(out, name, data)
+}
+
+// writeOutput writes data to the file with the given name in the output
+// directory out, creating its directory if it does not exist.
+func writeOutput(out, name string, data []byte) error {
+	name = filepath.Join(out, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(name), 0777)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0666)
+}
+
+// outputExists reports whether the file with the given name exists in the
+// output directory out.
+func outputExists(out, name string) bool {
+	_, err := os.Stat(filepath.Join(out, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// removeOutput removes the file with the given name from the output
+// directory out. It does nothing if the file does not exist.
+func removeOutput(out, name string) error {
+	err := os.Remove(filepath.Join(out, filepath.FromSlash(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// readSiteManifest reads the manifest in the output directory out. It
+// returns nil if the manifest does not exist or cannot be read.
+func readSiteManifest(out string) *siteManifest {
+	data, err := os.ReadFile(filepath.Join(out, siteManifestName))
+	if err != nil {
+		return nil
+	}
+	var manifest siteManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil
+	}
+	return &manifest
+}
+
+// writeSiteManifest writes the manifest to the output directory out.
+func writeSiteManifest(out string, manifest *siteManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeOutput(out, siteManifestName, data)
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSitePages(t *testing.T) {
+	files := map[string]fileStat{
+		"index.html":            {},
+		"about.md":              {},
+		"news.md":               {},
+		"news.html":             {},
+		"style.css":             {},
+		"_layout.html":          {},
+		"_partials/header.md":   {},
+		"layouts/base.html":     {},
+		"blog/post.md":          {},
+		"blog/post.inc.html":    {},
+		"home_test.html":        {},
+		"home_test.json":        {},
+		"home_test.html.golden": {},
+	}
+	pages, assets := sitePages(files, []string{"layouts", "*.inc.html"})
+	expectedPages := []string{"about.md", "blog/post.md", "index.html", "news.html"}
+	if !reflect.DeepEqual(pages, expectedPages) {
+		t.Fatalf("expected pages %v, got %v", expectedPages, pages)
+	}
+	expectedAssets := []string{"style.css"}
+	if !reflect.DeepEqual(assets, expectedAssets) {
+		t.Fatalf("expected assets %v, got %v", expectedAssets, assets)
+	}
+}
+
+func TestBuildSite(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "public")
+	write := func(name, src string) {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(name), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(name, []byte(src), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"_layout.html":    "<title>{{ Title() }}</title>{{ Body() }}",
+		"index.html":      "---\ntitle: Home\n---\n" + `{% extends "_layout.html" %}{% macro Title %}{{ frontMatter.title }}{% end %}{% macro Body %}{{ lang }} {{ filepath }}{% end %}`,
+		"blog/post.md":    "# Post",
+		"blog/about.html": `{% import "/_macros.html" %}{{ Hello() }}`,
+		"_macros.html":    "{% macro Hello %}hello{% end %}",
+		"css/style.css":   "body { color: red }",
+	}
+	for name, src := range files {
+		write(name, src)
+	}
+	build := func(flags siteFlags) []string {
+		t.Helper()
+		flags.o = out
+		flags.consts = []string{`lang="en"`}
+		flags.v = true
+		var b bytes.Buffer
+		err := buildSite(root, flags, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		written := strings.Fields(b.String())
+		sort.Strings(written)
+		return written
+	}
+	check := func(expected map[string]string) {
+		t.Helper()
+		for name, content := range expected {
+			got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != content {
+				t.Fatalf("expected %s content %q, got %q", name, content, got)
+			}
+		}
+	}
+
+	// Build the site.
+	written := build(siteFlags{j: 2})
+	expected := []string{"blog/about.html", "blog/post.html", "css/style.css", "index.html"}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("expected written files %v, got %v", expected, written)
+	}
+	check(map[string]string{
+		"index.html":      "<title>Home</title>en index",
+		"blog/post.html":  "# Post",
+		"blog/about.html": "hello",
+		"css/style.css":   "body { color: red }",
+	})
+	for _, name := range []string{"_layout.html", "_macros.html", "public/index.html"} {
+		if outputExists(out, name) {
+			t.Fatalf("unexpected output file %s", name)
+		}
+	}
+
+	// Rebuild with no changes.
+	written = build(siteFlags{})
+	if len(written) > 0 {
+		t.Fatalf("expected no written files, got %v", written)
+	}
+
+	// Rebuild after a change to the layout and the removal of an asset.
+	write("_layout.html", "<h1>{{ Title() }}</h1>{{ Body() }}")
+	err := os.Remove(filepath.Join(root, "css", "style.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	written = build(siteFlags{})
+	expected = []string{"index.html"}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("expected written files %v, got %v", expected, written)
+	}
+	check(map[string]string{"index.html": "<h1>Home</h1>en index"})
+	if outputExists(out, "css/style.css") {
+		t.Fatal("expected css/style.css to be removed")
+	}
+
+	// Force the rebuild.
+	written = build(siteFlags{force: true})
+	expected = []string{"blog/about.html", "blog/post.html", "index.html"}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("expected written files %v, got %v", expected, written)
+	}
+
+	// Build with an error.
+	write("blog/about.html", "{{ undefined }}")
+	err = buildSite(root, siteFlags{o: out, consts: []string{`lang="en"`}}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	if !strings.HasSuffix(err.Error(), "1 of 1 pages could not be built") {
+		t.Fatalf("unexpected error %q", err)
+	}
+}
@@ -0,0 +1,814 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/open2b/scriggo"
+
+	"golang.org/x/mod/modfile"
+)
+
+// maxDebugChildren is the maximum number of elements of a slice, array or map
+// returned by the debug adapter when a variable is expanded.
+const maxDebugChildren = 1000
+
+// debugServe executes the sub command "debug":
+//
+//	scriggo debug
+//
+// It runs a debug adapter that speaks the Debug Adapter Protocol. If addr is
+// empty, it serves a single session on the standard input and output,
+// otherwise it listens on the TCP address addr and serves the sessions of
+// the accepted connections, one at a time.
+func debugServe(addr string) error {
+	if addr == "" {
+		return newDebugAdapter(os.Stdin, os.Stdout).serve()
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	_, _ = fmt.Fprintf(os.Stderr, "Debug adapter is listening at %s\n", ln.Addr())
+	_, _ = fmt.Fprintf(os.Stderr, "Press Ctrl+C to stop\n\n")
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		err = newDebugAdapter(conn, conn).serve()
+		_ = conn.Close()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "scriggo debug: %s\n", err)
+		}
+	}
+}
+
+// dapRequest is a request of the Debug Adapter Protocol.
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// dapResponse is a response of the Debug Adapter Protocol.
+type dapResponse struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// dapEvent is an event of the Debug Adapter Protocol.
+type dapEvent struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// dapBody is the body of a response or an event.
+type dapBody map[string]interface{}
+
+// dapLaunchArguments are the arguments of the launch request.
+type dapLaunchArguments struct {
+	Program     string   `json:"program"`     // path of the template or program file.
+	Root        string   `json:"root"`        // root directory of a template.
+	Format      string   `json:"format"`      // format of a template.
+	Consts      []string `json:"consts"`      // global constants of a template.
+	StopOnEntry bool     `json:"stopOnEntry"` // stop at the first statement.
+	NoDebug     bool     `json:"noDebug"`     // run without debugging.
+}
+
+// dapSource is a source of the Debug Adapter Protocol.
+type dapSource struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// dapStackFrame is a stack frame of the Debug Adapter Protocol.
+type dapStackFrame struct {
+	ID     int        `json:"id"`
+	Name   string     `json:"name"`
+	Source *dapSource `json:"source,omitempty"`
+	Line   int        `json:"line"`
+	Column int        `json:"column"`
+}
+
+// dapVariable is a variable of the Debug Adapter Protocol.
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// debugAdapter is a debug adapter that debugs a template or a program,
+// speaking the Debug Adapter Protocol.
+type debugAdapter struct {
+	r *bufio.Reader
+
+	// wmu guards w and seq.
+	wmu sync.Mutex
+	w   io.Writer
+	seq int
+
+	debugger *scriggo.Debugger
+	paths    *debugPaths
+	run      func(options *scriggo.RunOptions) error // set by the launch request.
+	noDebug  bool
+	entry    bool // reports whether the execution must stop at the first statement.
+	started  bool
+	cancel   context.CancelFunc
+	done     chan struct{} // closed when the execution is terminated.
+	resume   chan scriggo.DebugStep
+
+	// mu guards stop and refs.
+	mu   sync.Mutex
+	stop *scriggo.DebugStop // current stop, nil if the execution is not stopped.
+	refs []interface{}      // variable containers of the current stop.
+}
+
+// newDebugAdapter returns a new debug adapter that reads the messages from r
+// and writes them to w.
+func newDebugAdapter(r io.Reader, w io.Writer) *debugAdapter {
+	a := &debugAdapter{
+		r:      bufio.NewReader(r),
+		w:      w,
+		done:   make(chan struct{}),
+		resume: make(chan scriggo.DebugStep),
+	}
+	a.debugger = scriggo.NewDebugger(a.stopped)
+	return a
+}
+
+// serve serves a session and returns when the session is terminated by a
+// disconnect request or the input is closed.
+func (a *debugAdapter) serve() error {
+	defer a.terminate()
+	for {
+		req, err := a.read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if req.Command == "disconnect" {
+			a.terminate()
+			a.respond(req, nil)
+			return nil
+		}
+		body, err := a.handle(req)
+		if err != nil {
+			a.respondError(req, err)
+			continue
+		}
+		a.respond(req, body)
+		if req.Command == "initialize" {
+			a.event("initialized", nil)
+		}
+	}
+}
+
+// handle handles the request req and returns the body of the response.
+func (a *debugAdapter) handle(req *dapRequest) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return dapBody{
+			"supportsConfigurationDoneRequest": true,
+			"supportsTerminateRequest":         true,
+			"supportsEvaluateForHovers":        true,
+		}, nil
+	case "launch":
+		var args dapLaunchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, a.launch(args)
+	case "setBreakpoints":
+		var args struct {
+			Source      dapSource `json:"source"`
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return a.setBreakpoints(args.Source.Path, args.Breakpoints)
+	case "setExceptionBreakpoints", "setFunctionBreakpoints":
+		return dapBody{"breakpoints": []interface{}{}}, nil
+	case "configurationDone":
+		return nil, a.start()
+	case "threads":
+		return dapBody{"threads": []dapBody{{"id": 1, "name": "main"}}}, nil
+	case "stackTrace":
+		return a.stackTrace()
+	case "scopes":
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return a.scopes(args.FrameID)
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return a.variables(args.VariablesReference)
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+			FrameID    int    `json:"frameId"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return a.evaluate(args.Expression, args.FrameID)
+	case "continue":
+		a.step(scriggo.DebugContinue)
+		return dapBody{"allThreadsContinued": true}, nil
+	case "next":
+		a.step(scriggo.DebugStepOver)
+		return nil, nil
+	case "stepIn":
+		a.step(scriggo.DebugStepInto)
+		return nil, nil
+	case "stepOut":
+		a.step(scriggo.DebugStepOut)
+		return nil, nil
+	case "pause":
+		a.debugger.Pause()
+		return nil, nil
+	case "terminate":
+		a.terminate()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request %q", req.Command)
+}
+
+// launch handles the launch request building the template or the program.
+func (a *debugAdapter) launch(args dapLaunchArguments) error {
+	if a.run != nil {
+		return errors.New("already launched")
+	}
+	if args.Program == "" {
+		return errors.New("missing program")
+	}
+	name, err := filepath.Abs(args.Program)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(name) == ".go" {
+		err = a.buildProgram(name)
+	} else {
+		err = a.buildTemplate(name, args)
+	}
+	if err != nil {
+		return err
+	}
+	a.noDebug = args.NoDebug
+	a.entry = args.StopOnEntry && !args.NoDebug
+	if a.entry {
+		a.debugger.Pause()
+	}
+	return nil
+}
+
+// buildTemplate builds the template file with the given name.
+func (a *debugAdapter) buildTemplate(name string, args dapLaunchArguments) error {
+	root := args.Root
+	if root == "" {
+		root = filepath.Dir(name)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	fsys, name, err := runFS(name, root, args.Format)
+	if err != nil {
+		return err
+	}
+	opts, err := templateBuildOptions(name, args.Consts)
+	if err != nil {
+		return err
+	}
+	template, err := scriggo.BuildTemplate(fsys, name, opts)
+	if err != nil {
+		return err
+	}
+	a.paths = &debugPaths{root: root}
+	a.run = func(options *scriggo.RunOptions) error {
+		return template.Run(dapOutput{a, "stdout"}, nil, options)
+	}
+	return nil
+}
+
+// buildProgram builds the program whose main package is in the file with the
+// given name.
+func (a *debugAdapter) buildProgram(name string) error {
+	dir := filepath.Dir(name)
+	program, err := scriggo.Build(os.DirFS(dir), &scriggo.BuildOptions{AllowGoStmt: true})
+	if err != nil {
+		return err
+	}
+	a.paths = &debugPaths{root: dir, main: name}
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		a.paths.module = modfile.ModulePath(data)
+	}
+	a.run = program.Run
+	return nil
+}
+
+// setBreakpoints handles the setBreakpoints request.
+func (a *debugAdapter) setBreakpoints(path string, breakpoints []struct {
+	Line int `json:"line"`
+}) (interface{}, error) {
+	if a.paths == nil {
+		return nil, errors.New("not launched")
+	}
+	p, ok := a.paths.toScriggo(path)
+	lines := make([]int, len(breakpoints))
+	verified := make([]dapBody, len(breakpoints))
+	for i, bp := range breakpoints {
+		lines[i] = bp.Line
+		verified[i] = dapBody{"verified": ok, "line": bp.Line}
+	}
+	if ok {
+		a.debugger.SetBreakpoints(p, lines)
+	}
+	return dapBody{"breakpoints": verified}, nil
+}
+
+// start handles the configurationDone request starting the execution.
+func (a *debugAdapter) start() error {
+	if a.run == nil {
+		return errors.New("not launched")
+	}
+	if a.started {
+		return nil
+	}
+	a.started = true
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	options := &scriggo.RunOptions{
+		Context: ctx,
+		Print: func(v interface{}) {
+			a.event("output", dapBody{"category": "stderr", "output": fmt.Sprint(v)})
+		},
+	}
+	if !a.noDebug {
+		options.Debugger = a.debugger
+	}
+	go func() {
+		defer close(a.done)
+		err := a.run(options)
+		code := 0
+		if err != nil && ctx.Err() == nil {
+			code = 1
+			var exit *scriggo.ExitError
+			if errors.As(err, &exit) {
+				code = exit.Code
+			}
+			a.event("output", dapBody{"category": "stderr", "output": err.Error() + "\n"})
+		}
+		a.event("exited", dapBody{"exitCode": code})
+		a.event("terminated", nil)
+	}()
+	return nil
+}
+
+// terminate terminates the execution, if it has been started, and waits for
+// it to terminate.
+func (a *debugAdapter) terminate() {
+	if !a.started {
+		return
+	}
+	a.cancel()
+	for {
+		select {
+		case a.resume <- scriggo.DebugContinue:
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// stopped is called when the execution stops. It sends a stopped event and
+// waits for a request that resumes the execution.
+func (a *debugAdapter) stopped(s *scriggo.DebugStop) scriggo.DebugStep {
+	var reason string
+	switch s.Reason() {
+	case scriggo.DebugBreakpoint:
+		reason = "breakpoint"
+	case scriggo.DebugStepped:
+		reason = "step"
+	case scriggo.DebugPaused:
+		reason = "pause"
+		if a.entry {
+			reason = "entry"
+			a.entry = false
+		}
+	}
+	a.mu.Lock()
+	a.stop = s
+	a.refs = nil
+	a.mu.Unlock()
+	a.event("stopped", dapBody{"reason": reason, "threadId": 1, "allThreadsStopped": true})
+	step := <-a.resume
+	a.mu.Lock()
+	a.stop = nil
+	a.refs = nil
+	a.mu.Unlock()
+	return step
+}
+
+// step resumes the stopped execution with the given step. If the execution
+// is not stopped, it does nothing.
+func (a *debugAdapter) step(step scriggo.DebugStep) {
+	a.mu.Lock()
+	stopped := a.stop != nil
+	a.mu.Unlock()
+	if stopped {
+		select {
+		case a.resume <- step:
+		case <-a.done:
+		}
+	}
+}
+
+// currentStop returns the current stop or an error if the execution is not
+// stopped. The execution remains stopped until a.mu is unlocked, so the
+// caller must unlock it.
+func (a *debugAdapter) currentStop() (*scriggo.DebugStop, error) {
+	a.mu.Lock()
+	if a.stop == nil {
+		a.mu.Unlock()
+		return nil, errors.New("execution is not stopped")
+	}
+	return a.stop, nil
+}
+
+// stackTrace handles the stackTrace request.
+func (a *debugAdapter) stackTrace() (interface{}, error) {
+	s, err := a.currentStop()
+	if err != nil {
+		return nil, err
+	}
+	defer a.mu.Unlock()
+	frames := s.Frames()
+	stackFrames := make([]dapStackFrame, len(frames))
+	for i, f := range frames {
+		frame := dapStackFrame{
+			ID:     i + 1,
+			Name:   f.Function,
+			Line:   f.Position.Line,
+			Column: f.Position.Column,
+		}
+		if path, ok := a.paths.fromScriggo(f.Path); ok {
+			frame.Source = &dapSource{Name: filepath.Base(path), Path: path}
+		}
+		stackFrames[i] = frame
+	}
+	return dapBody{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+// scopes handles the scopes request.
+func (a *debugAdapter) scopes(frameID int) (interface{}, error) {
+	s, err := a.currentStop()
+	if err != nil {
+		return nil, err
+	}
+	defer a.mu.Unlock()
+	locals := s.Locals(frameID - 1)
+	globals := s.Globals()
+	return dapBody{"scopes": []dapBody{
+		{"name": "Locals", "variablesReference": a.ref(locals), "expensive": false},
+		{"name": "Globals", "variablesReference": a.ref(globals), "expensive": false},
+	}}, nil
+}
+
+// variables handles the variables request.
+func (a *debugAdapter) variables(ref int) (interface{}, error) {
+	_, err := a.currentStop()
+	if err != nil {
+		return nil, err
+	}
+	defer a.mu.Unlock()
+	if ref < 1 || ref > len(a.refs) {
+		return nil, errors.New("invalid variables reference")
+	}
+	var vars []dapVariable
+	switch c := a.refs[ref-1].(type) {
+	case []scriggo.DebugVariable:
+		vars = make([]dapVariable, len(c))
+		for i, v := range c {
+			vars[i] = a.variable(v.Name, v.Type, v.Value)
+		}
+	case reflect.Value:
+		vars = a.children(c)
+	}
+	return dapBody{"variables": vars}, nil
+}
+
+// evaluate handles the evaluate request. Only the names of the local and
+// global variables can be evaluated.
+func (a *debugAdapter) evaluate(expr string, frameID int) (interface{}, error) {
+	s, err := a.currentStop()
+	if err != nil {
+		return nil, err
+	}
+	defer a.mu.Unlock()
+	expr = strings.TrimSpace(expr)
+	v, ok := s.Local(frameID-1, expr)
+	if !ok {
+		for _, g := range s.Globals() {
+			if g.Name == expr {
+				v, ok = g, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("undefined: %s", expr)
+	}
+	dv := a.variable(v.Name, v.Type, v.Value)
+	return dapBody{"result": dv.Value, "type": dv.Type, "variablesReference": dv.VariablesReference}, nil
+}
+
+// ref returns a new variables reference to the container c. It must be
+// called with a.mu locked.
+func (a *debugAdapter) ref(c interface{}) int {
+	a.refs = append(a.refs, c)
+	return len(a.refs)
+}
+
+// variable returns the variable with the given name, type and value. It
+// must be called with a.mu locked.
+func (a *debugAdapter) variable(name string, typ reflect.Type, v reflect.Value) dapVariable {
+	dv := dapVariable{Name: name, Value: debugValueString(v)}
+	if typ != nil {
+		dv.Type = typ.String()
+	} else if v.IsValid() {
+		dv.Type = v.Type().String()
+	}
+	if hasDebugChildren(v) {
+		dv.VariablesReference = a.ref(v)
+	}
+	return dv
+}
+
+// children returns the elements, fields or entries of v. It must be called
+// with a.mu locked.
+func (a *debugAdapter) children(v reflect.Value) []dapVariable {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	var vars []dapVariable
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			vars = append(vars, a.variable(t.Field(i).Name, nil, v.Field(i)))
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len() && i < maxDebugChildren; i++ {
+			vars = append(vars, a.variable("["+strconv.Itoa(i)+"]", nil, v.Index(i)))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = debugValueString(k)
+		}
+		sort.Sort(byName{names, keys})
+		for i, k := range keys {
+			if i == maxDebugChildren {
+				break
+			}
+			vars = append(vars, a.variable(names[i], nil, v.MapIndex(k)))
+		}
+	default:
+		vars = append(vars, a.variable("*", nil, v))
+	}
+	return vars
+}
+
+// byName sorts map keys by their names.
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (s byName) Len() int           { return len(s.names) }
+func (s byName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s byName) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// hasDebugChildren reports whether v has elements, fields or entries that can
+// be returned by the children method.
+func hasDebugChildren(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && (v.Kind() == reflect.Ptr || hasDebugChildren(v.Elem()))
+	case reflect.Struct:
+		return v.NumField() > 0
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	}
+	return false
+}
+
+// debugValueString returns the representation of v shown by the debug
+// adapter.
+func debugValueString(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "nil"
+		}
+		return "*" + v.Type().Elem().String()
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return debugValueString(v.Elem())
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "nil"
+		}
+		return v.Type().String() + " len: " + strconv.Itoa(v.Len())
+	case reflect.Array:
+		return v.Type().String() + " len: " + strconv.Itoa(v.Len())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		return v.Type().String()
+	}
+	return v.Type().String() + " {...}"
+}
+
+// read reads a message.
+func (a *debugAdapter) read() (*dapRequest, error) {
+	data, err := readMessage(a.r)
+	if err != nil {
+		return nil, err
+	}
+	req := &dapRequest{}
+	err = json.Unmarshal(data, req)
+	return req, err
+}
+
+// write writes the message msg setting its sequence number with the function
+// setSeq.
+func (a *debugAdapter) write(msg interface{}, setSeq func(int)) {
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	a.seq++
+	setSeq(a.seq)
+	writeMessage(a.w, msg)
+}
+
+// respond sends a successful response to the request req with the given body.
+func (a *debugAdapter) respond(req *dapRequest, body interface{}) {
+	res := &dapResponse{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body}
+	a.write(res, func(seq int) { res.Seq = seq })
+}
+
+// respondError sends an error response to the request req.
+func (a *debugAdapter) respondError(req *dapRequest, err error) {
+	res := &dapResponse{Type: "response", RequestSeq: req.Seq, Command: req.Command, Message: err.Error()}
+	a.write(res, func(seq int) { res.Seq = seq })
+}
+
+// event sends an event with the given body.
+func (a *debugAdapter) event(event string, body interface{}) {
+	ev := &dapEvent{Type: "event", Event: event, Body: body}
+	a.write(ev, func(seq int) { ev.Seq = seq })
+}
+
+// dapOutput is an io.Writer that sends what is written to it as output
+// events with the given category.
+type dapOutput struct {
+	a        *debugAdapter
+	category string
+}
+
+func (out dapOutput) Write(p []byte) (int, error) {
+	out.a.event("output", dapBody{"category": out.category, "output": string(p)})
+	return len(p), nil
+}
+
+// debugPaths maps the paths of the source files to the paths used by
+// Scriggo and vice versa.
+//
+// For templates, the paths used by Scriggo are relative to the root. For
+// programs, they are the import paths of the packages, "main" for the main
+// package whose file is main.
+type debugPaths struct {
+	root   string // root directory.
+	main   string // file of the main package; empty for templates.
+	module string // module path of a program.
+}
+
+// toScriggo returns the path used by Scriggo for the source file with the
+// given path and true, or false if the file is not a source file.
+func (p *debugPaths) toScriggo(path string) (string, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if p.main != "" {
+		if path == p.main {
+			return "main", true
+		}
+		path = filepath.Dir(path)
+	}
+	rel, err := filepath.Rel(p.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if p.main == "" {
+		return rel, true
+	}
+	if p.module == "" || rel == "." {
+		return "", false
+	}
+	return p.module + "/" + rel, true
+}
+
+// fromScriggo returns the path of the source file with the given path used
+// by Scriggo and true, or false if there is no such source file.
+func (p *debugPaths) fromScriggo(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if p.main == "" {
+		return filepath.Join(p.root, filepath.FromSlash(path)), true
+	}
+	if path == "main" {
+		return p.main, true
+	}
+	rel := strings.TrimPrefix(path, p.module+"/")
+	if p.module == "" || rel == path {
+		return "", false
+	}
+	files, _ := filepath.Glob(filepath.Join(p.root, filepath.FromSlash(rel), "*.go"))
+	if len(files) != 1 {
+		return "", false
+	}
+	return files[0], true
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dapClient is a Debug Adapter Protocol client used to test the debug
+// adapter.
+type dapClient struct {
+	t        *testing.T
+	w        io.Writer
+	seq      int
+	messages chan map[string]interface{}
+	output   strings.Builder
+}
+
+// newDapClient returns a client connected to a new debug adapter.
+func newDapClient(t *testing.T) *dapClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &dapClient{t: t, w: inW, messages: make(chan map[string]interface{}, 100)}
+	go func() {
+		_ = newDebugAdapter(inR, outW).serve()
+		_ = outW.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		r := bufio.NewReader(outR)
+		for {
+			header, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "Content-Length:")))
+			_, _ = r.ReadString('\n')
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			var msg map[string]interface{}
+			_ = json.Unmarshal(data, &msg)
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { _ = inW.Close() })
+	return c
+}
+
+// request sends a request and returns the body of its response.
+func (c *dapClient) request(command string, args interface{}) map[string]interface{} {
+	c.seq++
+	data, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	_, _ = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	res := c.expect("response", command)
+	if res["success"] != true {
+		c.t.Fatalf("%s request failed: %v", command, res["message"])
+	}
+	body, _ := res["body"].(map[string]interface{})
+	return body
+}
+
+// expect waits for a response or an event with the given name and returns
+// it. The output events are collected in c.output.
+func (c *dapClient) expect(typ, name string) map[string]interface{} {
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("expecting %s %s, got end of messages", typ, name)
+			}
+			if msg["type"] == "event" && msg["event"] == "output" {
+				c.output.WriteString(msg["body"].(map[string]interface{})["output"].(string))
+			}
+			if msg["type"] == typ && (msg["command"] == name || msg["event"] == name) {
+				return msg
+			}
+		case <-time.After(10 * time.Second):
+			c.t.Fatalf("expecting %s %s, got nothing", typ, name)
+		}
+	}
+}
+
+// locals returns the local variables of the top frame.
+func (c *dapClient) locals() string {
+	frames := c.request("stackTrace", map[string]interface{}{"threadId": 1})["stackFrames"].([]interface{})
+	frame := frames[0].(map[string]interface{})
+	scopes := c.request("scopes", map[string]interface{}{"frameId": frame["id"]})["scopes"].([]interface{})
+	ref := scopes[0].(map[string]interface{})["variablesReference"]
+	vars := c.request("variables", map[string]interface{}{"variablesReference": ref})["variables"].([]interface{})
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s:%v", filepath.Base(frame["source"].(map[string]interface{})["path"].(string)), frame["line"])
+	for _, v := range vars {
+		v := v.(map[string]interface{})
+		_, _ = fmt.Fprintf(&b, " %s=%s", v["name"], v["value"])
+	}
+	return b.String()
+}
+
+func TestDebugAdapter(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "index.txt")
+	err := os.WriteFile(name, []byte("{% var n = 2 %}\n{% for i := 0; i < n; i++ %}\n{{ i }}\n{% end %}"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newDapClient(t)
+	c.request("initialize", map[string]interface{}{"adapterID": "scriggo"})
+	c.expect("event", "initialized")
+	c.request("launch", map[string]interface{}{"program": name})
+	body := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": name},
+		"breakpoints": []interface{}{map[string]interface{}{"line": 3}},
+	})
+	if bp := body["breakpoints"].([]interface{})[0].(map[string]interface{}); bp["verified"] != true {
+		t.Fatal("expecting a verified breakpoint")
+	}
+	c.request("configurationDone", nil)
+	for i := 0; i < 2; i++ {
+		stopped := c.expect("event", "stopped")
+		if reason := stopped["body"].(map[string]interface{})["reason"]; reason != "breakpoint" {
+			t.Fatalf("expecting reason breakpoint, got %v", reason)
+		}
+		expected := fmt.Sprintf("index.txt:3 n=2 i=%d", i)
+		if got := c.locals(); got != expected {
+			t.Fatalf("expecting %q, got %q", expected, got)
+		}
+		c.request("continue", map[string]interface{}{"threadId": 1})
+	}
+	exited := c.expect("event", "exited")
+	if code := exited["body"].(map[string]interface{})["exitCode"]; code != 0.0 {
+		t.Fatalf("expecting exit code 0, got %v", code)
+	}
+	c.expect("event", "terminated")
+	if out := c.output.String(); out != "\n0\n1\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	c.request("disconnect", nil)
+}
+
+func TestDebugAdapterSteps(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "main.go")
+	src := "package main\n\nfunc f(a int) int {\n\treturn a * 2\n}\n\nfunc main() {\n\tx := f(3)\n\tprint(x)\n}\n"
+	err := os.WriteFile(name, []byte(src), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newDapClient(t)
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": name, "stopOnEntry": true})
+	c.request("configurationDone", nil)
+	var stops []string
+	for _, step := range []string{"stepIn", "stepOut", "next"} {
+		stopped := c.expect("event", "stopped")
+		stops = append(stops, fmt.Sprintf("%s %s", stopped["body"].(map[string]interface{})["reason"], c.locals()))
+		c.request(step, map[string]interface{}{"threadId": 1})
+	}
+	c.expect("event", "terminated")
+	expected := "entry main.go:8\nstep main.go:4 a=3\nstep main.go:9 x=6"
+	if got := strings.Join(stops, "\n"); got != expected {
+		t.Fatalf("expecting stops:\n%s\ngot:\n%s", expected, got)
+	}
+	if out := c.output.String(); out != "6" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
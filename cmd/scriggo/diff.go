@@ -0,0 +1,163 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of context lines of a unified diff.
+const diffContext = 3
+
+// diffLine is a line of a diff.
+type diffLine struct {
+	kind byte // ' ' for an unchanged line, '-' for a removed line and '+' for an added line
+	text string
+}
+
+// unifiedDiff returns the differences between old and new in the unified
+// format, naming them oldName and newName. It returns nil if old and new
+// are equal.
+func unifiedDiff(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	lines := diffLines(splitLines(old), splitLines(new))
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	oldLine, newLine := 1, 1 // line numbers of lines[i]
+	for i := 0; i < len(lines); {
+		// Find the next change.
+		j := i
+		for j < len(lines) && lines[j].kind == ' ' {
+			j++
+		}
+		if j == len(lines) {
+			break
+		}
+		start := j - diffContext
+		if start < i {
+			start = i
+		}
+		for k := i; k < start; k++ {
+			oldLine++
+			newLine++
+		}
+		// Extend the hunk with the changes separated by at most two
+		// contexts.
+		end := j
+		for {
+			for end < len(lines) && lines[end].kind != ' ' {
+				end++
+			}
+			k := end
+			for k < len(lines) && lines[k].kind == ' ' && k-end <= 2*diffContext {
+				k++
+			}
+			if k == len(lines) || lines[k].kind == ' ' {
+				break
+			}
+			end = k
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+		var oldCount, newCount int
+		for _, line := range lines[start:end] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, line := range lines[start:end] {
+			b.WriteByte(line.kind)
+			b.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+	return b.Bytes()
+}
+
+// hunkRange returns the range of a hunk that starts at line start and has
+// count lines.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits src in lines, each one with its terminating newline.
+func splitLines(src []byte) []string {
+	lines := strings.SplitAfter(string(src), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the lines of the diff between the lines a and b, based
+// on their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	var lines []diffLine
+	// Skip the common prefix and suffix.
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		lines = append(lines, diffLine{' ', a[prefix]})
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, diffLine{' ', x[i]})
+			i++
+			j++
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', y[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
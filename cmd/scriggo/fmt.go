@@ -0,0 +1,188 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/compiler"
+)
+
+// fmtFlags are the flags of the fmt command.
+type fmtFlags struct {
+	l      bool   // list the files whose formatting differs
+	w      bool   // write the result to the files
+	d      bool   // print the diffs
+	format string // format of the files
+}
+
+// templateExtensions are the extensions of the template files formatted
+// by the fmt command when it walks a directory.
+var templateExtensions = map[string]scriggo.Format{
+	".html":     scriggo.FormatHTML,
+	".css":      scriggo.FormatCSS,
+	".js":       scriggo.FormatJS,
+	".json":     scriggo.FormatJSON,
+	".md":       scriggo.FormatMarkdown,
+	".mdx":      scriggo.FormatMarkdown,
+	".mkd":      scriggo.FormatMarkdown,
+	".mkdn":     scriggo.FormatMarkdown,
+	".mdown":    scriggo.FormatMarkdown,
+	".markdown": scriggo.FormatMarkdown,
+}
+
+// formatTemplates formats the template files with the given paths, and the
+// template files in the directories with the given paths, writing the
+// results to out. If there are no paths, it formats the standard input.
+//
+// It reports the errors of the files to errOut and returns an error if
+// a file cannot be formatted.
+func formatTemplates(paths []string, flags fmtFlags, out, errOut io.Writer) error {
+
+	var format scriggo.Format
+	if flags.format != "" {
+		var err error
+		format, err = parseFormat(flags.format)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(paths) == 0 {
+		if flags.l || flags.w {
+			return errors.New("cannot use -l or -w with standard input")
+		}
+		if flags.format == "" {
+			format = scriggo.FormatText
+		}
+		return formatTemplate(os.Stdin, "<standard input>", format, flags, out)
+	}
+
+	failed := false
+	report := func(err error) {
+		_, _ = fmt.Fprintln(errOut, err)
+		failed = true
+	}
+	formatFile := func(name string, format scriggo.Format) {
+		fi, err := os.Open(name)
+		if err != nil {
+			report(err)
+			return
+		}
+		err = formatTemplate(fi, name, format, flags, out)
+		_ = fi.Close()
+		if err != nil {
+			report(err)
+		}
+	}
+
+	for _, path := range paths {
+		st, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !st.IsDir() {
+			if flags.format == "" {
+				format = templateExtensions[filepath.Ext(path)]
+			}
+			formatFile(path, format)
+			continue
+		}
+		err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				report(err)
+				return nil
+			}
+			if name != path && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			f, ok := templateExtensions[filepath.Ext(name)]
+			if !ok {
+				return nil
+			}
+			if flags.format != "" {
+				f = format
+			}
+			formatFile(name, f)
+			return nil
+		})
+		if err != nil {
+			report(err)
+		}
+	}
+
+	if failed {
+		return errors.New("some files could not be formatted")
+	}
+
+	return nil
+}
+
+// formatTemplate formats the template file named name, with content read
+// from r, in the given format.
+func formatTemplate(r io.Reader, name string, format scriggo.Format, flags fmtFlags, out io.Writer) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	res, err := compiler.FormatTemplateSource(src, ast.Format(format))
+	if err != nil {
+		if e, ok := err.(*compiler.SyntaxError); ok {
+			return fmt.Errorf("%s:%s: syntax error: %s", name, e.Position(), e.Message())
+		}
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	if !flags.l && !flags.w && !flags.d {
+		_, err = out.Write(res)
+		return err
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+	if flags.l {
+		_, err = fmt.Fprintln(out, name)
+		if err != nil {
+			return err
+		}
+	}
+	if flags.w {
+		st, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(name, res, st.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+	if flags.d {
+		name := filepath.ToSlash(name)
+		_, err = fmt.Fprintf(out, "diff %s.orig %s\n", name, name)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(unifiedDiff(name+".orig", name, src, res))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFormatTemplates tests the formatTemplates function.
+func TestFormatTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":       "{%if a%}\n  {{a+1}}\n  {%end%}\n",
+		"ok.html":          "{% if a %}\n  {{ a + 1 }}\n{% end %}\n",
+		"data.txt":         "{{a+1}}",
+		".hidden/a.html":   "{{a+1}}",
+		"partials/p.md":    "{{a}}",
+		"partials/bad.css": "{% if %}",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(name), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(name, []byte(src), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := filepath.Join(dir, "index.html")
+	p := filepath.Join(dir, "partials", "p.md")
+	bad := filepath.Join(dir, "partials", "bad.css")
+
+	// Print the formatted file.
+	var out, errOut bytes.Buffer
+	err := formatTemplates([]string{index}, fmtFlags{}, &out, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{% if a %}\n  {{ a + 1 }}\n{% end %}\n"; out.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, out.String())
+	}
+
+	// List the files.
+	out.Reset()
+	err = formatTemplates([]string{dir}, fmtFlags{l: true}, &out, &errOut)
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	if expected := index + "\n" + p + "\n"; out.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, out.String())
+	}
+	if expected := bad + ":1:7: syntax error: missing condition in if statement\n"; errOut.String() != expected {
+		t.Fatalf("expected error %q, got %q", expected, errOut.String())
+	}
+
+	// Print the diff.
+	out.Reset()
+	err = formatTemplates([]string{index}, fmtFlags{d: true}, &out, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.ToSlash(index)
+	expected := strings.Join([]string{
+		"diff " + name + ".orig " + name,
+		"--- " + name + ".orig",
+		"+++ " + name,
+		"@@ -1,3 +1,3 @@",
+		"-{%if a%}",
+		"-  {{a+1}}",
+		"-  {%end%}",
+		"+{% if a %}",
+		"+  {{ a + 1 }}",
+		"+{% end %}",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Fatalf("expected diff %q, got %q", expected, out.String())
+	}
+
+	// Write the file.
+	out.Reset()
+	err = formatTemplates([]string{index}, fmtFlags{w: true}, &out, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() > 0 {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	src, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != files["ok.html"] {
+		t.Fatalf("expected %q, got %q", files["ok.html"], src)
+	}
+}
+
+var unifiedDiffTests = []struct {
+	old, new string
+	diff     string
+}{
+	{"a\n", "a\n", ""},
+	{"a\nb\nc\n", "a\nB\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+	{"a", "b", "@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n"},
+	{"", "a\n", "@@ -0,0 +1 @@\n+a\n"},
+	{
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n12\n",
+		"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -8,5 +9,4 @@\n 8\n 9\n 10\n-11\n 12\n",
+	},
+}
+
+// TestUnifiedDiff tests the unifiedDiff function.
+func TestUnifiedDiff(t *testing.T) {
+	for _, test := range unifiedDiffTests {
+		got := unifiedDiff("a", "b", []byte(test.old), []byte(test.new))
+		expected := ""
+		if test.diff != "" {
+			expected = "--- a\n+++ b\n" + test.diff
+		}
+		if string(got) != expected {
+			t.Errorf("%q -> %q: expected diff %q, got %q", test.old, test.new, expected, got)
+		}
+	}
+}
@@ -17,9 +17,21 @@ The commands are:
 
     run         run a template
 
+    debug       debug a template or a program with an editor that supports
+                the Debug Adapter Protocol
+
+    lsp         run a language server for editors that support the Language
+                Server Protocol
+
     serve       run a web server and serve the template rooted at the current
                 directory
 
+    build       build a static site rendering the templates in a directory
+
+    fmt         format template files
+
+    test        test templates comparing their outputs with golden files
+
     init        initialize an interpreter for Go programs
 
     import      generate the source for an importer used by Scriggo to import 
@@ -158,6 +170,12 @@ the standard output.
 Markdown is converted to HTML with the Goldmark parser with the options
 html.WithUnsafe, parser.WithAutoHeadingID and extension.GFM.
 
+A front matter at the beginning of the executed file, a YAML document
+between two lines '---', is removed from the file and its values are
+accessible through the global 'frontMatter', for example
+'{{ frontMatter.title }}', also from the extended, imported and rendered
+files. TOML front matters are not supported.
+
 The run flags are:
 
 	-root dir
@@ -171,6 +189,12 @@ The run flags are:
 		use the named file format: Text, HTML, Markdown, CSS, JS or JSON.
 	-metrics
 		print metrics about execution time.
+	-profile file
+		write a CPU and allocation profile of the execution to file. The
+		profile can be read with the 'go tool pprof' command.
+	-sourcemap file
+		write the source map of the output to file, in the Source Map
+		Revision 3 format.
 	-S n
 		print the assembly code of the executed file to the standard error.
 		n determines the maximum length, in runes, of disassembled Text
@@ -192,6 +216,221 @@ Examples:
 
 	scriggo run -o ./public ./sources/index.html
 
+	scriggo run -profile index.prof index.html
+
+`
+
+const helpLSP = `
+usage: scriggo lsp [-root dir] [-const name=value]
+
+Lsp runs a language server for templates that speaks the Language Server
+Protocol on the standard input and output, so that editors like Visual Studio
+Code can show the errors of the templates while they are edited.
+
+The language server provides:
+
+	diagnostics
+		the syntax and type checking errors of the open template files.
+	hover
+		the type of the expression under the cursor.
+	go to definition
+		the declaration of macros, variables, constants and types, and the
+		files of extends, import and render.
+	completion
+		the globals, the declarations of the file and of the imported files,
+		the declarations of the packages and the fields and methods of the
+		values.
+
+An open file is analyzed as a template file whose root is the workspace root
+or, with the -root flag, the named directory. It is analyzed with the same
+globals of the run command, and with the global constants given with the
+-const flag as for the run command. The text of the open files is used in
+place of the text of the files on disk.
+
+Examples:
+
+	scriggo lsp
+
+	scriggo lsp -root site -const 'lang="en"'
+`
+
+const helpFmt = `
+usage: scriggo fmt [-l] [-w] [-d] [-format format] [path ...]
+
+Fmt formats template files. The code in {{ }}, {% %} and {%% %%} is written
+with canonical spacing, and the statements and comments alone in their lines
+are indented with tabs according to their nesting, as the macro bodies and
+the statements in an if or for statement. The text is preserved, except for
+the spaces around the statements alone in their lines that are not rendered.
+A formatted template renders the same output of the original one.
+
+Given a file, it formats the file. Given a directory, it formats all the
+files in the directory, and in its subdirectories, with extension .html,
+.css, .js, .json and .md (and the other Markdown extensions), ignoring the
+files and directories whose names start with a period. Without arguments,
+it formats the standard input.
+
+By default, fmt prints the formatted files to the standard output.
+
+The -l flag prints the names of the files whose formatting differs from
+fmt's, instead of the formatted files.
+
+The -w flag writes the result to the files, instead of the standard output.
+
+The -d flag prints the diffs between the files and the formatted files,
+instead of the formatted files.
+
+The -format flag forces fmt to format the files in the given format, as for
+the run command. By default, the format is determined by the file extension
+and it is Text for the standard input.
+
+Examples:
+
+	scriggo fmt index.html
+
+	scriggo fmt -l -w site
+
+	scriggo fmt -d .
+`
+
+const helpTest = `
+usage: scriggo test [-const name=value] [-run regexp] [-update] [-v] [dir]
+
+Test runs the tests of the templates in the directory dir or, if no
+directory is given, in the current directory.
+
+A test is a template file, in dir or in one of its subdirectories, whose
+name without extension ends with "_test", as "index_test.html" and
+"blog/post_test.md". It is rendered as the run command does, with dir as
+root, so it can extend, import and render the other files in dir.
+
+The variables of a test are read from its fixture file, a file with the
+same name of the test and extension .json, .yaml or .yml, for example
+"index_test.json". The fixture contains an object whose keys are the names
+of the variables and whose values are their values. The JSON integer
+numbers are read as int values.
+
+The output of a test is compared with its golden file, a file with the same
+name of the test followed by ".golden", for example "index_test.html.golden".
+If the output differs, the test fails and the differences are printed.
+
+The files and the directories whose names start with a period are ignored.
+
+The -const flag runs the tests with global constants as for the run command.
+
+The -run flag runs only the tests whose file names, relative to dir, match
+the regular expression.
+
+The -update flag writes the outputs of the tests to the golden files,
+creating the missing ones, instead of comparing them.
+
+The -v flag prints the names of all the tests as they are run.
+
+Examples:
+
+	scriggo test
+
+	scriggo test -update site
+
+	scriggo test -run '^blog/' -const 'lang="en"' site
+`
+
+const helpBuild = `
+usage: scriggo build [-o dir] [-const name=value] [-partial pattern] [-j n] [-force] [-v] [root]
+
+Build builds a static site from the templates in the directory root or, if
+no directory is given, in the current directory. It renders the pages as
+the serve command does and copies the other files, writing them to the
+output directory with the same paths.
+
+The pages are the files with extension .html and .md. A page 'article.md'
+is written as 'article.html', unless the file 'article.html' exists, in
+which case 'article.md' is ignored as the serve command does. Markdown is
+converted to HTML, and the front matter of a page is accessible through the
+global 'frontMatter', as the serve command does.
+
+The partial files, as layouts and imported files, are neither rendered nor
+copied but can be extended, imported and rendered by the pages. A file is
+partial if its name, or the name of one of its directories, starts with an
+underscore, for example '_layout.html' and '_partials/header.html'. The
+files and the directories whose names start with a period, the output
+directory, the tests of the test command and their fixture and golden
+files are ignored.
+
+Build is incremental. It writes the file '.scriggo-build.json' in the
+output directory with the files each page depends on, and the next build
+renders only the pages whose files have changed and copies only the changed
+files. The outputs of the removed pages and files are removed.
+
+The -o flag writes the site to the named directory instead of 'public'.
+
+The -const flag builds the pages with global constants as for the run
+command. A build with different constants renders all the pages.
+
+The -partial flag treats the files whose paths, relative to root, match
+the pattern, or that are in a directory whose path matches the pattern, as
+partial files. A pattern without a slash is matched against the names of
+the files and the directories instead of their paths. The pattern syntax is
+that of the path.Match function. The flag can be repeated.
+
+The -j flag sets the number of pages that can be rendered in parallel. The
+default is the number of CPUs.
+
+The -force flag renders all the pages and copies all the files, ignoring
+the previous build.
+
+The -v flag prints the names of the files as they are written.
+
+Examples:
+
+	scriggo build
+
+	scriggo build -o dist -partial 'layouts' -partial '*.inc.html' site
+
+	scriggo build -force -const 'lang="en"'
+`
+
+const helpDebug = `
+usage: scriggo debug [-addr address]
+
+Debug runs a debug adapter that speaks the Debug Adapter Protocol, so that
+editors like Visual Studio Code can run a template or a program with
+breakpoints, step controls and inspection of the variables.
+
+By default, the debug adapter serves a single debug session on the standard
+input and output. The -addr flag makes it listen on the named TCP address,
+for example 'localhost:4711', and serve the sessions of the accepted
+connections one at a time.
+
+A debug session is started by a launch request with these arguments:
+
+	program
+		path of the template file to run, or of the Go file of the main
+		package of a program if it has the .go extension.
+	root
+		root directory of the template, instead of the file's directory.
+	format
+		format of the template files: Text, HTML, Markdown, CSS, JS or JSON.
+	consts
+		global constants of the template, as passed to the -const flag of
+		the run command.
+	stopOnEntry
+		stop at the first statement.
+	noDebug
+		run without debugging.
+
+A template is built as the run command does, and its output is sent to the
+editor. A program can import only the packages of its module.
+
+Only the main goroutine is debugged. Goroutines started with the go statement
+and functions called by native functions are not debugged.
+
+Examples:
+
+	scriggo debug
+
+	scriggo debug -addr localhost:4711
+
 `
 
 const helpServe = `
@@ -218,6 +457,9 @@ it renders 'blog/index.html' or 'blog/index.md'.
 Markdown is converted to HTML with the Goldmark parser with the options
 html.WithUnsafe, parser.WithAutoHeadingID and extension.GFM.
 
+The front matter of the rendered file is accessible through the global
+'frontMatter' as for the run command.
+
 When a file is modified, the server automatically rebuilds templates, and the
 browser reloads the page.
 
@@ -330,12 +572,9 @@ Limitations
     under development. To check the state of a limitation please refer to the
     Github issue linked in the list below.
 
-    * methods declarations (issue #458)
-    * interface types definition (issue #218)
     * assigning to non-variables in 'for range' statements (issue #182)
     * importing the "unsafe" package from Scriggo (issue #288)
     * importing the "runtime" package from Scriggo (issue #524)
-    * labeled continue and break statements (issue #83)
     * some kinds of pointer shorthands (issue #383)
     * compilation of non-main packages without importing them (issue #521)
 
@@ -363,6 +602,12 @@ Limitations due to maintain the interoperability with Go official compiler 'gc'
       limitation of the StructOf function of reflect.
       See Go issue #15924 (https://github.com/golang/go/issues/15924).
 
+    * native code can only call the Error, String, HTML, CSS, JS, JSON and
+      Markdown methods of types defined in Scriggo, and at most two of them
+      for the same type: Error and String, or String and one of the others.
+      So a type defined in Scriggo implements a native interface only if the
+      interface has no other methods.
+
     * cannot define functions without a body (TODO)
 
     * a select supports a maximum of 65536 cases.
@@ -379,15 +624,15 @@ Arbitrary limitations
     These limitations have been arbitrarily added to Scriggo to enhance
     performances:
 
-    * 127 registers of a given type (integer, floating point, string or
+    * 32767 registers of a given type (integer, floating point, string or
       general) per function
-    * 256 function literal declarations plus unique functions calls per
+    * 65536 function literal declarations plus unique functions calls per
       function
-    * 256 types available per function
-    * 256 unique native functions per function
+    * 65536 types available per function
+    * 65536 unique native functions per function
     * 16384 integer values per function
-    * 256 string values per function
+    * 65536 string values per function
     * 16384 floating-point values per function
-    * 256 general values per function
+    * 65536 general values per function
 
 `
@@ -0,0 +1,859 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/compiler"
+	"github.com/open2b/scriggo/native"
+)
+
+// Kinds of the completion items of the Language Server Protocol.
+const (
+	lspMethodKind   = 2
+	lspFunctionKind = 3
+	lspFieldKind    = 5
+	lspVariableKind = 6
+	lspClassKind    = 7
+	lspModuleKind   = 9
+	lspConstantKind = 21
+)
+
+// lspFormatTypes contains the format types, as passed to the compiler by the
+// scriggo package.
+var lspFormatTypes = map[ast.Format]reflect.Type{
+	ast.FormatHTML:     reflect.TypeOf((*native.HTML)(nil)).Elem(),
+	ast.FormatCSS:      reflect.TypeOf((*native.CSS)(nil)).Elem(),
+	ast.FormatJS:       reflect.TypeOf((*native.JS)(nil)).Elem(),
+	ast.FormatJSON:     reflect.TypeOf((*native.JSON)(nil)).Elem(),
+	ast.FormatMarkdown: reflect.TypeOf((*native.Markdown)(nil)).Elem(),
+}
+
+// languageServe executes the sub command "lsp":
+//
+//	scriggo lsp
+//
+// It runs a language server for templates that speaks the Language Server
+// Protocol on the standard input and output.
+func languageServe(root string, consts []string) error {
+	// Check the constants before serving.
+	if _, err := templateBuildOptions("", consts); err != nil {
+		return err
+	}
+	return newLanguageServer(os.Stdin, os.Stdout, root, consts).serve()
+}
+
+// lspMessage is a request or a notification of the Language Server
+// Protocol.
+type lspMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// lspResponse is a response of the Language Server Protocol.
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+// lspNotification is a notification sent by the language server.
+type lspNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// lspError is the error of a response.
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspPosition is a position in a text document, with zero-based line and
+// character offset in UTF-16 code units.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is a range in a text document.
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspLocation is a location in a text document.
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// lspDiagnostic is a diagnostic of the Language Server Protocol.
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspCompletionItem is a completion item of the Language Server Protocol.
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// lspTextDocumentPosition are the parameters of the hover, definition and
+// completion requests.
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// lspDocument is a text document opened in the editor.
+type lspDocument struct {
+	uri  string
+	path string // path relative to the root, empty if outside the root.
+	text string
+
+	// analysis is the analysis of the last text that has been parsed, nil
+	// if no text has been parsed yet.
+	analysis *compiler.Analysis
+	// stale reports whether analysis does not refer to the current text.
+	stale bool
+	// published contains the paths of the files for which the analysis of
+	// the document has published diagnostics.
+	published map[string]bool
+}
+
+// languageServer is a language server for templates that speaks the
+// Language Server Protocol.
+type languageServer struct {
+	r      *bufio.Reader
+	w      io.Writer
+	root   string   // root directory of the templates.
+	consts []string // global constants, as passed to the -const flag.
+	docs   map[string]*lspDocument
+}
+
+// newLanguageServer returns a new language server that reads the messages
+// from r and writes them to w. root is the root directory of the templates,
+// if empty it is the root of the workspace.
+func newLanguageServer(r io.Reader, w io.Writer, root string, consts []string) *languageServer {
+	return &languageServer{
+		r:      bufio.NewReader(r),
+		w:      w,
+		root:   root,
+		consts: consts,
+		docs:   map[string]*lspDocument{},
+	}
+}
+
+// serve serves a session and returns when the session is terminated by an
+// exit notification or the input is closed.
+func (s *languageServer) serve() error {
+	for {
+		data, err := readMessage(s.r)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(&msg)
+		if msg.ID == nil {
+			// Notification.
+			continue
+		}
+		res := &lspResponse{JSONRPC: "2.0", ID: msg.ID}
+		if err != nil {
+			res.Error = &lspError{Code: -32603, Message: err.Error()}
+			if errors.Is(err, errUnsupportedMethod) {
+				res.Error.Code = -32601
+			}
+		} else {
+			res.Result, err = json.Marshal(result)
+			if err != nil {
+				return err
+			}
+		}
+		writeMessage(s.w, res)
+	}
+}
+
+// errUnsupportedMethod is returned by handle for unsupported methods.
+var errUnsupportedMethod = errors.New("unsupported method")
+
+// handle handles the request or the notification msg and returns the result
+// of the response.
+func (s *languageServer) handle(msg *lspMessage) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			RootURI  string `json:"rootUri"`
+			RootPath string `json:"rootPath"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params.RootURI, params.RootPath)
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.change(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		s.analyzeAll()
+		return nil, nil
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.close(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var params lspTextDocumentPosition
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		offset := lspOffset(doc.text, params.Position)
+		switch msg.Method {
+		case "textDocument/hover":
+			return s.hover(doc, offset), nil
+		case "textDocument/definition":
+			return s.definition(doc, offset), nil
+		}
+		return s.completion(doc, offset), nil
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnsupportedMethod, msg.Method)
+}
+
+// initialize handles the initialize request.
+func (s *languageServer) initialize(rootURI, rootPath string) (interface{}, error) {
+	if s.root == "" {
+		if rootURI != "" {
+			s.root, _ = lspURIToPath(rootURI)
+		} else {
+			s.root = rootPath
+		}
+	}
+	if s.root == "" {
+		var err error
+		s.root, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+	root, err := filepath.Abs(s.root)
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":   1, // full
+			"hoverProvider":      true,
+			"definitionProvider": true,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{"."},
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "scriggo",
+			"version": version(),
+		},
+	}, nil
+}
+
+// open handles the didOpen notification.
+func (s *languageServer) open(uri, text string) {
+	doc := &lspDocument{uri: uri, text: text, published: map[string]bool{}}
+	if name, ok := lspURIToPath(uri); ok {
+		if rel, err := filepath.Rel(s.root, name); err == nil && filepath.IsLocal(rel) {
+			doc.path = filepath.ToSlash(rel)
+		}
+	}
+	s.docs[uri] = doc
+	s.analyzeAll()
+}
+
+// change handles the didChange notification.
+func (s *languageServer) change(uri, text string) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return
+	}
+	doc.text = text
+	s.analyzeAll()
+}
+
+// close handles the didClose notification.
+func (s *languageServer) close(uri string) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return
+	}
+	delete(s.docs, uri)
+	s.publish(doc, map[string][]lspDiagnostic{})
+	s.analyzeAll()
+}
+
+// analyzeAll analyzes all the open documents. As the documents can import,
+// extend and render each other, all of them are analyzed when one changes.
+func (s *languageServer) analyzeAll() {
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		s.analyze(s.docs[uri])
+	}
+}
+
+// analyze analyzes the document doc, as the entry file of a template, and
+// publishes its diagnostics.
+func (s *languageServer) analyze(doc *lspDocument) {
+	if doc.path == "" {
+		return
+	}
+	opts, err := templateBuildOptions(doc.path, s.consts)
+	if err != nil {
+		return
+	}
+	// The front matter is not parsed, so its variable is declared as an
+	// empty map.
+	globals := make(native.Declarations, len(opts.Globals)+1)
+	for name, value := range opts.Globals {
+		globals[name] = value
+	}
+	globals[opts.FrontMatter.Name] = &map[string]interface{}{}
+	co := compiler.Options{
+		AllowGoStmt: opts.AllowGoStmt,
+		FormatTypes: lspFormatTypes,
+		Globals:     globals,
+		MDConverter: compiler.Converter(opts.MarkdownConverter),
+	}
+	fsys := s.fsys()
+	analysis, err := compiler.AnalyzeTemplate(fsys, doc.path, co)
+	if analysis != nil {
+		doc.analysis = analysis
+		doc.stale = false
+	} else {
+		doc.stale = true
+	}
+	diagnostics := map[string][]lspDiagnostic{}
+	if err != nil {
+		errs := []compiler.Error{}
+		switch e := err.(type) {
+		case compiler.CheckingErrors:
+			for _, ce := range e {
+				errs = append(errs, ce)
+			}
+		case compiler.Error:
+			errs = append(errs, e)
+		default:
+			diagnostics[doc.path] = []lspDiagnostic{{Severity: 1, Source: "scriggo", Message: err.Error()}}
+		}
+		for _, e := range errs {
+			path := e.Path()
+			src, err := s.readFile(fsys, path)
+			if err != nil {
+				continue
+			}
+			pos := e.Position()
+			diagnostics[path] = append(diagnostics[path], lspDiagnostic{
+				Range:    lspPositionRange(src, pos),
+				Severity: 1,
+				Source:   "scriggo",
+				Message:  e.Message(),
+			})
+		}
+	}
+	if diagnostics[doc.path] == nil {
+		diagnostics[doc.path] = []lspDiagnostic{}
+	}
+	s.publish(doc, diagnostics)
+}
+
+// publish publishes the diagnostics of the analysis of doc, indexed by path,
+// and clears the diagnostics previously published for the other paths.
+func (s *languageServer) publish(doc *lspDocument, diagnostics map[string][]lspDiagnostic) {
+	for path := range doc.published {
+		if _, ok := diagnostics[path]; !ok {
+			diagnostics[path] = []lspDiagnostic{}
+		}
+	}
+	paths := make([]string, 0, len(diagnostics))
+	for path := range diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		d := diagnostics[path]
+		if len(d) == 0 {
+			delete(doc.published, path)
+		} else {
+			doc.published[path] = true
+		}
+		writeMessage(s.w, &lspNotification{
+			JSONRPC: "2.0",
+			Method:  "textDocument/publishDiagnostics",
+			Params:  map[string]interface{}{"uri": s.uri(path), "diagnostics": d},
+		})
+	}
+}
+
+// hover handles the hover request.
+func (s *languageServer) hover(doc *lspDocument, offset int) interface{} {
+	if doc.analysis == nil || doc.stale {
+		return nil
+	}
+	nodes := doc.analysis.NodesAt(doc.path, offset)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		expr, ok := nodes[i].(ast.Expression)
+		if !ok {
+			continue
+		}
+		desc, ok := doc.analysis.Describe(expr)
+		if !ok {
+			continue
+		}
+		return map[string]interface{}{
+			"contents": map[string]string{
+				"kind":  "markdown",
+				"value": "```go\n" + desc + "\n```",
+			},
+			"range": lspNodeRange(doc.text, expr.Pos()),
+		}
+	}
+	return nil
+}
+
+// definition handles the definition request. It returns the location of
+// the declaration of an identifier, or the file of an extends, import or
+// render.
+func (s *languageServer) definition(doc *lspDocument, offset int) interface{} {
+	if doc.analysis == nil || doc.stale {
+		return nil
+	}
+	nodes := doc.analysis.NodesAt(doc.path, offset)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		var tree *ast.Tree
+		switch n := nodes[i].(type) {
+		case *ast.Extends:
+			tree = n.Tree
+		case *ast.Import:
+			tree = n.Tree
+		case *ast.Render:
+			tree = n.Tree
+		case ast.Expression:
+			path, pos, ok := doc.analysis.Declaration(n)
+			if !ok {
+				continue
+			}
+			src, err := s.readFile(s.fsys(), path)
+			if err != nil {
+				return nil
+			}
+			return lspLocation{URI: s.uri(path), Range: lspNodeRange(src, pos)}
+		}
+		if tree != nil {
+			return lspLocation{URI: s.uri(tree.Path)}
+		}
+	}
+	return nil
+}
+
+// completion handles the completion request. It completes the globals, the
+// declarations of the document and of the files it imports, the
+// declarations of the packages and the fields and methods of the values.
+func (s *languageServer) completion(doc *lspDocument, offset int) []lspCompletionItem {
+	if doc.path == "" {
+		return nil
+	}
+	text := doc.text
+	start := offset
+	for start > 0 && isIdentifierByte(text[start-1]) {
+		start--
+	}
+	prefix := text[start:offset]
+	opts, err := templateBuildOptions(doc.path, s.consts)
+	if err != nil {
+		return nil
+	}
+	var items []lspCompletionItem
+	if start > 0 && text[start-1] == '.' {
+		end := start - 1
+		begin := end
+		for begin > 0 && isIdentifierByte(text[begin-1]) {
+			begin--
+		}
+		items = s.memberCompletion(doc, opts.Globals, text[begin:end], begin)
+	} else {
+		for name, decl := range opts.Globals {
+			items = append(items, lspCompletionItem{Label: name, Kind: lspDeclarationKind(decl), Detail: lspDeclarationDetail(decl)})
+		}
+		if doc.analysis != nil {
+			items = append(items, lspTreeDeclarations(doc.analysis, doc.path, false, map[string]bool{})...)
+		}
+	}
+	completions := make([]lspCompletionItem, 0, len(items))
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, prefix) {
+			completions = append(completions, item)
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].Label < completions[j].Label
+	})
+	return completions
+}
+
+// memberCompletion returns the completion items after the name followed by
+// a period, where offset is the index of name in the text of doc.
+func (s *languageServer) memberCompletion(doc *lspDocument, globals native.Declarations, name string, offset int) []lspCompletionItem {
+	if name == "" {
+		return nil
+	}
+	// Native package declared as global.
+	if pkg, ok := globals[name].(native.ImportablePackage); ok {
+		var items []lspCompletionItem
+		_ = pkg.LookupFunc(func(name string, decl native.Declaration) error {
+			items = append(items, lspCompletionItem{Label: name, Kind: lspDeclarationKind(decl), Detail: lspDeclarationDetail(decl)})
+			return nil
+		})
+		return items
+	}
+	if doc.analysis == nil {
+		return nil
+	}
+	// Imported template file.
+	if tree := doc.analysis.Tree(doc.path); tree != nil {
+		for _, node := range tree.Nodes {
+			if im, ok := node.(*ast.Import); ok && im.Ident != nil && im.Ident.Name == name && im.Tree != nil {
+				return lspTreeDeclarations(doc.analysis, im.Tree.Path, true, map[string]bool{})
+			}
+		}
+	}
+	// Fields and methods of a value.
+	nodes := doc.analysis.NodesAt(doc.path, offset)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		expr, ok := nodes[i].(ast.Expression)
+		if !ok {
+			continue
+		}
+		if t := doc.analysis.TypeOf(expr); t != nil {
+			return lspTypeMembers(t)
+		}
+	}
+	return nil
+}
+
+// fsys returns the file system of the templates, where the files of the open
+// documents have the text of the documents.
+func (s *languageServer) fsys() fs.FS {
+	docs := map[string]string{}
+	for _, doc := range s.docs {
+		if doc.path != "" {
+			docs[doc.path] = doc.text
+		}
+	}
+	return lspFS{FS: os.DirFS(s.root), docs: docs}
+}
+
+// readFile reads the file with the given path from fsys.
+func (s *languageServer) readFile(fsys fs.FS, path string) (string, error) {
+	src, err := fs.ReadFile(fsys, path)
+	return string(src), err
+}
+
+// uri returns the URI of the file with the given path relative to the root.
+func (s *languageServer) uri(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(s.root, filepath.FromSlash(path)))}
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	return u.String()
+}
+
+// lspFS is a file system that reads the files from FS, except those of the
+// open documents that are read from docs.
+type lspFS struct {
+	fs.FS
+	docs map[string]string
+}
+
+func (fsys lspFS) ReadFile(name string) ([]byte, error) {
+	if text, ok := fsys.docs[name]; ok {
+		return []byte(text), nil
+	}
+	return fs.ReadFile(fsys.FS, name)
+}
+
+// lspURIToPath returns the file path of the file URI uri and true. If uri is
+// not a file URI, it returns an empty string and false.
+func lspURIToPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	p := u.Path
+	// Remove the leading slash of Windows paths as /C:/dir.
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p), true
+}
+
+// lspTreeDeclarations returns the completion items of the declarations of
+// the file with the given path, and of the files that it imports without a
+// name. If exported is true, only the exported declarations are returned.
+func lspTreeDeclarations(analysis *compiler.Analysis, path string, exported bool, visited map[string]bool) []lspCompletionItem {
+	tree := analysis.Tree(path)
+	if tree == nil || visited[path] {
+		return nil
+	}
+	visited[path] = true
+	var items []lspCompletionItem
+	add := func(ident *ast.Identifier, kind int, detail string) {
+		if ident == nil || ident.Name == "_" || exported && !isExported(ident.Name) {
+			return
+		}
+		items = append(items, lspCompletionItem{Label: ident.Name, Kind: kind, Detail: detail})
+	}
+	for _, node := range tree.Nodes {
+		switch n := node.(type) {
+		case *ast.Func:
+			add(n.Ident, lspFunctionKind, "macro")
+		case *ast.Var:
+			for _, ident := range n.Lhs {
+				add(ident, lspVariableKind, "var")
+			}
+		case *ast.Const:
+			for _, ident := range n.Lhs {
+				add(ident, lspConstantKind, "const")
+			}
+		case *ast.TypeDeclaration:
+			add(n.Ident, lspClassKind, "type")
+		case *ast.Import:
+			if n.Tree != nil && (n.Ident == nil || n.Ident.Name == ".") {
+				items = append(items, lspTreeDeclarations(analysis, n.Tree.Path, true, visited)...)
+			}
+		}
+	}
+	return items
+}
+
+// lspTypeMembers returns the completion items of the exported fields and
+// methods of the type t.
+func lspTypeMembers(t reflect.Type) []lspCompletionItem {
+	var items []lspCompletionItem
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.IsExported() {
+			items = append(items, lspCompletionItem{Label: m.Name, Kind: lspMethodKind, Detail: m.Type.String()})
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(t) {
+			if f.IsExported() && !f.Anonymous {
+				items = append(items, lspCompletionItem{Label: f.Name, Kind: lspFieldKind, Detail: f.Type.String()})
+			}
+		}
+	}
+	return items
+}
+
+// lspDeclarationKind returns the completion item kind of a native
+// declaration.
+func lspDeclarationKind(decl native.Declaration) int {
+	switch decl.(type) {
+	case reflect.Type:
+		return lspClassKind
+	case native.ImportablePackage:
+		return lspModuleKind
+	case native.UntypedStringConst, native.UntypedBooleanConst, native.UntypedNumericConst:
+		return lspConstantKind
+	}
+	switch reflect.TypeOf(decl).Kind() {
+	case reflect.Func:
+		return lspFunctionKind
+	case reflect.Ptr:
+		return lspVariableKind
+	}
+	return lspConstantKind
+}
+
+// lspDeclarationDetail returns the detail of the completion item of a
+// native declaration.
+func lspDeclarationDetail(decl native.Declaration) string {
+	switch d := decl.(type) {
+	case reflect.Type:
+		return "type " + d.String()
+	case native.ImportablePackage:
+		return "package " + d.PackageName()
+	case native.UntypedStringConst:
+		return "untyped string constant"
+	case native.UntypedBooleanConst:
+		return "untyped bool constant"
+	case native.UntypedNumericConst:
+		return "untyped numeric constant"
+	}
+	t := reflect.TypeOf(decl)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().String()
+	}
+	return t.String()
+}
+
+// isIdentifierByte reports whether c can be a byte of an identifier.
+func isIdentifierByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+// isExported reports whether name is exported.
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// lspPositionOf returns the position of the byte at index offset of src.
+func lspPositionOf(src string, offset int) lspPosition {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := strings.Count(src[:offset], "\n")
+	start := strings.LastIndexByte(src[:offset], '\n') + 1
+	var character int
+	for _, r := range src[start:offset] {
+		character++
+		if r >= 0x10000 {
+			character++
+		}
+	}
+	return lspPosition{Line: line, Character: character}
+}
+
+// lspOffset returns the index of the byte of src at the position pos.
+func lspOffset(src string, pos lspPosition) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i == -1 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	character := 0
+	for i, r := range src[offset:] {
+		if character >= pos.Character || r == '\n' {
+			return offset + i
+		}
+		character++
+		if r >= 0x10000 {
+			character++
+		}
+	}
+	return len(src)
+}
+
+// lspPositionRange returns the range of src at the position pos of a
+// diagnostic, whose Line and Column fields are always significant.
+func lspPositionRange(src string, pos ast.Position) lspRange {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i == -1 {
+			offset = len(src)
+			break
+		}
+		offset += i + 1
+	}
+	for column := 1; column < pos.Column && offset < len(src) && src[offset] != '\n'; column++ {
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	end := offset
+	if pos.End > pos.Start {
+		end += pos.End - pos.Start + 1
+	}
+	return lspRange{Start: lspPositionOf(src, offset), End: lspPositionOf(src, end)}
+}
+
+// lspNodeRange returns the range of src at the position pos of a node.
+func lspNodeRange(src string, pos *ast.Position) lspRange {
+	if pos == nil {
+		return lspRange{}
+	}
+	return lspRange{Start: lspPositionOf(src, pos.Start), End: lspPositionOf(src, pos.End+1)}
+}
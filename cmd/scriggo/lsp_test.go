@@ -0,0 +1,226 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lspClient is a Language Server Protocol client used to test the language
+// server.
+type lspClient struct {
+	t        *testing.T
+	w        io.Writer
+	id       int
+	messages chan map[string]interface{}
+}
+
+// newLspClient returns a client connected to a new language server.
+func newLspClient(t *testing.T) *lspClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &lspClient{t: t, w: inW, messages: make(chan map[string]interface{}, 100)}
+	go func() {
+		_ = newLanguageServer(inR, outW, "", nil).serve()
+		_ = outW.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		r := bufio.NewReader(outR)
+		for {
+			data, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			var msg map[string]interface{}
+			_ = json.Unmarshal(data, &msg)
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { _ = inW.Close() })
+	return c
+}
+
+// request sends a request and returns the result of its response.
+func (c *lspClient) request(method string, params interface{}) interface{} {
+	c.id++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+	for {
+		msg := c.next()
+		if msg["id"] == float64(c.id) {
+			if e, ok := msg["error"]; ok {
+				c.t.Fatalf("%s request failed: %v", method, e)
+			}
+			return msg["result"]
+		}
+	}
+}
+
+// notify sends a notification.
+func (c *lspClient) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// send sends the message msg.
+func (c *lspClient) send(msg interface{}) {
+	data, _ := json.Marshal(msg)
+	_, _ = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+// next returns the next message sent by the server.
+func (c *lspClient) next() map[string]interface{} {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("unexpected end of messages")
+		}
+		return msg
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("expecting a message, got nothing")
+	}
+	return nil
+}
+
+// diagnostics waits for the diagnostics published for the file with the
+// given URI and returns their messages.
+func (c *lspClient) diagnostics(uri string) []string {
+	for {
+		msg := c.next()
+		if msg["method"] != "textDocument/publishDiagnostics" {
+			continue
+		}
+		params := msg["params"].(map[string]interface{})
+		if params["uri"] != uri {
+			continue
+		}
+		var messages []string
+		for _, d := range params["diagnostics"].([]interface{}) {
+			d := d.(map[string]interface{})
+			start := d["range"].(map[string]interface{})["start"].(map[string]interface{})
+			messages = append(messages, fmt.Sprintf("%v:%v: %s", start["line"], start["character"], d["message"]))
+		}
+		return messages
+	}
+}
+
+func TestLanguageServer(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":  "{% extends \"layout.html\" %}\n{% import \"macros.html\" %}\n{% macro Body %}\n{% var s = \"a\" %}\n{{ Title(s) }}\n{% end %}",
+		"layout.html": "<body>{{ Body() }}</body>",
+		"macros.html": "{% macro Title(s string) %}<h1>{{ s }}</h1>{% end %}",
+	}
+	for name, src := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := func(name string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, name))}).String()
+	}
+	index := uri("index.html")
+	position := func(line, character int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": index},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		}
+	}
+
+	c := newLspClient(t)
+	c.request("initialize", map[string]interface{}{"rootUri": uri("")})
+	c.notify("initialized", map[string]interface{}{})
+
+	// Diagnostics.
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": index, "languageId": "html", "version": 1, "text": files["index.html"]},
+	})
+	if d := c.diagnostics(index); len(d) > 0 {
+		t.Fatalf("unexpected diagnostics %q", d)
+	}
+	text := strings.Replace(files["index.html"], "{{ Title(s) }}", "{{ Title(t) }}\n{{ 1 + \"a\" }}", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": index, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": text}},
+	})
+	expected := []string{"4:9: undefined: t", "5:5: invalid operation: 1 + \"a\" (mismatched types int and string)"}
+	if d := c.diagnostics(index); strings.Join(d, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected diagnostics %q, got %q", expected, d)
+	}
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": index, "version": 3},
+		"contentChanges": []interface{}{map[string]interface{}{"text": files["index.html"]}},
+	})
+	if d := c.diagnostics(index); len(d) > 0 {
+		t.Fatalf("unexpected diagnostics %q", d)
+	}
+
+	// Hover.
+	hover, _ := c.request("textDocument/hover", position(4, 9)).(map[string]interface{})
+	if hover == nil {
+		t.Fatal("expected hover, got nothing")
+	}
+	if value := hover["contents"].(map[string]interface{})["value"]; value != "```go\nstring\n```" {
+		t.Fatalf("unexpected hover %q", value)
+	}
+
+	// Definition.
+	tests := []struct {
+		line, character int
+		uri             string
+		start           float64
+	}{
+		{4, 4, uri("macros.html"), 9},
+		{4, 9, index, 7},
+		{0, 14, uri("layout.html"), 0},
+		{1, 12, uri("macros.html"), 0},
+	}
+	for _, test := range tests {
+		loc, _ := c.request("textDocument/definition", position(test.line, test.character)).(map[string]interface{})
+		if loc == nil {
+			t.Fatalf("%d:%d: expected a definition, got nothing", test.line, test.character)
+		}
+		start := loc["range"].(map[string]interface{})["start"].(map[string]interface{})
+		if loc["uri"] != test.uri || start["character"] != test.start {
+			t.Fatalf("%d:%d: expected definition at %s:%v, got %s:%v", test.line, test.character, test.uri, test.start, loc["uri"], start["character"])
+		}
+	}
+
+	// Completion.
+	text = strings.Replace(files["index.html"], "{{ Title(s) }}", "{{ toU }}{{ unsafeconv.To }}", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": index, "version": 4},
+		"contentChanges": []interface{}{map[string]interface{}{"text": text}},
+	})
+	completion := func(character int) string {
+		items, _ := c.request("textDocument/completion", position(4, character)).([]interface{})
+		labels := make([]string, len(items))
+		for i, item := range items {
+			labels[i] = item.(map[string]interface{})["label"].(string)
+		}
+		return strings.Join(labels, " ")
+	}
+	if labels := completion(6); labels != "toUpper" {
+		t.Fatalf("expected completion %q, got %q", "toUpper", labels)
+	}
+	if labels := completion(2); !strings.Contains(labels, "Body") || !strings.Contains(labels, "Title") || !strings.Contains(labels, "toUpper") {
+		t.Fatalf("unexpected completion %q", labels)
+	}
+	if labels := completion(25); labels != "ToCSS ToHTML ToJS ToJSON ToMarkdown" {
+		t.Fatalf("unexpected completion %q", labels)
+	}
+
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+}
@@ -117,6 +117,15 @@ var commandsHelp = map[string]func(){
 	"import": func() {
 		txtToHelp(helpImport)
 	},
+	"build": func() {
+		txtToHelp(helpBuild)
+	},
+	"debug": func() {
+		txtToHelp(helpDebug)
+	},
+	"fmt": func() {
+		txtToHelp(helpFmt)
+	},
 	"init": func() {
 		txtToHelp(helpInit)
 	},
@@ -129,6 +138,12 @@ var commandsHelp = map[string]func(){
 	"limitations": func() {
 		txtToHelp(helpLimitations)
 	},
+	"lsp": func() {
+		txtToHelp(helpLSP)
+	},
+	"test": func() {
+		txtToHelp(helpTest)
+	},
 	"stdlib": func() {
 		stderr(
 			`usage: scriggo stdlib`,
@@ -155,6 +170,65 @@ var commands = map[string]func(){
 		fmt.Fprintf(os.Stdout, "If you encountered an issue, report it at:\n\n\thttps://github.com/open2b/scriggo/issues/new\n\n")
 		exit(0)
 	},
+	"build": func() {
+		flag.Usage = commandsHelp["build"]
+		o := flag.String("o", "public", "write the site to the named directory.")
+		var consts []string
+		flag.Func("const", "build with global constants with the given names and values.", func(s string) error {
+			consts = append(consts, s)
+			return nil
+		})
+		var partials []string
+		flag.Func("partial", "treat the files whose paths match the pattern as partial files.", func(s string) error {
+			partials = append(partials, s)
+			return nil
+		})
+		j := flag.Int("j", runtime.NumCPU(), "number of pages that can be rendered in parallel.")
+		force := flag.Bool("force", false, "rebuild all the pages and copy all the assets.")
+		v := flag.Bool("v", false, "print the names of the files as they are written.")
+		flag.Parse()
+		root := "."
+		switch len(flag.Args()) {
+		case 0:
+		case 1:
+			root = flag.Arg(0)
+		default:
+			flag.Usage()
+			exitError(`bad number of arguments`)
+		}
+		err := buildSite(root, siteFlags{consts: consts, partials: partials, o: *o, j: *j, force: *force, v: *v}, os.Stdout)
+		if err != nil {
+			exitError("%s", err)
+		}
+		exit(0)
+	},
+	"debug": func() {
+		flag.Usage = commandsHelp["debug"]
+		addr := flag.String("addr", "", "listen on the named TCP address instead of using the standard input and output.")
+		flag.Parse()
+		if len(flag.Args()) > 0 {
+			flag.Usage()
+			exitError(`bad number of arguments`)
+		}
+		err := debugServe(*addr)
+		if err != nil {
+			exitError("%s", err)
+		}
+		exit(0)
+	},
+	"fmt": func() {
+		flag.Usage = commandsHelp["fmt"]
+		l := flag.Bool("l", false, "list the files whose formatting differs from the formatter's.")
+		w := flag.Bool("w", false, "write the result to the files instead of stdout.")
+		d := flag.Bool("d", false, "print the diffs instead of the formatted files.")
+		format := flag.String("format", "", "force fmt to use the named file format.")
+		flag.Parse()
+		err := formatTemplates(flag.Args(), fmtFlags{l: *l, w: *w, d: *d, format: *format}, os.Stdout, os.Stderr)
+		if err != nil {
+			exitError("%s", err)
+		}
+		exit(0)
+	},
 	"init": func() {
 		flag.Usage = commandsHelp["init"]
 		f := flag.String("f", "", "path of the Scriggofile.")
@@ -197,6 +271,25 @@ var commands = map[string]func(){
 		}
 		exit(0)
 	},
+	"lsp": func() {
+		flag.Usage = commandsHelp["lsp"]
+		root := flag.String("root", "", "set the root directory to named dir instead of the workspace root.")
+		var consts []string
+		flag.Func("const", "analyze with global constants with the given names and values.", func(s string) error {
+			consts = append(consts, s)
+			return nil
+		})
+		flag.Parse()
+		if len(flag.Args()) > 0 {
+			flag.Usage()
+			exitError(`bad number of arguments`)
+		}
+		err := languageServe(*root, consts)
+		if err != nil {
+			exitError("%s", err)
+		}
+		exit(0)
+	},
 	"run": func() {
 		flag.Usage = commandsHelp["run"]
 		root := flag.String("root", "", "set the root directory to named dir instead of the file's directory.")
@@ -208,6 +301,8 @@ var commands = map[string]func(){
 		format := flag.String("format", "", "force run to use the named file format.")
 		s := flag.Int("S", 0, "print assembly listing. n determines the length of Text instructions.")
 		metrics := flag.Bool("metrics", false, "print metrics about file execution.")
+		profile := flag.String("profile", "", "write a CPU and allocation profile of the execution to the named file.")
+		sourceMap := flag.String("sourcemap", "", "write the source map of the output to the named file.")
 		o := flag.String("o", "", "write the resulting code to the named file or directory instead of stdout.")
 		flag.Parse()
 		asm := -2 // -2: no assembler
@@ -228,7 +323,33 @@ var commands = map[string]func(){
 		default:
 			exitError("%s", "too many file names")
 		}
-		err := run(name, buildFlags{consts: consts, format: *format, metrics: *metrics, o: *o, root: *root, s: asm})
+		err := run(name, buildFlags{consts: consts, format: *format, metrics: *metrics, o: *o, profile: *profile, root: *root, s: asm, sourceMap: *sourceMap})
+		if err != nil {
+			exitError("%s", err)
+		}
+		exit(0)
+	},
+	"test": func() {
+		flag.Usage = commandsHelp["test"]
+		var consts []string
+		flag.Func("const", "run the tests with global constants with the given names and values.", func(s string) error {
+			consts = append(consts, s)
+			return nil
+		})
+		run := flag.String("run", "", "run only the tests whose file names match the regular expression.")
+		update := flag.Bool("update", false, "update the golden files with the outputs of the tests.")
+		v := flag.Bool("v", false, "print the names of the tests as they are run.")
+		flag.Parse()
+		dir := "."
+		switch len(flag.Args()) {
+		case 0:
+		case 1:
+			dir = flag.Arg(0)
+		default:
+			flag.Usage()
+			exitError(`bad number of arguments`)
+		}
+		err := testTemplates(dir, testFlags{consts: consts, run: *run, update: *update, v: *v}, os.Stdout)
 		if err != nil {
 			exitError("%s", err)
 		}
@@ -417,10 +538,10 @@ func _import(path string, flags buildFlags) (err error) {
 }
 
 type buildFlags struct {
-	metrics, work, v, x, w bool
-	f, format, o, root     string
-	consts                 []string
-	s                      int
+	metrics, work, v, x, w                 bool
+	f, format, o, profile, root, sourceMap string
+	consts                                 []string
+	s                                      int
 }
 
 // _init executes the sub commands "init":
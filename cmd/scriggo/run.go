@@ -6,6 +6,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -36,55 +37,14 @@ const runBufSize = 65536
 //	scriggo run
 func run(name string, flags buildFlags) (err error) {
 
-	var fsys fs.FS
-	if flags.root == "" {
-		fsys = os.DirFS(filepath.Dir(name))
-		name = filepath.Base(name)
-	} else {
-		root, err := filepath.Abs(flags.root)
-		if err != nil {
-			return err
-		}
-		nameAbs, err := filepath.Abs(name)
-		if err != nil {
-			return err
-		}
-		name, err = filepath.Rel(root, nameAbs)
-		if err != nil {
-			return err
-		}
-		fsys = os.DirFS(root)
-	}
-
-	// Handle "-format" option.
-	if flags.f != "" {
-		format, err := parseFormat(flags.f)
-		if err != nil {
-			return err
-		}
-		fsys = formatFS{FS: fsys, format: format}
-	}
-
-	md := goldmark.New(
-		goldmark.WithRendererOptions(html.WithUnsafe()),
-		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
-		goldmark.WithExtensions(extension.GFM))
-
-	opts := &scriggo.BuildOptions{
-		AllowGoStmt: true,
-		Globals:     globals,
-		MarkdownConverter: func(src []byte, out io.Writer) error {
-			return md.Convert(src, out)
-		},
+	fsys, name, err := runFS(name, flags.root, flags.format)
+	if err != nil {
+		return err
 	}
-	opts.Globals["filepath"] = strings.TrimSuffix(name, path.Ext(name))
 
-	// Handle "-const" option.
-	for _, consts := range flags.consts {
-		err = parseConstants(consts, opts.Globals)
-		if err != nil {
-			return err
-		}
+	opts, err := templateBuildOptions(name, flags.consts)
+	if err != nil {
+		return err
 	}
 
 	var start time.Time
@@ -95,7 +55,7 @@ func run(name string, flags buildFlags) (err error) {
 	// Build the template.
 	template, err := scriggo.BuildTemplate(fsys, name, opts)
 	if err != nil {
-		return err
+		return buildError(err)
 	}
 
 	var buildTime time.Duration
@@ -128,8 +88,23 @@ func run(name string, flags buildFlags) (err error) {
 		start = time.Now()
 	}
 
+	// Handle the "-profile" and "-sourcemap" options.
+	var runOpts *scriggo.RunOptions
+	if flags.profile != "" || flags.sourceMap != "" {
+		runOpts = &scriggo.RunOptions{}
+		if flags.profile != "" {
+			runOpts.Profiler = scriggo.NewProfiler()
+		}
+		if flags.sourceMap != "" {
+			runOpts.SourceMap = &scriggo.SourceMap{}
+			if out != os.Stdout {
+				runOpts.SourceMap.File = filepath.Base(out.Name())
+			}
+		}
+	}
+
 	// Run the template.
-	err = template.Run(buf, nil, nil)
+	err = template.Run(buf, nil, runOpts)
 
 	if flags.metrics {
 		runTime := time.Since(start)
@@ -142,9 +117,131 @@ func run(name string, flags buildFlags) (err error) {
 		err = buf.Flush()
 	}
 
+	if err == nil && flags.profile != "" {
+		err = writeProfile(flags.profile, runOpts.Profiler)
+	}
+	if err == nil && flags.sourceMap != "" {
+		err = writeSourceMap(flags.sourceMap, runOpts.SourceMap)
+	}
+
 	return err
 }
 
+// buildError returns the error err returned by a build. If err is a build
+// error with several diagnostics, it returns an error whose message has all
+// the diagnostics, one per line.
+func buildError(err error) error {
+	e, ok := err.(*scriggo.BuildError)
+	if !ok {
+		return err
+	}
+	diagnostics := e.Diagnostics()
+	if len(diagnostics) == 1 {
+		return err
+	}
+	var b strings.Builder
+	for i, d := range diagnostics {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(d.Error())
+	}
+	return errors.New(b.String())
+}
+
+// writeSourceMap writes the source map sm to the named file.
+func writeSourceMap(name string, sm *scriggo.SourceMap) error {
+	data, err := json.Marshal(sm)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0666)
+}
+
+// writeProfile writes the profile of profiler to the named file.
+func writeProfile(name string, profiler *scriggo.Profiler) error {
+	fi, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = profiler.WriteProfile(fi)
+	if err2 := fi.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// runFS returns the file system of the template file with the given
+// name and the name of the file in the file system. The file system is rooted
+// at root or, if root is empty, at the directory of the file. If format is not
+// empty, it is the format of all the files of the file system.
+func runFS(name, root, format string) (fs.FS, string, error) {
+	var fsys fs.FS
+	if root == "" {
+		fsys = os.DirFS(filepath.Dir(name))
+		name = filepath.Base(name)
+	} else {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, "", err
+		}
+		nameAbs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, "", err
+		}
+		name, err = filepath.Rel(root, nameAbs)
+		if err != nil {
+			return nil, "", err
+		}
+		fsys = os.DirFS(root)
+	}
+	// Handle the "-format" option.
+	if format != "" {
+		format, err := parseFormat(format)
+		if err != nil {
+			return nil, "", err
+		}
+		fsys = formatFS{FS: fsys, format: format}
+	}
+	return fsys, name, nil
+}
+
+// frontMatter is the front matter build option of the templates. The YAML
+// front matter of a template file is accessible through the "frontMatter"
+// global.
+var frontMatter = &scriggo.FrontMatter{Name: "frontMatter"}
+
+// templateBuildOptions returns the options to build the template file with
+// the given name and with the global constants in consts, as passed to the
+// "-const" option.
+func templateBuildOptions(name string, consts []string) (*scriggo.BuildOptions, error) {
+
+	md := goldmark.New(
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithExtensions(extension.GFM))
+
+	opts := &scriggo.BuildOptions{
+		AllowGoStmt: true,
+		Globals:     globals,
+		MarkdownConverter: func(src []byte, out io.Writer) error {
+			return md.Convert(src, out)
+		},
+		FrontMatter: frontMatter,
+	}
+	opts.Globals["filepath"] = strings.TrimSuffix(name, path.Ext(name))
+
+	// Handle the "-const" option.
+	for _, c := range consts {
+		err := parseConstants(c, opts.Globals)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
 // parseFormat parses and returns a format.
 func parseFormat(s string) (scriggo.Format, error) {
 	switch s {
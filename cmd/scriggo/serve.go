@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -52,13 +53,24 @@ func serve(asm int, metrics bool, disableLiveReload bool) error {
 	srv := &server{
 		fsys:   fsys,
 		static: http.FileServer(http.Dir(".")),
-		mdConverter: func(src []byte, out io.Writer) error {
-			return md.Convert(src, out)
-		},
-		templates:             map[string]*scriggo.Template{},
-		templatesDependencies: map[string]map[string]struct{}{},
-		asm:                   asm,
+		asm:    asm,
 	}
+	mdConverter := func(src []byte, out io.Writer) error {
+		return md.Convert(src, out)
+	}
+	srv.templates = scriggo.NewTemplateSet(fsys, func(name string) *scriggo.BuildOptions {
+		opts := scriggo.BuildOptions{
+			AllowGoStmt:       true,
+			MarkdownConverter: mdConverter,
+			Globals:           make(native.Declarations, len(globals)+1),
+			FrontMatter:       frontMatter,
+		}
+		for n, v := range globals {
+			opts.Globals[n] = v
+		}
+		opts.Globals["filepath"] = strings.TrimSuffix(name, path.Ext(name))
+		return &opts
+	})
 	if !disableLiveReload {
 		srv.liveReloads = map[*liveReload]struct{}{}
 	}
@@ -70,37 +82,16 @@ func serve(asm int, metrics bool, disableLiveReload bool) error {
 		for {
 			select {
 			case name := <-fsys.Changed():
-				srv.Lock()
-				invalidated := map[string]bool{}
-				if _, ok := srv.templates[name]; ok {
-					delete(srv.templates, name)
-					for _, dependents := range srv.templatesDependencies {
-						delete(dependents, name)
-					}
-					invalidated[name] = true
-				} else {
-					for dependency, dependents := range srv.templatesDependencies {
-						if dependency == name {
-							for d := range dependents {
-								delete(srv.templates, d)
-								invalidated[d] = true
-							}
-						}
-					}
-					for invalidated := range invalidated {
-						for _, dependents := range srv.templatesDependencies {
-							delete(dependents, invalidated)
-						}
-					}
-				}
+				invalidated := srv.templates.Invalidate(name)
 				if len(invalidated) > 0 {
+					srv.Lock()
 					for r := range srv.liveReloads {
-						if invalidated[r.file+".html"] || invalidated[r.file+".md"] {
+						if slices.Contains(invalidated, r.file+".html") || slices.Contains(invalidated, r.file+".md") {
 							go func() { r.reload() }()
 						}
 					}
+					srv.Unlock()
 				}
-				srv.Unlock()
 			case err := <-fsys.Errors:
 				srv.logf("%v", err)
 			}
@@ -122,17 +113,15 @@ func serve(asm int, metrics bool, disableLiveReload bool) error {
 }
 
 type server struct {
-	fsys        *templateFS
-	static      http.Handler
-	mdConverter scriggo.Converter
-	runOptions  *scriggo.RunOptions
-	asm         int
+	fsys       *templateFS
+	static     http.Handler
+	templates  *scriggo.TemplateSet
+	runOptions *scriggo.RunOptions
+	asm        int
 
 	sync.Mutex
-	templates             map[string]*scriggo.Template
-	templatesDependencies map[string]map[string]struct{}
-	liveReloads           map[*liveReload]struct{}
-	metrics               struct {
+	liveReloads map[*liveReload]struct{}
+	metrics     struct {
 		active bool
 		header bool
 	}
@@ -189,13 +178,9 @@ func (srv *server) serveLiveReload(w http.ResponseWriter, r *http.Request) {
 
 	srv.Lock()
 	srv.liveReloads[lr] = struct{}{}
-	_, ok := srv.templates[file+".html"]
-	if !ok {
-		_, ok = srv.templates[file+".md"]
-	}
 	srv.Unlock()
 
-	if !ok {
+	if srv.templates.Lookup(file+".html") == nil && srv.templates.Lookup(file+".md") == nil {
 		lr.reload()
 	}
 
@@ -243,31 +228,19 @@ func (srv *server) serveTemplate(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 	var buildTime time.Duration
-	srv.Lock()
-	template, ok := srv.templates[name]
-	srv.Unlock()
 	start := time.Now()
-	if !ok {
-		fs := newRecFS(srv.fsys)
-		opts := scriggo.BuildOptions{
-			AllowGoStmt:       true,
-			MarkdownConverter: srv.mdConverter,
-			Globals:           make(native.Declarations, len(globals)+1),
-		}
-		for n, v := range globals {
-			opts.Globals[n] = v
-		}
-		opts.Globals["filepath"] = strings.TrimSuffix(name, path.Ext(name))
-		template, err = scriggo.BuildTemplate(fs, name, &opts)
+	template := srv.templates.Lookup(name)
+	if template == nil {
+		template, err = srv.templates.Get(name)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				http.NotFound(w, r)
 				return
 			}
-			if err, ok := err.(*scriggo.BuildError); ok {
+			if _, ok := err.(*scriggo.BuildError); ok {
 				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 				w.WriteHeader(500)
-				fmt.Fprintf(w, "%s", err)
+				fmt.Fprintf(w, "%s", buildError(err))
 				return
 			}
 			http.Error(w, "Internal Server Error", 500)
@@ -275,15 +248,6 @@ func (srv *server) serveTemplate(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		buildTime = time.Since(start)
-		srv.Lock()
-		srv.templates[name] = template
-		for _, dependency := range fs.RecordedNames() {
-			if _, ok := srv.templatesDependencies[dependency]; !ok {
-				srv.templatesDependencies[dependency] = map[string]struct{}{}
-			}
-			srv.templatesDependencies[dependency][name] = struct{}{}
-		}
-		srv.Unlock()
 		start = time.Now()
 	}
 	b := bytes.Buffer{}
@@ -0,0 +1,293 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/native"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testFlags are the flags of the test command.
+type testFlags struct {
+	consts []string // global constants, as passed to the "-const" option
+	run    string   // regular expression that selects the tests to run
+	update bool     // update the golden files
+	v      bool     // verbose output
+}
+
+// fixtureExtensions are the extensions of the fixture files, in order of
+// precedence.
+var fixtureExtensions = []string{".json", ".yaml", ".yml"}
+
+// templateTest is a test of a template.
+type templateTest struct {
+	name    string // name of the test file
+	fixture string // name of the fixture file, empty if there is no fixture
+}
+
+// testTemplates executes the sub command "test":
+//
+//	scriggo test
+//
+// It runs the tests of the templates in the directory dir, writing the
+// results to out.
+func testTemplates(dir string, flags testFlags, out io.Writer) error {
+
+	var run *regexp.Regexp
+	if flags.run != "" {
+		var err error
+		run, err = regexp.Compile(flags.run)
+		if err != nil {
+			return fmt.Errorf("invalid -run regular expression: %s", err)
+		}
+	}
+
+	fsys, err := readFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	tests := templateTests(fsys)
+	for _, test := range tests {
+		if run != nil && !run.MatchString(test.name) {
+			continue
+		}
+		if flags.v {
+			_, _ = fmt.Fprintf(out, "=== RUN   %s\n", test.name)
+		}
+		start := time.Now()
+		updated, err := runTemplateTest(fsys, dir, test, flags)
+		elapsed := time.Since(start).Seconds()
+		switch {
+		case err != nil:
+			failed++
+			_, _ = fmt.Fprintf(out, "--- FAIL: %s (%.2fs)\n", test.name, elapsed)
+			for _, line := range strings.SplitAfter(strings.TrimSuffix(err.Error(), "\n"), "\n") {
+				_, _ = fmt.Fprintf(out, "    %s", line)
+			}
+			_, _ = fmt.Fprintln(out)
+		case updated:
+			_, _ = fmt.Fprintf(out, "--- UPDATE: %s (%.2fs)\n", test.name, elapsed)
+		case flags.v:
+			_, _ = fmt.Fprintf(out, "--- PASS: %s (%.2fs)\n", test.name, elapsed)
+		}
+	}
+
+	if failed > 0 {
+		_, _ = fmt.Fprintln(out, "FAIL")
+		return fmt.Errorf("%d of %d tests failed", failed, len(tests))
+	}
+	if len(tests) == 0 {
+		_, _ = fmt.Fprintln(out, "warning: no tests to run")
+	}
+	_, _ = fmt.Fprintln(out, "PASS")
+
+	return nil
+}
+
+// readFiles reads the files in the directory dir, and in its
+// subdirectories, and returns them as a Files file system. It ignores the
+// files and the directories whose names start with a period.
+func readFiles(dir string) (scriggo.Files, error) {
+	files := scriggo.Files{}
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+// templateTests returns the tests of the templates in fsys sorted by name.
+//
+// A test file is a template file whose name without extension ends with
+// "_test", as "index_test.html". Its fixture file, if it exists, has the
+// same name with extension ".json", ".yaml" or ".yml", as "index_test.json".
+// A fixture file is not a test file.
+func templateTests(fsys scriggo.Files) []templateTest {
+	var tests []templateTest
+	fixtures := map[string]bool{}
+	for name := range fsys {
+		ext := path.Ext(name)
+		if _, ok := templateExtensions[ext]; !ok || !strings.HasSuffix(strings.TrimSuffix(name, ext), "_test") {
+			continue
+		}
+		test := templateTest{name: name}
+		for _, fext := range fixtureExtensions {
+			fixture := strings.TrimSuffix(name, ext) + fext
+			if _, ok := fsys[fixture]; ok && fixture != name {
+				test.fixture = fixture
+				fixtures[fixture] = true
+				break
+			}
+		}
+		tests = append(tests, test)
+	}
+	n := 0
+	for _, test := range tests {
+		if !fixtures[test.name] {
+			tests[n] = test
+			n++
+		}
+	}
+	tests = tests[:n]
+	sort.Slice(tests, func(i, j int) bool { return tests[i].name < tests[j].name })
+	return tests
+}
+
+// runTemplateTest runs the test and compares the output with the golden
+// file, or updates the golden file if flags.update is true. dir is the
+// directory of the golden files. It reports whether the golden file has been
+// updated.
+func runTemplateTest(fsys scriggo.Files, dir string, test templateTest, flags testFlags) (bool, error) {
+
+	opts, err := templateBuildOptions(test.name, flags.consts)
+	if err != nil {
+		return false, err
+	}
+	globals := make(native.Declarations, len(opts.Globals))
+	for name, value := range opts.Globals {
+		globals[name] = value
+	}
+	opts.Globals = globals
+
+	// Declare the variables of the fixture.
+	if test.fixture != "" {
+		vars, err := readFixture(fsys, test.fixture)
+		if err != nil {
+			return false, err
+		}
+		for name, value := range vars {
+			if name == "_" || !isIdentifier(name) || isPredeclaredIdentifier(name) {
+				return false, fmt.Errorf("%s: variable name %s cannot be used as identifier", test.fixture, name)
+			}
+			var v reflect.Value
+			if value == nil {
+				v = reflect.New(emptyInterfaceType)
+			} else {
+				v = reflect.New(reflect.TypeOf(value))
+				v.Elem().Set(reflect.ValueOf(value))
+			}
+			globals[name] = v.Interface()
+		}
+	}
+
+	template, err := scriggo.BuildTemplate(fsys, test.name, opts)
+	if err != nil {
+		return false, buildError(err)
+	}
+	var b bytes.Buffer
+	err = template.Run(&b, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	output := b.Bytes()
+
+	golden := test.name + ".golden"
+	expected, ok := fsys[golden]
+	if flags.update {
+		if ok && bytes.Equal(expected, output) {
+			return false, nil
+		}
+		err = os.WriteFile(filepath.Join(dir, filepath.FromSlash(golden)), output, 0666)
+		return err == nil, err
+	}
+	if !ok {
+		return false, fmt.Errorf("missing golden file %s, run with -update to create it", golden)
+	}
+	if !bytes.Equal(expected, output) {
+		diff := unifiedDiff(golden, "output", expected, output)
+		return false, errors.New("output differs from golden file:\n" + string(diff))
+	}
+
+	return false, nil
+}
+
+// emptyInterfaceType is the reflect.Type of the empty interface.
+var emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// readFixture reads the fixture file with the given name and returns its
+// variables. JSON numbers are read as int values, if they are integers, and
+// as float64 values otherwise.
+func readFixture(fsys scriggo.Files, name string) (map[string]interface{}, error) {
+	var vars map[string]interface{}
+	data := fsys[name]
+	if path.Ext(name) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err := dec.Decode(&vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		for name, value := range vars {
+			vars[name] = jsonNumbers(value)
+		}
+		return vars, nil
+	}
+	err := yaml.Unmarshal(data, &vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return vars, nil
+}
+
+// jsonNumbers replaces the json.Number values in v with int and float64
+// values.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil && int64(int(n)) == n {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+)
+
+func TestTemplateTests(t *testing.T) {
+	fsys := scriggo.Files{
+		"index_test.html":      nil,
+		"index_test.json":      nil,
+		"index_test.yaml":      nil,
+		"data_test.json":       nil,
+		"layout.html":          nil,
+		"blog/post_test.md":    nil,
+		"blog/post_test.yml":   nil,
+		"blog/post_test.md.go": nil,
+		"blog/test.html":       nil,
+	}
+	expected := []templateTest{
+		{name: "blog/post_test.md", fixture: "blog/post_test.yml"},
+		{name: "data_test.json"},
+		{name: "index_test.html", fixture: "index_test.json"},
+	}
+	got := templateTests(fsys)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected tests %v, got %v", expected, got)
+	}
+}
+
+func TestTestTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"layout.html":        "<b>{{ Body() }}</b>",
+		"index_test.html":    `{% extends "layout.html" %}{% macro Body %}{{ user.name }} {{ n + 1 }} {{ lang }}{% end %}`,
+		"index_test.json":    `{"user": {"name": "Ann"}, "n": 2}`,
+		"blog/post_test.md":  "{% for v in x %}{{ v }}{% end %}",
+		"blog/post_test.yml": "x: [1, 2]\n",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(name), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(name, []byte(src), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	consts := []string{`lang="en"`}
+
+	// Missing golden files.
+	var out bytes.Buffer
+	err := testTemplates(dir, testFlags{consts: consts}, &out)
+	if err == nil || err.Error() != "2 of 2 tests failed" {
+		t.Fatalf("expected error %q, got %v", "2 of 2 tests failed", err)
+	}
+	if !strings.Contains(out.String(), "missing golden file index_test.html.golden, run with -update to create it") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	// Update the golden files.
+	out.Reset()
+	err = testTemplates(dir, testFlags{consts: consts, update: true}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"index_test.html.golden":   "<b>Ann 3 en</b>",
+		"blog/post_test.md.golden": "12",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != expected {
+			t.Fatalf("expected golden file %s %q, got %q", name, expected, got)
+		}
+	}
+
+	// Pass.
+	out.Reset()
+	err = testTemplates(dir, testFlags{consts: consts, v: true}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "--- PASS: index_test.html") || !strings.HasSuffix(out.String(), "PASS\n") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	// Fail with a diff.
+	err = os.WriteFile(filepath.Join(dir, "index_test.json"), []byte(`{"user": {"name": "Bob"}, "n": 2}`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = testTemplates(dir, testFlags{consts: consts, run: "index"}, &out)
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	expected := strings.Join([]string{
+		"--- FAIL: index_test.html",
+		"    output differs from golden file:",
+		"    --- index_test.html.golden",
+		"    +++ output",
+		"    @@ -1 +1 @@",
+		"    -<b>Ann 3 en</b>",
+		"    \\ No newline at end of file",
+		"    +<b>Bob 3 en</b>",
+		"    \\ No newline at end of file",
+		"FAIL",
+	}, "\n")
+	got := out.String()
+	if i := strings.Index(got, " ("); i > 0 {
+		if j := strings.Index(got[i:], "\n"); j > 0 {
+			got = got[:i] + got[i+j:]
+		}
+	}
+	if got != expected+"\n" {
+		t.Fatalf("expected output %q, got %q", expected+"\n", got)
+	}
+}
@@ -5,7 +5,10 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -89,8 +92,9 @@ var predeclaredIdentifier = []string{
 	"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
 	"int", "int8", "int16", "int32", "int64", "rune", "string", "uint", "uint8",
 	"uint16", "uint32", "uint64", "uintptr", "true", "false", "iota",
-	"nil", "append", "cap", "close", "complex", "copy", "delete", "imag",
-	"len", "make", "new", "panic", "print", "println", "real", "recover",
+	"nil", "append", "cap", "clear", "close", "complex", "copy", "delete",
+	"imag", "len", "make", "max", "min", "new", "panic", "print", "println",
+	"real", "recover",
 }
 
 // isPredeclaredIdentifier reports whether name is a Go predeclared
@@ -338,3 +342,46 @@ func cleanPath(path string) string {
 	}
 	return string(b)
 }
+
+// readMessage reads a message with a Content-Length header, as sent by the
+// Debug Adapter Protocol and the Language Server Protocol, and returns its
+// content.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length header %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("missing Content-Length header")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeMessage writes msg, encoded as JSON, with a Content-Length header.
+func writeMessage(w io.Writer, msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
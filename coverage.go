@@ -0,0 +1,118 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/open2b/scriggo/internal/compiler"
+)
+
+// Coverage collects the line coverage of the executions of programs and
+// templates built with the Coverage build option. To collect the coverage of
+// an execution, pass a Coverage to the Run method with the Coverage field of
+// RunOptions.
+//
+// A Coverage can collect the coverage of many executions, also of different
+// programs and templates and at the same time. The coverage of the files
+// shared by different templates, as imported and extended files, is merged.
+//
+// The zero value of Coverage is ready to use.
+type Coverage struct {
+	mu     sync.Mutex
+	blocks map[compiler.CoverBlock]int
+}
+
+// CoverLine represents the coverage of a source code line.
+//
+// Count is the number of times that the line has been executed, that is the
+// maximum number of times that one of its statements has been executed. For
+// templates, a line of text is executed when it is rendered.
+type CoverLine struct {
+	Path      string // path of the source code, as reported by build errors.
+	Line      int    // line.
+	Column    int    // column of the first covered character.
+	EndColumn int    // column after the last covered character.
+	NumStmt   int    // number of statements and texts in the line.
+	Count     int    // number of executions.
+}
+
+// add adds the executions counted by counters to the blocks.
+func (c *Coverage) add(blocks []compiler.CoverBlock, counters []uint32) {
+	c.mu.Lock()
+	if c.blocks == nil {
+		c.blocks = map[compiler.CoverBlock]int{}
+	}
+	for i, block := range blocks {
+		c.blocks[block] += int(counters[i])
+	}
+	c.mu.Unlock()
+}
+
+// Lines returns the coverage of the lines with at least a statement or, for
+// templates, a text, sorted by path and line.
+func (c *Coverage) Lines() []CoverLine {
+	c.mu.Lock()
+	type key struct {
+		path string
+		line int
+	}
+	lines := map[key]*CoverLine{}
+	for block, count := range c.blocks {
+		k := key{block.Path, block.Line}
+		l, ok := lines[k]
+		if !ok {
+			l = &CoverLine{Path: block.Path, Line: block.Line, Column: block.Column, EndColumn: block.EndColumn}
+			lines[k] = l
+		}
+		if block.Column < l.Column {
+			l.Column = block.Column
+		}
+		if block.EndColumn > l.EndColumn {
+			l.EndColumn = block.EndColumn
+		}
+		if count > l.Count {
+			l.Count = count
+		}
+		l.NumStmt++
+	}
+	c.mu.Unlock()
+	coverLines := make([]CoverLine, 0, len(lines))
+	for _, l := range lines {
+		coverLines = append(coverLines, *l)
+	}
+	sort.Slice(coverLines, func(i, j int) bool {
+		a, b := coverLines[i], coverLines[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	return coverLines
+}
+
+// WriteProfile writes the line coverage to w in the format of the profiles
+// written by the -coverprofile flag of the go test command, so it can be
+// read by the go tool cover command.
+//
+// filename, if not nil, is called to get the file name of a path written
+// in the profile. To visualize the coverage with 'go tool cover -html', the
+// file names must be absolute or start with '.', as "./index.html".
+func (c *Coverage) WriteProfile(w io.Writer, filename func(path string) string) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("mode: count\n")
+	for _, l := range c.Lines() {
+		name := l.Path
+		if filename != nil {
+			name = filename(name)
+		}
+		_, _ = fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n", name, l.Line, l.Column, l.Line, l.EndColumn, l.NumStmt, l.Count)
+	}
+	return bw.Flush()
+}
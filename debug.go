@@ -0,0 +1,234 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/open2b/scriggo/internal/compiler"
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// DebugStep indicates how a debugged execution is resumed after a stop.
+type DebugStep int
+
+const (
+	// DebugContinue continues the execution until a breakpoint is reached
+	// or a pause is requested.
+	DebugContinue DebugStep = iota
+
+	// DebugStepOver stops the execution at the next line of the current
+	// function, or of a calling function if the current one returns.
+	DebugStepOver
+
+	// DebugStepInto stops the execution at the next line, also if it is in a
+	// called function.
+	DebugStepInto
+
+	// DebugStepOut stops the execution at the next line of a calling
+	// function, after the current function has returned.
+	DebugStepOut
+)
+
+// DebugReason is the reason why a debugged execution has been stopped.
+type DebugReason int
+
+const (
+	DebugBreakpoint DebugReason = iota // a breakpoint has been reached
+	DebugStepped                       // a step has been completed
+	DebugPaused                        // a pause has been requested
+)
+
+// Debugger debugs the execution of a program or template. To debug an
+// execution, pass the debugger to the Run method with the Debugger field of
+// RunOptions.
+//
+// A debugger stops the execution at the start of a statement and calls the
+// stop function passed to NewDebugger. The stop function can inspect the
+// execution and returns how it must be resumed.
+//
+// Only the main goroutine is debugged. Goroutines started by the executed
+// code and functions called by native functions are not debugged.
+//
+// A Debugger must not be used by more executions at the same time.
+type Debugger struct {
+	d    *runtime.Debugger
+	stop func(s *DebugStop) DebugStep
+}
+
+// NewDebugger returns a new debugger. stop is called every time a debugged
+// execution stops, in the goroutine of the execution, and the execution is
+// resumed, as indicated by the returned step, when stop returns.
+func NewDebugger(stop func(s *DebugStop) DebugStep) *Debugger {
+	return &Debugger{d: runtime.NewDebugger(), stop: stop}
+}
+
+// SetBreakpoints sets the breakpoints of the file with the given path,
+// replacing the previous ones. The execution stops before executing the
+// first statement of every line in lines. If lines is empty, the breakpoints
+// of the file are removed.
+//
+// path is the same path reported by build errors: for templates, it is the
+// path of the file, and for programs, it is the import path of the package
+// of the file, "main" for the main package. SetBreakpoints can be called
+// while the execution is running.
+func (d *Debugger) SetBreakpoints(path string, lines []int) {
+	d.d.SetBreakpoints(path, lines)
+}
+
+// Pause requests to stop the execution before executing the next statement.
+// Pause can be called while the execution is running and, if it is called
+// before, the execution stops at the first statement.
+func (d *Debugger) Pause() {
+	d.d.Pause()
+}
+
+// debug sets d as the debugger of vm. globals are the global variables of
+// the executed code.
+func (d *Debugger) debug(vm *runtime.VM, globals []compiler.Global) {
+	vm.SetDebugger(d.d, func(s *runtime.DebugStop) runtime.DebugStep {
+		return runtime.DebugStep(d.stop(&DebugStop{s: s, globals: globals}))
+	})
+}
+
+// DebugStop represents a stop of a debugged execution. It can only be used
+// during the call to the stop function that it has been passed to.
+type DebugStop struct {
+	s       *runtime.DebugStop
+	globals []compiler.Global
+	frames  []runtime.DebugFrame
+}
+
+// DebugFrame represents a frame of the call stack of a stopped execution.
+type DebugFrame struct {
+	Function string   // function name, for example "main.f".
+	Path     string   // path of the file, if known.
+	Position Position // position in the file, if known.
+}
+
+// DebugVariable represents a variable of a stopped execution.
+//
+// Type is the type of the variable and Value its value. If the type of the
+// variable is an interface type, Value is the value in the interface or the
+// zero Value if the interface is nil.
+type DebugVariable struct {
+	Name  string
+	Type  reflect.Type
+	Value reflect.Value
+}
+
+// Reason returns the reason of the stop.
+func (s *DebugStop) Reason() DebugReason {
+	return DebugReason(s.s.Reason())
+}
+
+// Frames returns the frames of the call stack. The first frame is the frame
+// of the running function.
+func (s *DebugStop) Frames() []DebugFrame {
+	frames := make([]DebugFrame, len(s.runtimeFrames()))
+	for i, f := range s.frames {
+		frames[i] = DebugFrame{
+			Function: debugFunctionName(f.Function),
+			Path:     f.Path,
+			Position: Position{
+				Line:   f.Position.Line,
+				Column: f.Position.Column,
+				Start:  f.Position.Start,
+				End:    f.Position.End,
+			},
+		}
+	}
+	return frames
+}
+
+// Locals returns the local variables, parameters included, in scope in the
+// frame with index frame, as returned by Frames. The local variables of a
+// frame may not be available, for example if its function has been
+// tail-called.
+func (s *DebugStop) Locals(frame int) []DebugVariable {
+	frames := s.runtimeFrames()
+	if frame < 0 || frame >= len(frames) {
+		return nil
+	}
+	locals := s.s.Locals(frames[frame])
+	vars := make([]DebugVariable, len(locals))
+	for i, v := range locals {
+		vars[i] = DebugVariable(v)
+	}
+	return vars
+}
+
+// Local returns the local variable with the given name in scope in the frame
+// with index frame, as returned by Frames, and true. If there is no such
+// variable, it returns false.
+func (s *DebugStop) Local(frame int, name string) (DebugVariable, bool) {
+	for _, v := range s.Locals(frame) {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return DebugVariable{}, false
+}
+
+// Globals returns the global variables. The name of a global variable
+// declared in a package other than main is qualified with the package name.
+func (s *DebugStop) Globals() []DebugVariable {
+	values := s.s.Globals()
+	vars := make([]DebugVariable, len(values))
+	for i, v := range values {
+		global := s.globals[i]
+		name := global.Name
+		if global.Pkg != "main" {
+			name = global.Pkg + "." + name
+		}
+		vars[i] = DebugVariable{Name: name, Type: global.Type, Value: v}
+	}
+	return vars
+}
+
+// runtimeFrames returns the frames of the runtime.
+func (s *DebugStop) runtimeFrames() []runtime.DebugFrame {
+	if s.frames == nil {
+		s.frames = s.s.Frames()
+	}
+	return s.frames
+}
+
+// debugFunctionName returns the name of fn as reported by a debugger. As in
+// Go, function literals are named after their parent, numbered in the order
+// they occur in it, for example "main.main.func1".
+func debugFunctionName(fn *runtime.Function) string {
+	if fn.Name == "" && fn.Parent != nil {
+		n := 0
+		for _, f := range fn.Parent.Functions {
+			if f.Parent == fn.Parent && f.Name == "" {
+				n++
+			}
+			if f == fn {
+				break
+			}
+		}
+		return debugFunctionName(fn.Parent) + ".func" + strconv.Itoa(n)
+	}
+	if path, ok := renderedFile(fn); ok {
+		return "render " + path
+	}
+	return fn.Pkg + "." + fn.Name
+}
+
+// renderedFile returns the path of the rendered file if fn is the macro that
+// the compiler declares to render a file, as M"partial.html".
+func renderedFile(fn *runtime.Function) (string, bool) {
+	if !fn.Macro || !strings.HasPrefix(fn.Name, `M"`) {
+		return "", false
+	}
+	path, err := strconv.Unquote(fn.Name[1:])
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
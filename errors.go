@@ -24,7 +24,17 @@ func (p Position) String() string {
 	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
 }
 
+// ErrIncompatibleEncoding is returned by [LoadProgram] and [LoadTemplate]
+// when the data has been encoded by a different version of Scriggo or Go, or
+// when a native declaration referenced by the encoded code does not exist
+// anymore or has changed.
+var ErrIncompatibleEncoding = compiler.ErrIncompatibleEncoding
+
 // BuildError represents an error occurred building a program or template.
+//
+// If the type checking of a program or template reports several errors, the
+// Error, Path, Position and Message methods refer to the first error and the
+// Diagnostics method returns all the errors.
 type BuildError struct {
 	err compiler.Error
 }
@@ -50,6 +60,65 @@ func (err *BuildError) Message() string {
 	return err.err.Message()
 }
 
+// Diagnostics returns the diagnostics of the build, in the order in which
+// they have been reported. The first diagnostic is the error returned by the
+// Path, Position and Message methods.
+func (err *BuildError) Diagnostics() []*Diagnostic {
+	if errs, ok := err.err.(compiler.CheckingErrors); ok {
+		diagnostics := make([]*Diagnostic, len(errs))
+		for i, e := range errs {
+			diagnostics[i] = newDiagnostic(e)
+		}
+		return diagnostics
+	}
+	return []*Diagnostic{newDiagnostic(err.err)}
+}
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	SeverityError   Severity = iota // the build fails
+	SeverityWarning                 // the build does not fail
+)
+
+// String returns the name of the severity, "error" or "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Diagnostic represents a problem reported building a program or template.
+// Currently all the diagnostics have severity SeverityError.
+type Diagnostic struct {
+	Path     string   // path of the file.
+	Position Position // position in the file.
+	Message  string   // message, without path and position.
+	Severity Severity // severity.
+}
+
+// newDiagnostic returns a new error diagnostic for the compiler error err.
+func newDiagnostic(err compiler.Error) *Diagnostic {
+	pos := err.Position()
+	return &Diagnostic{
+		Path:     err.Path(),
+		Position: Position{Line: pos.Line, Column: pos.Column, Start: pos.Start, End: pos.End},
+		Message:  err.Message(),
+		Severity: SeverityError,
+	}
+}
+
+// Error returns a string representation of the diagnostic, in the form
+// "path:line:column: message".
+func (d *Diagnostic) Error() string {
+	return d.Path + ":" + d.Position.String() + ": " + d.Message
+}
+
 // ExitError represents an exit from an execution with a non-zero status code.
 // It may wrap the error that caused the exit.
 //
@@ -122,3 +191,32 @@ func (p *PanicError) Position() Position {
 	pos := p.p.Position()
 	return Position{Line: pos.Line, Column: pos.Column, Start: pos.Start, End: pos.End}
 }
+
+// LimitError represents the error that occurs when an executed program or
+// template exceeds a limit set in the run options. Unlike a panic, it cannot
+// be recovered by the executed code.
+type LimitError struct {
+	err *runtime.LimitError
+}
+
+// Error returns a string representation of the error.
+func (err *LimitError) Error() string {
+	return err.err.Error()
+}
+
+// Message returns the error message, without path and position.
+func (err *LimitError) Message() string {
+	return err.err.Message()
+}
+
+// Path returns the path of the file where the limit has been exceeded.
+func (err *LimitError) Path() string {
+	return err.err.Path()
+}
+
+// Position returns the position in the file where the limit has been
+// exceeded.
+func (err *LimitError) Position() Position {
+	pos := err.err.Position()
+	return Position{Line: pos.Line, Column: pos.Column, Start: pos.Start, End: pos.End}
+}
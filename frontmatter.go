@@ -0,0 +1,231 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/compiler"
+	"github.com/open2b/scriggo/native"
+)
+
+// FrontMatter declares the front matter of a template file as a global
+// variable. To parse the front matter, pass a FrontMatter to the
+// BuildTemplate function with the FrontMatter field of BuildOptions.
+//
+// A front matter is a block at the beginning of the file, delimited by two
+// lines "---" for a YAML front matter or, if DecodeTOML is not nil, by two
+// lines "+++" for a TOML front matter:
+//
+//	---
+//	title: Hello
+//	tags: [news, go]
+//	---
+//	{% extends "layout.html" %}
+//
+// The front matter is removed from the text of the file and its value is
+// declared as the global variable Name, so it is accessible from the file
+// and from the files it extends, imports and renders. If the file has no
+// front matter, the variable has the zero value of Type or, if Type is a
+// map type, an empty map. Only the front matter of the built file is parsed.
+//
+// Every run of the template has its own copy of the front matter, so the
+// template can change it without affecting the other runs.
+//
+// A template with a front matter can be loaded by the LoadTemplate function
+// only if the variable, with the same value, is declared in the globals.
+type FrontMatter struct {
+
+	// Name is the name of the global variable. If a global with the same
+	// name is declared in the Globals build option, it is replaced.
+	Name string
+
+	// Type is the type of the global variable, a struct type or a map type
+	// with string keys. A YAML front matter is decoded with the yaml.v3
+	// package, so the struct fields can have "yaml" tags. If Type is nil, it
+	// is map[string]interface{}.
+	Type reflect.Type
+
+	// DecodeTOML decodes a TOML front matter, for example the Unmarshal
+	// function of the BurntSushi/toml package. If DecodeTOML is nil, a file
+	// with a TOML front matter cannot be built.
+	DecodeTOML Decoder
+}
+
+// Decoder is implemented by front matter decoders. It decodes data and
+// stores the result in the value pointed to by v.
+type Decoder func(data []byte, v interface{}) error
+
+// frontMatterMapType is the default type of a front matter.
+var frontMatterMapType = reflect.TypeOf(map[string]interface{}(nil))
+
+// parseFrontMatter parses the front matter of the template file with the
+// given name. It returns the file system and the globals to build the
+// template and the length of the front matter in bytes.
+//
+// The returned file system reads the template file with the front matter
+// replaced by spaces, so that the positions in the file do not change.
+func parseFrontMatter(fsys fs.FS, name string, fm *FrontMatter, globals native.Declarations) (fs.FS, native.Declarations, int, error) {
+	typ := fm.Type
+	if typ == nil {
+		typ = frontMatterMapType
+	}
+	if k := typ.Kind(); k != reflect.Struct && (k != reflect.Map || typ.Key().Kind() != reflect.String) {
+		return nil, nil, 0, errors.New("scriggo: front matter type must be a struct or a map with string keys")
+	}
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	matter, err := compiler.ParseFrontMatter(name, src, typ, compiler.Decoder(fm.DecodeTOML))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var value reflect.Value
+	var n int
+	if matter == nil {
+		value = reflect.New(typ).Elem()
+		if typ.Kind() == reflect.Map {
+			value.Set(reflect.MakeMap(typ))
+		}
+	} else {
+		value, n = matter.Value, matter.Len
+		blanked := make([]byte, len(src))
+		copy(blanked, src)
+		for i := 0; i < n; i++ {
+			if blanked[i] != '\n' {
+				blanked[i] = ' '
+			}
+		}
+		if ff, ok := fsys.(compiler.FormatFS); ok {
+			fsys = frontMatterFormatFS{frontMatterFS{fsys, name, blanked}, ff}
+		} else {
+			fsys = frontMatterFS{fsys, name, blanked}
+		}
+	}
+	decls := make(native.Declarations, len(globals)+1)
+	for k, v := range globals {
+		decls[k] = v
+	}
+	decls[fm.Name] = value.Addr().Interface()
+	return fsys, decls, n, nil
+}
+
+// copyFrontMatter replaces, in values, the value of the front matter
+// variable with the given name with a deep copy, so that every run of a
+// template has its own front matter. variables are the global variables and
+// values their values.
+func copyFrontMatter(variables []compiler.Global, values []reflect.Value, name string) {
+	for i, v := range variables {
+		if v.Pkg == "main" && v.Name == name && v.Value.IsValid() {
+			value := reflect.New(v.Type).Elem()
+			value.Set(deepCopy(values[i]))
+			values[i] = value
+			return
+		}
+	}
+}
+
+// deepCopy returns a deep copy of v. The unexported fields of structs, as
+// the fields of time.Time, are copied as they are.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return s
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			a.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return a
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepCopy(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(deepCopy(v.Elem()))
+		return i
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := 0; i < s.NumField(); i++ {
+			if f := s.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return s
+	}
+	return v
+}
+
+// cutFrontMatter cuts the first n bytes, the spaces that replace the front
+// matter, from the text at the beginning of tree.
+func cutFrontMatter(tree *ast.Tree, n int) {
+	if len(tree.Nodes) == 0 {
+		return
+	}
+	text, ok := tree.Nodes[0].(*ast.Text)
+	if !ok || text.Start != 0 {
+		return
+	}
+	if m := len(text.Text) - text.Cut.Right; n > m {
+		n = m
+	}
+	if n > text.Cut.Left {
+		text.Cut.Left = n
+	}
+}
+
+// frontMatterFS is a file system that reads the template file with the
+// given name from src instead of from the wrapped file system.
+type frontMatterFS struct {
+	fs.FS
+	name string
+	src  []byte
+}
+
+func (fsys frontMatterFS) ReadFile(name string) ([]byte, error) {
+	if name == fsys.name {
+		return fsys.src, nil
+	}
+	return fs.ReadFile(fsys.FS, name)
+}
+
+// frontMatterFormatFS is a frontMatterFS that implements compiler.FormatFS.
+type frontMatterFormatFS struct {
+	frontMatterFS
+	format compiler.FormatFS
+}
+
+func (fsys frontMatterFormatFS) Format(name string) (ast.Format, error) {
+	return fsys.format.Format(name)
+}
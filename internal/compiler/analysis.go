@@ -0,0 +1,217 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"io/fs"
+	"reflect"
+	"strconv"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/ast/astutil"
+)
+
+// Analysis holds the trees of the files of a template and the information
+// collected type checking them. It is returned by AnalyzeTemplate and it is
+// used by tools, as the language server, that inspect the source code
+// without running it.
+type Analysis struct {
+	// trees contains the trees of the files, indexed by path, as they were
+	// before the type checking.
+	trees map[string]*ast.Tree
+
+	// typeInfos contains the type infos of the type checked nodes.
+	typeInfos map[ast.Node]*typeInfo
+
+	// uses maps the used identifiers and package selectors to the
+	// identifiers and import declarations that declare them.
+	uses map[ast.Expression]ast.Node
+
+	// declarations maps the identifiers and import declarations to the paths
+	// of the files in which they are located.
+	declarations map[ast.Node]string
+}
+
+// AnalyzeTemplate parses and type checks the named template file rooted at
+// the given file system, as BuildTemplate does, and returns its analysis.
+//
+// If the template cannot be parsed, it returns a nil analysis and the
+// error. If the type checking fails, it returns both the analysis, with the
+// information collected up to the errors, and a *CheckingError or a
+// CheckingErrors error.
+func AnalyzeTemplate(fsys fs.FS, name string, opts Options) (*Analysis, error) {
+
+	tree, err := ParseTemplate(fsys, name, opts.NoParseShortShowStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TreeTransformer != nil {
+		err := opts.TreeTransformer(tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a := &Analysis{
+		trees:        map[string]*ast.Tree{},
+		typeInfos:    map[ast.Node]*typeInfo{},
+		uses:         map[ast.Expression]ast.Node{},
+		declarations: map[ast.Node]string{},
+	}
+	a.addTree(tree)
+
+	checkerOpts := checkerOptions{
+		allowGoStmt: opts.AllowGoStmt,
+		formatTypes: opts.FormatTypes,
+		globals:     opts.Globals,
+		mdConverter: opts.MDConverter,
+		mod:         templateMod,
+		analysis:    a,
+	}
+	packages := newPackageRecorder(opts.Importer)
+	_, err = typecheck(tree, packages.asImporter(), checkerOpts)
+
+	return a, err
+}
+
+// addTree adds tree, and the trees it extends, imports and renders, to the
+// trees of the analysis.
+func (a *Analysis) addTree(tree *ast.Tree) {
+	if _, ok := a.trees[tree.Path]; ok {
+		return
+	}
+	a.trees[tree.Path] = ast.NewTree(tree.Path, tree.Nodes, tree.Format)
+	astutil.Inspect(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Extends:
+			if n.Tree != nil {
+				a.addTree(n.Tree)
+			}
+		case *ast.Import:
+			if n.Tree != nil {
+				a.addTree(n.Tree)
+			}
+		case *ast.Render:
+			if n.Tree != nil {
+				a.addTree(n.Tree)
+			}
+		}
+		return true
+	})
+}
+
+// Tree returns the tree of the file with the given path, as it was before
+// the type checking, or nil if the template does not have such file.
+func (a *Analysis) Tree(path string) *ast.Tree {
+	return a.trees[path]
+}
+
+// NodesAt returns the nodes of the file with the given path that contain the
+// byte at index offset, from the outermost to the innermost.
+func (a *Analysis) NodesAt(path string, offset int) []ast.Node {
+	tree, ok := a.trees[path]
+	if !ok {
+		return nil
+	}
+	var nodes []ast.Node
+	var inspect func(ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		if _, ok := node.(*ast.Tree); ok {
+			return true
+		}
+		if pos := node.Pos(); pos != nil && pos.Start <= offset && offset <= pos.End {
+			nodes = append(nodes, node)
+		}
+		// Walk does not visit the function of a call.
+		if call, ok := node.(*ast.Call); ok {
+			astutil.Inspect(call.Func, inspect)
+		}
+		return true
+	}
+	astutil.Inspect(tree, inspect)
+	return nodes
+}
+
+// Describe returns a description of the expression expr, as its type, and
+// true. If expr has not been type checked, it returns an empty string and
+// false.
+func (a *Analysis) Describe(expr ast.Expression) (string, bool) {
+	ti, ok := a.typeInfos[expr]
+	if !ok {
+		return "", false
+	}
+	switch {
+	case ti.IsPackage():
+		return "package " + ti.value.(*packageInfo).Name, true
+	case ti.IsType():
+		return "type " + ti.Type.String(), true
+	case ti.IsBuiltinFunction():
+		return "builtin function", true
+	case ti.IsConstant():
+		var c string
+		if ti.Type != nil && ti.Type.Kind() == reflect.String {
+			c = strconv.Quote(ti.Constant.string())
+		} else {
+			c = ti.Constant.String()
+		}
+		return ti.String() + " constant " + c, true
+	case ti.IsMacroDeclaration():
+		return "macro " + ti.Type.String(), true
+	}
+	return ti.String(), true
+}
+
+// TypeOf returns the type of the value of the expression expr, or nil if
+// expr has not been type checked or it is not a typed value.
+func (a *Analysis) TypeOf(expr ast.Expression) reflect.Type {
+	ti, ok := a.typeInfos[expr]
+	if !ok || ti.IsType() || ti.IsPackage() || ti.Nil() {
+		return nil
+	}
+	return ti.Type
+}
+
+// Declaration returns the path of the file and the position of the
+// identifier or import declaration that declares the identifier or package
+// selector expr, and true. If expr is not declared in a file of the
+// template, for example because it is a global or a native declaration, it
+// returns an empty string, nil and false.
+func (a *Analysis) Declaration(expr ast.Expression) (string, *ast.Position, bool) {
+	decl, ok := a.uses[expr]
+	if !ok {
+		return "", nil, false
+	}
+	path, ok := a.declarations[decl]
+	if !ok {
+		return "", nil, false
+	}
+	return path, decl.Pos(), true
+}
+
+// recordUse records, if the type checker is analyzing a template, that the
+// expression expr refers to the declaration decl. decl can be an identifier,
+// an import declaration or nil.
+func (tc *typechecker) recordUse(expr ast.Expression, decl ast.Node) {
+	if tc.opts.analysis == nil {
+		return
+	}
+	switch d := decl.(type) {
+	case *ast.Identifier:
+		if d == nil {
+			return
+		}
+	case *ast.Import:
+		if d == nil {
+			return
+		}
+	default:
+		return
+	}
+	tc.opts.analysis.uses[expr] = decl
+}
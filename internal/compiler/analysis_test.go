@@ -0,0 +1,90 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+func TestAnalyzeTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":  `{% extends "layout.html" %}{% import "macros.html" %}{% import m "macros.html" %}{% macro Body %}{% var s = "a" %}{{ Title(s) }}{{ m.Title(name) }}{{ undefined }}{% end %}`,
+		"layout.html": `<body>{{ Body() }}</body>`,
+		"macros.html": `{% macro Title(s string) %}<h1>{{ s }}</h1>{% end %}`,
+	}
+	opts := Options{
+		FormatTypes: formatTypes,
+		Globals:     native.Declarations{"name": (*string)(nil)},
+	}
+	a, err := AnalyzeTemplate(fsys, "index.html", opts)
+	if err == nil {
+		t.Fatal("expected a type checking error, got no error")
+	}
+	if msg := err.(Error).Message(); msg != "undefined: undefined" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+	for _, path := range []string{"index.html", "layout.html", "macros.html"} {
+		if a.Tree(path) == nil {
+			t.Fatalf("missing tree of %s", path)
+		}
+	}
+	src := fsys["index.html"]
+	// exprAt returns the innermost expression at the first occurrence of s.
+	exprAt := func(s string) ast.Expression {
+		offset := strings.Index(src, s)
+		nodes := a.NodesAt("index.html", offset)
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if expr, ok := nodes[i].(ast.Expression); ok {
+				return expr
+			}
+		}
+		t.Fatalf("no expression at %q", s)
+		return nil
+	}
+	tests := []struct {
+		expr string
+		desc string
+		path string
+		line int
+		col  int
+	}{
+		{"Title(s)", "macro func(string) compiler.html", "macros.html", 1, 10},
+		{"s) }}", "string", "index.html", 1, 105},
+		{"Title(name)", "macro func(string) compiler.html", "macros.html", 1, 10},
+		{"m.Title", "", "index.html", 1, 64},
+		{"name)", "string", "", 0, 0},
+	}
+	if typ := a.TypeOf(exprAt("s) }}")); typ != stringType {
+		t.Fatalf("expected type string, got %v", typ)
+	}
+	for _, test := range tests {
+		expr := exprAt(test.expr)
+		desc, ok := a.Describe(expr)
+		if !ok && test.desc != "" {
+			t.Errorf("%s: expression has not been described", test.expr)
+		} else if desc != test.desc {
+			t.Errorf("%s: expected description %q, got %q", test.expr, test.desc, desc)
+		}
+		path, pos, ok := a.Declaration(expr)
+		if test.path == "" {
+			if ok {
+				t.Errorf("%s: unexpected declaration in %s", test.expr, path)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: declaration not found", test.expr)
+			continue
+		}
+		if path != test.path || pos.Line != test.line || pos.Column != test.col {
+			t.Errorf("%s: expected declaration at %s:%d:%d, got %s:%s", test.expr, test.path, test.line, test.col, path, pos)
+		}
+	}
+}
@@ -17,20 +17,23 @@ import (
 // Define some constants that define limits of the implementation.
 const (
 	// Functions.
-	maxRegistersCount        = 127
-	maxNativeFunctionsCount  = 256
-	maxScriggoFunctionsCount = 256
-	maxFieldIndexesCount     = 256
+	maxRegistersCount        = math.MaxInt16 // 32767
+	maxNativeFunctionsCount  = 1 << 16       // 65536
+	maxScriggoFunctionsCount = 1 << 16       // 65536
+	maxFieldIndexesCount     = 1 << 16       // 65536
 	maxSelectCasesCount      = 65536
 
 	// Types.
-	maxTypesCount = 256
+	maxTypesCount = 1 << 16 // 65536
 
 	// Values.
 	maxIntValuesCount     = 1 << 14 // 16384
 	maxFloatValuesCount   = 1 << 14 // 16384
-	maxStringValuesCount  = 256
-	maxGeneralValuesCount = 256
+	maxStringValuesCount  = 1 << 16 // 65536
+	maxGeneralValuesCount = 1 << 16 // 65536
+
+	// Coverage.
+	maxCoverBlocksCount = 1 << 24 // 16777216
 )
 
 var intType = reflect.TypeOf(0)
@@ -67,48 +70,48 @@ func decodeRenderContext(c runtime.Context) (ast.Context, bool, bool) {
 	return ctx, inURL, isURLSet
 }
 
-func encodeInt16(v int16) (a, b int8) {
-	a = int8(v >> 8)
-	b = int8(v)
+func encodeInt16(v int16) (a, b int16) {
+	a = int16(v >> 8)
+	b = int16(v)
 	return
 }
 
-func decodeInt16(a, b int8) int16 {
+func decodeInt16(a, b int16) int16 {
 	return int16(int(a)<<8 | int(uint8(b)))
 }
 
-func encodeUint16(v uint16) (a, b int8) {
-	a = int8(uint8(v >> 8))
-	b = int8(uint8(v))
+func encodeUint16(v uint16) (a, b int16) {
+	a = int16(uint8(v >> 8))
+	b = int16(uint8(v))
 	return
 }
 
-func decodeUint16(a, b int8) uint16 {
+func decodeUint16(a, b int16) uint16 {
 	return uint16(uint8(a))<<8 | uint16(uint8(b))
 }
 
-func encodeUint24(v uint32) (a, b, c int8) {
-	a = int8(uint8(v >> 16))
-	b = int8(uint8(v >> 8))
-	c = int8(uint8(v))
+func encodeUint24(v uint32) (a, b, c int16) {
+	a = int16(uint8(v >> 16))
+	b = int16(uint8(v >> 8))
+	c = int16(uint8(v))
 	return
 }
 
-func decodeUint24(a, b, c int8) uint32 {
+func decodeUint24(a, b, c int16) uint32 {
 	return uint32(uint8(a))<<16 | uint32(uint8(b))<<8 | uint32(uint8(c))
 }
 
 // encodeValueIndex encodes a value index in the Function.Values slices of the
 // runtime.
-func encodeValueIndex(t registerType, i int) (a, b int8) {
+func encodeValueIndex(t registerType, i int) (a, b int16) {
 	a, b = encodeInt16(int16(i))
-	a |= int8(t << 6)
+	a |= int16(t << 6)
 	return a, b
 }
 
 // decodeValueIndex decodes a value index in the Function.Values slices of the
 // runtime.
-func decodeValueIndex(a, b int8) (t registerType, i int) {
+func decodeValueIndex(a, b int16) (t registerType, i int) {
 	return registerType(uint8(a) >> 6), int(decodeUint16(a, b) &^ (3 << 14))
 }
 
@@ -166,12 +169,20 @@ type functionBuilder struct {
 	fn                     *runtime.Function
 	labelAddrs             []runtime.Addr // addresses of the labels; the address of the label n is labelAddrs[n-1]
 	gotos                  map[runtime.Addr]label
-	maxRegs                map[registerType]int8 // max number of registers allocated at the same time.
-	numRegs                map[registerType]int8
-	scopes                 []map[string]int8
+	maxRegs                map[registerType]int16 // max number of registers allocated at the same time.
+	numRegs                map[registerType]int16
+	scopes                 []map[string]int16
+	scopeVars              []int // index in fn.LocalVars of the first variable of each scope.
 	scopeShifts            []runtime.StackShift
-	complexBinaryOpIndexes map[ast.OperatorType]int8 // indexes of complex binary op. functions.
-	complexUnaryOpIndex    int8                      // index of complex negation function.
+	complexBinaryOpIndexes map[ast.OperatorType]int16 // indexes of complex binary op. functions.
+	complexUnaryOpIndex    int16                      // index of complex negation function.
+
+	// typeIndexes, stringIndexes and generalIndexes are the indexes of the
+	// types, string values and general values of the function. They avoid
+	// scanning the slices when a function has many of them.
+	typeIndexes    map[reflect.Type]int
+	stringIndexes  map[string]int16
+	generalIndexes map[interface{}]int16
 
 	// text refers to the latest emitted Text instruction with its text to be flushed into the function.
 	text struct {
@@ -184,19 +195,61 @@ type functionBuilder struct {
 	// expression in a template the file path changes even if the function
 	// remains the same.
 	path string
+
+	// rangeFunc, if not nil, refers to the outermost range over a function
+	// iterator whose body is being emitted.
+	rangeFunc *rangeFunc
+
+	// breakables contains, from the outermost to the innermost, the
+	// statements whose body is being emitted and that can be the target of
+	// break and continue statements.
+	breakables []*breakable
+
+	// nextLabel is the label of the next statement to emit, if it is a
+	// labelled for, for range, switch, type switch or select statement.
+	nextLabel string
+}
+
+// breakable represents a for, for range, switch, type switch or select
+// statement. A break statement jumps to the breakLabel label and a continue
+// statement, only for loops, jumps to the continueLabel label.
+//
+// As the body of a range statement is executed in its own run of the VM,
+// a break or continue statement, that refers to a statement outside the
+// range that is not a range, breaks out of the range after setting the exit
+// register to the index, plus one, of the label in exits to jump to.
+type breakable struct {
+	name          string // label name, empty if the statement is not labelled.
+	isLoop        bool
+	isRange       bool
+	breakLabel    label
+	continueLabel label
+	exit          int16
+	exits         []label
+}
+
+// rangeFunc represents a range over a function iterator. As the body of such
+// range is executed by the iterator, a return statement in the body sets the
+// ret register and breaks out of the range statement, labelled with label,
+// that returns after the iterator has returned.
+type rangeFunc struct {
+	label label
+	ret   int16
 }
 
 // newBuilder returns a new function builder for the function fn in the given
 // path.
 func newBuilder(fn *runtime.Function, path string) *functionBuilder {
 	fn.Body = nil
+	fn.Statements = nil
+	fn.LocalVars = nil
 	builder := &functionBuilder{
 		fn:                     fn,
 		gotos:                  map[runtime.Addr]label{},
-		maxRegs:                map[registerType]int8{},
-		numRegs:                map[registerType]int8{},
-		scopes:                 []map[string]int8{},
-		complexBinaryOpIndexes: map[ast.OperatorType]int8{},
+		maxRegs:                map[registerType]int16{},
+		numRegs:                map[registerType]int16{},
+		scopes:                 []map[string]int16{},
+		complexBinaryOpIndexes: map[ast.OperatorType]int16{},
 		complexUnaryOpIndex:    -1,
 		path:                   path,
 	}
@@ -216,7 +269,8 @@ func (fb *functionBuilder) currentStackShift() runtime.StackShift {
 // enterScope enters a new scope.
 // Every enterScope call must be paired with a corresponding exitScope call.
 func (fb *functionBuilder) enterScope() {
-	fb.scopes = append(fb.scopes, map[string]int8{})
+	fb.scopes = append(fb.scopes, map[string]int16{})
+	fb.scopeVars = append(fb.scopeVars, len(fb.fn.LocalVars))
 	fb.enterStack()
 }
 
@@ -224,9 +278,23 @@ func (fb *functionBuilder) enterScope() {
 // Every exitScope call must be paired with a corresponding enterScope call.
 func (fb *functionBuilder) exitScope() {
 	fb.scopes = fb.scopes[:len(fb.scopes)-1]
+	fb.closeLocalVars(fb.scopeVars[len(fb.scopeVars)-1])
+	fb.scopeVars = fb.scopeVars[:len(fb.scopeVars)-1]
 	fb.exitStack()
 }
 
+// closeLocalVars ends, at the current address, the scope of the local
+// variables, starting from the variable at index i, whose scope has not
+// already ended.
+func (fb *functionBuilder) closeLocalVars(i int) {
+	addr := fb.currentAddr()
+	for ; i < len(fb.fn.LocalVars); i++ {
+		if v := &fb.fn.LocalVars[i]; v.End == 0 {
+			v.End = addr
+		}
+	}
+}
+
 // enterStack enters a new virtual stack, whose registers will be reused (if
 // necessary) after calling exitScope.
 // Every enterStack call must be paired with a corresponding exitStack call.
@@ -260,7 +328,7 @@ func (fb *functionBuilder) exitStack() {
 }
 
 // newRegister makes a new register of a given kind.
-func (fb *functionBuilder) newRegister(kind reflect.Kind) int8 {
+func (fb *functionBuilder) newRegister(kind reflect.Kind) int16 {
 	t := kindToType(kind)
 	num := fb.numRegs[t]
 	if num == maxRegistersCount {
@@ -271,20 +339,36 @@ func (fb *functionBuilder) newRegister(kind reflect.Kind) int8 {
 }
 
 // newIndirectRegister allocates a new indirect register.
-func (fb *functionBuilder) newIndirectRegister() int8 {
+func (fb *functionBuilder) newIndirectRegister() int16 {
 	return -fb.newRegister(reflect.Interface)
 }
 
 // bindVarReg binds name with register reg. To create a new variable, use
 // VariableRegister in conjunction with bindVarReg.
-func (fb *functionBuilder) bindVarReg(name string, reg int8) {
+//
+// If typ is not nil, the variable is added, with type typ, to the local
+// variables of the function, in scope from the current address.
+func (fb *functionBuilder) bindVarReg(name string, reg int16, typ reflect.Type) {
 	fb.scopes[len(fb.scopes)-1][name] = reg
+	if typ == nil {
+		return
+	}
+	addr := fb.currentAddr()
+	vars := fb.fn.LocalVars
+	for i := len(vars) - 1; i >= fb.scopeVars[len(fb.scopeVars)-1]; i-- {
+		if vars[i].Name == name && vars[i].End == 0 {
+			// The variable is bound to another register.
+			vars[i].End = addr
+			break
+		}
+	}
+	fb.fn.LocalVars = append(vars, runtime.LocalVar{Name: name, Type: typ, Reg: reg, Start: addr})
 }
 
 // declaredInCurrentScope returns the register where v is stored and true in
 // case of v is a variable declared in the current scope, else returns 0 and
 // false.
-func (fb *functionBuilder) declaredInCurrentScope(v string) (int8, bool) {
+func (fb *functionBuilder) declaredInCurrentScope(v string) (int16, bool) {
 	reg, ok := fb.scopes[len(fb.scopes)-1][v]
 	return reg, ok
 }
@@ -301,7 +385,7 @@ func (fb *functionBuilder) declaredInFunc(v string) bool {
 }
 
 // scopeLookup returns n's register.
-func (fb *functionBuilder) scopeLookup(n string) int8 {
+func (fb *functionBuilder) scopeLookup(n string) int16 {
 	for i := len(fb.scopes) - 1; i >= 0; i-- {
 		reg, ok := fb.scopes[i][n]
 		if ok {
@@ -330,6 +414,30 @@ func (fb *functionBuilder) addPosAndPath(pos *ast.Position) {
 	fb.fn.InstructionInfo[pc] = info
 }
 
+// addStatement adds a statement, with position pos, that starts at the
+// current address. If pos is nil, it does nothing.
+func (fb *functionBuilder) addStatement(pos *ast.Position) {
+	if pos == nil {
+		return
+	}
+	st := runtime.Statement{
+		Addr: fb.currentAddr(),
+		Path: fb.path,
+		Position: runtime.Position{
+			Line:   pos.Line,
+			Column: pos.Column,
+			Start:  pos.Start,
+			End:    pos.End,
+		},
+	}
+	// A previous statement without instructions is replaced.
+	if n := len(fb.fn.Statements); n > 0 && fb.fn.Statements[n-1].Addr == st.Addr {
+		fb.fn.Statements[n-1] = st
+		return
+	}
+	fb.fn.Statements = append(fb.fn.Statements, st)
+}
+
 // addOperandKinds adds the kind of the three operands of the next instruction.
 // If an operand has no kind (or if that kind is not meaningful) it is legal to
 // pass the zero of reflect.Kind for such operand.
@@ -386,54 +494,64 @@ func (fb *functionBuilder) addType(typ reflect.Type, preserveType bool) int {
 		}
 	}
 	fn := fb.fn
-	for i, t := range fn.Types {
-		if t == typ {
-			return i
+	if fb.typeIndexes == nil {
+		fb.typeIndexes = make(map[reflect.Type]int, len(fn.Types))
+		for i, t := range fn.Types {
+			fb.typeIndexes[t] = i
 		}
 	}
+	if i, ok := fb.typeIndexes[typ]; ok {
+		return i
+	}
 	index := len(fn.Types)
 	if index == maxTypesCount {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "types count exceeded %d", maxTypesCount))
 	}
 	fn.Types = append(fn.Types, typ)
+	fb.typeIndexes[typ] = index
 	return index
 }
 
 // addNativeFunction adds a native function to the builder's function.
-func (fb *functionBuilder) addNativeFunction(f *runtime.NativeFunction) int8 {
+func (fb *functionBuilder) addNativeFunction(f *runtime.NativeFunction) int16 {
 	fn := fb.fn
 	r := len(fn.NativeFunctions)
 	if r == maxNativeFunctionsCount {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "native functions count exceeded %d", maxNativeFunctionsCount))
 	}
 	fn.NativeFunctions = append(fn.NativeFunctions, f)
-	return int8(r)
+	return int16(r)
 }
 
 // addFunction adds a function to the builder's function.
-func (fb *functionBuilder) addFunction(f *runtime.Function) int8 {
+func (fb *functionBuilder) addFunction(f *runtime.Function) int16 {
 	fn := fb.fn
 	r := len(fn.Functions)
 	if r == maxScriggoFunctionsCount {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "Scriggo functions count exceeded %d", maxScriggoFunctionsCount))
 	}
 	fn.Functions = append(fn.Functions, f)
-	return int8(r)
+	return int16(r)
 }
 
 // makeStringValue makes a new string value, returning it's index.
-func (fb *functionBuilder) makeStringValue(v string) int8 {
-	for i, vv := range fb.fn.Values.String {
-		if v == vv {
-			return int8(i)
+func (fb *functionBuilder) makeStringValue(v string) int16 {
+	if fb.stringIndexes == nil {
+		fb.stringIndexes = make(map[string]int16, len(fb.fn.Values.String))
+		for i, vv := range fb.fn.Values.String {
+			fb.stringIndexes[vv] = int16(i)
 		}
 	}
+	if i, ok := fb.stringIndexes[v]; ok {
+		return i
+	}
 	r := len(fb.fn.Values.String)
 	if r == maxStringValuesCount {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "string values count exceeded %d", maxStringValuesCount))
 	}
 	fb.fn.Values.String = append(fb.fn.Values.String, v)
-	return int8(r)
+	fb.stringIndexes[v] = int16(r)
+	return int16(r)
 }
 
 // makeGeneralValue makes a new general value, returning it's index.
@@ -443,37 +561,47 @@ func (fb *functionBuilder) makeStringValue(v string) int8 {
 // If the VM's internal representation of v is different from the external, v
 // must always have the external representation. Any conversion, if needed,
 // will be internally handled.
-func (fb *functionBuilder) makeGeneralValue(v reflect.Value) int8 {
-	// Check if v has already been added to the general Values slice.
-	if v.IsValid() {
-		t := v.Type()
-		if t.Comparable() {
-			vi := v.Interface()
-			for i, vv := range fb.fn.Values.General {
-				if vv.IsValid() && vi == vv.Interface() {
-					return int8(i)
-				}
-			}
-		} else {
-			for i, vv := range fb.fn.Values.General {
-				if vv.IsValid() && t == vv.Type() {
-					return int8(i)
-				}
-			}
-		}
-	} else {
+func (fb *functionBuilder) makeGeneralValue(v reflect.Value) int16 {
+	if fb.generalIndexes == nil {
+		fb.generalIndexes = make(map[interface{}]int16, len(fb.fn.Values.General))
 		for i, vv := range fb.fn.Values.General {
-			if !vv.IsValid() {
-				return int8(i)
+			key := generalValueKey(vv)
+			if _, ok := fb.generalIndexes[key]; !ok {
+				fb.generalIndexes[key] = int16(i)
 			}
 		}
 	}
+	// Check if v has already been added to the general Values slice.
+	key := generalValueKey(v)
+	if i, ok := fb.generalIndexes[key]; ok {
+		return i
+	}
 	r := len(fb.fn.Values.General)
 	if r == maxGeneralValuesCount {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "general values count exceeded %d", maxGeneralValuesCount))
 	}
 	fb.fn.Values.General = append(fb.fn.Values.General, v)
-	return int8(r)
+	fb.generalIndexes[key] = int16(r)
+	return int16(r)
+}
+
+// zeroValueKey is the key, in functionBuilder.generalIndexes, of the zero
+// value of a non-comparable type.
+type zeroValueKey struct{ typ reflect.Type }
+
+// invalidValueKey is the key, in functionBuilder.generalIndexes, of the
+// invalid reflect.Value.
+type invalidValueKey struct{}
+
+// generalValueKey returns the key of v in functionBuilder.generalIndexes.
+func generalValueKey(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return invalidValueKey{}
+	}
+	if t := v.Type(); !t.Comparable() {
+		return zeroValueKey{t}
+	}
+	return v.Interface()
 }
 
 // makeFloatValue makes a new float value, returning it's index.
@@ -520,10 +648,10 @@ func sameFieldIndex(i1, i2 []int) bool {
 }
 
 // makeFieldIndex makes a new field index, returning it's index.
-func (fb *functionBuilder) makeFieldIndex(index []int) int8 {
+func (fb *functionBuilder) makeFieldIndex(index []int) int16 {
 	for i, index2 := range fb.fn.FieldIndexes {
 		if sameFieldIndex(index, index2) {
-			return int8(i)
+			return int16(i)
 		}
 	}
 	r := len(fb.fn.FieldIndexes)
@@ -531,7 +659,7 @@ func (fb *functionBuilder) makeFieldIndex(index []int) int8 {
 		panic(newLimitExceededError(fb.fn.Pos, fb.path, "field indexes count exceeded %d", maxFieldIndexesCount))
 	}
 	fb.fn.FieldIndexes = append(fb.fn.FieldIndexes, index)
-	return int8(r)
+	return int16(r)
 }
 
 // currentAddr returns builder's current address.
@@ -585,6 +713,7 @@ func (fb *functionBuilder) end() {
 		fn.Body[addr] = i
 	}
 	fb.gotos = nil
+	fb.closeLocalVars(0)
 	for typ, num := range fb.maxRegs {
 		if num > fn.NumReg[typ] {
 			fn.NumReg[typ] = num
@@ -592,7 +721,7 @@ func (fb *functionBuilder) end() {
 	}
 }
 
-func (fb *functionBuilder) allocRegister(typ registerType, reg int8) {
+func (fb *functionBuilder) allocRegister(typ registerType, reg int16) {
 	if max, ok := fb.maxRegs[typ]; !ok || reg > max {
 		fb.maxRegs[typ] = reg
 	}
@@ -603,7 +732,7 @@ func (fb *functionBuilder) allocRegister(typ registerType, reg int8) {
 
 // complexOperationIndex returns the index of the function which performs the
 // binary or unary operation specified by op.
-func (fb *functionBuilder) complexOperationIndex(op ast.OperatorType, unary bool) int8 {
+func (fb *functionBuilder) complexOperationIndex(op ast.OperatorType, unary bool) int16 {
 	if unary {
 		if fb.complexUnaryOpIndex != -1 {
 			return fb.complexUnaryOpIndex
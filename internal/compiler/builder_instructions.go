@@ -20,7 +20,7 @@ func (fb *functionBuilder) emitNop() {
 // emitAdd appends a new "Add" instruction to the function body.
 //
 //	z = x + y
-func (fb *functionBuilder) emitAdd(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitAdd(k bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -31,7 +31,7 @@ func (fb *functionBuilder) emitAdd(k bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpAdd
 	}
 	if k {
@@ -44,7 +44,7 @@ func (fb *functionBuilder) emitAdd(k bool, x, y, z int8, kind reflect.Kind) {
 //
 //	dest = &expr.Field
 //	dest = &expr[index]
-func (fb *functionBuilder) emitAddr(expr, index, dest int8, pos *ast.Position) {
+func (fb *functionBuilder) emitAddr(expr, index, dest int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpAddr, A: expr, B: index, C: dest})
 }
@@ -52,7 +52,7 @@ func (fb *functionBuilder) emitAddr(expr, index, dest int8, pos *ast.Position) {
 // emitAnd appends a new "And" instruction to the function body.
 //
 //	z = x & y
-func (fb *functionBuilder) emitAnd(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitAnd(k bool, x, y, z int16, kind reflect.Kind) {
 	op := runtime.OpAnd
 	if k {
 		op = -op
@@ -63,7 +63,7 @@ func (fb *functionBuilder) emitAnd(k bool, x, y, z int8, kind reflect.Kind) {
 // emitAndNot appends a new "AndNot" instruction to the function body.
 //
 //	z = x &^ y
-func (fb *functionBuilder) emitAndNot(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitAndNot(k bool, x, y, z int16, kind reflect.Kind) {
 	op := runtime.OpAndNot
 	if k {
 		op = -op
@@ -72,7 +72,8 @@ func (fb *functionBuilder) emitAndNot(k bool, x, y, z int8, kind reflect.Kind) {
 }
 
 // emitAppend appends a new "Append" instruction to the function body.
-func (fb *functionBuilder) emitAppend(start, end, s int8, elementsKind reflect.Kind) {
+func (fb *functionBuilder) emitAppend(start, end, s int16, elementsKind reflect.Kind, pos *ast.Position) {
+	fb.addPosAndPath(pos)
 	fb.addOperandKinds(elementsKind, elementsKind, 0)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpAppend, A: start, B: end, C: s})
@@ -81,7 +82,7 @@ func (fb *functionBuilder) emitAppend(start, end, s int8, elementsKind reflect.K
 // emitAppendSlice appends a new "AppendSlice" instruction to the function body.
 //
 //	s = append(s, t)
-func (fb *functionBuilder) emitAppendSlice(t, s int8, pos *ast.Position) {
+func (fb *functionBuilder) emitAppendSlice(t, s int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpAppendSlice, A: t, C: s})
@@ -90,9 +91,9 @@ func (fb *functionBuilder) emitAppendSlice(t, s int8, pos *ast.Position) {
 // emitAssert appends a new "assert" instruction to the function body.
 //
 //	z = e.(t)
-func (fb *functionBuilder) emitAssert(e int8, typ reflect.Type, z int8) {
+func (fb *functionBuilder) emitAssert(e int16, typ reflect.Type, z int16) {
 	t := fb.addType(typ, true)
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpAssert, A: e, B: int8(t), C: z})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpAssert, A: e, B: int16(t), C: z})
 }
 
 // emitBreak appends a new "Break" instruction to the function body.
@@ -107,7 +108,7 @@ func (fb *functionBuilder) emitBreak(lab label) {
 // emitCallFunc appends a new "CallFunc" instruction to the function body.
 //
 //	p.f()
-func (fb *functionBuilder) emitCallFunc(f int8, shift runtime.StackShift, pos *ast.Position) {
+func (fb *functionBuilder) emitCallFunc(f int16, shift runtime.StackShift, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallFunc, A: f})
@@ -117,28 +118,28 @@ func (fb *functionBuilder) emitCallFunc(f int8, shift runtime.StackShift, pos *a
 // emitCallMacro appends a new "CallMacro" instruction to the function body.
 //
 //	p.m()
-func (fb *functionBuilder) emitCallMacro(f int8, shift runtime.StackShift, pos *ast.Position, toFormat ast.Format) {
+func (fb *functionBuilder) emitCallMacro(f int16, shift runtime.StackShift, pos *ast.Position, toFormat ast.Format) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallMacro, A: f, B: int8(toFormat)})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallMacro, A: f, B: int16(toFormat)})
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.Operation(shift[0]), A: shift[1], B: shift[2], C: shift[3]})
 }
 
 // emitCallIndirect appends a new "CallIndirect" instruction to the function body.
 //
 //	f()
-func (fb *functionBuilder) emitCallIndirect(f int8, numVariadic int8, shift runtime.StackShift, pos *ast.Position, funcType reflect.Type, toFormat ast.Format) {
+func (fb *functionBuilder) emitCallIndirect(f int16, numVariadic int16, shift runtime.StackShift, pos *ast.Position, funcType reflect.Type, toFormat ast.Format) {
 	fb.addPosAndPath(pos)
 	fb.addFunctionType(funcType)
 	fn := fb.fn
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallIndirect, A: f, B: int8(toFormat), C: numVariadic})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallIndirect, A: f, B: int16(toFormat), C: numVariadic})
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.Operation(shift[0]), A: shift[1], B: shift[2], C: shift[3]})
 }
 
 // emitCallNative appends a new "CallNative" instruction to the function body.
 //
 //	p.F()
-func (fb *functionBuilder) emitCallNative(f int8, numVariadic int8, shift runtime.StackShift, pos *ast.Position) {
+func (fb *functionBuilder) emitCallNative(f int16, numVariadic int16, shift runtime.StackShift, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpCallNative, A: f, C: numVariadic})
@@ -148,7 +149,7 @@ func (fb *functionBuilder) emitCallNative(f int8, numVariadic int8, shift runtim
 // emitCap appends a new "cap" instruction to the function body.
 //
 //	z = cap(s)
-func (fb *functionBuilder) emitCap(s, z int8) {
+func (fb *functionBuilder) emitCap(s, z int16) {
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpCap, A: s, C: z})
 }
 
@@ -157,8 +158,8 @@ func (fb *functionBuilder) emitCap(s, z int8) {
 //	case ch <- value
 //	case value = <-ch
 //	default
-func (fb *functionBuilder) emitCase(kvalue bool, dir reflect.SelectDir, value, ch int8) {
-	in := runtime.Instruction{Op: runtime.OpCase, A: int8(dir)}
+func (fb *functionBuilder) emitCase(kvalue bool, dir reflect.SelectDir, value, ch int16) {
+	in := runtime.Instruction{Op: runtime.OpCase, A: int16(dir)}
 	if kvalue {
 		in.Op = -in.Op
 	}
@@ -173,10 +174,17 @@ func (fb *functionBuilder) emitCase(kvalue bool, dir reflect.SelectDir, value, c
 	fb.fn.Body = append(fb.fn.Body, in)
 }
 
+// emitClear appends a new "Clear" instruction to the function body.
+//
+//	clear(m)
+func (fb *functionBuilder) emitClear(m int16) {
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpClear, A: m})
+}
+
 // emitClose appends a new "Close" instruction to the function body.
 //
 //	close(ch)
-func (fb *functionBuilder) emitClose(ch int8, pos *ast.Position) {
+func (fb *functionBuilder) emitClose(ch int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpClose, A: ch})
 }
@@ -184,7 +192,7 @@ func (fb *functionBuilder) emitClose(ch int8, pos *ast.Position) {
 // emitComplex appends a new "Complex" instruction to the function body.
 //
 //	z = complex(x, y)
-func (fb *functionBuilder) emitComplex(x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitComplex(x, y, z int16, kind reflect.Kind) {
 	op := runtime.OpComplex128
 	if kind == reflect.Complex64 {
 		op = runtime.OpComplex64
@@ -195,7 +203,8 @@ func (fb *functionBuilder) emitComplex(x, y, z int8, kind reflect.Kind) {
 // emitConcat appends a new "concat" instruction to the function body.
 //
 //	z = concat(s, t)
-func (fb *functionBuilder) emitConcat(s, t, z int8) {
+func (fb *functionBuilder) emitConcat(s, t, z int16, pos *ast.Position) {
+	fb.addPosAndPath(pos)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpConcat, A: s, B: t, C: z})
 }
@@ -212,7 +221,7 @@ func (fb *functionBuilder) emitContinue(lab label) {
 // emitConvert appends a new "Convert" instruction to the function body.
 //
 //	dst = typ(src)
-func (fb *functionBuilder) emitConvert(src int8, typ reflect.Type, dst int8, srcKind reflect.Kind) {
+func (fb *functionBuilder) emitConvert(src int16, typ reflect.Type, dst int16, srcKind reflect.Kind) {
 	fn := fb.fn
 	regType := fb.addType(typ, false)
 	var op runtime.Operation
@@ -236,21 +245,32 @@ func (fb *functionBuilder) emitConvert(src int8, typ reflect.Type, dst int8, src
 	case floatRegister:
 		op = runtime.OpConvertFloat
 	}
-	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: src, B: int8(regType), C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: src, B: int16(regType), C: dst})
 }
 
 // emitCopy appends a new "Copy" instruction to the function body.
 //
 //	n == 0:   copy(dst, src)
 //	n != 0:   n := copy(dst, src)
-func (fb *functionBuilder) emitCopy(dst, src, n int8) {
+func (fb *functionBuilder) emitCopy(dst, src, n int16) {
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpCopy, A: src, B: n, C: dst})
 }
 
+// emitCover appends a new "Cover" instruction to the function body.
+//
+//	coverage[block]++
+func (fb *functionBuilder) emitCover(block int) {
+	if block >= maxCoverBlocksCount {
+		panic(newLimitExceededError(fb.fn.Pos, fb.path, "coverage blocks count exceeded %d", maxCoverBlocksCount))
+	}
+	a, b, c := encodeUint24(uint32(block))
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpCover, A: a, B: b, C: c})
+}
+
 // emitDefer appends a new "Defer" instruction to the function body.
 //
 //	defer
-func (fb *functionBuilder) emitDefer(f int8, numVariadic int8, off, arg runtime.StackShift, funcType reflect.Type) {
+func (fb *functionBuilder) emitDefer(f int16, numVariadic int16, off, arg runtime.StackShift, funcType reflect.Type) {
 	fb.addFunctionType(funcType)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpDefer, A: f, C: numVariadic})
@@ -261,14 +281,14 @@ func (fb *functionBuilder) emitDefer(f int8, numVariadic int8, off, arg runtime.
 // emitDelete appends a new "delete" instruction to the function body.
 //
 //	delete(m, k)
-func (fb *functionBuilder) emitDelete(m, k int8) {
+func (fb *functionBuilder) emitDelete(m, k int16) {
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpDelete, A: m, B: k})
 }
 
 // emitDiv appends a new "div" instruction to the function body.
 //
 //	z = x / y
-func (fb *functionBuilder) emitDiv(ky bool, x, y, z int8, kind reflect.Kind, pos *ast.Position) {
+func (fb *functionBuilder) emitDiv(ky bool, x, y, z int16, kind reflect.Kind, pos *ast.Position) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -279,7 +299,7 @@ func (fb *functionBuilder) emitDiv(ky bool, x, y, z int8, kind reflect.Kind, pos
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpDiv
 	}
 	fb.addPosAndPath(pos)
@@ -292,7 +312,7 @@ func (fb *functionBuilder) emitDiv(ky bool, x, y, z int8, kind reflect.Kind, pos
 // emitField appends a new "Field" instruction to the function body.
 //
 //	c = a.field
-func (fb *functionBuilder) emitField(a, field, c int8, dstKind reflect.Kind) {
+func (fb *functionBuilder) emitField(a, field, c int16, dstKind reflect.Kind) {
 	fb.addOperandKinds(0, 0, dstKind)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpField, A: a, B: field, C: c})
 }
@@ -300,7 +320,7 @@ func (fb *functionBuilder) emitField(a, field, c int8, dstKind reflect.Kind) {
 // emitGetVar appends a new "GetVar" instruction to the function body.
 //
 //	r = v
-func (fb *functionBuilder) emitGetVar(v int, r int8, varKind reflect.Kind) {
+func (fb *functionBuilder) emitGetVar(v int, r int16, varKind reflect.Kind) {
 	a, b := encodeInt16(int16(v))
 	fb.addOperandKinds(0, 0, varKind)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpGetVar, A: a, B: b, C: r})
@@ -309,7 +329,7 @@ func (fb *functionBuilder) emitGetVar(v int, r int8, varKind reflect.Kind) {
 // emitGetVarAddr appends a new "GetVarAddr" instruction to the function body.
 //
 //	r = &v
-func (fb *functionBuilder) emitGetVarAddr(v int, r int8) {
+func (fb *functionBuilder) emitGetVarAddr(v int, r int16) {
 	a, b := encodeInt16(int16(v))
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpGetVarAddr, A: a, B: b, C: r})
 }
@@ -359,7 +379,7 @@ func (fb *functionBuilder) emitGoto(lab label) {
 //	len(x) >  y
 //	len(x) >= y
 //	x contains y
-func (fb *functionBuilder) emitIf(ky bool, x int8, o runtime.Condition, y int8, kind reflect.Kind, pos *ast.Position) {
+func (fb *functionBuilder) emitIf(ky bool, x int16, o runtime.Condition, y int16, kind reflect.Kind, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	var op runtime.Operation
 	switch kindToType(kind) {
@@ -375,7 +395,7 @@ func (fb *functionBuilder) emitIf(ky bool, x int8, o runtime.Condition, y int8,
 	if ky {
 		op = -op
 	}
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: x, B: int8(o), C: y})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: x, B: int16(o), C: y})
 }
 
 // emitIndex appends a new "Index", "IndexRef", "MapIndex", or "IndexString"
@@ -388,7 +408,7 @@ func (fb *functionBuilder) emitIf(ky bool, x int8, o runtime.Condition, y int8,
 //
 // TODO: consider splitting emitIndex in two methods removing the 'ref bool'
 // argument.
-func (fb *functionBuilder) emitIndex(ki bool, expr, i, dst int8, t reflect.Type, pos *ast.Position, ref bool) {
+func (fb *functionBuilder) emitIndex(ki bool, expr, i, dst int16, t reflect.Type, pos *ast.Position, ref bool) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	kind := t.Kind()
@@ -425,20 +445,20 @@ func (fb *functionBuilder) emitIndex(ki bool, expr, i, dst int8, t reflect.Type,
 // emitLen appends a new "len" instruction to the function body.
 //
 //	l = len(s)
-func (fb *functionBuilder) emitLen(s, l int8, t reflect.Type) {
+func (fb *functionBuilder) emitLen(s, l int16, t reflect.Type) {
 	a := stringRegister
 	if t.Kind() != reflect.String {
 		a = generalRegister
 	}
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpLen, A: int8(a), B: s, C: l})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpLen, A: int16(a), B: s, C: l})
 }
 
 // emitLoadFunc appends a new "LoadFunc" instruction to the function body.
 //
 //	z = p.f
-func (fb *functionBuilder) emitLoadFunc(native bool, f int8, z int8) {
+func (fb *functionBuilder) emitLoadFunc(native bool, f int16, z int16) {
 	fn := fb.fn
-	var a int8
+	var a int16
 	if native {
 		a = 1
 	}
@@ -446,13 +466,13 @@ func (fb *functionBuilder) emitLoadFunc(native bool, f int8, z int8) {
 }
 
 // emitLoad appends a new "Load" instruction to the function body.
-func (fb *functionBuilder) emitLoad(index int, dst int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitLoad(index int, dst int16, kind reflect.Kind) {
 	a, b := encodeValueIndex(kindToType(kind), index)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpLoad, A: a, B: b, C: dst})
 }
 
 // emitMakeArray appends a new "MakeArray" instruction to the function body.
-func (fb *functionBuilder) emitMakeArray(typ reflect.Type, dst int8) {
+func (fb *functionBuilder) emitMakeArray(typ reflect.Type, dst int16) {
 	if typ.Kind() != reflect.Array {
 		panic(internalError("%s is not an array type", typ))
 	}
@@ -461,13 +481,13 @@ func (fb *functionBuilder) emitMakeArray(typ reflect.Type, dst int8) {
 	// the other methods.
 	fn := fb.fn
 	b := fb.addType(typ, false)
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeArray, B: int8(b), C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeArray, B: int16(b), C: dst})
 }
 
 // emitMakeChan appends a new "MakeChan" instruction to the function body.
 //
 //	dst = make(typ, capacity)
-func (fb *functionBuilder) emitMakeChan(typ reflect.Type, kCapacity bool, capacity int8, dst int8, pos *ast.Position) {
+func (fb *functionBuilder) emitMakeChan(typ reflect.Type, kCapacity bool, capacity int16, dst int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	t := fb.addType(typ, false)
@@ -475,30 +495,30 @@ func (fb *functionBuilder) emitMakeChan(typ reflect.Type, kCapacity bool, capaci
 	if kCapacity {
 		op = -op
 	}
-	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: int8(t), B: capacity, C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: int16(t), B: capacity, C: dst})
 }
 
 // emitMakeMap appends a new "MakeMap" instruction to the function body.
 //
 //	dst = make(typ, size)
-func (fb *functionBuilder) emitMakeMap(typ reflect.Type, kSize bool, size int8, dst int8) {
+func (fb *functionBuilder) emitMakeMap(typ reflect.Type, kSize bool, size int16, dst int16) {
 	fn := fb.fn
 	t := fb.addType(typ, false)
 	op := runtime.OpMakeMap
 	if kSize {
 		op = -op
 	}
-	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: int8(t), B: size, C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: op, A: int16(t), B: size, C: dst})
 }
 
 // emitMakeSlice appends a new "MakeSlice" instruction to the function body.
 //
 //	make(sliceType, len, cap)
-func (fb *functionBuilder) emitMakeSlice(kLen, kCap bool, sliceType reflect.Type, len, cap, dst int8, pos *ast.Position) {
+func (fb *functionBuilder) emitMakeSlice(kLen, kCap bool, sliceType reflect.Type, len, cap, dst int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	t := fb.addType(sliceType, false)
-	var k int8
+	var k int16
 	if len == 0 && cap == 0 {
 		k = 0
 	} else {
@@ -510,14 +530,14 @@ func (fb *functionBuilder) emitMakeSlice(kLen, kCap bool, sliceType reflect.Type
 			k |= 1 << 2
 		}
 	}
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeSlice, A: int8(t), B: k, C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeSlice, A: int16(t), B: k, C: dst})
 	if k > 0 {
 		fn.Body = append(fn.Body, runtime.Instruction{A: len, B: cap})
 	}
 }
 
 // emitMakeStruct appends a new "MakeStruct" instruction to the function body.
-func (fb *functionBuilder) emitMakeStruct(typ reflect.Type, dst int8) {
+func (fb *functionBuilder) emitMakeStruct(typ reflect.Type, dst int16) {
 	if typ.Kind() != reflect.Struct {
 		panic(internalError("%s is not a struct type", typ.Kind()))
 	}
@@ -526,33 +546,55 @@ func (fb *functionBuilder) emitMakeStruct(typ reflect.Type, dst int8) {
 	// the other methods.
 	fn := fb.fn
 	b := fb.addType(typ, false)
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeStruct, B: int8(b), C: dst})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpMakeStruct, B: int16(b), C: dst})
+}
+
+// emitMax appends a new "Max" instruction to the function body.
+//
+//	z = max(z, y)
+func (fb *functionBuilder) emitMax(k bool, y, z int16, kind reflect.Kind) {
+	op := runtime.OpMax
+	if k {
+		op = -op
+	}
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: int16(kind), B: y, C: z})
 }
 
 // emitMethodValue appends a new "MethodValue" instruction to the function body.
 //
 //	dst = receiver.name
-func (fb *functionBuilder) emitMethodValue(name int8, receiver int8, dst int8, pos *ast.Position) {
+func (fb *functionBuilder) emitMethodValue(name int16, receiver int16, dst int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpMethodValue, A: receiver, B: name, C: dst})
 }
 
+// emitMin appends a new "Min" instruction to the function body.
+//
+//	z = min(z, y)
+func (fb *functionBuilder) emitMin(k bool, y, z int16, kind reflect.Kind) {
+	op := runtime.OpMin
+	if k {
+		op = -op
+	}
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: int16(kind), B: y, C: z})
+}
+
 // emitMove appends a new "Move" instruction to the function body.
 //
 //	z = x
-func (fb *functionBuilder) emitMove(k bool, x, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitMove(k bool, x, z int16, kind reflect.Kind) {
 	op := runtime.OpMove
 	if k {
 		op = -op
 	}
-	a := int8(kindToType(kind))
+	a := int16(kindToType(kind))
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: a, B: x, C: z})
 }
 
 // emitMul appends a new "mul" instruction to the function body.
 //
 //	z = x * y
-func (fb *functionBuilder) emitMul(ky bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitMul(ky bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -563,7 +605,7 @@ func (fb *functionBuilder) emitMul(ky bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpMul
 	}
 	if ky {
@@ -575,26 +617,26 @@ func (fb *functionBuilder) emitMul(ky bool, x, y, z int8, kind reflect.Kind) {
 // emitNeg appends a new "neg" instruction to the function body.
 //
 //	z = -y
-func (fb *functionBuilder) emitNeg(y, z int8, kind reflect.Kind) {
-	x := int8(flattenIntegerKind(kind))
+func (fb *functionBuilder) emitNeg(y, z int16, kind reflect.Kind) {
+	x := int16(flattenIntegerKind(kind))
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpNeg, A: x, B: y, C: z})
 }
 
 // emitNew appends a new "new" instruction to the function body.
 //
 //	z = new(t)
-func (fb *functionBuilder) emitNew(typ reflect.Type, z int8) {
+func (fb *functionBuilder) emitNew(typ reflect.Type, z int16) {
 	// NOTE: the code of emitMakeArray, emitMakeStruct and emitNew is very
 	// similar. If you change this code remember to review/change the code of
 	// the other methods.
 	fn := fb.fn
 	b := fb.addType(typ, false)
-	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpNew, B: int8(b), C: z})
+	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpNew, B: int16(b), C: z})
 }
 
 // emitNotZero appends a new "NotZero" instruction to the function body.
-func (fb *functionBuilder) emitNotZero(kind reflect.Kind, dst, src int8) {
-	regType := int8(kindToType(kind))
+func (fb *functionBuilder) emitNotZero(kind reflect.Kind, dst, src int16) {
+	regType := int16(kindToType(kind))
 	regType += 10 // to distinguish "NotZero" from "Zero".
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpZero, A: regType, B: src, C: dst})
 }
@@ -602,7 +644,7 @@ func (fb *functionBuilder) emitNotZero(kind reflect.Kind, dst, src int8) {
 // emitOr appends a new "Or" instruction to the function body.
 //
 //	z = x | y
-func (fb *functionBuilder) emitOr(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitOr(k bool, x, y, z int16, kind reflect.Kind) {
 	op := runtime.OpOr
 	if k {
 		op = -op
@@ -616,12 +658,12 @@ func (fb *functionBuilder) emitOr(k bool, x, y, z int8, kind reflect.Kind) {
 // otherwise typ should be nil.
 //
 //	panic(v)
-func (fb *functionBuilder) emitPanic(v int8, typ reflect.Type, pos *ast.Position) {
+func (fb *functionBuilder) emitPanic(v int16, typ reflect.Type, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	in := runtime.Instruction{Op: runtime.OpPanic, A: v}
 	if typ != nil {
-		in.C = int8(fb.addType(typ, true))
+		in.C = int16(fb.addType(typ, true))
 	}
 	fn.Body = append(fn.Body, in)
 }
@@ -629,14 +671,14 @@ func (fb *functionBuilder) emitPanic(v int8, typ reflect.Type, pos *ast.Position
 // emitPrint appends a new "Print" instruction to the function body.
 //
 //	print(arg)
-func (fb *functionBuilder) emitPrint(arg int8) {
+func (fb *functionBuilder) emitPrint(arg int16) {
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpPrint, A: arg})
 }
 
 // emitRange appends a new "Range" instruction to the function body.
 //
 //	for i, e := range s
-func (fb *functionBuilder) emitRange(k bool, s, i, e int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitRange(k bool, s, i, e int16, kind reflect.Kind) {
 	fn := fb.fn
 	var op runtime.Operation
 	switch kind {
@@ -657,7 +699,7 @@ func (fb *functionBuilder) emitRange(k bool, s, i, e int8, kind reflect.Kind) {
 // emitRealImag appends a new "RealImag" instruction to the function body.
 //
 //	y, z = real(x), imag(x)
-func (fb *functionBuilder) emitRealImag(k bool, x, y, z int8) {
+func (fb *functionBuilder) emitRealImag(k bool, x, y, z int16) {
 	op := runtime.OpRealImag
 	if k {
 		op = -op
@@ -670,7 +712,7 @@ func (fb *functionBuilder) emitRealImag(k bool, x, y, z int8) {
 //	dst = <- ch
 //
 //	dst, ok = <- ch
-func (fb *functionBuilder) emitReceive(ch, ok, dst int8) {
+func (fb *functionBuilder) emitReceive(ch, ok, dst int16) {
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpReceive, A: ch, B: ok, C: dst})
 }
 
@@ -678,8 +720,8 @@ func (fb *functionBuilder) emitReceive(ch, ok, dst int8) {
 //
 //	recover()
 //	defer recover()
-func (fb *functionBuilder) emitRecover(r int8, down bool) {
-	var a int8
+func (fb *functionBuilder) emitRecover(r int16, down bool) {
+	var a int16
 	if down {
 		// Recover down the stack.
 		a = 1
@@ -690,7 +732,7 @@ func (fb *functionBuilder) emitRecover(r int8, down bool) {
 // emitRem appends a new "Rem" instruction to the function body.
 //
 //	z = x % y
-func (fb *functionBuilder) emitRem(ky bool, x, y, z int8, kind reflect.Kind, pos *ast.Position) {
+func (fb *functionBuilder) emitRem(ky bool, x, y, z int16, kind reflect.Kind, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	var op runtime.Operation
 	switch kind {
@@ -700,7 +742,7 @@ func (fb *functionBuilder) emitRem(ky bool, x, y, z int8, kind reflect.Kind, pos
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpRem
 	}
 	if ky {
@@ -726,7 +768,7 @@ func (fb *functionBuilder) emitSelect() {
 // emitSend appends a new "Send" instruction to the function body.
 //
 //	ch <- v
-func (fb *functionBuilder) emitSend(ch, v int8, pos *ast.Position, chanElemKind reflect.Kind) {
+func (fb *functionBuilder) emitSend(ch, v int16, pos *ast.Position, chanElemKind reflect.Kind) {
 	fb.addPosAndPath(pos)
 	fb.addOperandKinds(chanElemKind, 0, 0)
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpSend, A: v, C: ch})
@@ -735,7 +777,7 @@ func (fb *functionBuilder) emitSend(ch, v int8, pos *ast.Position, chanElemKind
 // emitSetField appends a new "SetField" instruction to the function body.
 //
 //	s.field = v
-func (fb *functionBuilder) emitSetField(k bool, s, field, v int8, fieldKind reflect.Kind) {
+func (fb *functionBuilder) emitSetField(k bool, s, field, v int16, fieldKind reflect.Kind) {
 	fb.addOperandKinds(fieldKind, 0, 0)
 	op := runtime.OpSetField
 	if k {
@@ -747,7 +789,7 @@ func (fb *functionBuilder) emitSetField(k bool, s, field, v int8, fieldKind refl
 // emitSetMap appends a new "SetMap" instruction to the function body.
 //
 //	m[key] = value
-func (fb *functionBuilder) emitSetMap(k bool, m, value, key int8, mapType reflect.Type, pos *ast.Position) {
+func (fb *functionBuilder) emitSetMap(k bool, m, value, key int16, mapType reflect.Type, pos *ast.Position) {
 	keyType := mapType.Key()
 	valueType := mapType.Elem()
 	fb.addPosAndPath(pos)
@@ -763,7 +805,7 @@ func (fb *functionBuilder) emitSetMap(k bool, m, value, key int8, mapType reflec
 // emitSetSlice appends a new "SetSlice" instruction to the function body.
 //
 //	slice[index] = value
-func (fb *functionBuilder) emitSetSlice(k bool, slice, value, index int8, pos *ast.Position, sliceElemKind reflect.Kind) {
+func (fb *functionBuilder) emitSetSlice(k bool, slice, value, index int16, pos *ast.Position, sliceElemKind reflect.Kind) {
 	fb.addPosAndPath(pos)
 	fb.addOperandKinds(sliceElemKind, 0, 0)
 	in := runtime.Instruction{Op: runtime.OpSetSlice, A: value, B: slice, C: index}
@@ -776,19 +818,19 @@ func (fb *functionBuilder) emitSetSlice(k bool, slice, value, index int8, pos *a
 // emitSetVar appends a new "SetVar" instruction to the function body.
 //
 //	v = r
-func (fb *functionBuilder) emitSetVar(k bool, r int8, v int, dstKind reflect.Kind) {
+func (fb *functionBuilder) emitSetVar(k bool, r int16, v int, dstKind reflect.Kind) {
 	fb.addOperandKinds(dstKind, 0, 0)
 	op := runtime.OpSetVar
 	if k {
 		op = -op
 	}
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: r, B: int8(v >> 8), C: int8(v)})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: r, B: int16(v >> 8), C: int16(v)})
 }
 
 // emitShl appends a new "Shl" instruction to the function body.
 //
 //	z = x << y
-func (fb *functionBuilder) emitShl(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitShl(k bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -797,7 +839,7 @@ func (fb *functionBuilder) emitShl(k bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpShl
 	}
 	if k {
@@ -809,16 +851,17 @@ func (fb *functionBuilder) emitShl(k bool, x, y, z int8, kind reflect.Kind) {
 // emitShow appends a new "Show" instruction to the function body.
 //
 //	show(type, value, ctx)
-func (fb *functionBuilder) emitShow(typ reflect.Type, v int8, ctx ast.Context, inURL, isURLSet bool) {
+func (fb *functionBuilder) emitShow(typ reflect.Type, v int16, ctx ast.Context, inURL, isURLSet bool, pos *ast.Position) {
 	t := fb.addType(typ, true)
+	fb.addPosAndPath(pos)
 	c := encodeRenderContext(ctx, inURL, isURLSet)
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpShow, A: int8(t), B: v, C: int8(c)})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpShow, A: int16(t), B: v, C: int16(c)})
 }
 
 // emitShr appends a new "Shr" instruction to the function body.
 //
 //	z = x >> y
-func (fb *functionBuilder) emitShr(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitShr(k bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -827,7 +870,7 @@ func (fb *functionBuilder) emitShr(k bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpShr
 	}
 	if k {
@@ -839,10 +882,10 @@ func (fb *functionBuilder) emitShr(k bool, x, y, z int8, kind reflect.Kind) {
 // emitSlice appends a new "Slice" instruction to the function body.
 //
 //	slice[low:high:max]
-func (fb *functionBuilder) emitSlice(klow, khigh, kmax bool, src, dst, low, high, max int8, pos *ast.Position) {
+func (fb *functionBuilder) emitSlice(klow, khigh, kmax bool, src, dst, low, high, max int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
-	var b int8
+	var b int16
 	if klow {
 		b = 1
 	}
@@ -859,10 +902,10 @@ func (fb *functionBuilder) emitSlice(klow, khigh, kmax bool, src, dst, low, high
 // emitStringSlice appends a new "StringSlice" instruction to the function body.
 //
 //	string[low:high]
-func (fb *functionBuilder) emitStringSlice(klow, khigh bool, src, dst, low, high int8, pos *ast.Position) {
+func (fb *functionBuilder) emitStringSlice(klow, khigh bool, src, dst, low, high int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
-	var b int8
+	var b int16
 	if klow {
 		b = 1
 	}
@@ -876,7 +919,7 @@ func (fb *functionBuilder) emitStringSlice(klow, khigh bool, src, dst, low, high
 // emitSub appends a new "Sub" instruction to the function body.
 //
 //	z = x - y
-func (fb *functionBuilder) emitSub(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitSub(k bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -887,7 +930,7 @@ func (fb *functionBuilder) emitSub(k bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpSub
 	}
 	if k {
@@ -899,7 +942,7 @@ func (fb *functionBuilder) emitSub(k bool, x, y, z int8, kind reflect.Kind) {
 // emitSubInv appends a new "SubInv" instruction to the function body.
 //
 //	z = y - x
-func (fb *functionBuilder) emitSubInv(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitSubInv(k bool, x, y, z int16, kind reflect.Kind) {
 	var op runtime.Operation
 	switch kind {
 	case reflect.Int:
@@ -910,7 +953,7 @@ func (fb *functionBuilder) emitSubInv(k bool, x, y, z int8, kind reflect.Kind) {
 		if z != x {
 			panic(fmt.Errorf("z must be == x for kind %s", kind))
 		}
-		x = int8(flattenIntegerKind(kind))
+		x = int16(flattenIntegerKind(kind))
 		op = runtime.OpSubInv
 	}
 	if k {
@@ -922,7 +965,7 @@ func (fb *functionBuilder) emitSubInv(k bool, x, y, z int8, kind reflect.Kind) {
 // emitText appends a new "Text" instruction to the function body.
 //
 //	text(txt, ctx)
-func (fb *functionBuilder) emitText(txt []byte, inURL, isURLSet bool) {
+func (fb *functionBuilder) emitText(txt []byte, inURL, isURLSet bool, pos *ast.Position) {
 	if len(fb.text.txt) > 0 {
 		addr := fb.currentAddr()
 		if addr == fb.text.addr+1 && inURL == fb.text.inURL {
@@ -943,8 +986,9 @@ func (fb *functionBuilder) emitText(txt []byte, inURL, isURLSet bool) {
 	fb.text.addr = fb.currentAddr()
 	fb.text.txt = append(fb.text.txt, txt)
 	fb.text.inURL = inURL
+	fb.addPosAndPath(pos)
 	a, b := encodeUint16(uint16(len(fb.fn.Text)))
-	var c int8
+	var c int16
 	if inURL {
 		c = 1
 		if isURLSet {
@@ -957,26 +1001,26 @@ func (fb *functionBuilder) emitText(txt []byte, inURL, isURLSet bool) {
 // emitTailCall appends a new "TailCall" instruction to the function body.
 //
 //	f()
-func (fb *functionBuilder) emitTailCall(f int8, pos *ast.Position) {
+func (fb *functionBuilder) emitTailCall(f int16, pos *ast.Position) {
 	fb.addPosAndPath(pos)
 	fn := fb.fn
 	fn.Body = append(fn.Body, runtime.Instruction{Op: runtime.OpTailCall, A: f})
 }
 
 // emitTypify appends a new "Typify" instruction to the function body.
-func (fb *functionBuilder) emitTypify(k bool, typ reflect.Type, x, z int8) {
+func (fb *functionBuilder) emitTypify(k bool, typ reflect.Type, x, z int16) {
 	t := fb.addType(typ, true)
 	op := runtime.OpTypify
 	if k {
 		op = -op
 	}
-	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: int8(t), B: x, C: z})
+	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: op, A: int16(t), B: x, C: z})
 }
 
 // emitXor appends a new "Xor" instruction to the function body.
 //
 //	z = x ^ y
-func (fb *functionBuilder) emitXor(k bool, x, y, z int8, kind reflect.Kind) {
+func (fb *functionBuilder) emitXor(k bool, x, y, z int16, kind reflect.Kind) {
 	op := runtime.OpXor
 	if k {
 		op = -op
@@ -985,7 +1029,7 @@ func (fb *functionBuilder) emitXor(k bool, x, y, z int8, kind reflect.Kind) {
 }
 
 // emitZero appends a new "Zero" instruction to the function body.
-func (fb *functionBuilder) emitZero(kind reflect.Kind, dst, src int8) {
-	regType := int8(kindToType(kind))
+func (fb *functionBuilder) emitZero(kind reflect.Kind, dst, src int16) {
+	regType := int16(kindToType(kind))
 	fb.fn.Body = append(fb.fn.Body, runtime.Instruction{Op: runtime.OpZero, A: regType, B: src, C: dst})
 }
@@ -43,7 +43,9 @@ func typecheck(tree *ast.Tree, importer native.Importer, opts checkerOptions) (m
 			return nil, &CheckingError{path: tree.Path, pos: *pkg.Pos(), err: errors.New("package name must be main")}
 		}
 		compilation := newCompilation(nil)
+		compilation.recoverErrors = true
 		err := checkPackage(compilation, pkg, tree.Path, importer, opts, false)
+		err = compilation.checkingError(err)
 		if err != nil {
 			return nil, err
 		}
@@ -61,6 +63,10 @@ func typecheck(tree *ast.Tree, importer native.Importer, opts checkerOptions) (m
 	}
 
 	compilation := newCompilation(globalScope)
+	compilation.recoverErrors = true
+	if opts.analysis != nil {
+		compilation.typeInfos = opts.analysis.typeInfos
+	}
 	tc := newTypechecker(compilation, tree.Path, opts, importer)
 
 	// If tree extends another template file, transform it swapping the files
@@ -85,6 +91,7 @@ func typecheck(tree *ast.Tree, importer native.Importer, opts checkerOptions) (m
 		tree.Nodes = append([]ast.Node{dummyImport}, extends.Tree.Nodes...)
 		tree.Path = extends.Tree.Path
 		tc.path = extends.Tree.Path
+		tc.scopes.path = extends.Tree.Path
 	}
 
 	// Type check a template file.
@@ -93,6 +100,11 @@ func typecheck(tree *ast.Tree, importer native.Importer, opts checkerOptions) (m
 	if err != nil {
 		return nil, err
 	}
+	err = compilation.checkPendingInstances()
+	err = compilation.checkingError(err)
+	if err != nil {
+		return nil, err
+	}
 	mainPkgInfo := &packageInfo{}
 	mainPkgInfo.IndirectVars = tc.compilation.indirectVars
 	mainPkgInfo.TypeInfos = tc.compilation.typeInfos
@@ -120,6 +132,14 @@ type checkerOptions struct {
 
 	// mdConverter converts a Markdown source code to HTML.
 	mdConverter Converter
+
+	// analysis, if not nil, records the type checking information of an
+	// analyzed template.
+	analysis *Analysis
+
+	// shared, if not nil, shares the type checked imported files with the
+	// other compilations.
+	shared *sharedImports
 }
 
 // typechecker represents the state of the type checking.
@@ -225,6 +245,9 @@ func newTypechecker(compilation *compilation, path string, opts checkerOptions,
 	if tc.opts.mod == templateMod {
 		tc.scopes.AllowUnused()
 	}
+	if opts.analysis != nil {
+		tc.scopes.declarations = opts.analysis.declarations
+	}
 	return &tc
 }
 
@@ -493,7 +493,7 @@ func (tc *typechecker) checkAssignTo(ti *typeInfo, expr ast.Expression) ast.Expr
 		}
 	case *ast.Index:
 		ti := tc.compilation.typeInfos[e.Expr]
-		if ti.Type.Kind() == reflect.String {
+		if ti != nil && ti.Type.Kind() == reflect.String {
 			format += " (strings are immutable)"
 		}
 	case *ast.Slicing:
@@ -116,6 +116,30 @@ func (d *deps) analyzeGlobalDeclarationAssignment(n *ast.Assignment) {
 // analyzeGlobalFunc analyzes a global function declaration.
 func (d *deps) analyzeGlobalFunc(n *ast.Func) {
 	scopes := depScopes{map[string]struct{}{}}
+	scopes = d.declareTypeParams(n.Ident, n.TypeParams, scopes)
+	if n.Recv != nil {
+		// Declare the type parameters of a generic receiver type.
+		recv := n.Recv.Type
+		if op, ok := recv.(*ast.UnaryOperator); ok && op.Op == ast.OperatorPointer {
+			recv = op.Expr
+		}
+		var params []ast.Expression
+		switch r := recv.(type) {
+		case *ast.Index:
+			recv, params = r.Expr, []ast.Expression{r.Index}
+		case *ast.IndexList:
+			recv, params = r.Expr, r.Indices
+		}
+		for _, param := range params {
+			if ident, ok := param.(*ast.Identifier); ok {
+				scopes = declareLocally(scopes, ident.Name)
+			}
+		}
+		d.addDepsToGlobal(n.Ident, recv, scopes)
+		if n.Recv.Ident != nil {
+			scopes = declareLocally(scopes, n.Recv.Ident.Name)
+		}
+	}
 	for _, f := range n.Type.Parameters {
 		if f.Ident != nil {
 			scopes = declareLocally(scopes, f.Ident.Name)
@@ -134,7 +158,24 @@ func (d *deps) analyzeGlobalFunc(n *ast.Func) {
 
 // analyzeGlobalTypeDeclaration analyzes a global type declaration.
 func (d *deps) analyzeGlobalTypeDeclaration(td *ast.TypeDeclaration) {
-	d.addDepsToGlobal(td.Ident, td.Type, nil)
+	scopes := depScopes{map[string]struct{}{}}
+	scopes = d.declareTypeParams(td.Ident, td.TypeParams, scopes)
+	d.addDepsToGlobal(td.Ident, td.Type, scopes)
+}
+
+// declareTypeParams declares the type parameters params of the global
+// declaration ident in scopes, and adds the dependencies of their constraints
+// to ident.
+func (d *deps) declareTypeParams(ident *ast.Identifier, params []*ast.Field, scopes depScopes) depScopes {
+	for _, param := range params {
+		for _, name := range param.Idents {
+			scopes = declareLocally(scopes, name.Name)
+		}
+	}
+	for _, param := range params {
+		d.addDepsToGlobal(ident, param.Type, scopes)
+	}
+	return scopes
 }
 
 // analyzeTree analyzes tree returning a data structure holding all dependencies
@@ -375,8 +416,18 @@ func (d *deps) nodeDeps(n ast.Node, scopes depScopes) []*ast.Identifier {
 	case *ast.Index:
 		deps := d.nodeDeps(n.Expr, scopes)
 		return append(deps, d.nodeDeps(n.Index, scopes)...)
+	case *ast.IndexList:
+		deps := d.nodeDeps(n.Expr, scopes)
+		for _, index := range n.Indices {
+			deps = append(deps, d.nodeDeps(index, scopes)...)
+		}
+		return deps
 	case *ast.Interface:
-		return nil
+		deps := []*ast.Identifier{}
+		for _, elem := range n.Elements {
+			deps = append(deps, d.nodeDeps(elem.Type, scopes)...)
+		}
+		return deps
 	case *ast.Label:
 		return nil
 	case *ast.MapType:
@@ -15,6 +15,7 @@ import (
 
 	"github.com/open2b/scriggo/ast"
 	"github.com/open2b/scriggo/internal/compiler/types"
+	"github.com/open2b/scriggo/internal/runtime"
 )
 
 var untypedBoolTypeInfo = &typeInfo{Type: boolType, Properties: propertyUntyped}
@@ -24,6 +25,9 @@ func (tc *typechecker) checkIdentifier(ident *ast.Identifier, used bool) *typeIn
 
 	ti, decl, ok := tc.scopes.Lookup(ident.Name)
 	if !ok {
+		if tc.scopes.IsUnresolved(ident.Name) {
+			panic(errUnresolvedName)
+		}
 		panic(tc.errorf(ident, "undefined: %s", ident.Name))
 	}
 
@@ -67,10 +71,19 @@ func (tc *typechecker) checkIdentifier(ident *ast.Identifier, used bool) *typeIn
 		}
 	}
 
+	tc.recordUse(ident, decl)
+
 	if ti.IsBuiltinFunction() {
 		panic(tc.errorf(ident, "use of builtin %s not in function call", ident.Name))
 	}
 
+	if ti.IsGeneric() {
+		panic(tc.errorf(ident, "cannot use generic %s %s without instantiation", ti.value.(*generic).kind(), ident.Name))
+	}
+	if ti.IsConstraint() {
+		panic(tc.errorf(ident, "%s", ti.value.(*typeConstraint).misuse(ident)))
+	}
+
 	// Check if it is an upvar.
 	isUpVar := ti.Addressable() && tc.scopes.Function(ident.Name) != tc.scopes.CurrentFunction()
 
@@ -276,6 +289,9 @@ func (tc *typechecker) typeof(expr ast.Expression, typeExpected bool) *typeInfo
 		}
 
 	case *ast.UnaryOperator:
+		if expr.Op == ast.OperatorTilde {
+			panic(tc.errorf(expr, "cannot use ~ outside of interface or type constraint"))
+		}
 		t := tc.checkExprOrType(expr.Expr)
 		if t.IsType() {
 			if expr.Op == ast.OperatorPointer {
@@ -532,7 +548,7 @@ func (tc *typechecker) typeof(expr ast.Expression, typeExpected bool) *typeInfo
 		panic(tc.errorf(expr, "cannot use default expression in this context"))
 
 	case *ast.Interface:
-		return &typeInfo{Type: emptyInterfaceType, Properties: propertyIsType | propertyUniverse}
+		return tc.checkInterface(expr)
 
 	case *ast.FuncType:
 		tc.checkDuplicateParams(expr)
@@ -605,6 +621,9 @@ func (tc *typechecker) typeof(expr ast.Expression, typeExpected bool) *typeInfo
 		return tis[0]
 
 	case *ast.Index:
+		if g, ok := tc.genericOf(expr.Expr); ok {
+			return tc.checkInstantiation(expr, expr.Expr, g, []ast.Expression{expr.Index})
+		}
 		t := tc.checkExpr(expr.Expr)
 		if t.Nil() {
 			panic(tc.errorf(expr, "use of untyped nil"))
@@ -658,6 +677,15 @@ func (tc *typechecker) typeof(expr ast.Expression, typeExpected bool) *typeInfo
 	case *ast.Render:
 		return tc.checkRender(expr)
 
+	case *ast.IndexList:
+		if g, ok := tc.genericOf(expr.Expr); ok {
+			return tc.checkInstantiation(expr, expr.Expr, g, expr.Indices)
+		}
+		if t := tc.checkExprOrType(expr.Expr); t.IsType() {
+			panic(tc.errorf(expr.Expr, "%s is not a generic type", expr.Expr))
+		}
+		panic(tc.errorf(expr, "invalid operation: more than one index"))
+
 	case *ast.Slicing:
 		t := tc.checkExpr(expr.Expr)
 		if t.Nil() {
@@ -738,6 +766,10 @@ func (tc *typechecker) typeof(expr ast.Expression, typeExpected bool) *typeInfo
 		if t.Nil() {
 			panic(tc.errorf(expr.Expr, "use of untyped nil"))
 		}
+		// Method value or method expression of a method declared in Scriggo.
+		if ti, ok := tc.checkMethodSelector(t, expr); ok {
+			return ti
+		}
 		if t.IsType() {
 			// Method expression.
 			return tc.checkMethodExpression(t, expr)
@@ -1233,6 +1265,22 @@ func (tc *typechecker) checkBuiltinCall(expr *ast.Call) []*typeInfo {
 		}
 		return []*typeInfo{ti}
 
+	case "clear":
+		if len(expr.Args) == 0 {
+			panic(tc.errorf(expr, "not enough arguments for %s (expected 1, found 0)", expr))
+		}
+		if len(expr.Args) > 1 {
+			panic(tc.errorf(expr, "too many arguments for %s (expected 1, found %d)", expr, len(expr.Args)))
+		}
+		t := tc.checkExpr(expr.Args[0])
+		if t.Nil() {
+			panic(tc.errorf(expr, "use of untyped nil"))
+		}
+		if k := t.Type.Kind(); k != reflect.Map && k != reflect.Slice {
+			panic(tc.errorf(expr, "invalid argument: cannot clear %s (type %s): argument must be map or slice", expr.Args[0], t))
+		}
+		return nil
+
 	case "close":
 		if len(expr.Args) == 0 {
 			panic(tc.errorf(expr, "missing argument to close: %s", expr))
@@ -1467,6 +1515,80 @@ func (tc *typechecker) checkBuiltinCall(expr *ast.Call) []*typeInfo {
 		}
 		return []*typeInfo{{Type: t.Type}}
 
+	case "max", "min":
+		if len(expr.Args) == 0 {
+			panic(tc.errorf(expr, "not enough arguments for %s (expected 1, found 0)", expr))
+		}
+		// Check the arguments and determine the type of the result. It is the
+		// type of the typed arguments or, if all arguments are untyped, the
+		// type of the untyped argument with the largest kind.
+		args := make([]*typeInfo, len(expr.Args))
+		var typ *typeInfo
+		for i, arg := range expr.Args {
+			t := tc.checkExpr(arg)
+			if t.Nil() {
+				panic(tc.errorf(expr, "use of untyped nil"))
+			}
+			if k := t.Type.Kind(); !isNumeric(k) && k != reflect.String || isComplex(k) {
+				panic(tc.errorf(expr, "invalid argument: %s (type %s) cannot be ordered", arg, t))
+			}
+			switch {
+			case typ == nil:
+				typ = t
+			case t.Untyped() && typ.Untyped():
+				if (t.Type.Kind() == reflect.String) != (typ.Type.Kind() == reflect.String) {
+					panic(tc.errorf(expr, "invalid argument: mismatched types %s (previous argument) and %s (type of %s)", typ, t, arg))
+				}
+				if t.Type.Kind() > typ.Type.Kind() {
+					typ = t
+				}
+			case !t.Untyped() && !typ.Untyped():
+				if t.Type != typ.Type {
+					panic(tc.errorf(expr, "invalid argument: mismatched types %s (previous argument) and %s (type of %s)", typ, t, arg))
+				}
+			case !t.Untyped():
+				typ = t
+			}
+			args[i] = t
+		}
+		isConstant := true
+		for _, t := range args {
+			isConstant = isConstant && t.IsConstant()
+		}
+		ti := &typeInfo{Type: typ.Type}
+		if typ.Untyped() && isConstant {
+			ti.Properties = propertyUntyped
+		} else {
+			// Convert the untyped arguments to the type of the result.
+			for i, t := range args {
+				if !t.Untyped() {
+					t.setValue(nil)
+					continue
+				}
+				c, err := tc.convert(t, expr.Args[i], typ.Type)
+				if err != nil {
+					if err == errNotRepresentable {
+						err = fmt.Errorf("cannot convert %#v (type %s) to type %s", t.Constant, t, typ.Type)
+					}
+					panic(tc.errorf(expr, "%s", err))
+				}
+				t.setValue(typ.Type)
+				args[i] = &typeInfo{Type: typ.Type, Constant: c}
+			}
+		}
+		if isConstant {
+			c := args[0].Constant
+			for _, t := range args[1:] {
+				if ident.Name == "min" {
+					c = minConst(c, t.Constant)
+				} else {
+					c = maxConst(c, t.Constant)
+				}
+			}
+			ti.Constant = c
+		}
+		return []*typeInfo{ti}
+
 	case "new":
 		if len(expr.Args) == 0 {
 			panic(tc.errorf(expr, "missing argument to new"))
@@ -1598,6 +1720,14 @@ func (tc *typechecker) checkCallExpression(expr *ast.Call) []*typeInfo {
 		}
 	}
 
+	// Check a call to a generic function, replacing the called function
+	// with its instance.
+	tc.checkGenericCall(expr)
+
+	// Check a call to a method declared in Scriggo, replacing the called
+	// method with its function.
+	tc.checkMethodCall(expr)
+
 	t := tc.checkExprOrType(expr.Func)
 
 	switch t.MethodType {
@@ -2183,6 +2313,13 @@ func (tc *typechecker) isCompileConstant(expr ast.Expression) bool {
 		switch tc.builtinCallName(expr) {
 		case "len", "cap":
 			return tc.isCompileConstant(expr.Args[0])
+		case "max", "min":
+			for _, arg := range expr.Args {
+				if !tc.isCompileConstant(arg) {
+					return false
+				}
+			}
+			return true
 		}
 		return false
 	case *ast.CompositeLiteral:
@@ -2415,10 +2552,11 @@ func (tc *typechecker) checkPackageSelector(expr *ast.Selector) (*typeInfo, bool
 	if !ok {
 		return nil, false
 	}
-	pkg, _, ok := tc.scopes.Lookup(ident.Name)
+	pkg, decl, ok := tc.scopes.Lookup(ident.Name)
 	if !ok || !pkg.IsPackage() {
 		return nil, false
 	}
+	tc.recordUse(ident, decl)
 	if !isExported(expr.Ident) {
 		panic(tc.errorf(expr, "cannot refer to unexported name %s", expr))
 	}
@@ -2427,6 +2565,16 @@ func (tc *typechecker) checkPackageSelector(expr *ast.Selector) (*typeInfo, bool
 	if !ok {
 		panic(tc.errorf(expr, "undefined: %v", expr))
 	}
+	if decl, ok := pkg.value.(*packageInfo).DeclarationNodes[expr.Ident]; ok {
+		tc.recordUse(expr, decl)
+	}
+
+	if ti.IsGeneric() {
+		panic(tc.errorf(expr, "cannot use generic %s %s without instantiation", ti.value.(*generic).kind(), expr))
+	}
+	if ti.IsConstraint() {
+		panic(tc.errorf(expr, "%s", ti.value.(*typeConstraint).misuse(expr)))
+	}
 
 	if rv, ok := ti.value.(*reflect.Value); ok && ti.Addressable() {
 		// ti is a predefined variable.
@@ -2479,20 +2627,7 @@ func (tc *typechecker) checkMethodExpression(t *typeInfo, expr *ast.Selector) *t
 		if !isExported(name) {
 			panic(tc.errorf(expr, "%s undefined (cannot refer to unexported field or method %s)", expr, name))
 		}
-		mt := method.Type
-		in := make([]reflect.Type, mt.NumIn()+1)
-		in[0] = t.Type
-		for i := 0; i < mt.NumIn(); i++ {
-			in[i+1] = mt.In(i)
-		}
-		out := make([]reflect.Type, mt.NumOut())
-		for i := 0; i < mt.NumOut(); i++ {
-			out[i] = mt.Out(i)
-		}
-		f := func(args []reflect.Value) []reflect.Value {
-			return args[0].MethodByName(method.Name).Call(args[1:])
-		}
-		methExpr := reflect.MakeFunc(reflect.FuncOf(in, out, mt.IsVariadic()), f)
+		methExpr := interfaceMethodExpr(t.Type, method)
 		ti.Type = removeEnvArg(methExpr.Type(), false)
 		ti.value = methExpr
 	} else {
@@ -2503,6 +2638,25 @@ func (tc *typechecker) checkMethodExpression(t *typeInfo, expr *ast.Selector) *t
 	return ti
 }
 
+// interfaceMethodExpr returns the function of the method expression t.M,
+// where t is an interface type and method is its method M.
+func interfaceMethodExpr(t reflect.Type, method reflect.Method) reflect.Value {
+	mt := method.Type
+	in := make([]reflect.Type, mt.NumIn()+1)
+	in[0] = t
+	for i := 0; i < mt.NumIn(); i++ {
+		in[i+1] = mt.In(i)
+	}
+	out := make([]reflect.Type, mt.NumOut())
+	for i := 0; i < mt.NumOut(); i++ {
+		out[i] = mt.Out(i)
+	}
+	f := func(args []reflect.Value) []reflect.Value {
+		return args[0].MethodByName(method.Name).Call(args[1:])
+	}
+	return reflect.MakeFunc(reflect.FuncOf(in, out, mt.IsVariadic()), f)
+}
+
 // checkMethodValue checks a method value. If the type has the method, it
 // returns the type info and true, otherwise returns nil and false.
 func (tc *typechecker) checkMethodValue(t *typeInfo, expr *ast.Selector) (*typeInfo, bool) {
@@ -2535,7 +2689,7 @@ func (tc *typechecker) checkMethodValue(t *typeInfo, expr *ast.Selector) (*typeI
 	}
 
 	if kind == reflect.Interface {
-		if !isExported(name) {
+		if _, ok := typ.(runtime.ScriggoType); !ok && !isExported(name) {
 			panic(tc.errorf(expr, "%s undefined (cannot refer to unexported field or method %s)", expr, name))
 		}
 		return &typeInfo{
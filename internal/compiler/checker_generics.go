@@ -0,0 +1,847 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/ast/astutil"
+	"github.com/open2b/scriggo/internal/compiler/types"
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// maxGenericInstances is the maximum number of instances of a single generic
+// function or type. It prevents an infinite instantiation when a generic
+// function instantiates itself with ever-growing type arguments.
+const maxGenericInstances = 1000
+
+// A generic represents a generic function or a generic type declared at
+// package level.
+//
+// The body of a generic function, and the type of a generic type, are type
+// checked only when instantiated, once for every distinct list of type
+// arguments, replacing the type parameters with the type arguments.
+type generic struct {
+	// tc is the type checker of the package where it is declared.
+	tc *typechecker
+	// pkg is the package where it is declared.
+	pkg *ast.Package
+	// fn is the function declaration. It is nil for generic types.
+	fn *ast.Func
+	// typ is the type declaration. It is nil for generic functions.
+	typ *ast.TypeDeclaration
+	// params are the type parameters.
+	params []*ast.Identifier
+	// constraints are the constraints of the type parameters.
+	constraints []ast.Expression
+	// instances are the instances created so far.
+	instances []*genericInstance
+	// methods are the methods of a generic type.
+	methods []*generic
+}
+
+// A genericInstance is an instance of a generic function or type.
+type genericInstance struct {
+	// generic is the instantiated generic function or type.
+	generic *generic
+	// args are the type arguments.
+	args []reflect.Type
+	// name is the name of the instance, as "Map[int,string]".
+	name string
+	// fn is the declaration of the function instance. It is nil for types.
+	fn *ast.Func
+	// ti is the type info of the function instance or of the type instance.
+	ti *typeInfo
+}
+
+// name returns the name of g.
+func (g *generic) name() string {
+	if g.fn != nil {
+		return g.fn.Ident.Name
+	}
+	return g.typ.Ident.Name
+}
+
+// kind returns "function" for generic functions and "type" for generic
+// types.
+func (g *generic) kind() string {
+	if g.fn != nil {
+		return "function"
+	}
+	return "type"
+}
+
+// paramIndex returns the index of the type parameter with the given name, or
+// -1 if there is no such type parameter.
+func (g *generic) paramIndex(name string) int {
+	for i, param := range g.params {
+		if param.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// declareGeneric declares, in the file/package block, the generic function
+// fn or the generic type typ, with type parameters params.
+func (tc *typechecker) declareGeneric(pkg *ast.Package, ident *ast.Identifier, params []*ast.Field, fn *ast.Func, typ *ast.TypeDeclaration) {
+	g := &generic{tc: tc, pkg: pkg, fn: fn, typ: typ}
+	for _, field := range params {
+		for _, param := range field.Idents {
+			if param.Name != "_" && g.paramIndex(param.Name) != -1 {
+				panic(tc.errorf(param, "%s redeclared in this block", param.Name))
+			}
+			g.params = append(g.params, param)
+			g.constraints = append(g.constraints, field.Type)
+		}
+	}
+	// The packages referenced only in the generic declaration are marked as
+	// used, because the declaration is type checked only when instantiated.
+	var node ast.Node = typ
+	if fn != nil {
+		node = fn
+	}
+	tc.useImports(node)
+	for _, field := range params {
+		tc.useImports(field.Type)
+	}
+	if isBlankIdentifier(ident) {
+		return
+	}
+	tc.assignScope(ident.Name, &typeInfo{Properties: propertyIsGeneric, value: g}, ident, nil)
+}
+
+// useImports marks as used the packages referenced by the selectors in node.
+func (tc *typechecker) useImports(node ast.Node) {
+	var inspect func(ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Call:
+			astutil.Inspect(n.Func, inspect)
+		case *ast.Func:
+			astutil.Inspect(n.Type, inspect)
+		case *ast.Selector:
+			if ident, ok := n.Expr.(*ast.Identifier); ok {
+				if ti, _, ok := tc.scopes.Lookup(ident.Name); ok && ti.IsPackage() {
+					tc.scopes.Use(ident.Name)
+				}
+			}
+		}
+		return true
+	}
+	astutil.Inspect(node, inspect)
+}
+
+// genericOf returns the generic function or type referred by expr and true,
+// if expr is an identifier or a package selector referring to a generic
+// declaration. Otherwise it returns nil and false.
+func (tc *typechecker) genericOf(expr ast.Expression) (*generic, bool) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		ti, _, ok := tc.scopes.Lookup(expr.Name)
+		if ok && ti.IsGeneric() {
+			tc.scopes.Use(expr.Name)
+			return ti.value.(*generic), true
+		}
+	case *ast.Selector:
+		ident, ok := expr.Expr.(*ast.Identifier)
+		if !ok || !isExported(expr.Ident) {
+			return nil, false
+		}
+		pkg, _, ok := tc.scopes.Lookup(ident.Name)
+		if !ok || !pkg.IsPackage() {
+			return nil, false
+		}
+		ti, ok := pkg.value.(*packageInfo).Declarations[expr.Ident]
+		if ok && ti.IsGeneric() {
+			tc.scopes.Use(ident.Name)
+			return ti.value.(*generic), true
+		}
+	}
+	return nil, false
+}
+
+// withTypeArgs calls f with the type checker of the package of g, where the
+// type parameters of g are declared with the types in args.
+func (g *generic) withTypeArgs(args []reflect.Type, f func(tc *typechecker)) {
+	tc := g.tc
+	names := make(map[string]scopeName, len(g.params))
+	for i, param := range g.params {
+		if param.Name != "_" {
+			names[param.Name] = scopeName{ti: &typeInfo{Type: args[i], Properties: propertyIsType}, decl: param}
+		}
+	}
+	scopes := *tc.scopes
+	scopes.s = append(tc.scopes.s[:4:4], scope{names: names})
+	saved, iota, ancestors := tc.scopes, tc.iota, tc.ancestors
+	tc.scopes, tc.iota, tc.ancestors = &scopes, -1, nil
+	defer func() {
+		tc.scopes, tc.iota, tc.ancestors = saved, iota, ancestors
+	}()
+	f(tc)
+}
+
+// checkTypeArgs checks the type arguments in indices and returns their types.
+func (tc *typechecker) checkTypeArgs(indices []ast.Expression) []reflect.Type {
+	args := make([]reflect.Type, len(indices))
+	for i, index := range indices {
+		args[i] = tc.checkType(index).Type
+	}
+	return args
+}
+
+// checkInstantiation checks the instantiation expr of the generic function or
+// type g, referred by fn, with the type arguments in indices.
+func (tc *typechecker) checkInstantiation(expr ast.Expression, fn ast.Expression, g *generic, indices []ast.Expression) *typeInfo {
+	if len(indices) > len(g.params) {
+		panic(tc.errorf(expr, "got %d type arguments but %s has %d type parameters", len(indices), fn, len(g.params)))
+	}
+	if len(indices) < len(g.params) {
+		panic(tc.errorf(expr, "not enough type arguments for %s %s: have %d, want %d", g.kind(), fn, len(indices), len(g.params)))
+	}
+	inst := tc.instantiate(g, fn, tc.checkTypeArgs(indices), expr)
+	if g.typ != nil {
+		return inst.ti
+	}
+	return &typeInfo{Type: inst.ti.Type, replacement: tc.instanceRef(fn, inst)}
+}
+
+// instanceRef returns an expression, to be used in place of fn, that refers
+// to the function instance inst. fn is the identifier or the package selector
+// that refers to the instantiated generic function.
+func (tc *typechecker) instanceRef(fn ast.Expression, inst *genericInstance) ast.Expression {
+	var ref ast.Expression
+	switch fn := fn.(type) {
+	case *ast.Identifier:
+		ref = ast.NewIdentifier(fn.Pos(), inst.name)
+	case *ast.Selector:
+		ref = ast.NewSelector(fn.Pos(), fn.Expr, inst.name)
+	}
+	tc.compilation.typeInfos[ref] = &typeInfo{Type: inst.ti.Type}
+	return ref
+}
+
+// instantiate returns the instance of the generic function or type g, referred
+// by fn, with type arguments args. node is the node that requires the
+// instance and it is used in error messages.
+func (tc *typechecker) instantiate(g *generic, fn ast.Expression, args []reflect.Type, node ast.Node) *genericInstance {
+
+	// Look for an already created instance.
+	name := g.name() + "["
+	for i, arg := range args {
+		if i > 0 {
+			name += ","
+		}
+		name += arg.String()
+	}
+	name += "]"
+	instanceName := name
+	for _, inst := range g.instances {
+		if equalTypes(inst.args, args) {
+			return inst
+		}
+		if inst.name == instanceName {
+			// Different types with the same name, as types declared in
+			// different packages.
+			instanceName = name + "·" + strconv.Itoa(len(g.instances))
+		}
+	}
+	if len(g.instances) == maxGenericInstances {
+		panic(tc.errorf(node, "instantiation cycle in %s", fn))
+	}
+
+	// Check that the type arguments satisfy the constraints.
+	g.withTypeArgs(args, func(gtc *typechecker) {
+		for i, c := range g.constraints {
+			constraint := gtc.checkConstraint(astutil.CloneExpression(c))
+			if err := constraint.satisfiedBy(args[i], c); err != nil {
+				panic(tc.errorf(node, "%s", err))
+			}
+		}
+	})
+
+	inst := &genericInstance{generic: g, args: args, name: instanceName}
+
+	// Instantiate a generic type.
+	if g.typ != nil {
+		var underlying reflect.Type
+		g.withTypeArgs(args, func(gtc *typechecker) {
+			underlying = gtc.checkType(astutil.CloneExpression(g.typ.Type)).Type
+		})
+		t := g.tc.types.DefinedOf(instanceName, underlying)
+		if t.Kind() == reflect.Struct {
+			g.tc.structDeclPkg[t] = g.tc.path
+		}
+		inst.ti = &typeInfo{Type: t, Properties: propertyIsType}
+		g.instances = append(g.instances, inst)
+		tc.compilation.typeInstances[t] = inst
+		for _, m := range g.methods {
+			m.instantiateMethod(inst)
+		}
+		return inst
+	}
+
+	// Instantiate a generic function. Its body is checked later, so the
+	// instance can also be referred by its body.
+	inst.fn = astutil.CloneNode(g.fn).(*ast.Func)
+	inst.fn.Ident.Name = instanceName
+	inst.fn.TypeParams = nil
+	g.withTypeArgs(args, func(gtc *typechecker) {
+		inst.ti = &typeInfo{Type: gtc.checkType(inst.fn.Type).Type}
+	})
+	g.instances = append(g.instances, inst)
+	g.pkg.Declarations = append(g.pkg.Declarations, inst.fn)
+	tc.compilation.pendingInstances = append(tc.compilation.pendingInstances, inst)
+
+	return inst
+}
+
+// checkPendingInstances checks the bodies of the instantiated generic
+// functions that have not been checked yet.
+func (compilation *compilation) checkPendingInstances() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rerr, ok := r.(*CheckingError); ok {
+				err = rerr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	for len(compilation.pendingInstances) > 0 {
+		inst := compilation.pendingInstances[0]
+		compilation.pendingInstances = compilation.pendingInstances[1:]
+		inst.generic.withTypeArgs(inst.args, func(tc *typechecker) {
+			tc.checkFunc(inst.fn)
+		})
+	}
+	return nil
+}
+
+// removeGenerics removes the generic declarations from pkg. Only their
+// instances are emitted.
+func removeGenerics(pkg *ast.Package) {
+	declarations := pkg.Declarations[:0]
+	for _, decl := range pkg.Declarations {
+		switch decl := decl.(type) {
+		case *ast.Func:
+			if decl.TypeParams != nil || hasGenericReceiver(decl) {
+				continue
+			}
+		case *ast.TypeDeclaration:
+			if decl.TypeParams != nil {
+				continue
+			}
+		}
+		declarations = append(declarations, decl)
+	}
+	pkg.Declarations = declarations
+}
+
+// checkGenericCall checks the call expr, if it is a call to a generic
+// function, inferring the type arguments not explicitly given. It replaces
+// the called function with the function instance and reports whether the
+// call is a call to a generic function.
+func (tc *typechecker) checkGenericCall(expr *ast.Call) bool {
+	fn := expr.Func
+	var indices []ast.Expression
+	switch f := fn.(type) {
+	case *ast.Index:
+		fn, indices = f.Expr, []ast.Expression{f.Index}
+	case *ast.IndexList:
+		fn, indices = f.Expr, f.Indices
+	}
+	g, ok := tc.genericOf(fn)
+	if !ok || g.fn == nil {
+		return false
+	}
+	if len(indices) > len(g.params) {
+		panic(tc.errorf(expr.Func, "got %d type arguments but %s has %d type parameters", len(indices), fn, len(g.params)))
+	}
+	args := make([]reflect.Type, len(g.params))
+	copy(args, tc.checkTypeArgs(indices))
+	if len(indices) < len(g.params) {
+		tc.inferTypeArgs(g, fn, expr, args)
+	}
+	inst := tc.instantiate(g, fn, args, expr)
+	expr.Func = tc.instanceRef(fn, inst)
+	return true
+}
+
+// inferTypeArgs infers the type arguments of the call expr to the generic
+// function g, referred by fn, from the types of the call arguments and from
+// the core types of the constraints. args contains the type arguments, nil
+// if not explicitly given, and it is filled with the inferred types.
+func (tc *typechecker) inferTypeArgs(g *generic, fn ast.Expression, expr *ast.Call, args []reflect.Type) {
+
+	params := g.fn.Type.Parameters
+	variadic := g.fn.Type.IsVariadic
+
+	// Types of the parameters, where the type of the parameters declared as
+	// in "a, b int" is the type of the last parameter of the group.
+	paramTypes := make([]ast.Expression, len(params))
+	for i := len(params) - 1; i >= 0; i-- {
+		if params[i].Type == nil {
+			paramTypes[i] = paramTypes[i+1]
+		} else {
+			paramTypes[i] = params[i].Type
+		}
+	}
+
+	// Types of the call arguments.
+	var tis []*typeInfo
+	if len(expr.Args) == 1 && !expr.IsVariadic && len(params) > 1 {
+		if call, ok := expr.Args[0].(*ast.Call); ok {
+			if results := tc.checkCallExpression(call); len(results) > 1 {
+				tis = results
+			}
+		}
+	}
+	if tis == nil {
+		tis = make([]*typeInfo, len(expr.Args))
+		for i, arg := range expr.Args {
+			tis[i] = tc.checkExpr(arg)
+		}
+	}
+
+	// paramTypeOf returns the type of the parameter corresponding to the
+	// argument with index i, and whether the argument is passed to the
+	// variadic parameter.
+	paramTypeOf := func(i int) (ast.Expression, bool) {
+		last := len(paramTypes) - 1
+		if i < last || !variadic && i == last {
+			return paramTypes[i], false
+		}
+		if variadic && last >= 0 {
+			return paramTypes[last], true
+		}
+		return nil, false
+	}
+
+	// Infer from the typed arguments.
+	for i, ti := range tis {
+		typ, isVariadic := paramTypeOf(i)
+		if typ == nil || ti.Nil() || ti.Untyped() {
+			continue
+		}
+		t := ti.Type
+		if isVariadic && expr.IsVariadic {
+			if t.Kind() != reflect.Slice {
+				continue
+			}
+			t = t.Elem()
+		}
+		tc.unify(g, typ, t, args)
+	}
+
+	// Infer from the untyped constant arguments passed to parameters whose
+	// type is a type parameter, using their default types.
+	untyped := make([]reflect.Type, len(args))
+	for i, ti := range tis {
+		typ, _ := paramTypeOf(i)
+		ident, ok := typ.(*ast.Identifier)
+		if !ok || ti.Nil() || !ti.Untyped() {
+			continue
+		}
+		if p := g.paramIndex(ident.Name); p != -1 && args[p] == nil {
+			if t := untyped[p]; t == nil || untypedRank(ti.Type) > untypedRank(t) {
+				untyped[p] = ti.Type
+			}
+		}
+	}
+	for i, t := range untyped {
+		if t != nil {
+			args[i] = t
+		}
+	}
+
+	// Infer from the core types of the constraints.
+	for inferred := true; inferred; {
+		inferred = false
+		for i, c := range g.constraints {
+			core := coreTerm(c)
+			if core == nil || args[i] == nil {
+				continue
+			}
+			n := countInferred(args)
+			tc.unify(g, core, args[i], args)
+			inferred = inferred || countInferred(args) > n
+		}
+	}
+
+	for i, arg := range args {
+		if arg == nil {
+			panic(tc.errorf(expr, "in call to %s, cannot infer %s", fn, g.params[i].Name))
+		}
+	}
+
+}
+
+// equalTypes reports whether ts1 and ts2 contain the same types at the same
+// positions.
+func equalTypes(ts1, ts2 []reflect.Type) bool {
+	if len(ts1) != len(ts2) {
+		return false
+	}
+	for i := range ts1 {
+		if ts1[i] != ts2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// countInferred returns the number of non-nil types in args.
+func countInferred(args []reflect.Type) int {
+	n := 0
+	for _, arg := range args {
+		if arg != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// untypedRank returns the rank of the default type t of an untyped constant.
+// When untyped constants of different kinds are passed to the same type
+// parameter, the default type with the greater rank is inferred.
+func untypedRank(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int32:
+		return 1
+	case reflect.Float64:
+		return 2
+	case reflect.Complex128:
+		return 3
+	}
+	return 0
+}
+
+// coreTerm returns the single type term of the constraint c, or nil if c has
+// no single type term.
+func coreTerm(c ast.Expression) ast.Expression {
+	switch c := c.(type) {
+	case *ast.UnaryOperator:
+		if c.Op == ast.OperatorTilde {
+			return c.Expr
+		}
+		if c.Op == ast.OperatorPointer {
+			return c
+		}
+	case *ast.Interface:
+		if len(c.Elements) == 1 {
+			return coreTerm(c.Elements[0].Type)
+		}
+	case *ast.ArrayType, *ast.ChanType, *ast.FuncType, *ast.MapType, *ast.SliceType:
+		return c
+	}
+	return nil
+}
+
+// unify unifies the type expression expr, declared in the package of g, with
+// the type t, inferring the type arguments of the type parameters of g
+// that appear in expr. Inferred types are stored in args.
+func (tc *typechecker) unify(g *generic, expr ast.Expression, t reflect.Type, args []reflect.Type) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if i := g.paramIndex(expr.Name); i != -1 && args[i] == nil {
+			args[i] = t
+		}
+	case *ast.UnaryOperator:
+		if expr.Op == ast.OperatorPointer && t.Kind() == reflect.Ptr {
+			tc.unify(g, expr.Expr, t.Elem(), args)
+		}
+	case *ast.ArrayType:
+		if t.Kind() == reflect.Array {
+			tc.unify(g, expr.ElementType, t.Elem(), args)
+		}
+	case *ast.SliceType:
+		if t.Kind() == reflect.Slice {
+			tc.unify(g, expr.ElementType, t.Elem(), args)
+		}
+	case *ast.ChanType:
+		if t.Kind() == reflect.Chan {
+			tc.unify(g, expr.ElementType, t.Elem(), args)
+		}
+	case *ast.MapType:
+		if t.Kind() == reflect.Map {
+			tc.unify(g, expr.KeyType, t.Key(), args)
+			tc.unify(g, expr.ValueType, t.Elem(), args)
+		}
+	case *ast.FuncType:
+		if t.Kind() != reflect.Func || t.NumIn() != len(expr.Parameters) ||
+			t.NumOut() != len(expr.Result) || t.IsVariadic() != expr.IsVariadic {
+			return
+		}
+		var typ ast.Expression
+		for i := len(expr.Parameters) - 1; i >= 0; i-- {
+			if p := expr.Parameters[i].Type; p != nil {
+				typ = p
+			}
+			in := t.In(i)
+			if expr.IsVariadic && i == len(expr.Parameters)-1 {
+				in = in.Elem()
+			}
+			tc.unify(g, typ, in, args)
+		}
+		for i := len(expr.Result) - 1; i >= 0; i-- {
+			if r := expr.Result[i].Type; r != nil {
+				typ = r
+			}
+			tc.unify(g, typ, t.Out(i), args)
+		}
+	case *ast.Index:
+		tc.unifyInstance(g, expr.Expr, []ast.Expression{expr.Index}, t, args)
+	case *ast.IndexList:
+		tc.unifyInstance(g, expr.Expr, expr.Indices, t, args)
+	}
+}
+
+// unifyInstance unifies the instantiation, with type arguments indices, of
+// the generic type referred by expr with the type t.
+func (tc *typechecker) unifyInstance(g *generic, expr ast.Expression, indices []ast.Expression, t reflect.Type, args []reflect.Type) {
+	inst, ok := tc.compilation.typeInstances[t]
+	if !ok || len(inst.args) != len(indices) {
+		return
+	}
+	if generic, ok := g.tc.genericOf(expr); !ok || generic != inst.generic {
+		return
+	}
+	for i, index := range indices {
+		tc.unify(g, index, inst.args[i], args)
+	}
+}
+
+// A typeConstraint represents a type constraint.
+type typeConstraint struct {
+	// comparable reports whether the types must be comparable.
+	comparable bool
+	// unions are the unions of terms. A type satisfies the constraint only
+	// if, for every union, it satisfies a term of the union.
+	unions [][]constraintTerm
+	// ifaces are the interfaces that the types must implement.
+	ifaces []reflect.Type
+}
+
+// A constraintTerm is a term of a union in a type constraint.
+type constraintTerm struct {
+	tilde bool         // reports whether the term has the form ~T.
+	typ   reflect.Type // type of the term.
+}
+
+// String returns the string representation of the term.
+func (term constraintTerm) String() string {
+	if term.tilde {
+		return "~" + term.typ.String()
+	}
+	return term.typ.String()
+}
+
+// isTypeSet reports whether c can only be used as a type constraint and not
+// as an ordinary interface type.
+func (c *typeConstraint) isTypeSet() bool {
+	return c.comparable || c.unions != nil
+}
+
+// embed embeds the type constraint e into c.
+func (c *typeConstraint) embed(e *typeConstraint) {
+	c.comparable = c.comparable || e.comparable
+	c.unions = append(c.unions, e.unions...)
+	c.ifaces = append(c.ifaces, e.ifaces...)
+}
+
+// satisfiedBy returns an error if the type t does not satisfy c, denoted by
+// the expression expr.
+func (c *typeConstraint) satisfiedBy(t reflect.Type, expr ast.Expression) error {
+	for _, iface := range c.ifaces {
+		if !types.Implements(t, iface) {
+			for i := 0; i < iface.NumMethod(); i++ {
+				if name := iface.Method(i).Name; !hasMethod(t, name) {
+					return fmt.Errorf("%s does not satisfy %s (missing method %s)", t, expr, name)
+				}
+			}
+			return fmt.Errorf("%s does not satisfy %s", t, expr)
+		}
+	}
+	if c.comparable && !t.Comparable() {
+		return fmt.Errorf("%s does not satisfy comparable", t)
+	}
+	for _, union := range c.unions {
+		satisfied := false
+		for _, term := range union {
+			if term.typ == t || term.tilde && types.IdenticalUnderlying(t, term.typ) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			terms := make([]string, len(union))
+			for i, term := range union {
+				terms[i] = term.String()
+			}
+			return fmt.Errorf("%s does not satisfy %s (%s missing in %s)", t, expr, t, strings.Join(terms, " | "))
+		}
+	}
+	return nil
+}
+
+// hasMethod reports whether the type t has a method with the given name.
+func hasMethod(t reflect.Type, name string) bool {
+	_, ok := t.MethodByName(name)
+	return ok
+}
+
+// misuse returns the error message for the use of the constraint named name
+// outside a type constraint.
+func (c *typeConstraint) misuse(name fmt.Stringer) string {
+	if c.comparable {
+		return fmt.Sprintf("cannot use type %s outside a type constraint: interface is (or embeds) comparable", name)
+	}
+	return fmt.Sprintf("cannot use type %s outside a type constraint: interface contains type constraints", name)
+}
+
+// checkConstraint checks the type constraint expr and returns it.
+func (tc *typechecker) checkConstraint(expr ast.Expression) *typeConstraint {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if ti, _, ok := tc.scopes.Lookup(e.Name); ok && ti.IsConstraint() {
+			tc.scopes.Use(e.Name)
+			return ti.value.(*typeConstraint)
+		}
+	case *ast.Selector:
+		if ident, ok := e.Expr.(*ast.Identifier); ok {
+			if pkg, _, ok := tc.scopes.Lookup(ident.Name); ok && pkg.IsPackage() {
+				ti, ok := pkg.value.(*packageInfo).Declarations[e.Ident]
+				if ok && ti.IsConstraint() && isExported(e.Ident) {
+					tc.scopes.Use(ident.Name)
+					return ti.value.(*typeConstraint)
+				}
+			}
+		}
+	case *ast.Interface:
+		c := &typeConstraint{}
+		var methods []reflect.Method
+		var idents []*ast.Identifier // identifiers of the declared methods.
+		for _, elem := range e.Elements {
+			if elem.Idents == nil {
+				c.embed(tc.checkConstraint(elem.Type))
+				continue
+			}
+			// Method.
+			ident := elem.Idents[0]
+			if isBlankIdentifier(ident) {
+				panic(tc.errorf(ident, "methods must have a unique non-blank name"))
+			}
+			for _, m := range methods {
+				if m.Name == ident.Name {
+					panic(tc.errorf(ident, "duplicate method %s", ident.Name))
+				}
+			}
+			methods = append(methods, reflect.Method{Name: ident.Name, Type: tc.checkType(elem.Type).Type})
+			idents = append(idents, ident)
+		}
+		if methods == nil && len(c.ifaces) <= 1 {
+			return c
+		}
+		// Merge the declared methods and the methods of the embedded
+		// interfaces into a single interface.
+		for _, iface := range c.ifaces {
+			for i := 0; i < iface.NumMethod(); i++ {
+				im := iface.Method(i)
+				duplicate := false
+				for j, m := range methods {
+					if m.Name == im.Name {
+						if m.Type != im.Type {
+							var node ast.Node = e
+							if j < len(idents) {
+								node = idents[j]
+							}
+							panic(tc.errorf(node, "duplicate method %s", im.Name))
+						}
+						duplicate = true
+						break
+					}
+				}
+				if !duplicate {
+					methods = append(methods, reflect.Method{Name: im.Name, PkgPath: im.PkgPath, Type: im.Type})
+				}
+			}
+		}
+		c.ifaces = []reflect.Type{tc.types.InterfaceOf(methods)}
+		return c
+	case *ast.UnaryOperator:
+		if e.Op == ast.OperatorTilde {
+			t := tc.checkType(e.Expr).Type
+			if t.Kind() == reflect.Interface {
+				panic(tc.errorf(expr, "invalid use of ~ (%s is an interface)", t))
+			}
+			if isDefinedType(t) {
+				panic(tc.errorf(expr, "invalid use of ~ (underlying type of %s is %s)", t, t.Kind()))
+			}
+			return &typeConstraint{unions: [][]constraintTerm{{{tilde: true, typ: t}}}}
+		}
+	case *ast.BinaryOperator:
+		if e.Op == ast.OperatorBitOr {
+			var union []constraintTerm
+			for _, operand := range []ast.Expression{e.Expr1, e.Expr2} {
+				c := tc.checkConstraint(operand)
+				switch {
+				case c.comparable:
+					panic(tc.errorf(operand, "cannot use comparable in union"))
+				case c.ifaces != nil:
+					panic(tc.errorf(operand, "cannot use %s in union (%s contains methods)", operand, operand))
+				case len(c.unions) > 1:
+					panic(tc.errorf(operand, "cannot use %s in union", operand))
+				case len(c.unions) == 0:
+					// The operand is the empty interface, so the union is
+					// satisfied by every type.
+					return &typeConstraint{}
+				}
+				union = append(union, c.unions[0]...)
+			}
+			return &typeConstraint{unions: [][]constraintTerm{union}}
+		}
+	}
+	t := tc.checkType(expr).Type
+	if t.Kind() == reflect.Interface {
+		if t.NumMethod() == 0 {
+			return &typeConstraint{}
+		}
+		return &typeConstraint{ifaces: []reflect.Type{t}}
+	}
+	return &typeConstraint{unions: [][]constraintTerm{{{typ: t}}}}
+}
+
+// isDefinedType reports whether t is a defined type, that is a type whose
+// underlying type is not itself. Predeclared types, such as int and string,
+// are not considered defined types.
+func isDefinedType(t reflect.Type) bool {
+	if t.Name() == "" {
+		return false
+	}
+	if _, ok := t.(runtime.ScriggoType); ok {
+		return true
+	}
+	return t.PkgPath() != ""
+}
+
+// checkInterface checks an interface type.
+func (tc *typechecker) checkInterface(expr *ast.Interface) *typeInfo {
+	if len(expr.Elements) == 0 {
+		return &typeInfo{Type: emptyInterfaceType, Properties: propertyIsType | propertyUniverse}
+	}
+	c := tc.checkConstraint(expr)
+	if c.isTypeSet() {
+		panic(tc.errorf(expr, "%s", c.misuse(expr)))
+	}
+	if len(c.ifaces) == 0 {
+		return &typeInfo{Type: emptyInterfaceType, Properties: propertyIsType}
+	}
+	return &typeInfo{Type: c.ifaces[0], Properties: propertyIsType}
+}
@@ -0,0 +1,372 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/ast/astutil"
+	"github.com/open2b/scriggo/internal/compiler/types"
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// A method declared in Scriggo is type checked and emitted as a function,
+// named "T.M", that has the receiver as first parameter. Method calls are
+// replaced with calls to these functions, and method values and method
+// expressions are replaced with function literals that call them.
+//
+// Methods of generic types are instantiated, as generic functions, with the
+// instances of their receiver types.
+
+// checkMethodDeclaration checks the declaration of the method fn, and adds
+// the method to the method set of its receiver type. The body of the method
+// is checked later, as for the other functions.
+func (tc *typechecker) checkMethodDeclaration(fn *ast.Func) {
+
+	recv := fn.Recv
+	base := recv.Type
+	pointer := false
+	if op, ok := base.(*ast.UnaryOperator); ok && op.Op == ast.OperatorPointer {
+		base, pointer = op.Expr, true
+	}
+
+	// Resolve the receiver base type.
+	var t reflect.Type
+	switch b := base.(type) {
+	case *ast.Identifier:
+		ti, ok := tc.scopes.FilePackage(b.Name)
+		if !ok {
+			if ti, _, ok := tc.scopes.Lookup(b.Name); ok && ti.IsType() {
+				panic(tc.errorf(b, "cannot define new methods on non-local type %s", b.Name))
+			}
+			panic(tc.errorf(b, "undefined: %s", b.Name))
+		}
+		if ti.IsGeneric() {
+			panic(tc.errorf(b, "cannot use generic type %s without instantiation", b.Name))
+		}
+		if !ti.IsType() {
+			panic(tc.errorf(b, "%s is not a type", b.Name))
+		}
+		if _, ok := tc.scopes.LookupImport(b.Name); ok || !types.IsDefined(ti.Type) {
+			panic(tc.errorf(b, "cannot define new methods on non-local type %s", ti.Type))
+		}
+		tc.scopes.Use(b.Name)
+		t = ti.Type
+	case *ast.Index:
+		tc.declareGenericMethod(fn, b.Expr, []ast.Expression{b.Index})
+		return
+	case *ast.IndexList:
+		tc.declareGenericMethod(fn, b.Expr, b.Indices)
+		return
+	case *ast.Placeholder:
+		// Instance of a generic type.
+		t = tc.compilation.typeInfos[b].Type
+	default:
+		panic(tc.errorf(recv.Type, "invalid receiver type %s", recv.Type))
+	}
+	if k := t.Kind(); k == reflect.Ptr || k == reflect.Interface {
+		panic(tc.errorf(base, "invalid receiver type %s (pointer or interface type)", t))
+	}
+
+	name := fn.Ident.Name
+	if name != "_" {
+		if _, ok := types.MethodOf(t, name); ok {
+			panic(tc.errorf(fn.Ident, "method %s.%s already declared", t, name))
+		}
+		if t.Kind() == reflect.Struct {
+			for i := 0; i < t.NumField(); i++ {
+				if decodeFieldName(t.Field(i).Name) == name {
+					panic(tc.errorf(fn.Ident, "field and method with the same name %s", name))
+				}
+			}
+		}
+	}
+
+	// Transform the method into a function with the receiver as first
+	// parameter.
+	typ := tc.checkType(fn.Type).Type
+	ident := recv.Ident
+	if ident == nil {
+		ident = ast.NewIdentifier(recv.Type.Pos(), "_")
+	}
+	params := append([]*ast.Parameter{ast.NewParameter(ident, recv.Type)}, fn.Type.Parameters...)
+	fn.Type = ast.NewFuncType(fn.Type.Pos(), false, params, fn.Type.Result, fn.Type.IsVariadic)
+	fullType := tc.checkType(fn.Type).Type
+	if name == "_" {
+		return
+	}
+	fn.Ident = ast.NewIdentifier(fn.Ident.Pos(), t.String()+"."+name)
+
+	m := &types.Method{
+		Name:    name,
+		Type:    typ,
+		Pointer: pointer,
+		Func:    newFunction("main", fn.Ident.Name, fullType, tc.path, fn.Pos()),
+	}
+	tc.types.AddMethod(t, m)
+	tc.compilation.typeInfos[fn] = &typeInfo{Type: fullType, value: m}
+
+}
+
+// declareGenericMethod declares the method fn of the generic type referred by
+// expr. indices are the type parameters of the receiver type.
+func (tc *typechecker) declareGenericMethod(fn *ast.Func, expr ast.Expression, indices []ast.Expression) {
+	ident, ok := expr.(*ast.Identifier)
+	if !ok {
+		panic(tc.errorf(expr, "invalid receiver type %s", fn.Recv.Type))
+	}
+	ti, ok := tc.scopes.FilePackage(ident.Name)
+	if !ok || !ti.IsGeneric() || ti.value.(*generic).typ == nil {
+		if _, ok := tc.scopes.LookupImport(ident.Name); ok {
+			panic(tc.errorf(ident, "cannot define new methods on non-local type %s", ident.Name))
+		}
+		panic(tc.errorf(fn.Recv.Type, "%s is not a generic type", ident.Name))
+	}
+	tc.scopes.Use(ident.Name)
+	g := ti.value.(*generic)
+	if len(indices) != len(g.params) {
+		panic(tc.errorf(fn.Recv.Type, "got %d type parameters, but receiver base type declares %d", len(indices), len(g.params)))
+	}
+	mg := &generic{tc: tc, pkg: g.pkg, fn: fn, constraints: g.constraints}
+	for _, index := range indices {
+		param, ok := index.(*ast.Identifier)
+		if !ok {
+			panic(tc.errorf(index, "receiver type parameter %s must be an identifier", index))
+		}
+		if param.Name != "_" && mg.paramIndex(param.Name) != -1 {
+			panic(tc.errorf(param, "%s redeclared in this block", param.Name))
+		}
+		mg.params = append(mg.params, param)
+	}
+	tc.useImports(fn)
+	g.methods = append(g.methods, mg)
+	// Instantiate the method for the instances of the type created so far.
+	for _, inst := range g.instances {
+		mg.instantiateMethod(inst)
+	}
+}
+
+// instantiateMethod instantiates the method of a generic type g, declared on
+// the type instance inst.
+func (g *generic) instantiateMethod(inst *genericInstance) {
+	fn := astutil.CloneNode(g.fn).(*ast.Func)
+	recv := g.tc.typePlaceholder(inst.ti.Type)
+	if op, ok := fn.Recv.Type.(*ast.UnaryOperator); ok && op.Op == ast.OperatorPointer {
+		op.Expr = recv
+	} else {
+		fn.Recv.Type = recv
+	}
+	mi := &genericInstance{generic: g, args: inst.args, name: inst.name + "." + fn.Ident.Name, fn: fn}
+	g.withTypeArgs(inst.args, func(gtc *typechecker) {
+		gtc.checkMethodDeclaration(fn)
+	})
+	g.instances = append(g.instances, mi)
+	g.pkg.Declarations = append(g.pkg.Declarations, fn)
+	g.tc.compilation.pendingInstances = append(g.tc.compilation.pendingInstances, mi)
+}
+
+// hasGenericReceiver reports whether fn is a method declared on a generic
+// type. Only the instances of these methods are checked and emitted.
+func hasGenericReceiver(fn *ast.Func) bool {
+	if fn.Recv == nil {
+		return false
+	}
+	t := fn.Recv.Type
+	if op, ok := t.(*ast.UnaryOperator); ok && op.Op == ast.OperatorPointer {
+		t = op.Expr
+	}
+	switch t.(type) {
+	case *ast.Index, *ast.IndexList:
+		return true
+	}
+	return false
+}
+
+// typePlaceholder returns a placeholder for the type t.
+func (tc *typechecker) typePlaceholder(t reflect.Type) *ast.Placeholder {
+	ph := ast.NewPlaceholder()
+	tc.compilation.typeInfos[ph] = &typeInfo{Type: t, Properties: propertyIsType}
+	return ph
+}
+
+// checkMethodCall checks the call expr, if it is a call of a method declared
+// in Scriggo, replacing the called method with the function of the method
+// and, for a method value, adding the receiver as first argument. It reports
+// whether expr is such a call.
+func (tc *typechecker) checkMethodCall(expr *ast.Call) bool {
+	sel, ok := expr.Func.(*ast.Selector)
+	if !ok {
+		return false
+	}
+	if ident, ok := sel.Expr.(*ast.Identifier); ok {
+		if ti, _, ok := tc.scopes.Lookup(ident.Name); ok && ti.IsPackage() {
+			return false
+		}
+	}
+	t := tc.checkExprOrType(sel.Expr)
+	if t.Nil() {
+		return false
+	}
+	m, index, _, ok := types.LookupMethod(t.Type, sel.Ident)
+	if !ok {
+		return false
+	}
+	if t.IsType() {
+		// Method expression. Only a method expression with the same
+		// receiver type of the method is called directly.
+		if index != nil || m.Pointer != (t.Type.Kind() == reflect.Ptr) {
+			return false
+		}
+		expr.Func = tc.methodRef(sel, m)
+		return true
+	}
+	rcvr := tc.methodReceiver(t, sel, m, index)
+	expr.Func = tc.methodRef(sel, m)
+	expr.Args = append([]ast.Expression{rcvr}, expr.Args...)
+	return true
+}
+
+// methodRef returns an expression, to be used in place of the selector sel,
+// that refers to the function of the method m.
+func (tc *typechecker) methodRef(sel *ast.Selector, m *types.Method) *ast.Identifier {
+	ref := ast.NewIdentifier(sel.Pos(), sel.String())
+	tc.compilation.typeInfos[ref] = &typeInfo{Type: m.Func.Type, value: m}
+	return ref
+}
+
+// methodReceiver returns the receiver, for the method m, of the selector sel
+// whose expression has type info t. If m is promoted, index is the index
+// sequence of the embedded field that declares it. It takes the address of,
+// or dereferences, the receiver if required by m.
+func (tc *typechecker) methodReceiver(t *typeInfo, sel *ast.Selector, m *types.Method, index []int) ast.Expression {
+	rcvr := sel.Expr
+	if index != nil {
+		typ := t.Type
+		for _, i := range index {
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			field := typ.Field(i)
+			rcvr = ast.NewSelector(rcvr.Pos(), rcvr, decodeFieldName(field.Name))
+			typ = field.Type
+		}
+		t = tc.checkExpr(rcvr)
+	}
+	isPtr := t.Type.Kind() == reflect.Ptr
+	switch {
+	case m.Pointer && !isPtr:
+		if !t.Addressable() {
+			panic(tc.errorf(sel, "cannot call pointer method %s on %s", sel.Ident, t))
+		}
+		rcvr = ast.NewUnaryOperator(rcvr.Pos(), ast.OperatorAddress, rcvr)
+		tc.checkExpr(rcvr)
+	case !m.Pointer && isPtr:
+		rcvr = ast.NewUnaryOperator(rcvr.Pos(), ast.OperatorPointer, rcvr)
+		tc.checkExpr(rcvr)
+	}
+	return rcvr
+}
+
+// checkMethodSelector checks the selector expr, whose expression has type
+// info t, if it is a method value or a method expression of a method declared
+// in Scriggo. It returns the type info of expr and true, otherwise it returns
+// nil and false.
+//
+// The returned type info has, as replacement, an expression that evaluates to
+// a function equivalent to the method value or the method expression.
+func (tc *typechecker) checkMethodSelector(t *typeInfo, expr *ast.Selector) (*typeInfo, bool) {
+	if _, ok := t.Type.(runtime.ScriggoType); ok && t.IsType() && t.Type.Kind() == reflect.Interface {
+		// A method expression I.M, where I is an interface type declared in
+		// Scriggo, is replaced with
+		//
+		//     func(r I, p0 P0, p1 P1, ...) (R0, R1, ...) { return r.M(p0, p1, ...) }
+		//
+		m, ok := t.Type.MethodByName(expr.Ident)
+		if !ok {
+			return nil, false
+		}
+		lit := tc.methodFuncLit(expr, t.Type, m.Type, true)
+		ti := tc.checkExpr(lit)
+		return &typeInfo{Type: ti.Type, replacement: lit}, true
+	}
+	m, index, indirect, ok := types.LookupMethod(t.Type, expr.Ident)
+	if !ok {
+		return nil, false
+	}
+	isPtr := t.Type.Kind() == reflect.Ptr
+	if t.IsType() {
+		// Method expression.
+		if m.Pointer && !isPtr && !indirect {
+			panic(tc.errorf(expr, "invalid method expression %s (needs pointer receiver: (*%s).%s)",
+				expr, expr.Expr, expr.Ident))
+		}
+		if index == nil && m.Pointer == isPtr {
+			return &typeInfo{Type: m.Func.Type, replacement: tc.methodRef(expr, m)}, true
+		}
+		// A method expression T.M, where M has a different receiver type
+		// or it is promoted, is replaced with
+		//
+		//     func(r T, p0 P0, p1 P1, ...) (R0, R1, ...) { return r.M(p0, p1, ...) }
+		//
+		lit := tc.methodFuncLit(expr, t.Type, m.Type, true)
+		ti := tc.checkExpr(lit)
+		return &typeInfo{Type: ti.Type, replacement: lit}, true
+	}
+	// A method value x.M is replaced with
+	//
+	//     func(r T) func(p0 P0, p1 P1, ...) (R0, R1, ...) {
+	//         return func(p0 P0, p1 P1, ...) (R0, R1, ...) { return r.M(p0, p1, ...) }
+	//     }(x)
+	//
+	// where x is possibly replaced, as in method calls, with &x or *x, or with
+	// a selector of the embedded field that declares M.
+	rcvr := tc.methodReceiver(t, expr, m, index)
+	rt := tc.compilation.typeInfos[rcvr].Type
+	pos := expr.Pos()
+	inner := tc.methodFuncLit(expr, rt, m.Type, false)
+	outer := ast.NewFunc(pos, nil, ast.NewFuncType(pos, false,
+		[]*ast.Parameter{ast.NewParameter(ast.NewIdentifier(pos, "r"), tc.typePlaceholder(rt))},
+		[]*ast.Parameter{ast.NewParameter(nil, tc.typePlaceholder(m.Type))}, false),
+		ast.NewBlock(pos, []ast.Node{ast.NewReturn(pos, []ast.Expression{inner})}), false, 0)
+	call := ast.NewCall(pos, outer, []ast.Expression{rcvr}, false)
+	tc.checkExpr(call)
+	return &typeInfo{Type: m.Type, replacement: call}, true
+}
+
+// methodFuncLit returns a function literal that calls the method, of type
+// typ, selected by sel on a receiver "r" of type rt. If withReceiver is
+// true, the receiver is the first parameter of the function literal,
+// otherwise the function literal refers to the receiver of the enclosing
+// function.
+func (tc *typechecker) methodFuncLit(sel *ast.Selector, rt, typ reflect.Type, withReceiver bool) *ast.Func {
+	pos := sel.Pos()
+	r := ast.NewIdentifier(pos, "r")
+	var params []*ast.Parameter
+	if withReceiver {
+		params = append(params, ast.NewParameter(r, tc.typePlaceholder(rt)))
+	}
+	args := make([]ast.Expression, typ.NumIn())
+	for i := 0; i < typ.NumIn(); i++ {
+		in := typ.In(i)
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			in = in.Elem()
+		}
+		name := "p" + strconv.Itoa(i)
+		params = append(params, ast.NewParameter(ast.NewIdentifier(pos, name), tc.typePlaceholder(in)))
+		args[i] = ast.NewIdentifier(pos, name)
+	}
+	result := make([]*ast.Parameter, typ.NumOut())
+	for i := 0; i < typ.NumOut(); i++ {
+		result[i] = ast.NewParameter(nil, tc.typePlaceholder(typ.Out(i)))
+	}
+	var body ast.Node = ast.NewCall(pos, ast.NewSelector(pos, r, sel.Ident), args, typ.IsVariadic())
+	if typ.NumOut() > 0 {
+		body = ast.NewReturn(pos, []ast.Expression{body.(ast.Expression)})
+	}
+	fnType := ast.NewFuncType(pos, false, params, result, typ.IsVariadic())
+	return ast.NewFunc(pos, nil, fnType, ast.NewBlock(pos, []ast.Node{body}), false, 0)
+}
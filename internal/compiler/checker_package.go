@@ -253,6 +253,7 @@ func sortDeclarations(pkg *ast.Package) error {
 	vars := []*ast.Var{}
 	imports := []*ast.Import{}
 	funcs := []*ast.Func{}
+	methods := []*ast.Func{}
 
 	// Fragments global declarations.
 	for _, decl := range pkg.Declarations {
@@ -262,6 +263,11 @@ func sortDeclarations(pkg *ast.Package) error {
 		case *ast.Import:
 			imports = append(imports, decl)
 		case *ast.Func:
+			if decl.Recv != nil {
+				// Methods cannot be referred by name.
+				methods = append(methods, decl)
+				continue
+			}
 			funcs = append(funcs, decl)
 		case *ast.Const:
 			if len(decl.Rhs) == 0 {
@@ -288,6 +294,11 @@ func sortDeclarations(pkg *ast.Package) error {
 
 	// Removes from deps all non-global dependencies.
 	deps := analyzeTree(pkg)
+	for _, m := range methods {
+		// Methods cannot be referred by name, so their names must not be
+		// confused with the names of the global declarations.
+		delete(deps, m.Ident)
+	}
 	for decl, ds := range deps {
 		newDs := []*ast.Identifier{}
 		for _, d := range ds {
@@ -498,12 +509,46 @@ varsLoop:
 	for _, f := range funcs {
 		sorted = append(sorted, f)
 	}
+	for _, m := range methods {
+		sorted = append(sorted, m)
+	}
 	pkg.Declarations = sorted
 
 	return nil
 
 }
 
+// checkDeclaration type checks the function, constant or variable package
+// level declaration d.
+//
+// If the errors are recovered and d has a type checking error, as for the
+// statements, checkDeclaration adds it to the errors of the compilation and
+// declares as unresolved the names declared by d.
+func (tc *typechecker) checkDeclaration(d ast.Node) {
+	state := tc.saveState()
+	defer func() {
+		if r := recover(); r != nil {
+			tc.recoverError(r, state)
+			names, _ := declaredNames(d)
+			for _, name := range names {
+				if _, ok := tc.scopes.Current(name); !ok {
+					tc.scopes.DeclareUnresolved(name)
+				}
+			}
+		}
+	}()
+	switch d := d.(type) {
+	case *ast.Func:
+		if d.TypeParams == nil && !hasGenericReceiver(d) {
+			tc.checkFunc(d)
+		}
+	case *ast.Const:
+		tc.checkConstantDeclaration(d)
+	case *ast.Var:
+		tc.checkVariableDeclaration(d)
+	}
+}
+
 // checkPackage type checks a package.
 //
 // extendingFile indicates whether the package pkg was originally a template
@@ -527,6 +572,10 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 
 	tc := newTypechecker(compilation, path, opts, importer)
 
+	// numErrors is the number of errors of the compilation before checking
+	// the package.
+	numErrors := len(compilation.errors)
+
 	// Check package level names for "init" and "main"
 	// and check that constant declarations are balanced.
 	for _, decl := range pkg.Declarations {
@@ -566,8 +615,12 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 		compilation.alreadySortedPkgs[pkg] = true
 	}
 
+	// The instances of generic functions and methods, appended to the
+	// package declarations when instantiated, are checked separately.
+	declarations := pkg.Declarations
+
 	// First: import packages.
-	for _, d := range pkg.Declarations {
+	for _, d := range declarations {
 		if d, ok := d.(*ast.Import); ok {
 			err := tc.checkImport(d)
 			if err != nil {
@@ -577,8 +630,12 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 	}
 
 	// Second: check all type declarations.
-	for _, d := range pkg.Declarations {
+	for _, d := range declarations {
 		if td, ok := d.(*ast.TypeDeclaration); ok {
+			if td.TypeParams != nil {
+				tc.declareGeneric(pkg, td.Ident, td.TypeParams, nil, td)
+				continue
+			}
 			name, ti := tc.checkTypeDeclaration(td)
 			if ti != nil {
 				tc.assignScope(name, ti, td.Ident, nil)
@@ -588,11 +645,15 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 
 	// Defines functions in file/package block before checking all
 	// declarations.
-	for _, d := range pkg.Declarations {
+	for _, d := range declarations {
 		if f, ok := d.(*ast.Func); ok {
 			if f.Body == nil {
 				return tc.errorf(f.Ident.Pos(), "missing function body")
 			}
+			if f.Recv != nil {
+				tc.checkMethodDeclaration(f)
+				continue
+			}
 			if f.Ident.Name == "init" || f.Ident.Name == "main" {
 				if len(f.Type.Parameters) > 0 || len(f.Type.Result) > 0 {
 					return tc.errorf(f.Ident, "func %s must have no arguments and no return values", f.Ident.Name)
@@ -601,6 +662,18 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 			if f.Type.Macro && len(f.Type.Result) == 0 {
 				tc.makeMacroResultExplicit(f)
 			}
+			if f.TypeParams != nil {
+				if f.Ident.Name == "init" || f.Ident.Name == "main" {
+					return tc.errorf(f.Ident, "func %s must have no type parameters", f.Ident.Name)
+				}
+				if _, ok := tc.scopes.FilePackage(f.Ident.Name); ok {
+					return tc.errorf(f.Ident, "%s redeclared in this block", f.Ident.Name)
+				}
+				// The type of a generic function is checked when it is
+				// instantiated.
+				tc.declareGeneric(pkg, f.Ident, f.TypeParams, f, nil)
+				continue
+			}
 			// Function type must be checked for every function, including
 			// 'init's functions.
 			funcType := tc.checkType(f.Type).Type
@@ -623,18 +696,19 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 	}
 
 	// Type check and defined functions, variables and constants.
-	for _, d := range pkg.Declarations {
-		switch d := d.(type) {
-		case *ast.Func:
-			tc.checkFunc(d)
-		case *ast.Const:
-			tc.checkConstantDeclaration(d)
-		case *ast.Var:
-			tc.checkVariableDeclaration(d)
-		}
+	for _, d := range declarations {
+		tc.checkDeclaration(d)
 	}
 
-	if tc.opts.mod != templateMod {
+	// Type check the bodies of the instantiated generic functions.
+	err = compilation.checkPendingInstances()
+	if err != nil {
+		return err
+	}
+
+	// If a statement of the package has an error, an imported package may
+	// be used only in the statements that have not been checked.
+	if tc.opts.mod != templateMod && len(compilation.errors) == numErrors {
 		// Check that the imported packages have been used.
 		if node := tc.scopes.UnusedImport(); node != nil {
 			var s string
@@ -667,5 +741,7 @@ func checkPackage(compilation *compilation, pkg *ast.Package, path string, impor
 		return err
 	}
 
+	removeGenerics(pkg)
+
 	return nil
 }
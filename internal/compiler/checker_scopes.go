@@ -21,6 +21,9 @@ type scopes struct {
 	s           []scope
 	path        string
 	allowUnused bool
+	// declarations, if not nil, records the paths of the files in which
+	// the declared identifiers and import declarations are located.
+	declarations map[ast.Node]string
 }
 
 // scope is a scope.
@@ -31,6 +34,9 @@ type scope struct {
 	block *ast.Position
 	// names are the declared names in the scope.
 	names map[string]scopeName
+	// unresolved are the names declared in the scope by statements with
+	// type checking errors.
+	unresolved map[string]bool
 }
 
 // scopeFunc represents a function scope. Only scopes 4 onwards have a function scope.
@@ -180,6 +186,13 @@ func (scopes *scopes) Declare(name string, ti *typeInfo, decl *ast.Identifier, i
 	} else {
 		names[name] = n
 	}
+	if scopes.declarations != nil {
+		if impor == nil && decl != nil {
+			scopes.declarations[decl] = scopes.path
+		} else if impor != nil && decl == nil {
+			scopes.declarations[impor] = scopes.path
+		}
+	}
 	return true
 }
 
@@ -392,6 +405,53 @@ func (scopes *scopes) Functions() []*ast.Func {
 	return functions
 }
 
+// DeclareUnresolved declares name in the current scope as unresolved, that
+// is declared by a statement with a type checking error.
+func (scopes *scopes) DeclareUnresolved(name string) {
+	if name == "_" {
+		return
+	}
+	c := len(scopes.s) - 1
+	if scopes.s[c].unresolved == nil {
+		scopes.s[c].unresolved = map[string]bool{}
+	}
+	scopes.s[c].unresolved[name] = true
+}
+
+// IsUnresolved reports whether name, that is not declared, has been declared
+// as unresolved in the current scope or in an enclosing scope.
+func (scopes *scopes) IsUnresolved(name string) bool {
+	for i := len(scopes.s) - 1; i >= 3; i-- {
+		if scopes.s[i].unresolved[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// UseAll marks as used all the variables and labels declared in the
+// function scopes, and forgets the labels used but not yet declared. It is
+// called after a type checking error so as not to report errors that are
+// consequence of the statements that have not been checked.
+func (scopes *scopes) UseAll() {
+	for i := 4; i < len(scopes.s); i++ {
+		for name, n := range scopes.s[i].names {
+			if !n.used {
+				n.used = true
+				scopes.s[i].names[name] = n
+			}
+		}
+		for name, lbl := range scopes.s[i].fn.labels {
+			if lbl.node == nil {
+				delete(scopes.s[i].fn.labels, name)
+			} else if !lbl.used {
+				lbl.used = true
+				scopes.s[i].fn.labels[name] = lbl
+			}
+		}
+	}
+}
+
 // Enter enters a new scope. block is the block of the scope.
 func (scopes *scopes) Enter(block ast.Node) {
 	s := scope{block: block.Pos()}
@@ -445,7 +505,7 @@ func (scopes *scopes) Exit() {
 		// Check for unused variables.
 		var ident *ast.Identifier
 		for _, n := range scopes.s[c].names {
-			if n.used || n.ti.IsConstant() || n.ti.IsType() {
+			if n.used || n.ti.IsConstant() || n.ti.IsType() || n.ti.IsConstraint() {
 				continue
 			}
 			if ident == nil || n.decl.Position.Start < ident.Start {
@@ -478,6 +538,7 @@ var errorType = reflect.TypeOf((*error)(nil)).Elem()
 var universe = map[string]scopeName{
 	"append":     {ti: &typeInfo{Properties: propertyUniverse}},
 	"cap":        {ti: &typeInfo{Properties: propertyUniverse}},
+	"clear":      {ti: &typeInfo{Properties: propertyUniverse}},
 	"close":      {ti: &typeInfo{Properties: propertyUniverse}},
 	"complex":    {ti: &typeInfo{Properties: propertyUniverse}},
 	"copy":       {ti: &typeInfo{Properties: propertyUniverse}},
@@ -487,6 +548,8 @@ var universe = map[string]scopeName{
 	"itea":       {ti: &typeInfo{Properties: propertyUniverse | propertyUntyped | propertyAddressable}},
 	"len":        {ti: &typeInfo{Properties: propertyUniverse}},
 	"make":       {ti: &typeInfo{Properties: propertyUniverse}},
+	"max":        {ti: &typeInfo{Properties: propertyUniverse}},
+	"min":        {ti: &typeInfo{Properties: propertyUniverse}},
 	"new":        {ti: &typeInfo{Properties: propertyUniverse}},
 	"nil":        {ti: &typeInfo{Properties: propertyUntyped | propertyUniverse}},
 	"panic":      {ti: &typeInfo{Properties: propertyUniverse}},
@@ -495,6 +558,7 @@ var universe = map[string]scopeName{
 	"real":       {ti: &typeInfo{Properties: propertyUniverse}},
 	"recover":    {ti: &typeInfo{Properties: propertyUniverse}},
 	"any":        {ti: &typeInfo{Type: emptyInterfaceType, Alias: "any", Properties: propertyIsType | propertyUniverse}},
+	"comparable": {ti: &typeInfo{Type: emptyInterfaceType, Properties: propertyIsConstraint | propertyUniverse, value: &typeConstraint{comparable: true}}},
 	"byte":       {ti: &typeInfo{Type: uint8Type, Alias: "byte", Properties: propertyIsType | propertyUniverse}},
 	"bool":       {ti: &typeInfo{Type: boolType, Properties: propertyIsType | propertyUniverse}},
 	"complex128": {ti: &typeInfo{Type: complex128Type, Properties: propertyIsType | propertyUniverse}},
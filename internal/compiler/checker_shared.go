@@ -0,0 +1,345 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"maps"
+
+	"github.com/open2b/scriggo/ast"
+)
+
+// sharedImports shares the type checked imported files of a compilation with
+// the other compilations with the same options, storing them in a parse
+// cache.
+//
+// The type checking of an imported file does not depend on the file that
+// imports it, but only on the options and on the files it imports and
+// renders, so a compilation can use an imported file type checked by
+// another compilation if these files have not changed. In this case, the
+// compilation uses the checked trees of the other compilation and a copy of
+// their type infos, so the checked trees are shared by the compilations and
+// they must not be changed by the type checker and by the emitter.
+type sharedImports struct {
+	cache *ParseCache
+	key   any                      // key of the options
+	files map[*ast.Tree]parsedFile // files parsed using the cache
+}
+
+// closure returns the cache entries of the file with the given parse tree
+// and of the files it imports and renders, directly or indirectly. ok is
+// false if one of these files has not been parsed using the cache.
+func (shared *sharedImports) closure(tree *ast.Tree) (files map[*parseCacheEntry]struct{}, ok bool) {
+	files = map[*parseCacheEntry]struct{}{}
+	seen := map[*ast.Tree]bool{tree: true}
+	trees := []*ast.Tree{tree}
+	for len(trees) > 0 {
+		tree := trees[len(trees)-1]
+		trees = trees[:len(trees)-1]
+		file, ok := shared.files[tree]
+		if !ok {
+			return nil, false
+		}
+		files[file.entry] = struct{}{}
+		for _, t := range file.trees {
+			if !seen[t] {
+				seen[t] = true
+				trees = append(trees, t)
+			}
+		}
+	}
+	return files, true
+}
+
+// checkedImport is an imported file, together with the files it imports and
+// renders, type checked by a compilation. It is stored in a parse cache and
+// it can be used by other compilations, so it must not be changed.
+type checkedImport struct {
+	key          any                           // key of the options
+	files        map[*parseCacheEntry]struct{} // cache entries of the files
+	trees        map[string]*ast.Tree          // checked trees, indexed by path
+	pkgInfos     map[string]*packageInfo       // package infos, indexed by path
+	typeInfos    map[ast.Node]*typeInfo
+	indirectVars map[*ast.Identifier]bool
+	packages     []string // paths of the imported native packages
+}
+
+// importCheck is a type check, in progress, of an imported file.
+type importCheck struct {
+	trees     map[string]*ast.Tree // trees of the files checked by the type check
+	included  []*checkedImport     // checked imports used by these files
+	packages  []string             // paths of the imported native packages
+	shareable bool                 // reports whether it can be shared
+}
+
+// useCheckedImport replaces the tree of the imported file of impor with the
+// tree of a checked import if the compilation already uses a checked import
+// for the file, or if there is one in the parse cache, in which case it adds
+// the checked import to the compilation.
+//
+// It does nothing if the type checking of the imported files is not shared.
+func (tc *typechecker) useCheckedImport(impor *ast.Import) {
+
+	shared := tc.opts.shared
+	if shared == nil {
+		return
+	}
+	compilation := tc.compilation
+	path := impor.Tree.Path
+
+	if checked, ok := compilation.sharedChecks[path]; ok {
+		impor.Tree = checked.trees[path]
+		return
+	}
+	if _, ok := compilation.pkgInfos[path]; ok {
+		return
+	}
+
+	// Look for a checked import of the same files.
+	file, ok := shared.files[impor.Tree]
+	if !ok {
+		return
+	}
+	files, ok := shared.closure(impor.Tree)
+	if !ok {
+		return
+	}
+	checked := shared.cache.checkedImport(file.entry, shared.key, files)
+	if checked == nil {
+		return
+	}
+
+	// The files of the checked import already checked by the compilation
+	// must have the same trees.
+	for path, tree := range checked.trees {
+		if c, ok := compilation.sharedChecks[path]; ok {
+			if c.trees[path] != tree {
+				return
+			}
+			continue
+		}
+		if _, ok := compilation.pkgInfos[path]; ok {
+			return
+		}
+	}
+
+	// Add the checked import to the compilation, copying the type infos so
+	// that they can be changed by the type checker and by the emitter.
+	var copier typeInfoCopier
+	for node, ti := range checked.typeInfos {
+		if _, ok := compilation.typeInfos[node]; !ok {
+			compilation.typeInfos[node] = copier.typeInfo(ti)
+		}
+	}
+	maps.Copy(compilation.indirectVars, checked.indirectVars)
+	for path, pkgInfo := range checked.pkgInfos {
+		if _, ok := compilation.pkgInfos[path]; !ok {
+			compilation.pkgInfos[path] = copier.packageInfo(pkgInfo)
+			compilation.sharedChecks[path] = checked
+		}
+	}
+	// Import the native packages again, so that they are recorded as
+	// imported by the compilation.
+	if tc.importer != nil {
+		for _, path := range checked.packages {
+			_, _ = tc.importer.Import(path)
+		}
+	}
+
+	impor.Tree = checked.trees[path]
+}
+
+// checkImportedPackage checks the package of the imported file of impor. If
+// the type checking of the imported files is shared, it stores the checked
+// import in the parse cache when possible.
+func (tc *typechecker) checkImportedPackage(impor *ast.Import) error {
+
+	compilation := tc.compilation
+	tree := impor.Tree
+	path := tree.Path
+	pkg := tree.Nodes[0].(*ast.Package)
+	extendingFile := compilation.extendingTrees[path]
+
+	shared := tc.opts.shared
+	if shared == nil {
+		return checkPackage(compilation, pkg, path, tc.importer, tc.opts, extendingFile)
+	}
+
+	// If the package has already been checked, the type checks in progress
+	// can be shared only if it has been checked by them or if it is shared.
+	if _, ok := compilation.pkgInfos[path]; ok {
+		checked, ok := compilation.sharedChecks[path]
+		for _, c := range compilation.sharing {
+			if ok {
+				c.included = append(c.included, checked)
+			} else if _, ok := c.trees[path]; !ok {
+				c.shareable = false
+			}
+		}
+		return nil
+	}
+
+	file, ok := shared.files[tree]
+	check := &importCheck{
+		trees:     map[string]*ast.Tree{path: tree},
+		shareable: ok && !extendingFile && !hasGenerics(pkg),
+	}
+
+	// Check the package recording its type infos and indirect variables.
+	typeInfos := compilation.typeInfos
+	indirectVars := compilation.indirectVars
+	compilation.typeInfos = map[ast.Node]*typeInfo{}
+	compilation.indirectVars = map[*ast.Identifier]bool{}
+	compilation.sharing = append(compilation.sharing, check)
+	numErrors := len(compilation.errors)
+	err := checkPackage(compilation, pkg, path, tc.importer, tc.opts, extendingFile)
+	compilation.sharing = compilation.sharing[:len(compilation.sharing)-1]
+	checkTypeInfos := compilation.typeInfos
+	checkIndirectVars := compilation.indirectVars
+	compilation.typeInfos = typeInfos
+	compilation.indirectVars = indirectVars
+	maps.Copy(compilation.typeInfos, checkTypeInfos)
+	maps.Copy(compilation.indirectVars, checkIndirectVars)
+	if err != nil {
+		return err
+	}
+	if len(compilation.errors) > numErrors {
+		check.shareable = false
+	}
+
+	// The files checked by the type check are also checked by the type
+	// check that includes it, if there is one.
+	if n := len(compilation.sharing); n > 0 {
+		outer := compilation.sharing[n-1]
+		maps.Copy(outer.trees, check.trees)
+		outer.included = append(outer.included, check.included...)
+		outer.packages = append(outer.packages, check.packages...)
+		outer.shareable = outer.shareable && check.shareable
+	}
+
+	if !check.shareable {
+		return nil
+	}
+	files, ok := shared.closure(tree)
+	if !ok {
+		return nil
+	}
+
+	// Store the checked import, copying the type infos so that they are
+	// not changed by this compilation.
+	checked := &checkedImport{
+		key:          shared.key,
+		files:        files,
+		trees:        check.trees,
+		pkgInfos:     map[string]*packageInfo{},
+		typeInfos:    map[ast.Node]*typeInfo{},
+		indirectVars: maps.Clone(checkIndirectVars),
+		packages:     check.packages,
+	}
+	var copier typeInfoCopier
+	for node, ti := range checkTypeInfos {
+		checked.typeInfos[node] = copier.typeInfo(ti)
+	}
+	for path := range check.trees {
+		checked.pkgInfos[path] = copier.packageInfo(compilation.pkgInfos[path])
+	}
+	for _, c := range check.included {
+		for path, tree := range c.trees {
+			if _, ok := checked.trees[path]; !ok {
+				checked.trees[path] = tree
+				checked.pkgInfos[path] = c.pkgInfos[path]
+			}
+		}
+		for node, ti := range c.typeInfos {
+			if _, ok := checked.typeInfos[node]; !ok {
+				checked.typeInfos[node] = ti
+			}
+		}
+		maps.Copy(checked.indirectVars, c.indirectVars)
+		checked.packages = append(checked.packages, c.packages...)
+	}
+	shared.cache.storeChecked(file.entry, checked)
+	for path := range check.trees {
+		compilation.sharedChecks[path] = checked
+	}
+
+	return nil
+}
+
+// hasGenerics reports whether pkg declares generic functions, methods or
+// types. The declarations of a package with generics are changed when they
+// are instantiated, so its type checking cannot be shared.
+func hasGenerics(pkg *ast.Package) bool {
+	for _, decl := range pkg.Declarations {
+		switch d := decl.(type) {
+		case *ast.Func:
+			if d.TypeParams != nil || hasGenericReceiver(d) {
+				return true
+			}
+		case *ast.TypeDeclaration:
+			if d.TypeParams != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// universeTypeInfos contains the type infos of the universe block. They are
+// shared by all the compilations, so they are never copied.
+var universeTypeInfos = func() map[*typeInfo]bool {
+	tis := map[*typeInfo]bool{untypedBoolTypeInfo: true}
+	for _, name := range universe {
+		tis[name.ti] = true
+	}
+	return tis
+}()
+
+// typeInfoCopier copies type infos, and the package infos they refer to.
+// A type info, or a package info, referred more than once is copied once.
+type typeInfoCopier struct {
+	typeInfos map[*typeInfo]*typeInfo
+	pkgInfos  map[*packageInfo]*packageInfo
+}
+
+// typeInfo returns a copy of ti.
+func (c *typeInfoCopier) typeInfo(ti *typeInfo) *typeInfo {
+	if ti == nil || universeTypeInfos[ti] {
+		return ti
+	}
+	if t, ok := c.typeInfos[ti]; ok {
+		return t
+	}
+	if c.typeInfos == nil {
+		c.typeInfos = map[*typeInfo]*typeInfo{}
+	}
+	t := new(typeInfo)
+	*t = *ti
+	c.typeInfos[ti] = t
+	if pkg, ok := ti.value.(*packageInfo); ok {
+		t.value = c.packageInfo(pkg)
+	}
+	return t
+}
+
+// packageInfo returns a copy of pkg. The copy has no type infos and indirect
+// variables, as they are stored in the compilation.
+func (c *typeInfoCopier) packageInfo(pkg *packageInfo) *packageInfo {
+	if p, ok := c.pkgInfos[pkg]; ok {
+		return p
+	}
+	if c.pkgInfos == nil {
+		c.pkgInfos = map[*packageInfo]*packageInfo{}
+	}
+	p := &packageInfo{
+		Name:             pkg.Name,
+		Declarations:     make(map[string]*typeInfo, len(pkg.Declarations)),
+		DeclarationNodes: pkg.DeclarationNodes,
+	}
+	c.pkgInfos[pkg] = p
+	for name, ti := range pkg.Declarations {
+		p.Declarations[name] = c.typeInfo(ti)
+	}
+	return p
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/fstest"
+)
+
+// TestSharedImports tests that the imported files type checked by a build
+// are used by the builds with the same parse cache and check key, and that
+// they are type checked again when a file they use changes.
+func TestSharedImports(t *testing.T) {
+
+	fsys := fstest.Files{
+		"index.html":   `{% import "macros.html" %}{{ M("a") }}`,
+		"page.html":    `{% import "helpers.html" %}{% import m "macros.html" %}{{ H() }}{{ m.M("b") }}`,
+		"macros.html":  `{% import "helpers.html" %}{% macro M(s string) %}{{ H() }}{{ s }}{{ render "partial.html" }}{% end %}`,
+		"helpers.html": `{% macro H %}h{% end %}`,
+		"partial.html": `p`,
+	}
+	cache := NewParseCache()
+	key := parseCacheKey{path: "macros.html", format: ast.FormatHTML, imported: true}
+
+	build := func(name string, checkKey any) *checkedImport {
+		t.Helper()
+		opts := Options{FormatTypes: formatTypes, ParseCache: cache, CheckKey: checkKey}
+		_, err := BuildTemplate(fsys, name, opts)
+		if err != nil {
+			t.Fatalf("unexpected error building %q: %s", name, err)
+		}
+		entry := cache.entries[key]
+		if entry == nil {
+			t.Fatalf("%q is not cached", key.path)
+		}
+		return entry.checked
+	}
+
+	checked := build("index.html", 1)
+	if checked == nil {
+		t.Fatalf("expected a checked import of %q, got nothing", key.path)
+	}
+	if got := build("page.html", 1); got != checked {
+		t.Fatalf("expected the checked import of the previous build")
+	}
+	if got := build("index.html", 2); got == nil || got == checked {
+		t.Fatalf("expected a new checked import with a different check key")
+	}
+	checked = build("index.html", 1)
+
+	// Change a file imported by macros.html.
+	fsys["helpers.html"] = `{% macro H %}H{% end %}`
+	if got := build("index.html", 1); got == nil || got == checked {
+		t.Fatalf("expected a new checked import after a change of an imported file")
+	}
+
+}
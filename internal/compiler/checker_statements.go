@@ -5,6 +5,7 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -117,7 +118,8 @@ func iteaHasBeenShadowed(nodes []ast.Node) bool {
 	return false
 }
 
-// checkNodesInNewScopeError calls checkNodesInNewScope returning checking errors.
+// checkNodesInNewScopeError calls checkNodesInNewScope returning checking
+// errors, including the errors added to the compilation.
 func (tc *typechecker) checkNodesInNewScopeError(block ast.Node, nodes []ast.Node) (newNodes []ast.Node, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -127,6 +129,7 @@ func (tc *typechecker) checkNodesInNewScopeError(block ast.Node, nodes []ast.Nod
 				panic(r)
 			}
 		}
+		err = tc.compilation.checkingError(err)
 	}()
 	tc.scopes.Enter(block)
 	newNodes = tc.checkNodes(nodes)
@@ -160,13 +163,105 @@ func (tc *typechecker) checkNodesError(nodes []ast.Node) (newNodes []ast.Node, e
 	return newNodes, err
 }
 
+// errUnresolvedName is the panic value of the checking of a statement that
+// refers to a name declared by a statement with a type checking error. The
+// checking of the statement is aborted without reporting an error.
+var errUnresolvedName = errors.New("unresolved name")
+
+// checkerState is the state of a type checker, saved before checking a
+// statement or a declaration and restored if it has a recovered error.
+type checkerState struct {
+	scopes                  *scopes
+	numScopes               int
+	ancestors               []ast.Node
+	iota                    int
+	withinUsingAffectedStmt bool
+	toBeEmitted             bool
+}
+
+// saveState returns the current state of the type checker.
+func (tc *typechecker) saveState() checkerState {
+	return checkerState{
+		scopes:                  tc.scopes,
+		numScopes:               len(tc.scopes.s),
+		ancestors:               tc.ancestors,
+		iota:                    tc.iota,
+		withinUsingAffectedStmt: tc.withinUsingAffectedStmt,
+		toBeEmitted:             tc.toBeEmitted,
+	}
+}
+
+// recoverError recovers the panic r of the checking of a statement or a
+// declaration, adding the error to the errors of the compilation and
+// restoring the state of the type checker to state. It panics again if the
+// errors are not recovered or r is not a type checking error.
+func (tc *typechecker) recoverError(r interface{}, state checkerState) {
+	if !tc.compilation.recoverErrors {
+		panic(r)
+	}
+	switch err := r.(type) {
+	case *CheckingError:
+		tc.compilation.addError(err)
+	default:
+		// A panic that is not a type checking error is not
+		// recoverable, unless it is the consequence of an
+		// error already occurred.
+		if r != errUnresolvedName && len(tc.compilation.errors) == 0 {
+			panic(r)
+		}
+	}
+	tc.scopes = state.scopes
+	tc.scopes.s = tc.scopes.s[:state.numScopes]
+	tc.scopes.UseAll()
+	tc.ancestors, tc.iota = state.ancestors, state.iota
+	tc.withinUsingAffectedStmt, tc.toBeEmitted = state.withinUsingAffectedStmt, state.toBeEmitted
+}
+
 // checkNodes type checks one or more statements, returning the new tree branch
-// with transformations, if any. Panics on error.
+// with transformations, if any.
+//
+// If the errors are recovered and a statement has a type checking error, the
+// error is added to the errors of the compilation and the checking continues
+// with the next statement. Panics on other errors.
 func (tc *typechecker) checkNodes(nodes []ast.Node) []ast.Node {
-
 	tc.terminating = false
+	for i := 0; i < len(nodes); {
+		nodes, i = tc.checkNodesFrom(nodes, i)
+	}
+	return nodes
+}
 
-	i := 0
+// checkNodesFrom type checks the statements in nodes starting from the
+// statement with index i. It returns the new tree branch and the index of
+// the next statement to check, that is len(nodes) if all the statements
+// have been checked.
+//
+// If the errors are recovered and a statement has a type checking error,
+// checkNodesFrom adds it to the errors of the compilation, restores the
+// state of the type checker, declares as unresolved the names declared by
+// the statement and returns the index of the next statement. If the names
+// declared by the statement are not known, it returns len(nodes).
+func (tc *typechecker) checkNodesFrom(nodes []ast.Node, i int) (newNodes []ast.Node, next int) {
+
+	state := tc.saveState()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tc.recoverError(r, state)
+			// Do not report a missing return after the error.
+			tc.terminating = true
+			newNodes, next = nodes, i+1
+			names, known := declaredNames(nodes[i])
+			if !known {
+				next = len(nodes)
+			}
+			for _, name := range names {
+				if _, ok := tc.scopes.Current(name); !ok {
+					tc.scopes.DeclareUnresolved(name)
+				}
+			}
+		}
+	}()
 
 nodesLoop:
 	for {
@@ -259,29 +354,8 @@ nodesLoop:
 			tc.terminating = node.Condition == nil && !tc.hasBreak[node]
 
 		case *ast.ForIn:
-			// Check range expression.
-			expr := node.Expr
-			ti := tc.checkExpr(expr)
-			if ti.Nil() {
-				panic(tc.errorf(node, "cannot range over nil"))
-			}
-			ti.setValue(nil)
 			// Replace the node with a ForRange node.
-			ipos := node.Ident.Pos()
-			blank := ast.NewIdentifier(ipos.WithEnd(ipos.Start), "_")
-			aPos := ipos.WithEnd(node.Expr.Pos().End)
-			var lhs []ast.Expression
-			switch ti.Type.Kind() {
-			default:
-				lhs = []ast.Expression{blank, node.Ident}
-			case reflect.Map:
-				lhs = []ast.Expression{node.Ident, blank}
-			case reflect.Chan:
-				lhs = []ast.Expression{node.Ident}
-			}
-			assignment := ast.NewAssignment(aPos, lhs, ast.AssignmentDeclaration, []ast.Expression{expr})
-			assignment.End = node.Expr.Pos().End
-			nodes[i] = ast.NewForRange(node.Pos(), assignment, node.Body, node.Else)
+			nodes[i] = tc.forInToForRange(node)
 			continue
 
 		case *ast.ForRange:
@@ -319,12 +393,26 @@ nodesLoop:
 				}
 				typ1 = typ.Elem()
 				maxLhs = 1
+			case reflect.Func:
+				var cause string
+				typ1, typ2, maxLhs, cause = rangeFuncTypes(typ)
+				if cause != "" {
+					panic(tc.errorf(node.Assignment.Rhs[0], "cannot range over %s (type %s): %s", expr, ti.StringWithNumber(true), cause))
+				}
 			default:
 				panic(tc.errorf(node.Assignment.Rhs[0], "cannot range over %s (type %s)", expr, ti.StringWithNumber(true)))
 			}
 			// Check variables.
 			if lhs != nil {
 				if len(lhs) > maxLhs {
+					if ti.Type.Kind() == reflect.Func {
+						switch maxLhs {
+						case 0:
+							panic(tc.errorf(lhs[0], "range over %s permits no iteration variables", expr))
+						case 1:
+							panic(tc.errorf(lhs[1], "range over %s permits only one iteration variable", expr))
+						}
+					}
 					panic(tc.errorf(node, "too many variables in range"))
 				}
 				ti1 := &typeInfo{Type: typ1, Properties: propertyAddressable}
@@ -663,6 +751,9 @@ nodesLoop:
 			tc.terminating = false
 
 		case *ast.TypeDeclaration:
+			if node.TypeParams != nil {
+				panic(tc.errorf(node, "generic type cannot be declared inside a function in this release of Scriggo"))
+			}
 			name, ti := tc.checkTypeDeclaration(node)
 			if ti != nil {
 				tc.assignScope(name, ti, node.Ident, nil)
@@ -767,12 +858,12 @@ nodesLoop:
 			if ti.IsBuiltinFunction() {
 				name := call.Func.(*ast.Identifier).Name
 				switch name {
-				case "append", "cap", "complex", "imag", "len", "make", "new", "real":
+				case "append", "cap", "complex", "imag", "len", "make", "max", "min", "new", "real":
 					panic(tc.errorf(node, "defer discards result of %s", call))
 				case "recover":
 					// The statement "defer recover()" is a special case
 					// implemented by the emitter.
-				case "close", "copy", "delete", "panic", "print", "println":
+				case "clear", "close", "copy", "delete", "panic", "print", "println":
 					tc.compilation.typeInfos[call.Func] = deferGoBuiltin(name)
 				}
 			}
@@ -794,9 +885,9 @@ nodesLoop:
 			if ti.IsBuiltinFunction() {
 				name := call.Func.(*ast.Identifier).Name
 				switch name {
-				case "append", "cap", "complex", "imag", "len", "make", "new", "real":
+				case "append", "cap", "complex", "imag", "len", "make", "max", "min", "new", "real":
 					panic(tc.errorf(node, "go discards result of %s", call))
-				case "close", "copy", "delete", "panic", "print", "println", "recover":
+				case "clear", "close", "copy", "delete", "panic", "print", "println", "recover":
 					tc.compilation.typeInfos[call.Func] = deferGoBuiltin(name)
 				}
 			}
@@ -853,6 +944,11 @@ nodesLoop:
 
 		case *ast.Label:
 			tc.scopes.DeclareLabel(node)
+			if forIn, ok := node.Statement.(*ast.ForIn); ok {
+				// Replace the statement before checking it, so that the break
+				// and continue statements in its body can refer to the label.
+				node.Statement = tc.forInToForRange(forIn)
+			}
 			if node.Statement != nil {
 				_ = tc.checkNodes([]ast.Node{node.Statement})
 			}
@@ -936,7 +1032,7 @@ nodesLoop:
 
 	}
 
-	return nodes
+	return nodes, len(nodes)
 
 }
 
@@ -957,6 +1053,10 @@ func (tc *typechecker) checkImport(impor *ast.Import) error {
 		if pkg == nil {
 			return tc.errorf(impor, "cannot find package %q", impor.Path)
 		}
+		if n := len(tc.compilation.sharing); n > 0 {
+			c := tc.compilation.sharing[n-1]
+			c.packages = append(c.packages, impor.Path)
+		}
 
 		// 'import _ "pkg"': nothing to do.
 		if isBlankImport(impor) {
@@ -1021,6 +1121,7 @@ func (tc *typechecker) checkImport(impor *ast.Import) error {
 	// Non-native package (i.e. a package declared in Scriggo).
 
 	if tc.opts.mod == templateMod {
+		tc.useCheckedImport(impor)
 		tc.templateFileToPackage(impor.Tree)
 	}
 	if impor.Tree.Nodes[0].(*ast.Package).Name == "main" {
@@ -1028,7 +1129,7 @@ func (tc *typechecker) checkImport(impor *ast.Import) error {
 	}
 
 	// Check the package and retrieve the package infos.
-	err := checkPackage(tc.compilation, impor.Tree.Nodes[0].(*ast.Package), impor.Tree.Path, tc.importer, tc.opts, tc.compilation.extendingTrees[impor.Tree.Path])
+	err := tc.checkImportedPackage(impor)
 	if err != nil {
 		return err
 	}
@@ -1321,6 +1422,15 @@ func (tc *typechecker) checkReturn(node *ast.Return) ast.Node {
 //	type Int int
 //	type Int = int
 func (tc *typechecker) checkTypeDeclaration(node *ast.TypeDeclaration) (string, *typeInfo) {
+	// An interface that is a type set can only be used as type constraint.
+	if iface, ok := node.Type.(*ast.Interface); ok {
+		if c := tc.checkConstraint(iface); c.isTypeSet() {
+			if isBlankIdentifier(node.Ident) {
+				return "", nil
+			}
+			return node.Ident.Name, &typeInfo{Type: emptyInterfaceType, Properties: propertyIsConstraint, value: c}
+		}
+	}
 	typ := tc.checkType(node.Type)
 	if isBlankIdentifier(node.Ident) {
 		return "", nil
@@ -1346,6 +1456,67 @@ func (tc *typechecker) checkTypeDeclaration(node *ast.TypeDeclaration) (string,
 	}
 }
 
+// forInToForRange checks the expression of a ForIn node and returns the
+// ForRange node that replaces it.
+func (tc *typechecker) forInToForRange(node *ast.ForIn) *ast.ForRange {
+	expr := node.Expr
+	ti := tc.checkExpr(expr)
+	if ti.Nil() {
+		panic(tc.errorf(node, "cannot range over nil"))
+	}
+	ti.setValue(nil)
+	ipos := node.Ident.Pos()
+	blank := ast.NewIdentifier(ipos.WithEnd(ipos.Start), "_")
+	aPos := ipos.WithEnd(node.Expr.Pos().End)
+	var lhs []ast.Expression
+	switch ti.Type.Kind() {
+	default:
+		lhs = []ast.Expression{blank, node.Ident}
+	case reflect.Map:
+		lhs = []ast.Expression{node.Ident, blank}
+	case reflect.Chan, reflect.Func:
+		lhs = []ast.Expression{node.Ident}
+	}
+	assignment := ast.NewAssignment(aPos, lhs, ast.AssignmentDeclaration, []ast.Expression{expr})
+	assignment.End = node.Expr.Pos().End
+	return ast.NewForRange(node.Pos(), assignment, node.Body, node.Else)
+}
+
+// rangeFuncTypes returns the types of the values, and their number, that a
+// for range statement gets from a function iterator of type typ. If typ is
+// not the type of a function iterator, it returns the cause.
+func rangeFuncTypes(typ reflect.Type) (typ1, typ2 reflect.Type, n int, cause string) {
+	const must = "func must be func(yield func(...) bool): "
+	switch {
+	case typ.NumIn() != 1:
+		return nil, nil, 0, must + "wrong argument count"
+	case typ.NumOut() != 0:
+		return nil, nil, 0, must + "unexpected results"
+	}
+	yield := typ.In(0)
+	switch {
+	case yield.Kind() != reflect.Func:
+		return nil, nil, 0, must + "argument is not func"
+	case yield.NumIn() > 2:
+		return nil, nil, 0, must + "yield func has too many parameters"
+	case yield.NumOut() != 1 || yield.Out(0) != boolType:
+		if yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool {
+			return nil, nil, 0, must + "yield func returns user-defined boolean, not bool"
+		}
+		return nil, nil, 0, must + "yield func does not return bool"
+	case yield.IsVariadic():
+		return nil, nil, 0, "yield func of type " + yield.String() + " cannot be variadic"
+	}
+	n = yield.NumIn()
+	if n >= 1 {
+		typ1 = yield.In(0)
+	}
+	if n == 2 {
+		typ2 = yield.In(1)
+	}
+	return typ1, typ2, n, ""
+}
+
 // explodeUsingStatement explodes an 'using' statement.
 func (tc *typechecker) explodeUsingStatement(using *ast.Using, iteaIdent string) (*ast.Var, ast.Node) {
 
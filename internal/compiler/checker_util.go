@@ -13,6 +13,7 @@ import (
 
 	"github.com/open2b/scriggo/ast"
 	"github.com/open2b/scriggo/internal/compiler/types"
+	"github.com/open2b/scriggo/internal/runtime"
 	"github.com/open2b/scriggo/native"
 )
 
@@ -242,6 +243,10 @@ func (tc *typechecker) convert(ti *typeInfo, expr ast.Expression, t2 reflect.Typ
 func deferGoBuiltin(name string) *typeInfo {
 	var fun interface{}
 	switch name {
+	case "clear":
+		fun = func(v interface{}) {
+			reflect.ValueOf(v).Clear()
+		}
 	case "close":
 		fun = func(ch interface{}) {
 			reflect.ValueOf(ch).Close()
@@ -344,6 +349,44 @@ func isBlankImport(impor *ast.Import) bool {
 	return impor.Ident != nil && impor.Ident.Name == "_"
 }
 
+// declaredNames returns the names declared by the statement node. known
+// reports whether the declared names are known, as they are not for the
+// imports that import all the exported names of a package or template file.
+func declaredNames(node ast.Node) (names []string, known bool) {
+	switch n := node.(type) {
+	case *ast.Import:
+		if n.For != nil {
+			for _, ident := range n.For {
+				names = append(names, ident.Name)
+			}
+			return names, true
+		}
+		if n.Ident == nil || n.Ident.Name == "." {
+			return nil, false
+		}
+		return []string{n.Ident.Name}, true
+	case *ast.Const:
+		for _, ident := range n.Lhs {
+			names = append(names, ident.Name)
+		}
+	case *ast.Var:
+		for _, ident := range n.Lhs {
+			names = append(names, ident.Name)
+		}
+	case *ast.TypeDeclaration:
+		names = append(names, n.Ident.Name)
+	case *ast.Assignment:
+		if n.Type == ast.AssignmentDeclaration {
+			for _, lh := range n.Lhs {
+				if ident, ok := lh.(*ast.Identifier); ok {
+					names = append(names, ident.Name)
+				}
+			}
+		}
+	}
+	return names, true
+}
+
 // isComparison reports whether op is a comparison operator.
 func isComparison(op ast.OperatorType) bool {
 	return op >= ast.OperatorEqual && op <= ast.OperatorGreaterEqual ||
@@ -549,8 +592,21 @@ func (tc *typechecker) typedValue(ti *typeInfo, t reflect.Type) interface{} {
 func (tc *typechecker) errTypeAssertion(typ reflect.Type, iface reflect.Type) error {
 	msg := fmt.Sprintf("impossible type assertion:\n\t%s does not implement %s", typ, iface)
 	num := iface.NumMethod()
+	_, isScriggo := typ.(runtime.ScriggoType)
 	for i := 0; i < num; i++ {
 		mi := iface.Method(i)
+		if isScriggo {
+			m, _, indirect, ok := types.LookupMethod(typ, mi.Name)
+			switch {
+			case !ok:
+				return fmt.Errorf("%s (missing %s method)", msg, mi.Name)
+			case m.Pointer && typ.Kind() != reflect.Ptr && !indirect:
+				return fmt.Errorf("%s (%s method has pointer receiver)", msg, mi.Name)
+			case m.Type != mi.Type:
+				return fmt.Errorf("%s (wrong type for %s method)\n\t\thave %s\n\t\twant %s", msg, mi.Name, m.Type, mi.Type)
+			}
+			continue
+		}
 		mt, ok := typ.MethodByName(mi.Name)
 		if !ok {
 			ptr := tc.types.PointerTo(typ)
@@ -591,6 +647,10 @@ func (tc *typechecker) errTypeAssertion(typ reflect.Type, iface reflect.Type) er
 			return fmt.Errorf("%s (wrong type for %s method)\n\t\thave %s\n\t\twant %s", msg, mi.Name, have, want)
 		}
 	}
+	if isScriggo {
+		// typ has all the methods, but not all of them can be called by Go.
+		return errors.New(msg)
+	}
 	panic("unexpected")
 }
 
@@ -5,6 +5,7 @@
 package compiler
 
 import (
+	"reflect"
 	"sort"
 	"strconv"
 
@@ -76,6 +77,32 @@ type compilation struct {
 	// This information must be kept here because it becomes lost after
 	// transforming the tree in case of extends.
 	extendedTrees map[string]bool
+
+	// pendingInstances holds the instances of generic functions whose bodies
+	// have not yet been type checked.
+	pendingInstances []*genericInstance
+
+	// typeInstances maps the instances of generic types to the related
+	// generic instances. It is used to infer type arguments.
+	typeInstances map[reflect.Type]*genericInstance
+
+	// recoverErrors reports whether the type checking errors of the
+	// statements are recovered, so that the checking continues with the
+	// next statements.
+	recoverErrors bool
+
+	// errors holds the type checking errors of the statements that have
+	// been recovered, in the order in which they occurred.
+	errors []*CheckingError
+
+	// sharing holds the type checks in progress, from the outermost, of the
+	// imported files whose type checking can be shared with the other
+	// compilations.
+	sharing []*importCheck
+
+	// sharedChecks maps the paths of the imported files whose type checking
+	// is shared with the other compilations to their checked imports.
+	sharedChecks map[string]*checkedImport
 }
 
 type renderIR struct {
@@ -96,6 +123,8 @@ func newCompilation(globalScope map[string]scopeName) *compilation {
 		globalScope:       globalScope,
 		extendingTrees:    map[string]bool{},
 		extendedTrees:     map[string]bool{},
+		typeInstances:     map[reflect.Type]*genericInstance{},
+		sharedChecks:      map[string]*checkedImport{},
 	}
 }
 
@@ -105,6 +134,11 @@ func newCompilation(globalScope map[string]scopeName) *compilation {
 // TODO(Gianluca): we should keep an index of the last (or the next) package
 // index, instead of recalculate it every time.
 func (compilation *compilation) UniqueIndex(path string) int {
+	// The indexes depend on the compilation, so the type checks that use
+	// them cannot be shared.
+	for _, c := range compilation.sharing {
+		c.shareable = false
+	}
 	i, ok := compilation.pkgPathToIndex[path]
 	if ok {
 		return i
@@ -119,6 +153,39 @@ func (compilation *compilation) UniqueIndex(path string) int {
 	return max + 1
 }
 
+// addError adds the type checking error err to the errors of the
+// compilation, if it has not already been added.
+func (compilation *compilation) addError(err *CheckingError) {
+	for _, e := range compilation.errors {
+		if e.path == err.path && e.pos == err.pos && e.err.Error() == err.err.Error() {
+			return
+		}
+	}
+	compilation.errors = append(compilation.errors, err)
+}
+
+// checkingError returns the error of the type checking, given the error err
+// returned by the type checker. If err is not nil and it is not a
+// *CheckingError, it returns err, otherwise it returns the errors of the
+// compilation, including err.
+//
+// If the compilation has only one error, it returns a *CheckingError,
+// otherwise it returns a CheckingErrors value.
+func (compilation *compilation) checkingError(err error) error {
+	if e, ok := err.(*CheckingError); ok {
+		compilation.addError(e)
+	} else if err != nil {
+		return err
+	}
+	switch len(compilation.errors) {
+	case 0:
+		return nil
+	case 1:
+		return compilation.errors[0]
+	}
+	return CheckingErrors(compilation.errors)
+}
+
 // generateIteaName generates a new name that can be used when transforming the
 // predeclared identifier 'itea'.
 func (compilation *compilation) generateIteaName() string {
@@ -129,6 +196,9 @@ func (compilation *compilation) generateIteaName() string {
 // finalizeUsingStatements finalizes the 'using' statements neutralizing 'itea'
 // declarations that should not be emitted. It also returns a type checking
 // error if the 'itea' identifier of a 'using' statement is not used.
+//
+// A finalized statement is not finalized again, so the trees of the already
+// checked packages are not changed.
 func (compilation *compilation) finalizeUsingStatements(tc *typechecker) error {
 	names := make([]string, 0, len(compilation.iteaToUsingCheck))
 	for name := range compilation.iteaToUsingCheck {
@@ -148,6 +218,7 @@ func (compilation *compilation) finalizeUsingStatements(tc *typechecker) error {
 			uc.itea.Rhs = []ast.Expression{ast.NewBasicLiteral(nil, ast.IntLiteral, "0")}
 			tc.checkNodes([]ast.Node{uc.itea})
 		}
+		delete(compilation.iteaToUsingCheck, name)
 	}
 	return nil
 }
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"io/fs"
 	"reflect"
+	"sort"
 	"unicode"
 	"unicode/utf8"
 
@@ -36,17 +37,18 @@ var formatTypeName = [...]string{"string", "html", "css", "js", "json", "markdow
 // As a special case, if the operand is an interface type then its value is
 // compared with the zero of the dynamic type of the interface.
 const (
-	internalOperatorZero = ast.OperatorExtendedNot + iota + 1
+	internalOperatorZero = ast.OperatorTilde + iota + 1
 	internalOperatorNotZero
 )
 
 // Error represents an error returned by the compiler. The types that
-// implement the Error interface are four types of the compiler package
+// implement the Error interface are these types of the compiler package
 //
 //	*GoModError
 //	*SyntaxError
 //	*CycleError
 //	*CheckingError
+//	CheckingErrors
 //	*LimitExceededError
 type Error interface {
 	error
@@ -70,6 +72,20 @@ type Options struct {
 	MDConverter Converter
 
 	TreeTransformer func(*ast.Tree) error
+
+	// ParseCache, if not nil, caches the parse trees of the template files.
+	ParseCache *ParseCache
+
+	// CheckKey, if not nil, is a comparable value that identifies the
+	// options, other than Coverage, with which a template is built. If
+	// ParseCache is also not nil, the imported files type checked by the
+	// build are cached in ParseCache and they are not type checked again by
+	// the builds with the same CheckKey.
+	CheckKey any
+
+	// Coverage reports whether the code is instrumented with Cover
+	// instructions to collect the coverage.
+	Coverage bool
 }
 
 // GoModError represents an error in a go.mod file.
@@ -127,7 +143,8 @@ func BuildProgram(fsys fs.FS, opts Options) (*Code, error) {
 		allowGoStmt: opts.AllowGoStmt,
 		globals:     opts.Globals,
 	}
-	tci, err := typecheck(tree, opts.Importer, checkerOpts)
+	packages := newPackageRecorder(opts.Importer)
+	tci, err := typecheck(tree, packages.asImporter(), checkerOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -139,10 +156,11 @@ func BuildProgram(fsys fs.FS, opts Options) (*Code, error) {
 	}
 
 	// Emit the code.
-	code, err := emitProgram(tree.Nodes[0].(*ast.Package), typeInfos, tci["main"].IndirectVars)
+	code, err := emitProgram(tree.Nodes[0].(*ast.Package), typeInfos, tci["main"].IndirectVars, opts.Coverage)
 	if err != nil {
 		return nil, err
 	}
+	code.Packages = packages.imported()
 
 	return code, nil
 }
@@ -155,9 +173,17 @@ func BuildTemplate(fsys fs.FS, name string, opts Options) (*Code, error) {
 
 	var tree *ast.Tree
 
+	// Share the type checked imported files, if requested.
+	var shared *sharedImports
+	var files map[*ast.Tree]parsedFile
+	if opts.ParseCache != nil && opts.CheckKey != nil {
+		files = map[*ast.Tree]parsedFile{}
+		shared = &sharedImports{cache: opts.ParseCache, key: opts.CheckKey, files: files}
+	}
+
 	// Parse the source code.
 	var err error
-	tree, err = ParseTemplate(fsys, name, opts.NoParseShortShowStmt)
+	tree, err = parseTemplate(fsys, name, opts.NoParseShortShowStmt, opts.ParseCache, files)
 	if err != nil {
 		return nil, err
 	}
@@ -177,8 +203,10 @@ func BuildTemplate(fsys fs.FS, name string, opts Options) (*Code, error) {
 		globals:     opts.Globals,
 		mdConverter: opts.MDConverter,
 		mod:         templateMod,
+		shared:      shared,
 	}
-	tci, err := typecheck(tree, opts.Importer, checkerOpts)
+	packages := newPackageRecorder(opts.Importer)
+	tci, err := typecheck(tree, packages.asImporter(), checkerOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -190,9 +218,13 @@ func BuildTemplate(fsys fs.FS, name string, opts Options) (*Code, error) {
 	}
 
 	// Emit the code.
-	code, err := emitTemplate(tree, typeInfos, tci["main"].IndirectVars, opts.FormatTypes)
+	code, err := emitTemplate(tree, typeInfos, tci["main"].IndirectVars, opts.FormatTypes, opts.Coverage)
+	if err != nil {
+		return nil, err
+	}
+	code.Packages = packages.imported()
 
-	return code, err
+	return code, nil
 }
 
 // CheckingError records a type checking error with the path and the position
@@ -224,6 +256,31 @@ func (e *CheckingError) Position() ast.Position {
 	return e.pos
 }
 
+// CheckingErrors records the type checking errors of a compilation, in the
+// order in which they occurred. It has at least two errors and its Error,
+// Message, Path and Position methods return those of the first error.
+type CheckingErrors []*CheckingError
+
+// Error returns a string representation of the first error.
+func (e CheckingErrors) Error() string {
+	return e[0].Error()
+}
+
+// Message returns the message of the first error.
+func (e CheckingErrors) Message() string {
+	return e[0].Message()
+}
+
+// Path returns the path of the first error.
+func (e CheckingErrors) Path() string {
+	return e[0].Path()
+}
+
+// Position returns the position of the first error.
+func (e CheckingErrors) Position() ast.Position {
+	return e[0].Position()
+}
+
 // Global represents a global variable with a package, name, type (only for
 // not predefined globals) and value (only for predefined globals). Value, if
 // present, must be a pointer to the variable value.
@@ -244,12 +301,73 @@ type Code struct {
 	Main *runtime.Function
 	// TypeOf returns the type of a value, including new types defined in code.
 	TypeOf runtime.TypeOfFunc
+	// Packages contains the paths of the imported native packages, sorted.
+	Packages []string
+	// Coverage contains the coverage blocks, indexed by the operands of the
+	// Cover instructions. It is nil if the code is not instrumented.
+	Coverage []CoverBlock
+}
+
+// CoverBlock represents a block of source code, on a single line, whose
+// executions are counted by a Cover instruction.
+type CoverBlock struct {
+	Path      string // path of the source code.
+	Line      int    // line.
+	Column    int    // column of the first character.
+	EndColumn int    // column after the last character.
+}
+
+// packageRecorder is a native.Importer that records the paths of the
+// packages imported through it.
+type packageRecorder struct {
+	importer native.Importer
+	paths    []string
+}
+
+// newPackageRecorder returns a new packageRecorder that imports the packages
+// from importer. If importer is nil, it returns nil.
+func newPackageRecorder(importer native.Importer) *packageRecorder {
+	if importer == nil {
+		return nil
+	}
+	return &packageRecorder{importer: importer}
+}
+
+// Import implements the native.Importer interface.
+func (r *packageRecorder) Import(path string) (native.ImportablePackage, error) {
+	pkg, err := r.importer.Import(path)
+	if pkg != nil && err == nil {
+		i := sort.SearchStrings(r.paths, path)
+		if i == len(r.paths) || r.paths[i] != path {
+			r.paths = append(r.paths, "")
+			copy(r.paths[i+1:], r.paths[i:])
+			r.paths[i] = path
+		}
+	}
+	return pkg, err
+}
+
+// asImporter returns r as a native.Importer, or nil if r is nil.
+func (r *packageRecorder) asImporter() native.Importer {
+	if r == nil {
+		return nil
+	}
+	return r
+}
+
+// imported returns the paths of the imported packages.
+func (r *packageRecorder) imported() []string {
+	if r == nil {
+		return nil
+	}
+	return r.paths
 }
 
 // emitProgram emits the code for a program given its ast node, the type info
 // and indirect variables. emitProgram returns an emittedPackage  instance
-// with the global variables and the main function.
-func emitProgram(pkgMain *ast.Package, typeInfos map[ast.Node]*typeInfo, indirectVars map[*ast.Identifier]bool) (_ *Code, err error) {
+// with the global variables and the main function. If coverage is true, the
+// code is instrumented to collect the coverage.
+func emitProgram(pkgMain *ast.Package, typeInfos map[ast.Node]*typeInfo, indirectVars map[*ast.Identifier]bool, coverage bool) (_ *Code, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if e, ok := r.(*LimitExceededError); ok {
@@ -260,6 +378,7 @@ func emitProgram(pkgMain *ast.Package, typeInfos map[ast.Node]*typeInfo, indirec
 		}
 	}()
 	e := newEmitter(typeInfos, nil, indirectVars)
+	e.coverage = coverage
 	functions, _, _ := e.emitPackage(pkgMain, false, "main")
 	main, _ := e.fnStore.availableScriggoFn(pkgMain, "main")
 	pkg := &Code{
@@ -267,14 +386,16 @@ func emitProgram(pkgMain *ast.Package, typeInfos map[ast.Node]*typeInfo, indirec
 		Functions: functions,
 		Main:      main,
 		TypeOf:    e.types.TypeOf,
+		Coverage:  e.coverBlocks,
 	}
 	return pkg, nil
 }
 
 // emitTemplate emits the code for a template given its tree, the type info and
 // indirect variables. emitTemplate returns a function that is the entry point
-// of the template and the global variables.
-func emitTemplate(tree *ast.Tree, typeInfos map[ast.Node]*typeInfo, indirectVars map[*ast.Identifier]bool, formatTypes map[ast.Format]reflect.Type) (_ *Code, err error) {
+// of the template and the global variables. If coverage is true, the code is
+// instrumented to collect the coverage.
+func emitTemplate(tree *ast.Tree, typeInfos map[ast.Node]*typeInfo, indirectVars map[*ast.Identifier]bool, formatTypes map[ast.Format]reflect.Type, coverage bool) (_ *Code, err error) {
 	// Recover and eventually return a LimitExceededError.
 	defer func() {
 		if r := recover(); r != nil {
@@ -288,6 +409,7 @@ func emitTemplate(tree *ast.Tree, typeInfos map[ast.Node]*typeInfo, indirectVars
 	e := newEmitter(typeInfos, formatTypes, indirectVars)
 	e.pkg = &ast.Package{}
 	e.isTemplate = true
+	e.coverage = coverage
 	typ := reflect.FuncOf(nil, nil, false)
 	e.fb = newBuilder(newMacro("main", "main", typ, tree.Format, tree.Path, tree.Pos()), tree.Path)
 	e.fb.changePath(tree.Path)
@@ -295,7 +417,7 @@ func emitTemplate(tree *ast.Tree, typeInfos map[ast.Node]*typeInfo, indirectVars
 	e.emitNodes(tree.Nodes)
 	e.fb.exitScope()
 	e.fb.end()
-	return &Code{Main: e.fb.fn, TypeOf: e.types.TypeOf, Globals: e.varStore.getGlobals()}, nil
+	return &Code{Main: e.fb.fn, TypeOf: e.types.TypeOf, Globals: e.varStore.getGlobals(), Coverage: e.coverBlocks}, nil
 }
 
 // isExported reports whether name is exported, according to
@@ -1239,6 +1239,24 @@ func toSameConstImpl(c1, c2 constant) (constant, constant) {
 	return n1, n2
 }
 
+// minConst returns the smaller of the constants c1 and c2, that must be both
+// numbers, but not complex numbers, or both strings.
+func minConst(c1, c2 constant) constant {
+	if less, _ := c2.binaryOp(ast.OperatorLess, c1); less.bool() {
+		return c2
+	}
+	return c1
+}
+
+// maxConst returns the larger of the constants c1 and c2, that must be both
+// numbers, but not complex numbers, or both strings.
+func maxConst(c1, c2 constant) constant {
+	if greater, _ := c2.binaryOp(ast.OperatorGreater, c1); greater.bool() {
+		return c2
+	}
+	return c1
+}
+
 var errNegativeShiftCount = errors.New("negative shift count")
 var errShiftCountTooLarge = errors.New("shift count too large")
 var errShiftCountTruncatedToInteger = errors.New("shift count truncated to integer")
@@ -266,11 +266,11 @@ func disassembleFunction(b *bytes.Buffer, globals []Global, fn *runtime.Function
 			_, _ = fmt.Fprintf(b, "%s\t%s", indent, disassembleInstruction(fn, globals, addr, textSize))
 		}
 		// TODO: this part is not clear:
-		if in.Op == runtime.OpLoadFunc && (int(in.B) < len(fn.Functions)) && fn.Functions[uint8(in.B)].Parent != nil { // function literal
+		if in.Op == runtime.OpLoadFunc && (int(in.B) < len(fn.Functions)) && fn.Functions[uint16(in.B)].Parent != nil { // function literal
 			b.WriteByte(' ')
 			b.WriteString(disassembleOperand(fn, in.C, reflect.Interface, false))
 			b.WriteString(" func")
-			disassembleFunction(b, globals, fn.Functions[uint8(in.B)], 0, depth+1)
+			disassembleFunction(b, globals, fn.Functions[uint16(in.B)], 0, depth+1)
 		} else {
 			b.WriteByte('\n')
 		}
@@ -319,7 +319,8 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 	s := operationName[op]
 	switch op {
 	case runtime.OpAdd, runtime.OpSub, runtime.OpSubInv, runtime.OpMul,
-		runtime.OpDiv, runtime.OpRem, runtime.OpShl, runtime.OpShr:
+		runtime.OpDiv, runtime.OpRem, runtime.OpShl, runtime.OpShr,
+		runtime.OpMax, runtime.OpMin:
 		kind := reflect.Kind(a)
 		s += " " + kind.String()
 		s += " " + disassembleOperand(fn, b, kind, k)
@@ -354,31 +355,31 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 	case runtime.OpAssert:
 		s += " " + disassembleOperand(fn, a, reflect.Interface, false)
-		s += " " + fn.Types[b].String()
-		t := fn.Types[int(uint(b))]
+		s += " " + fn.Types[uint16(b)].String()
+		t := fn.Types[uint16(b)]
 		var kind = reflectToRegisterKind(t.Kind())
 		s += " " + disassembleOperand(fn, c, kind, false)
-	case runtime.OpBreak, runtime.OpContinue, runtime.OpGoto:
+	case runtime.OpBreak, runtime.OpContinue, runtime.OpCover, runtime.OpGoto:
 		s += " " + strconv.Itoa(int(decodeUint24(a, b, c)))
 	case runtime.OpCallFunc, runtime.OpCallMacro, runtime.OpCallIndirect, runtime.OpCallNative, runtime.OpTailCall, runtime.OpDefer:
 		if a != runtime.CurrentFunction {
 			switch op {
 			case runtime.OpCallFunc, runtime.OpCallMacro, runtime.OpTailCall:
-				sf := fn.Functions[uint8(a)]
+				sf := fn.Functions[uint16(a)]
 				s += " " + packageName(sf.Pkg) + "." + sf.Name
 			case runtime.OpCallIndirect:
 				s += " " + "("
 				s += disassembleOperand(fn, a, reflect.Interface, false)
 				s += ")"
 			case runtime.OpCallNative:
-				nf := fn.NativeFunctions[uint8(a)]
+				nf := fn.NativeFunctions[uint16(a)]
 				s += " " + packageName(nf.Package()) + "." + nf.Name()
 			case runtime.OpDefer:
 				s += " " + disassembleOperand(fn, a, reflect.Interface, false)
 			}
 		}
 		grow := fn.Body[addr+1]
-		stackShift := runtime.StackShift{int8(grow.Op), grow.A, grow.B, grow.C}
+		stackShift := runtime.StackShift{int16(grow.Op), grow.A, grow.B, grow.C}
 		if c != runtime.NoVariadicArgs && (op == runtime.OpCallIndirect || op == runtime.OpCallNative || op == runtime.OpDefer) {
 			s += " ..." + strconv.Itoa(int(c))
 		}
@@ -405,7 +406,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		default:
 			s += " Default"
 		}
-	case runtime.OpClose, runtime.OpPanic, runtime.OpPrint:
+	case runtime.OpClear, runtime.OpClose, runtime.OpPanic, runtime.OpPrint:
 		s += " " + disassembleOperand(fn, a, reflect.Interface, false)
 	case runtime.OpComplex64, runtime.OpComplex128:
 		s += " " + disassembleOperand(fn, a, reflect.Float64, false)
@@ -417,22 +418,22 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		s += " " + disassembleOperand(fn, c, reflect.String, false)
 	case runtime.OpConvert:
 		s += " " + disassembleOperand(fn, a, reflect.Interface, false)
-		typ := fn.Types[int(uint(b))]
+		typ := fn.Types[uint16(b)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, c, typ.Kind(), false)
 	case runtime.OpConvertInt, runtime.OpConvertUint:
 		s += " " + disassembleOperand(fn, a, reflect.Int, false)
-		typ := fn.Types[int(uint(b))]
+		typ := fn.Types[uint16(b)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, c, reflect.Kind(typ.Kind()), false)
 	case runtime.OpConvertFloat:
 		s += " " + disassembleOperand(fn, a, reflect.Float64, false)
-		typ := fn.Types[int(uint(b))]
+		typ := fn.Types[uint16(b)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, c, reflect.Kind(typ.Kind()), false)
 	case runtime.OpConvertString:
 		s += " " + disassembleOperand(fn, a, reflect.String, false)
-		typ := fn.Types[int(uint(b))]
+		typ := fn.Types[uint16(b)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, c, reflect.Kind(typ.Kind()), false)
 	case runtime.OpCopy:
@@ -512,7 +513,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		}
 	case runtime.OpField:
 		s += " " + disassembleOperand(fn, a, reflect.Interface, false)
-		s += " " + disassembleFieldIndex(fn.FieldIndexes[uint8(b)])
+		s += " " + disassembleFieldIndex(fn.FieldIndexes[uint16(b)])
 		s += " " + disassembleOperand(fn, c, getKind('c', fn, addr), false)
 	case runtime.OpGetVar:
 		s += " " + disassembleVarRef(fn, globals, int16(int(a)<<8|int(uint8(b))))
@@ -539,7 +540,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		s += " " + disassembleOperand(fn, c, reflect.Int, false)
 	case runtime.OpLoadFunc:
 		if a == 0 {
-			f := fn.Functions[uint8(b)]
+			f := fn.Functions[uint16(b)]
 			if f.Parent != nil { // f is a function literal.
 				s = "Func" // overwrite s.
 			} else {
@@ -547,7 +548,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 				s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 			}
 		} else { // LoadFunc (native).
-			f := fn.NativeFunctions[uint8(b)]
+			f := fn.NativeFunctions[uint16(b)]
 			s += " " + packageName(f.Package()) + "." + f.Name()
 			s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 		}
@@ -573,14 +574,14 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 			s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 		}
 	case runtime.OpMakeArray, runtime.OpMakeStruct, runtime.OpNew:
-		s += " " + fn.Types[int(uint(b))].String()
+		s += " " + fn.Types[uint16(b)].String()
 		s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 	case runtime.OpMakeChan, runtime.OpMakeMap:
-		s += " " + fn.Types[int(uint(a))].String()
+		s += " " + fn.Types[uint16(a)].String()
 		s += " " + disassembleOperand(fn, b, reflect.Int, k)
 		s += " " + disassembleOperand(fn, c, reflect.Interface, false)
 	case runtime.OpMakeSlice:
-		s += " " + fn.Types[int(uint(a))].Elem().String()
+		s += " " + fn.Types[uint16(a)].Elem().String()
 		if b > 0 {
 			next := fn.Body[addr+1]
 			s += " " + disassembleOperand(fn, next.A, reflect.Int, (b&(1<<1)) != 0)
@@ -651,7 +652,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 	case runtime.OpSetField:
 		s += " " + disassembleOperand(fn, a, getKind('a', fn, addr), k)
 		s += " " + disassembleOperand(fn, b, reflect.Interface, false)
-		s += " " + disassembleFieldIndex(fn.FieldIndexes[uint8(c)])
+		s += " " + disassembleFieldIndex(fn.FieldIndexes[uint16(c)])
 	case runtime.OpSetMap:
 		// fn, addr, 'a'
 		s += " " + disassembleOperand(fn, a, getKind('a', fn, addr), k)
@@ -665,7 +666,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 		s += " " + disassembleOperand(fn, a, getKind('a', fn, addr), k)
 		s += " " + disassembleVarRef(fn, globals, int16(int(b)<<8|int(uint8(c))))
 	case runtime.OpShow:
-		typ := fn.Types[int(uint(a))]
+		typ := fn.Types[uint16(a)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, b, reflectToRegisterKind(typ.Kind()), false)
 		ctx, _, _ := decodeRenderContext(runtime.Context(c))
@@ -705,7 +706,7 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 			s += " " + disassembleText(fn.Text[i], textSize)
 		}
 	case runtime.OpTypify:
-		typ := fn.Types[int(uint(a))]
+		typ := fn.Types[uint16(a)]
 		s += " " + typ.String()
 		s += " " + disassembleOperand(fn, b, reflectToRegisterKind(typ.Kind()), k)
 		s += " " + disassembleOperand(fn, c, reflect.Interface, false)
@@ -736,16 +737,16 @@ func disassembleInstruction(fn *runtime.Function, globals []Global, addr runtime
 // funcNameType returns a boolean indications if the specific function is a
 // macro, its name and its type. If the function is not available. Only one of
 // index and addr is meaningful, depending on the operation specified by op.
-func funcNameType(fn *runtime.Function, index int8, addr runtime.Addr, op runtime.Operation) (bool, string, reflect.Type) {
+func funcNameType(fn *runtime.Function, index int16, addr runtime.Addr, op runtime.Operation) (bool, string, reflect.Type) {
 	switch op {
 	case runtime.OpCallFunc, runtime.OpCallMacro:
-		macro := fn.Functions[index].Macro
-		typ := fn.Functions[index].Type
-		name := fn.Functions[index].Name
+		macro := fn.Functions[uint16(index)].Macro
+		typ := fn.Functions[uint16(index)].Type
+		name := fn.Functions[uint16(index)].Name
 		return macro, name, typ
 	case runtime.OpCallNative:
-		name := fn.NativeFunctions[index].Name()
-		typ := reflect.TypeOf(fn.NativeFunctions[index].Func())
+		name := fn.NativeFunctions[uint16(index)].Name()
+		typ := reflect.TypeOf(fn.NativeFunctions[uint16(index)].Func())
 		return false, name, typ
 	case runtime.OpCallIndirect, runtime.OpDefer:
 		return false, "", fn.InstructionInfo[addr].FuncType
@@ -775,7 +776,7 @@ func funcHasParameterInRegister(fn reflect.Type, reg registerType) bool {
 // disassembleFunctionCall disassemble a function call returning an
 // human-readable string representing the call. The result of this function is
 // used as a comment to the byte code.
-func disassembleFunctionCall(fn *runtime.Function, index int8, addr runtime.Addr, op runtime.Operation, stackShift runtime.StackShift, variadic int8) string {
+func disassembleFunctionCall(fn *runtime.Function, index int16, addr runtime.Addr, op runtime.Operation, stackShift runtime.StackShift, variadic int16) string {
 	macro, name, typ := funcNameType(fn, index, addr, op)
 	if typ == nil {
 		return ""
@@ -828,7 +829,7 @@ func disassembleFunctionCall(fn *runtime.Function, index int8, addr runtime.Addr
 			s += print(typ.In(lastIn))
 		} else {
 			varType := typ.In(lastIn).Elem()
-			for i := int8(0); i < variadic; i++ {
+			for i := int16(0); i < variadic; i++ {
 				s += print(varType)
 				if i < variadic-1 {
 					s += ", "
@@ -851,7 +852,7 @@ func disassembleVarRef(fn *runtime.Function, globals []Global, ref int16) string
 		v := globals[ref]
 		return packageName(v.Pkg) + "." + v.Name
 	}
-	s := disassembleOperand(fn, -int8(ref), reflect.Interface, false)
+	s := disassembleOperand(fn, -int16(ref), reflect.Interface, false)
 	if depth > 0 {
 		s += "@" + strconv.Itoa(depth)
 	}
@@ -925,7 +926,7 @@ func registerKindToLabel(kind reflect.Kind) string {
 	}
 }
 
-func disassembleOperand(fn *runtime.Function, op int8, kind reflect.Kind, constant bool) string {
+func disassembleOperand(fn *runtime.Function, op int16, kind reflect.Kind, constant bool) string {
 	if constant {
 		switch {
 		case reflect.Int <= kind && kind <= reflect.Int64:
@@ -942,11 +943,11 @@ func disassembleOperand(fn *runtime.Function, op int8, kind reflect.Kind, consta
 			}
 			return "true"
 		case kind == reflect.String:
-			return strconv.Quote(fn.Values.String[uint8(op)])
+			return strconv.Quote(fn.Values.String[uint16(op)])
 		case kind == reflect.Invalid:
 			return "?"
 		default:
-			v := fn.Values.General[uint8(op)]
+			v := fn.Values.General[uint16(op)]
 			if v.IsValid() {
 				return fmt.Sprintf("%#v", v.Interface())
 			}
@@ -1008,6 +1009,8 @@ var operationName = [...]string{
 
 	runtime.OpCase: "Case",
 
+	runtime.OpClear: "Clear",
+
 	runtime.OpClose: "Close",
 
 	runtime.OpComplex64:  "Complex64",
@@ -1025,6 +1028,8 @@ var operationName = [...]string{
 
 	runtime.OpCopy: "Copy",
 
+	runtime.OpCover: "Cover",
+
 	runtime.OpDefer: "Defer",
 
 	runtime.OpDelete: "Delete",
@@ -1072,8 +1077,12 @@ var operationName = [...]string{
 	runtime.OpMapIndex:    "MapIndex",
 	runtime.OpMapIndexAny: "MapIndex",
 
+	runtime.OpMax: "Max",
+
 	runtime.OpMethodValue: "MethodValue",
 
+	runtime.OpMin: "Min",
+
 	runtime.OpMove: "Move",
 
 	runtime.OpMul:        "Mul",
@@ -36,21 +36,6 @@ type emitter struct {
 	// isTemplate reports whether the emitter is currently emitting a template.
 	isTemplate bool
 
-	// rangeLabels contains the addresses of the current active Range instructions.
-	rangeLabels []label
-
-	// breakable is true if emitting a "breakable" statement (except ForRange,
-	// which implements his own "breaking" system).
-	breakable bool
-
-	// inForRange reports whether the emitter is currently emitting the body of
-	// a ForRange node.
-	inForRange bool
-
-	// breakLabel, if not nil, is the label to which pre-stated "breaks" must
-	// jump.
-	breakLabel *label
-
 	// inURL indicates if the emitter is currently inside an *ast.URL node.
 	inURL bool
 
@@ -83,6 +68,16 @@ type emitter struct {
 	// alreadyInitializedTemplatePkgs keeps track of the template packages for
 	// which the initialization code has already been emitted.
 	alreadyInitializedTemplatePkgs map[string]bool
+
+	// macroNames maps the macros declared in templates, emitted as function
+	// literals, to their names.
+	macroNames map[*ast.Func]string
+
+	// coverage reports whether the Cover instructions must be emitted and
+	// coverBlocks are the blocks of the emitted Cover instructions, indexed
+	// by their operand.
+	coverage    bool
+	coverBlocks []CoverBlock
 }
 
 // newEmitter returns a new emitter with the given type infos, format types,
@@ -96,6 +91,7 @@ func newEmitter(typeInfos map[ast.Node]*typeInfo, formatTypes map[ast.Format]ref
 		alreadyEmittedFuncs:            map[*ast.Func]*runtime.Function{},
 		alreadyInitializedVars:         map[*ast.Identifier]int16{},
 		alreadyInitializedTemplatePkgs: map[string]bool{},
+		macroNames:                     map[*ast.Func]string{},
 	}
 	em.fnStore = newFunctionStore(em)
 	em.varStore = newVarStore(em, indirectVars)
@@ -168,6 +164,8 @@ func (em *emitter) emitPackage(pkg *ast.Package, extendingFile bool, path string
 				var fn *runtime.Function
 				if emFn, ok := em.alreadyEmittedFuncs[fun]; ok {
 					fn = emFn
+				} else if m, ok := em.fnStore.methodFn(fun); ok {
+					fn = m
 				} else {
 					if fun.Type.Macro {
 						fn = newMacro("main", fun.Ident.Name, fun.Type.Reflect, fun.Format, path, fun.Pos())
@@ -181,7 +179,7 @@ func (em *emitter) emitPackage(pkg *ast.Package, extendingFile bool, path string
 				}
 				em.fnStore.makeAvailableScriggoFn(em.pkg, fun.Ident.Name, fn)
 				isDummyMacroForRender := strings.HasPrefix(fun.Ident.Name, `M"`) && strings.HasSuffix(fun.Ident.Name, `"`)
-				if isExported(fun.Ident.Name) || isDummyMacroForRender {
+				if fun.Recv == nil && isExported(fun.Ident.Name) || isDummyMacroForRender {
 					functions[fun.Ident.Name] = fn
 				}
 			}
@@ -208,7 +206,7 @@ func (em *emitter) emitPackage(pkg *ast.Package, extendingFile bool, path string
 			}
 			em.fb = initVarsFb
 			addresses := make([]address, len(n.Lhs))
-			pkgVarRegs := map[string]int8{}
+			pkgVarRegs := map[string]int16{}
 			pkgVarTypes := map[string]reflect.Type{}
 			for i, v := range n.Lhs {
 				if isBlankIdentifier(v) {
@@ -318,11 +316,11 @@ type callOptions struct {
 //
 // Note that while prepareCallParameters is called before calling the function,
 // prepareFunctionBodyParameters is called before emitting its body.
-func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Expression, opts callOptions) ([]int8, []reflect.Type) {
+func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Expression, opts callOptions) ([]int16, []reflect.Type) {
 
 	fNumOut := fType.NumOut()
 	fNumIn := fType.NumIn()
-	fOutRegs := make([]int8, fNumOut)
+	fOutRegs := make([]int16, fNumOut)
 	fOutTypes := make([]reflect.Type, fNumOut)
 
 	// Reserve space for the output parameters.
@@ -351,13 +349,13 @@ func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Express
 			nonVarArgsCount := fNumIn - 1
 			varArgsCount := gOutCount - (fNumIn - 1)
 			// Reserve space for non variadic parameters.
-			var nonVarParamRegs []int8
+			var nonVarParamRegs []int16
 			for i := 0; i < nonVarArgsCount; i++ {
 				reg := em.fb.newRegister(fType.In(i).Kind())
 				nonVarParamRegs = append(nonVarParamRegs, reg)
 			}
 			// Reserve space for variadic parameters.
-			var varParamRegs []int8
+			var varParamRegs []int16
 			sliceType := fType.In(fNumIn - 1)
 			if opts.predefined {
 				// When calling a predefined variadic function, the variadic
@@ -370,7 +368,7 @@ func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Express
 			} else {
 				// When calling a non-predefined variadic function, the
 				// variadic parameters must be emitted inside a slice.
-				varParamRegs = []int8{em.fb.newRegister(reflect.Slice)}
+				varParamRegs = []int16{em.fb.newRegister(reflect.Slice)}
 			}
 			em.fb.enterStack()
 			gOutRegs, gOutTypes := em.emitCallNode(g, false, false, runtime.ReturnString)
@@ -394,12 +392,12 @@ func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Express
 					em.changeRegister(true, c, slice, sliceType, sliceType)
 				} else {
 					pos := fArgs[0].Pos()
-					em.fb.emitMakeSlice(true, true, sliceType, int8(varArgsCount), int8(varArgsCount), slice, pos)
+					em.fb.emitMakeSlice(true, true, sliceType, int16(varArgsCount), int16(varArgsCount), slice, pos)
 					for i := nonVarArgsCount; i < len(gOutRegs); i++ {
 						gArgReg := gOutRegs[i]
 						gArgType := gOutTypes[i]
 						index := em.fb.newRegister(reflect.Int)
-						em.changeRegister(true, int8(i-nonVarArgsCount), index, intType, intType)
+						em.changeRegister(true, int16(i-nonVarArgsCount), index, intType, intType)
 						if canEmitDirectly(gArgType.Kind(), sliceType.Elem().Kind()) {
 							em.fb.emitSetSlice(false, slice, gArgReg, index, pos, sliceType.Elem().Kind())
 						} else {
@@ -446,14 +444,14 @@ func (em *emitter) prepareCallParameters(fType reflect.Type, fArgs []ast.Express
 			}
 		} else {
 			slice := em.fb.newRegister(reflect.Slice)
-			em.fb.emitMakeSlice(true, true, fType.In(fNumIn-1), int8(varArgsCount), int8(varArgsCount), slice, nil) // TODO: fix pos.
+			em.fb.emitMakeSlice(true, true, fType.In(fNumIn-1), int16(varArgsCount), int16(varArgsCount), slice, nil) // TODO: fix pos.
 			for i := 0; i < varArgsCount; i++ {
 				tmp := em.fb.newRegister(t.Kind())
 				em.fb.enterStack()
 				em.emitExprR(fArgs[i+fNumIn-1], t, tmp)
 				em.fb.exitStack()
 				index := em.fb.newRegister(reflect.Int)
-				em.fb.emitMove(true, int8(i), index, reflect.Int)
+				em.fb.emitMove(true, int16(i), index, reflect.Int)
 				pos := fArgs[len(fArgs)-1].Pos()
 				em.fb.emitSetSlice(false, slice, tmp, index, pos, fType.In(fNumIn-1).Elem().Kind())
 			}
@@ -499,19 +497,20 @@ func (em *emitter) prepareFunctionBodyParameters(fn *ast.Func) {
 
 	// Reserve space for the return parameters and eventually bind them.
 	for _, out := range fn.Type.Result {
-		kind := em.typ(out.Type).Kind()
-		reg := em.fb.newRegister(kind)
+		typ := em.typ(out.Type)
+		reg := em.fb.newRegister(typ.Kind())
 		if out.Ident != nil && !isBlankIdentifier(out.Ident) {
-			em.fb.bindVarReg(out.Ident.Name, reg)
+			em.fb.bindVarReg(out.Ident.Name, reg, typ)
 		}
 	}
 
 	// Reserve space for the input parameters and eventually bind them.
 	for i, inParam := range fn.Type.Parameters {
-		kind := em.typ(inParam.Type).Kind()
+		typ := em.typ(inParam.Type)
 		if fn.Type.IsVariadic && i == len(fn.Type.Parameters)-1 {
-			kind = reflect.Slice
+			typ = em.types.SliceOf(typ)
 		}
+		kind := typ.Kind()
 		if inParam.Ident == nil || isBlankIdentifier(inParam.Ident) {
 			// Just reserve space for this parameter.
 			_ = em.fb.newRegister(kind)
@@ -522,7 +521,7 @@ func (em *emitter) prepareFunctionBodyParameters(fn *ast.Func) {
 			//
 			// Indirect input parameters are handled below.
 			arg := em.fb.newRegister(kind)
-			em.fb.bindVarReg(inParam.Ident.Name, arg)
+			em.fb.bindVarReg(inParam.Ident.Name, arg, typ)
 		}
 	}
 
@@ -532,9 +531,10 @@ func (em *emitter) prepareFunctionBodyParameters(fn *ast.Func) {
 		if out.Ident != nil && em.varStore.mustBeDeclaredAsIndirect(out.Ident) {
 			dst := em.fb.scopeLookup(out.Ident.Name)
 			reg := em.fb.newIndirectRegister()
-			em.fb.emitNew(em.typ(out.Type), -reg)
-			em.fb.bindVarReg(out.Ident.Name, reg)
-			em.fb.fn.FinalRegs = append(em.fb.fn.FinalRegs, [2]int8{-reg, dst})
+			typ := em.typ(out.Type)
+			em.fb.emitNew(typ, -reg)
+			em.fb.bindVarReg(out.Ident.Name, reg, typ)
+			em.fb.fn.FinalRegs = append(em.fb.fn.FinalRegs, [2]int16{-reg, dst})
 		}
 	}
 
@@ -550,18 +550,36 @@ func (em *emitter) prepareFunctionBodyParameters(fn *ast.Func) {
 			typ := em.typ(param.Type)
 			em.fb.emitNew(typ, -indirect)
 			em.changeRegister(false, reg, indirect, typ, typ)
-			em.fb.bindVarReg(param.Ident.Name, indirect)
+			em.fb.bindVarReg(param.Ident.Name, indirect, typ)
 		}
 
 	}
 
 }
 
+// numVariadicArgs returns the number of the variadic arguments of the call
+// of a predefined function with type typ, or runtime.NoVariadicArgs if typ is
+// not variadic or the call has the dots.
+func (em *emitter) numVariadicArgs(call *ast.Call, typ reflect.Type) int16 {
+	if !typ.IsVariadic() || call.IsVariadic {
+		return runtime.NoVariadicArgs
+	}
+	numArgs := len(call.Args)
+	if len(call.Args) == 1 {
+		if callArg, ok := call.Args[0].(*ast.Call); ok {
+			if numOut, ok := em.numOut(callArg); ok {
+				numArgs = numOut
+			}
+		}
+	}
+	return int16(numArgs - (typ.NumIn() - 1))
+}
+
 // emitCallNode emits instructions for a function call node. It returns the
 // registers and the reflect types of the returned values.
 // goStmt indicates if the call node belongs to a 'go statement', while
 // deferStmt reports whether it must be deferred.
-func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toFormat ast.Format) ([]int8, []reflect.Type) {
+func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toFormat ast.Format) ([]int16, []reflect.Type) {
 
 	funTi := em.ti(call.Func)
 
@@ -586,14 +604,13 @@ func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toF
 			callHasDots:   call.IsVariadic,
 		}
 		regs, types := em.prepareCallParameters(funTi.Type, call.Args, opts)
-		// TODO(Gianluca): handle variadic method calls.
 		if goStmt {
 			em.fb.emitGo()
 		}
 		if deferStmt {
 			panic(internalError("not implemented"))
 		}
-		em.fb.emitCallIndirect(method, 0, stackShift, call.Pos(), funTi.Type, toFormat)
+		em.fb.emitCallIndirect(method, em.numVariadicArgs(call, funTi.Type), stackShift, call.Pos(), funTi.Type, toFormat)
 		return regs, types
 	}
 
@@ -602,8 +619,11 @@ func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toF
 	// common "indirect" calls.
 	if funTi.IsNative() && !funTi.Addressable() {
 		if funTi.MethodType == methodCallConcrete {
+			// Pass the receiver as first argument, without changing the tree.
 			rcv := call.Func.(*ast.Selector).Expr // TODO(Gianluca): is this correct?
-			call.Args = append([]ast.Expression{rcv}, call.Args...)
+			c := *call
+			c.Args = append([]ast.Expression{rcv}, call.Args...)
+			call = &c
 		}
 		stackShift := em.fb.currentStackShift()
 		opts := callOptions{
@@ -616,32 +636,25 @@ func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toF
 		if goStmt {
 			em.fb.emitGo()
 		}
-		numVar := runtime.NoVariadicArgs
-		if funTi.Type.IsVariadic() && !call.IsVariadic {
-			numArgs := len(call.Args)
-			if len(call.Args) == 1 {
-				if callArg, ok := call.Args[0].(*ast.Call); ok {
-					if numOut, ok := em.numOut(callArg); ok {
-						numArgs = numOut
-					}
-				}
-			}
-			numVar = numArgs - (funTi.Type.NumIn() - 1)
-		}
+		numVar := em.numVariadicArgs(call, funTi.Type)
 		if deferStmt {
 			args := em.fb.currentStackShift()
 			reg := em.fb.newRegister(reflect.Func)
 			em.fb.emitLoadFunc(true, index, reg)
-			em.fb.emitDefer(reg, int8(numVar), stackShift, args, funTi.Type)
+			em.fb.emitDefer(reg, numVar, stackShift, args, funTi.Type)
 			return regs, types
 		}
-		em.fb.emitCallNative(index, int8(numVar), stackShift, call.Pos())
+		em.fb.emitCallNative(index, numVar, stackShift, call.Pos())
 		return regs, types
 	}
 
 	// Scriggo-defined function (identifier).
 	if ident, ok := call.Func.(*ast.Identifier); ok && !em.fb.declaredInFunc(ident.Name) {
-		if fn, ok := em.fnStore.availableScriggoFn(em.pkg, ident.Name); ok {
+		fn, ok := em.fnStore.methodFn(ident)
+		if !ok {
+			fn, ok = em.fnStore.availableScriggoFn(em.pkg, ident.Name)
+		}
+		if ok {
 			stackShift := em.fb.currentStackShift()
 			regs, types := em.prepareCallParameters(fn.Type, call.Args, callOptions{callHasDots: call.IsVariadic})
 			index := em.fnStore.scriggoFnIndex(fn)
@@ -703,23 +716,23 @@ func (em *emitter) emitCallNode(call *ast.Call, goStmt bool, deferStmt bool, toF
 	}
 	if deferStmt {
 		args := stackDifference(em.fb.currentStackShift(), stackShift)
-		em.fb.emitDefer(reg, int8(runtime.NoVariadicArgs), stackShift, args, funTi.Type)
+		em.fb.emitDefer(reg, int16(runtime.NoVariadicArgs), stackShift, args, funTi.Type)
 		return regs, types
 	}
-	em.fb.emitCallIndirect(reg, int8(runtime.NoVariadicArgs), stackShift, call.Pos(), funTi.Type, toFormat)
+	em.fb.emitCallIndirect(reg, int16(runtime.NoVariadicArgs), stackShift, call.Pos(), funTi.Type, toFormat)
 
 	return regs, types
 }
 
 // emitBuiltin emits instructions for a builtin call, writing the result, if
 // necessary, into the register reg.
-func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
+func (em *emitter) emitBuiltin(call *ast.Call, reg int16, dstType reflect.Type) {
 	args := call.Args
 	switch call.Func.(*ast.Identifier).Name {
 	case "append":
-		// Change the tree if necessary.
+		// Replace the second argument if necessary, without changing the tree.
 		if call.IR.AppendArg1 != nil {
-			call.Args[1] = call.IR.AppendArg1
+			args = append([]ast.Expression{args[0], call.IR.AppendArg1}, args[2:]...)
 		}
 		sliceType := em.typ(args[0])
 		slice := em.emitExpr(args[0], sliceType)
@@ -738,7 +751,7 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 		em.fb.enterStack()
 		tmp := em.fb.newRegister(sliceType.Kind())
 		em.changeRegister(false, slice, tmp, sliceType, sliceType)
-		elems := []int8{}
+		elems := []int16{}
 		for _, argExpr := range args[1:] {
 			elem := em.fb.newRegister(sliceType.Elem().Kind())
 			em.fb.enterStack()
@@ -748,7 +761,7 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 		}
 		// TODO(Gianluca): if len(appendArgs) > 255 split in blocks
 		if len(elems) > 0 {
-			em.fb.emitAppend(elems[0], elems[0]+int8(len(elems)), tmp, sliceType.Elem().Kind())
+			em.fb.emitAppend(elems[0], elems[0]+int16(len(elems)), tmp, sliceType.Elem().Kind(), call.Pos())
 		}
 		em.changeRegister(false, tmp, reg, sliceType, dstType)
 		em.fb.exitStack()
@@ -761,6 +774,9 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 		tmp := em.fb.newRegister(intType.Kind())
 		em.fb.emitCap(s, tmp)
 		em.changeRegister(false, tmp, reg, intType, dstType)
+	case "clear":
+		v := em.emitExpr(args[0], em.typ(args[0]))
+		em.fb.emitClear(v)
 	case "close":
 		chann := em.emitExpr(args[0], em.typ(args[0]))
 		em.fb.emitClose(chann, call.Pos())
@@ -833,7 +849,7 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 			lenExpr := args[1]
 			lenn, kLen := em.emitExprK(lenExpr, intType)
 			var kCap bool
-			var capp int8
+			var capp int16
 			if len(args) == 3 {
 				capArg := args[2]
 				capp, kCap = em.emitExprK(capArg, intType)
@@ -844,7 +860,7 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 			em.fb.emitMakeSlice(kLen, kCap, typ, lenn, capp, reg, call.Pos())
 		case reflect.Chan:
 			var kCapacity bool
-			var capacity int8
+			var capacity int16
 			if len(args) == 1 {
 				capacity = 0
 				kCapacity = true
@@ -856,6 +872,23 @@ func (em *emitter) emitBuiltin(call *ast.Call, reg int8, dstType reflect.Type) {
 		default:
 			panic(internalError("unexpected type %s", typ))
 		}
+	case "max", "min":
+		typ := em.typ(call)
+		em.fb.enterStack()
+		tmp := em.fb.newRegister(typ.Kind())
+		em.emitExprR(args[0], typ, tmp)
+		for _, arg := range args[1:] {
+			em.fb.enterStack()
+			y, ky := em.emitExprK(arg, typ)
+			if call.Func.(*ast.Identifier).Name == "min" {
+				em.fb.emitMin(ky, y, tmp, typ.Kind())
+			} else {
+				em.fb.emitMax(ky, y, tmp, typ.Kind())
+			}
+			em.fb.exitStack()
+		}
+		em.changeRegister(false, tmp, reg, typ, dstType)
+		em.fb.exitStack()
 	case "new":
 		em.fb.emitNew(em.typ(args[0]), reg)
 	case "panic":
@@ -985,7 +1018,7 @@ func (em *emitter) emitCondition(cond ast.Expression) {
 	if ti := em.ti(cond); ti != nil && ti.HasValue() && !ti.IsNative() {
 		// The condition of the 'if' instruction of VM is a binary operation,
 		// so the boolean constant expression 'x' is emitted as 'x == true'.
-		var c int8 = 0
+		var c int16 = 0
 		if ti.value.(int64) == 1 {
 			c = 1
 		}
@@ -1165,7 +1198,7 @@ func (em *emitter) emitCondition(cond ast.Expression) {
 
 // emitComplexOperation emits the operation on the given complex numbers putting
 // the result into the given register.
-func (em *emitter) emitComplexOperation(exprType reflect.Type, expr1 ast.Expression, op ast.OperatorType, expr2 ast.Expression, reg int8, dstType reflect.Type) {
+func (em *emitter) emitComplexOperation(exprType reflect.Type, expr1 ast.Expression, op ast.OperatorType, expr2 ast.Expression, reg int16, dstType reflect.Type) {
 	stackShift := em.fb.currentStackShift()
 	em.fb.enterScope()
 	index := em.fb.complexOperationIndex(op, false)
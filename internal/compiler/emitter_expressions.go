@@ -16,19 +16,19 @@ import (
 // emitExpr emits expr into a register of a given type. emitExpr tries to not
 // create a new register, but to use an existing one. The register used for
 // emission is returned.
-func (em *emitter) emitExpr(expr ast.Expression, dstType reflect.Type) int8 {
+func (em *emitter) emitExpr(expr ast.Expression, dstType reflect.Type) int16 {
 	reg, _ := em._emitExpr(expr, dstType, 0, false, false)
 	return reg
 }
 
 // emitExprK emits expr into a register of a given type. The boolean return
-// parameter reports whether the returned int8 is a constant or not.
-func (em *emitter) emitExprK(expr ast.Expression, dstType reflect.Type) (int8, bool) {
+// parameter reports whether the returned int16 is a constant or not.
+func (em *emitter) emitExprK(expr ast.Expression, dstType reflect.Type) (int16, bool) {
 	return em._emitExpr(expr, dstType, 0, false, true)
 }
 
 // emitExprR emits expr into register reg with the given type.
-func (em *emitter) emitExprR(expr ast.Expression, dstType reflect.Type, reg int8) {
+func (em *emitter) emitExprR(expr ast.Expression, dstType reflect.Type, reg int16) {
 	_, _ = em._emitExpr(expr, dstType, reg, true, false)
 }
 
@@ -43,7 +43,7 @@ func (em *emitter) emitExprR(expr ast.Expression, dstType reflect.Type, reg int8
 //
 // _emitExpr is an internal support method, and should be called by emitExpr,
 // emitExprK and emitExprR exclusively.
-func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8, useGivenReg bool, allowK bool) (int8, bool) {
+func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int16, useGivenReg bool, allowK bool) (int16, bool) {
 
 	// Take the type info of the expression.
 	ti := em.ti(expr)
@@ -57,13 +57,13 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 			case int64:
 				if canEmitDirectly(reflect.Int, dstType.Kind()) {
 					if -128 <= v && v <= 127 {
-						return int8(v), true
+						return int16(v), true
 					}
 				}
 			case float64:
 				if canEmitDirectly(reflect.Float64, dstType.Kind()) {
 					if math.Floor(v) == v && -128 <= v && v <= 127 {
-						return int8(v), true
+						return int16(v), true
 					}
 				}
 			}
@@ -191,7 +191,7 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 			return reg, false
 		}
 
-		var tmp int8
+		var tmp int16
 		if canEmitDirectly(reflect.Func, dstType.Kind()) {
 			tmp = reg
 		} else {
@@ -199,6 +199,7 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 		}
 		fn := &runtime.Function{
 			Pkg:    em.fb.fn.Pkg,
+			Name:   em.macroNames[expr],
 			File:   em.fb.fn.File,
 			Macro:  expr.Type.Macro,
 			Format: expr.Format,
@@ -252,7 +253,11 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 		}
 
 		// Identifier represents a function.
-		if fun, ok := em.fnStore.availableScriggoFn(em.pkg, expr.Name); ok {
+		fun, ok := em.fnStore.methodFn(expr)
+		if !ok {
+			fun, ok = em.fnStore.availableScriggoFn(em.pkg, expr.Name)
+		}
+		if ok {
 			em.fb.emitLoadFunc(false, em.fnStore.scriggoFnIndex(fun), reg)
 			em.changeRegister(false, reg, reg, ti.Type, dstType)
 			return reg, false
@@ -262,8 +267,18 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 
 	case *ast.Index:
 
+		// Instance of a generic function.
+		if ti.replacement != nil {
+			return em._emitExpr(ti.replacement.(ast.Expression), dstType, reg, true, allowK)
+		}
+
 		em.emitIndex(expr, reg, dstType)
 
+	case *ast.IndexList:
+
+		// Instance of a generic function.
+		return em._emitExpr(ti.replacement.(ast.Expression), dstType, reg, true, allowK)
+
 	case *ast.Render:
 
 		// Emit the code that imports the dummy file, then emit the call to the
@@ -275,7 +290,7 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 
 		exprType := em.typ(expr.Expr)
 		src := em.emitExpr(expr.Expr, exprType)
-		var low, high int8 = 0, -1
+		var low, high int16 = 0, -1
 		var kLow, kHigh = true, true
 		// emit low
 		if expr.Low != nil {
@@ -298,7 +313,7 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 			}
 		} else {
 			// If necessary, emit max.
-			var max int8 = -1
+			var max int16 = -1
 			var kMax = true
 			if expr.Max != nil {
 				max, kMax = em.emitExprK(expr.Max, em.typ(expr.Max))
@@ -331,7 +346,7 @@ func (em *emitter) _emitExpr(expr ast.Expression, dstType reflect.Type, reg int8
 
 // emitBinaryOp emits the code for the binary expression expr and stores the
 // result in the register reg of type regType.
-func (em *emitter) emitBinaryOp(expr *ast.BinaryOperator, reg int8, regType reflect.Type) {
+func (em *emitter) emitBinaryOp(expr *ast.BinaryOperator, reg int16, regType reflect.Type) {
 
 	var (
 		ti   = em.ti(expr)
@@ -350,7 +365,7 @@ func (em *emitter) emitBinaryOp(expr *ast.BinaryOperator, reg int8, regType refl
 	// Emit code for the operators && and ||.
 	if op == ast.OperatorAnd || op == ast.OperatorOr {
 		x := reg
-		y := int8(0)
+		y := int16(0)
 		direct := canEmitDirectly(regType.Kind(), reflect.Bool)
 		if !direct {
 			em.fb.enterStack()
@@ -395,12 +410,12 @@ func (em *emitter) emitBinaryOp(expr *ast.BinaryOperator, reg int8, regType refl
 			y = em.emitExpr(expr.Expr2, t2)
 		}
 		if canEmitDirectly(kind, regType.Kind()) {
-			em.fb.emitConcat(x, y, reg)
+			em.fb.emitConcat(x, y, reg, expr.Pos())
 			return
 		}
 		em.fb.enterStack()
 		tmp := em.fb.newRegister(kind)
-		em.fb.emitConcat(x, y, tmp)
+		em.fb.emitConcat(x, y, tmp, expr.Pos())
 		em.changeRegister(false, tmp, reg, typ, regType)
 		em.fb.exitStack()
 		return
@@ -529,7 +544,7 @@ func (em *emitter) emitBinaryOp(expr *ast.BinaryOperator, reg int8, regType refl
 
 }
 
-func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, dstType reflect.Type) (int8, bool) {
+func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int16, dstType reflect.Type) (int16, bool) {
 	typ := em.typ(expr.Type)
 	switch typ.Kind() {
 	case reflect.Slice, reflect.Array:
@@ -547,13 +562,13 @@ func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, ds
 		}
 		if typ.Kind() == reflect.Slice {
 			length := em.compositeLiteralLen(expr)
-			k := length <= 127
+			k := length <= math.MaxInt16
 			if !k {
 				r := em.fb.newRegister(reflect.Int)
 				em.fb.emitLoad(em.fb.makeIntValue(int64(length)), r, reflect.Int)
 				length = int(r)
 			}
-			em.fb.emitMakeSlice(k, k, typ, int8(length), int8(length), workingReg, expr.Pos())
+			em.fb.emitMakeSlice(k, k, typ, int16(length), int16(length), workingReg, expr.Pos())
 		} else {
 			em.fb.emitMakeArray(typ, workingReg)
 		}
@@ -582,10 +597,10 @@ func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, ds
 			}
 			em.fb.enterStack()
 			indexReg := em.fb.newRegister(reflect.Int)
-			if index > 127 {
+			if index > math.MaxInt16 {
 				em.fb.emitLoad(em.fb.makeIntValue(index), indexReg, reflect.Int)
 			} else {
-				em.fb.emitMove(true, int8(index), indexReg, reflect.Int)
+				em.fb.emitMove(true, int16(index), indexReg, reflect.Int)
 			}
 			elem, k := em.emitExprK(kv.Value, typ.Elem())
 			if workingReg != 0 {
@@ -620,7 +635,7 @@ func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, ds
 		}
 		// Assign key-value pairs to the struct fields.
 		em.fb.enterStack()
-		var structt int8
+		var structt int16
 		if canEmitDirectly(typ.Kind(), dstType.Kind()) {
 			structt = em.fb.newRegister(reflect.Struct)
 		} else {
@@ -658,8 +673,8 @@ func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, ds
 		}
 		tmp := em.fb.newRegister(reflect.Map)
 		size := len(expr.KeyValues)
-		if size <= 127 {
-			em.fb.emitMakeMap(typ, true, int8(size), tmp)
+		if size <= math.MaxInt16 {
+			em.fb.emitMakeMap(typ, true, int16(size), tmp)
 		} else {
 			index := em.fb.makeIntValue(int64(size))
 			sizeReg := em.fb.newRegister(reflect.Int)
@@ -680,7 +695,7 @@ func (em *emitter) emitCompositeLiteral(expr *ast.CompositeLiteral, reg int8, ds
 }
 
 // emitIndex emits an index in register reg.
-func (em *emitter) emitIndex(v *ast.Index, reg int8, dstType reflect.Type) {
+func (em *emitter) emitIndex(v *ast.Index, reg int16, dstType reflect.Type) {
 	exprType := em.typ(v.Expr)
 	exprReg := em.emitExpr(v.Expr, exprType)
 	var indexType reflect.Type
@@ -709,7 +724,7 @@ func (em *emitter) emitIndex(v *ast.Index, reg int8, dstType reflect.Type) {
 }
 
 // emitSelector emits selector in register reg.
-func (em *emitter) emitSelector(v *ast.Selector, reg int8, dstType reflect.Type) {
+func (em *emitter) emitSelector(v *ast.Selector, reg int16, dstType reflect.Type) {
 
 	ti := em.ti(v)
 
@@ -732,6 +747,12 @@ func (em *emitter) emitSelector(v *ast.Selector, reg int8, dstType reflect.Type)
 		return
 	}
 
+	// Method value or method expression of a method declared in Scriggo.
+	if ti.replacement != nil {
+		em.emitExprR(ti.replacement.(ast.Expression), dstType, reg)
+		return
+	}
+
 	// Method value on concrete and interface values.
 	if ti.MethodType == methodValueConcrete || ti.MethodType == methodValueInterface {
 		expr := v.Expr
@@ -807,7 +828,7 @@ func (em *emitter) emitSelector(v *ast.Selector, reg int8, dstType reflect.Type)
 
 // emitUnaryOp emits the code for the unary expression expr and stores the
 // result in the register reg of type regType.
-func (em *emitter) emitUnaryOp(expr *ast.UnaryOperator, reg int8, regType reflect.Type) {
+func (em *emitter) emitUnaryOp(expr *ast.UnaryOperator, reg int16, regType reflect.Type) {
 
 	var (
 		exprType    = em.typ(expr)
@@ -917,8 +938,17 @@ func (em *emitter) emitUnaryOp(expr *ast.UnaryOperator, reg int8, regType reflec
 		case *ast.Identifier:
 			if em.fb.declaredInFunc(operand.Name) {
 				r := em.fb.scopeLookup(operand.Name)
-				em.fb.emitNew(em.types.PointerTo(exprType), reg)
-				em.fb.emitMove(false, -r, reg, regType.Kind())
+				if canEmitDirectly(exprKind, regType.Kind()) {
+					em.fb.emitNew(em.types.PointerTo(exprType), reg)
+					em.fb.emitMove(false, -r, reg, regType.Kind())
+					return
+				}
+				em.fb.enterStack()
+				tmp := em.fb.newRegister(exprKind)
+				em.fb.emitNew(em.types.PointerTo(exprType), tmp)
+				em.fb.emitMove(false, -r, tmp, exprKind)
+				em.changeRegister(false, tmp, reg, exprType, regType)
+				em.fb.exitStack()
 				return
 			}
 			// Address of a non-local variable.
@@ -16,6 +16,18 @@ import (
 func (em *emitter) emitNodes(nodes []ast.Node) {
 
 	for _, node := range nodes {
+
+		// Add the statement for the debugger.
+		switch node.(type) {
+		case *ast.Block, *ast.Comment, *ast.Const, *ast.Import, *ast.Label,
+			*ast.Raw, *ast.Statements, *ast.Text, *ast.TypeDeclaration, *ast.URL:
+		default:
+			em.fb.addStatement(node.Pos())
+			if em.coverage {
+				em.emitCoverStatement(node.Pos())
+			}
+		}
+
 		switch node := node.(type) {
 
 		case *ast.Assignment:
@@ -27,18 +39,7 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			em.fb.exitScope()
 
 		case *ast.Break:
-			if em.breakable {
-				if em.breakLabel == nil {
-					label := em.fb.newLabel()
-					em.breakLabel = &label
-				}
-				em.fb.emitGoto(*em.breakLabel)
-			} else {
-				if node.Label != nil {
-					panic(internalError("not implemented"))
-				}
-				em.fb.emitBreak(em.rangeLabels[len(em.rangeLabels)-1])
-			}
+			em.emitBranch(node.Label, true)
 
 		case *ast.Comment:
 			// Nothing to do.
@@ -47,15 +48,7 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			// Nothing to do.
 
 		case *ast.Continue:
-			if node.Label != nil {
-				panic(internalError("not implemented"))
-			}
-			forHead := em.rangeLabels[len(em.rangeLabels)-1]
-			if em.inForRange {
-				em.fb.emitContinue(forHead)
-			} else {
-				em.fb.emitGoto(forHead)
-			}
+			em.emitBranch(node.Label, false)
 
 		case *ast.Defer:
 			call := node.Call.(*ast.Call)
@@ -103,48 +96,32 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			// emitter.emitSwitch.
 
 		case *ast.For:
-			currentBreakable := em.breakable
-			currentBreakLabel := em.breakLabel
-			em.breakable = true
-			em.breakLabel = nil
+			forHead := em.fb.newLabel()
+			forPost := em.fb.newLabel()
+			endForLabel := em.fb.newLabel()
+			em.enterBreakable(endForLabel, forPost, false)
 			em.fb.enterScope()
 			if node.Init != nil {
 				em.emitNodes([]ast.Node{node.Init})
 			}
+			em.fb.setLabelAddr(forHead)
+			em.fb.addStatement(node.Pos())
 			if node.Condition != nil {
-				forHead := em.fb.newLabel()
-				forPost := em.fb.newLabel()
-				em.fb.setLabelAddr(forHead)
 				em.emitCondition(node.Condition)
-				endForLabel := em.fb.newLabel()
 				em.fb.emitGoto(endForLabel)
-				em.rangeLabels = append(em.rangeLabels, forPost)
-				em.emitNodes(node.Body)
-				em.rangeLabels = em.rangeLabels[:len(em.rangeLabels)-1]
-				em.fb.setLabelAddr(forPost)
-				if node.Post != nil {
-					em.emitNodes([]ast.Node{node.Post})
-				}
-				em.fb.emitGoto(forHead)
-				em.fb.setLabelAddr(endForLabel)
-			} else {
-				forLabel := em.fb.newLabel()
-				em.fb.setLabelAddr(forLabel)
-				endForLabel := em.fb.newLabel()
-				em.rangeLabels = append(em.rangeLabels, forLabel)
-				em.emitNodes(node.Body)
-				if node.Post != nil {
-					em.emitNodes([]ast.Node{node.Post})
-				}
-				em.fb.emitGoto(forLabel)
-				em.fb.setLabelAddr(endForLabel)
 			}
-			em.fb.exitScope()
-			if em.breakLabel != nil {
-				em.fb.setLabelAddr(*em.breakLabel)
+			em.emitNodes(node.Body)
+			em.fb.setLabelAddr(forPost)
+			if node.Post != nil {
+				em.emitNodes([]ast.Node{node.Post})
 			}
-			em.breakable = currentBreakable
-			em.breakLabel = currentBreakLabel
+			// The jump has the position of the loop so that it can be
+			// reported if the execution is stopped on the jump.
+			em.fb.addPosAndPath(node.Pos())
+			em.fb.emitGoto(forHead)
+			em.fb.setLabelAddr(endForLabel)
+			em.fb.exitScope()
+			em.exitBreakable()
 
 		case *ast.ForRange:
 			em.emitForRange(node)
@@ -156,6 +133,7 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			em.fb.exitStack()
 
 		case *ast.Goto:
+			em.fb.addPosAndPath(node.Pos())
 			if lab, ok := em.labels[em.fb.fn][node.Label.Name]; ok {
 				em.fb.emitGoto(lab)
 			} else {
@@ -203,6 +181,10 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			em.emitNodes(node.Nodes)
 
 		case *ast.Label:
+			switch node.Statement.(type) {
+			case *ast.For, *ast.ForRange, *ast.Switch, *ast.TypeSwitch, *ast.Select:
+				em.fb.nextLabel = node.Ident.Name
+			}
 			if _, found := em.labels[em.fb.fn][node.Ident.Name]; !found {
 				if em.labels[em.fb.fn] == nil {
 					em.labels[em.fb.fn] = make(map[string]label)
@@ -218,12 +200,15 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			if text := node.Text; text != nil {
 				txt := text.Text[node.Text.Cut.Left : len(text.Text)-text.Cut.Right]
 				if len(txt) != 0 {
-					em.fb.emitText(txt, em.inURL, em.isURLSet)
+					if em.coverage {
+						em.emitCoverText(text)
+					}
+					em.fb.emitText(txt, em.inURL, em.isURLSet, text.Pos())
 				}
 			}
 
 		case *ast.Return:
-			offset := [4]int8{}
+			offset := [4]int16{}
 			// Emit return statements with a function call that returns more
 			// than one value.
 			//
@@ -235,7 +220,7 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			if len(node.Values) == 1 && fnType.NumOut() > 1 {
 				returnedRegs, types := em.emitCallNode(node.Values[0].(*ast.Call), false, false, runtime.ReturnString)
 				for i, typ := range types {
-					var dstReg int8
+					var dstReg int16
 					switch kindToType(typ.Kind()) {
 					case intRegister:
 						offset[0]++
@@ -252,12 +237,12 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 					}
 					em.changeRegister(false, returnedRegs[i], dstReg, typ, fnType.Out(i))
 				}
-				em.fb.emitReturn()
+				em.emitReturn()
 				continue
 			}
 			for i, v := range node.Values {
 				typ := fnType.Out(i)
-				var reg int8
+				var reg int16
 				switch kindToType(typ.Kind()) {
 				case intRegister:
 					offset[0]++
@@ -274,16 +259,14 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 				}
 				em.emitExprR(v, typ, reg)
 			}
-			em.fb.emitReturn()
+			em.emitReturn()
 
 		case *ast.Select:
-			currentBreakable := em.breakable
-			currentBreakLabel := em.breakLabel
-			em.breakable = true
-			em.breakLabel = nil
+			end := em.fb.newLabel()
+			em.enterBreakable(end, 0, false)
 			em.emitSelect(node)
-			em.breakable = currentBreakable
-			em.breakLabel = currentBreakLabel
+			em.exitBreakable()
+			em.fb.setLabelAddr(end)
 
 		case *ast.Send:
 			chanType := em.typ(node.Channel)
@@ -308,43 +291,36 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 					ti := em.ti(expr)
 					em.fb.enterStack()
 					r := em.emitExpr(expr, ti.Type)
-					em.fb.emitShow(ti.Type, r, ctx, em.inURL, em.isURLSet)
+					em.fb.emitShow(ti.Type, r, ctx, em.inURL, em.isURLSet, expr.Pos())
 					em.fb.exitStack()
 				}
 			}
 
 		case *ast.Switch:
-			currentBreakable := em.breakable
-			currentBreakLabel := em.breakLabel
-			em.breakable = true
-			em.breakLabel = nil
+			end := em.fb.newLabel()
+			em.enterBreakable(end, 0, false)
 			em.emitSwitch(node)
-			if em.breakLabel != nil {
-				em.fb.setLabelAddr(*em.breakLabel)
-			}
-			em.breakable = currentBreakable
-			em.breakLabel = currentBreakLabel
+			em.exitBreakable()
+			em.fb.setLabelAddr(end)
 
 		case *ast.Text:
 			txt := node.Text[node.Cut.Left : len(node.Text)-node.Cut.Right]
 			if len(txt) != 0 {
-				em.fb.emitText(txt, em.inURL, em.isURLSet)
+				if em.coverage {
+					em.emitCoverText(node)
+				}
+				em.fb.emitText(txt, em.inURL, em.isURLSet, textPos(node))
 			}
 
 		case *ast.TypeDeclaration:
 			// Nothing to do.
 
 		case *ast.TypeSwitch:
-			currentBreakable := em.breakable
-			currentBreakLabel := em.breakLabel
-			em.breakable = true
-			em.breakLabel = nil
+			end := em.fb.newLabel()
+			em.enterBreakable(end, 0, false)
 			em.emitTypeSwitch(node)
-			if em.breakLabel != nil {
-				em.fb.setLabelAddr(*em.breakLabel)
-			}
-			em.breakable = currentBreakable
-			em.breakLabel = currentBreakLabel
+			em.exitBreakable()
+			em.fb.setLabelAddr(end)
 
 		case *ast.URL:
 			if len(node.Value) == 1 {
@@ -366,13 +342,13 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 			// declaration of a variable on the left side of = would shadow a
 			// variable with the same name on the right (they are two different
 			// variables).
-			varsToBind := make(map[string]int8, len(node.Lhs))
+			varsToBind := make(map[string]int16, len(node.Lhs))
 			for i, v := range node.Lhs {
 				if isBlankIdentifier(v) {
 					addresses[i] = em.addressBlankIdent(v.Pos())
 				} else {
 					staticType := em.typ(v)
-					var varr int8
+					var varr int16
 					if em.varStore.mustBeDeclaredAsIndirect(v) {
 						varr = em.fb.newIndirectRegister()
 						addresses[i] = em.addressNewIndirectVar(varr, staticType, v.Pos(), 0)
@@ -384,8 +360,10 @@ func (em *emitter) emitNodes(nodes []ast.Node) {
 				}
 			}
 			em.assignValuesToAddresses(addresses, node.Rhs)
-			for name, reg := range varsToBind {
-				em.fb.bindVarReg(name, reg)
+			for _, v := range node.Lhs {
+				if reg, ok := varsToBind[v.Name]; ok {
+					em.fb.bindVarReg(v.Name, reg, em.typ(v))
+				}
 			}
 
 		case ast.Expression:
@@ -460,10 +438,19 @@ func (em *emitter) canOptimizeShowMacro(expr ast.Expression, ctx ast.Context) bo
 // emitAssignmentNode emits the instructions for an assignment node.
 func (em *emitter) emitAssignmentNode(node *ast.Assignment) {
 
+	// A macro declared in a template is assigned as a function literal.
+	if node.Type == ast.AssignmentSimple && len(node.Rhs) == 1 {
+		if fn, ok := node.Rhs[0].(*ast.Func); ok && fn.Type.Macro {
+			if ident, ok := node.Lhs[0].(*ast.Identifier); ok {
+				em.macroNames[fn] = ident.Name
+			}
+		}
+	}
+
 	// Emit a short declaration.
 	if node.Type == ast.AssignmentDeclaration {
 		addresses := make([]address, len(node.Lhs))
-		varsToBind := make(map[string]int8, len(node.Lhs))
+		varsToBind := make(map[string]int16, len(node.Lhs))
 		for i, v := range node.Lhs {
 			pos := v.Pos()
 			if isBlankIdentifier(v) {
@@ -490,8 +477,12 @@ func (em *emitter) emitAssignmentNode(node *ast.Assignment) {
 			}
 		}
 		em.assignValuesToAddresses(addresses, node.Rhs)
-		for name, reg := range varsToBind {
-			em.fb.bindVarReg(name, reg)
+		for _, v := range node.Lhs {
+			if v, ok := v.(*ast.Identifier); ok {
+				if reg, ok := varsToBind[v.Name]; ok {
+					em.fb.bindVarReg(v.Name, reg, em.typ(v))
+				}
+			}
 		}
 		return
 	}
@@ -705,9 +696,9 @@ func (em *emitter) emitSelect(selectNode *ast.Select) {
 	// the 'select' statement will be released at the end of it.
 	em.fb.enterStack()
 
-	chs := make([]int8, len(selectNode.Cases))
+	chs := make([]int16, len(selectNode.Cases))
 	ok := em.fb.newRegister(reflect.Bool)
-	value := [4]int8{
+	value := [4]int16{
 		intRegister:     em.fb.newRegister(reflect.Int),
 		floatRegister:   em.fb.newRegister(reflect.Float64),
 		stringRegister:  em.fb.newRegister(reflect.String),
@@ -777,7 +768,7 @@ func (em *emitter) emitSelect(selectNode *ast.Select) {
 			chExpr := receiveExpr.Expr
 			elemType := em.typ(chExpr).Elem()
 			// Split the assignment in the received value and the ok value if this exists.
-			em.fb.bindVarReg("$chanElem", value[kindToType(elemType.Kind())])
+			em.fb.bindVarReg("$chanElem", value[kindToType(elemType.Kind())], nil)
 			pos := chExpr.Pos()
 			valueExpr := ast.NewIdentifier(pos, "$chanElem")
 			em.typeInfos[valueExpr] = em.typeInfos[receiveExpr]
@@ -791,7 +782,7 @@ func (em *emitter) emitSelect(selectNode *ast.Select) {
 				em.typeInfos[okExpr] = &typeInfo{
 					Type: boolType,
 				}
-				em.fb.bindVarReg("$ok", ok)
+				em.fb.bindVarReg("$ok", ok, nil)
 				okAssignment := ast.NewAssignment(pos, assignment.Lhs[1:2], assignment.Type, []ast.Expression{okExpr})
 				em.emitAssignmentNode(okAssignment)
 			}
@@ -820,15 +811,17 @@ func (em *emitter) emitSwitch(node *ast.Switch) {
 		em.emitNodes([]ast.Node{node.Init})
 	}
 
-	var expr int8
+	var expr int16
 	var typ reflect.Type
 
-	if node.Expr == nil {
+	// switchExpr is the switch expression, or 'true' if it is missing.
+	switchExpr := node.Expr
+	if switchExpr == nil {
 		typ = boolType
 		expr = em.fb.newRegister(reflect.Bool)
 		em.fb.emitMove(true, 1, expr, reflect.Bool)
-		node.Expr = ast.NewIdentifier(node.Pos(), "true")
-		em.typeInfos[node.Expr] = &typeInfo{
+		switchExpr = ast.NewIdentifier(node.Pos(), "true")
+		em.typeInfos[switchExpr] = &typeInfo{
 			Constant:   boolConst(true),
 			Type:       boolType,
 			value:      int64(1), // true
@@ -852,7 +845,7 @@ func (em *emitter) emitSwitch(node *ast.Switch) {
 		for _, caseExpr := range cas.Expressions {
 			em.fb.enterStack()
 			pos := caseExpr.Pos()
-			binOp := ast.NewBinaryOperator(pos, ast.OperatorNotEqual, node.Expr, caseExpr)
+			binOp := ast.NewBinaryOperator(pos, ast.OperatorNotEqual, switchExpr, caseExpr)
 			em.typeInfos[binOp] = &typeInfo{
 				Type: boolType,
 			}
@@ -915,10 +908,10 @@ func (em *emitter) emitTypeSwitch(node *ast.TypeSwitch) {
 		guardNewVar = node.Assignment.Lhs[0].(*ast.Identifier).Name
 	}
 
-	var intReg int8
-	var floatReg int8
-	var stringReg int8
-	var generalReg int8
+	var intReg int16
+	var floatReg int16
+	var stringReg int16
+	var generalReg int16
 
 	// Allocate only the necessary register.
 	// Note that 'expr' has already been allocated; these registers are
@@ -964,7 +957,7 @@ func (em *emitter) emitTypeSwitch(node *ast.TypeSwitch) {
 				em.fb.emitIf(false, expr, runtime.ConditionInterfaceNil, 0, reflect.Interface, clause.Expressions[0].Pos())
 			} else {
 				typ := em.ti(clause.Expressions[0]).Type
-				var reg int8
+				var reg int16
 				switch kindToType(typ.Kind()) {
 				case intRegister:
 					reg = intReg
@@ -1015,18 +1008,19 @@ func (em *emitter) emitTypeSwitch(node *ast.TypeSwitch) {
 		em.fb.enterScope()
 		if guardNewVar != "" {
 			if len(clause.Expressions) == 1 && !em.isPredeclNil(clause.Expressions[0]) {
-				switch kindToType(em.ti(clause.Expressions[0]).Type.Kind()) {
+				typ := em.ti(clause.Expressions[0]).Type
+				switch kindToType(typ.Kind()) {
 				case intRegister:
-					em.fb.bindVarReg(guardNewVar, intReg)
+					em.fb.bindVarReg(guardNewVar, intReg, typ)
 				case floatRegister:
-					em.fb.bindVarReg(guardNewVar, floatReg)
+					em.fb.bindVarReg(guardNewVar, floatReg, typ)
 				case stringRegister:
-					em.fb.bindVarReg(guardNewVar, stringReg)
+					em.fb.bindVarReg(guardNewVar, stringReg, typ)
 				case generalRegister:
-					em.fb.bindVarReg(guardNewVar, generalReg)
+					em.fb.bindVarReg(guardNewVar, generalReg, typ)
 				}
 			} else {
-				em.fb.bindVarReg(guardNewVar, expr)
+				em.fb.bindVarReg(guardNewVar, expr, em.typ(guardExpr))
 			}
 		}
 		em.emitNodes(clause.Body)
@@ -1042,14 +1036,38 @@ func (em *emitter) emitTypeSwitch(node *ast.TypeSwitch) {
 // emitForRange emits a for range statement.
 func (em *emitter) emitForRange(node *ast.ForRange) {
 
-	inForRange := em.inForRange
-	em.inForRange = true
-
-	em.fb.enterScope()
-
 	vars := node.Assignment.Lhs
 	expr := node.Assignment.Rhs[0]
 	exprType := em.typ(expr)
+
+	rangeLabel := em.fb.newLabel()
+
+	// If it is the outermost range over a function iterator, allocate the
+	// register used by the return statements in the body.
+	var rf *rangeFunc
+	if exprType.Kind() == reflect.Func && em.fb.rangeFunc == nil {
+		rf = &rangeFunc{label: rangeLabel, ret: em.fb.newRegister(reflect.Bool)}
+		em.fb.emitMove(true, 0, rf.ret, reflect.Bool)
+		em.fb.rangeFunc = rf
+	}
+
+	// If the range statement is within a labelled statement, that is not a
+	// range statement, allocate the register for the exit code.
+	var exit int16
+	for i := len(em.fb.breakables) - 1; i >= 0; i-- {
+		b := em.fb.breakables[i]
+		if b.isRange {
+			break
+		}
+		if b.name != "" {
+			exit = em.fb.newRegister(reflect.Int)
+			em.fb.emitMove(true, 0, exit, reflect.Int)
+			break
+		}
+	}
+
+	em.fb.enterScope()
+
 	exprReg, kExpr := em.emitExprK(expr, exprType)
 	if exprType.Kind() != reflect.String && kExpr {
 		kExpr = false
@@ -1061,21 +1079,21 @@ func (em *emitter) emitForRange(node *ast.ForRange) {
 	// indirect and move values between them before executing the instructions
 	// of the for statement's body.
 
-	var index, elem int8
-	var indirectIndex, indirectElem int8
+	var index, elem int16
+	var indirectIndex, indirectElem int16
 	var indexType, elemType reflect.Type
 
 	if len(vars) >= 1 && !isBlankIdentifier(vars[0]) {
 		name := vars[0].(*ast.Identifier).Name
 		indexType = em.typ(vars[0])
 		if node.Assignment.Type == ast.AssignmentDeclaration {
-			index = em.fb.newRegister(reflect.Int)
+			index = em.fb.newRegister(indexType.Kind())
 			if em.varStore.mustBeDeclaredAsIndirect(vars[0].(*ast.Identifier)) {
 				indirectIndex = em.fb.newIndirectRegister()
 				em.fb.emitNew(indexType, -indirectIndex)
-				em.fb.bindVarReg(name, indirectIndex)
+				em.fb.bindVarReg(name, indirectIndex, indexType)
 			} else {
-				em.fb.bindVarReg(name, index)
+				em.fb.bindVarReg(name, index, indexType)
 			}
 		} else {
 			index = em.fb.scopeLookup(name)
@@ -1090,19 +1108,20 @@ func (em *emitter) emitForRange(node *ast.ForRange) {
 			if em.varStore.mustBeDeclaredAsIndirect(vars[1].(*ast.Identifier)) {
 				indirectElem = em.fb.newIndirectRegister()
 				em.fb.emitNew(elemType, -indirectElem)
-				em.fb.bindVarReg(name, indirectElem)
+				em.fb.bindVarReg(name, indirectElem, elemType)
 			} else {
-				em.fb.bindVarReg(name, elem)
+				em.fb.bindVarReg(name, elem, elemType)
 			}
 		} else {
 			elem = em.fb.scopeLookup(name)
 		}
 	}
 
-	rangeLabel := em.fb.newLabel()
 	em.fb.setLabelAddr(rangeLabel)
+	em.fb.addStatement(node.Pos())
 	endRange := em.fb.newLabel()
-	em.rangeLabels = append(em.rangeLabels, rangeLabel)
+	b := em.enterBreakable(rangeLabel, rangeLabel, true)
+	b.exit = exit
 	em.fb.emitRange(kExpr, exprReg, index, elem, exprType.Kind())
 	em.fb.emitGoto(endRange)
 	em.fb.enterScope()
@@ -1117,10 +1136,9 @@ func (em *emitter) emitForRange(node *ast.ForRange) {
 	em.emitNodes(node.Body)
 	em.fb.emitContinue(rangeLabel)
 	em.fb.setLabelAddr(endRange)
-	em.rangeLabels = em.rangeLabels[:len(em.rangeLabels)-1]
+	em.exitBreakable()
 	em.fb.exitScope()
 	em.fb.exitScope()
-	em.inForRange = inForRange
 
 	if node.Else != nil {
 		endForLabel := em.fb.newLabel()
@@ -1132,4 +1150,99 @@ func (em *emitter) emitForRange(node *ast.ForRange) {
 		em.fb.setLabelAddr(endForLabel)
 	}
 
+	// Jump to the label referred by the break or continue statement, if any,
+	// executed in the body.
+	for i, exit := range b.exits {
+		em.fb.emitIf(true, b.exit, runtime.ConditionNotEqual, int16(i+1), reflect.Int, nil)
+		em.fb.emitGoto(exit)
+	}
+
+	// Return if a return statement has been executed in the body.
+	if rf != nil {
+		em.fb.rangeFunc = nil
+		em.fb.emitIf(false, rf.ret, runtime.ConditionZero, 0, reflect.Bool, nil)
+		em.fb.emitReturn()
+	}
+
+}
+
+// enterBreakable enters a breakable statement. breakLabel and continueLabel
+// are the labels to which the break and continue statements jump, with
+// continueLabel zero if the statement is not a loop.
+func (em *emitter) enterBreakable(breakLabel, continueLabel label, isRange bool) *breakable {
+	b := &breakable{
+		name:          em.fb.nextLabel,
+		isRange:       isRange,
+		breakLabel:    breakLabel,
+		continueLabel: continueLabel,
+	}
+	em.fb.nextLabel = ""
+	em.fb.breakables = append(em.fb.breakables, b)
+	return b
+}
+
+// exitBreakable exits the innermost breakable statement.
+func (em *emitter) exitBreakable() {
+	em.fb.breakables = em.fb.breakables[:len(em.fb.breakables)-1]
+}
+
+// emitBranch emits a break statement, if isBreak is true, or a continue
+// statement. ident is the label of the statement, or nil if it has no label.
+func (em *emitter) emitBranch(ident *ast.Identifier, isBreak bool) {
+	// Find the target statement and the outermost range statement, if any,
+	// within the target.
+	var target, outer *breakable
+	for i := len(em.fb.breakables) - 1; i >= 0; i-- {
+		b := em.fb.breakables[i]
+		if ident == nil && (isBreak || b.continueLabel != 0) || ident != nil && ident.Name == b.name {
+			target = b
+			break
+		}
+		if b.isRange {
+			outer = b
+		}
+	}
+	if target.isRange {
+		if isBreak {
+			em.fb.emitBreak(target.breakLabel)
+		} else {
+			em.fb.emitContinue(target.continueLabel)
+		}
+		return
+	}
+	to := target.breakLabel
+	if !isBreak {
+		to = target.continueLabel
+	}
+	if outer == nil {
+		em.fb.emitGoto(to)
+		return
+	}
+	// Break out of the outermost range statement, which jumps to the label
+	// after it has terminated.
+	code := -1
+	for i, exit := range outer.exits {
+		if exit == to {
+			code = i
+			break
+		}
+	}
+	if code == -1 {
+		code = len(outer.exits)
+		outer.exits = append(outer.exits, to)
+	}
+	em.fb.emitMove(true, int16(code+1), outer.exit, reflect.Int)
+	em.fb.emitBreak(outer.breakLabel)
+}
+
+// emitReturn emits a return instruction. If a return statement is emitted in
+// the body of a range over a function iterator, it breaks out of the range,
+// and the return instruction is executed after the iterator has returned.
+func (em *emitter) emitReturn() {
+	if rf := em.fb.rangeFunc; rf != nil {
+		em.fb.emitMove(true, 1, rf.ret, reflect.Bool)
+		em.fb.emitBreak(rf.label)
+		return
+	}
+	em.fb.emitReturn()
 }
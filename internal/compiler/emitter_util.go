@@ -7,6 +7,7 @@ package compiler
 import (
 	"fmt"
 	"reflect"
+	"unicode"
 
 	"github.com/open2b/scriggo/ast"
 	"github.com/open2b/scriggo/internal/runtime"
@@ -14,19 +15,19 @@ import (
 
 // changeRegister emits the code that move the content of register src to
 // register dst, making a conversion if necessary.
-func (em *emitter) changeRegister(k bool, src, dst int8, srcType reflect.Type, dstType reflect.Type) {
+func (em *emitter) changeRegister(k bool, src, dst int16, srcType reflect.Type, dstType reflect.Type) {
 	em._changeRegister(k, src, dst, srcType, dstType, false)
 }
 
 // changeRegisterConvertFormat behaves like changeRegister but handles a format
 // conversion from a value with type 'markdown' to 'html'.
-func (em *emitter) changeRegisterConvertFormat(k bool, src, dst int8, srcType reflect.Type, dstType reflect.Type) {
+func (em *emitter) changeRegisterConvertFormat(k bool, src, dst int16, srcType reflect.Type, dstType reflect.Type) {
 	em._changeRegister(k, src, dst, srcType, dstType, true)
 }
 
 // _changeRegister should be called only by 'changeRegister' and
 // 'changeRegisterMDToHTML'.
-func (em *emitter) _changeRegister(k bool, src, dst int8, srcType reflect.Type, dstType reflect.Type, mdToHTML bool) {
+func (em *emitter) _changeRegister(k bool, src, dst int16, srcType reflect.Type, dstType reflect.Type, mdToHTML bool) {
 
 	// dst is indirect, so the value must be "typed" to its true (original) type
 	// before putting it into general.
@@ -292,7 +293,7 @@ func (em *emitter) setFunctionVarRefs(fn *runtime.Function, closureVars []ast.Up
 	fn.VarRefs = refs
 }
 
-func (em *emitter) emitValueNotPredefined(ti *typeInfo, reg int8, dstType reflect.Type) (int8, bool) {
+func (em *emitter) emitValueNotPredefined(ti *typeInfo, reg int16, dstType reflect.Type) (int16, bool) {
 	typ := ti.Type
 	if reg == 0 {
 		return reg, false
@@ -380,7 +381,7 @@ func (em *emitter) emitValueNotPredefined(ti *typeInfo, reg int8, dstType reflec
 // emitComparison emits the comparison expression x op y as a sequence of
 // instructions where the last one is an 'if' instruction. ky indicates if y
 // is a constant.
-func (em *emitter) emitComparison(op ast.OperatorType, ky bool, x, y int8, tx, ty reflect.Type, pos *ast.Position) {
+func (em *emitter) emitComparison(op ast.OperatorType, ky bool, x, y int16, tx, ty reflect.Type, pos *ast.Position) {
 	xKind := tx.Kind()
 	yKind := ty.Kind()
 	var condition runtime.Condition
@@ -452,7 +453,7 @@ func (em *emitter) emitComparison(op ast.OperatorType, ky bool, x, y int8, tx, t
 // it emits 'x not contains y'. ky indicates if y is a constant.
 //
 // ty is nil if the expression is 'x contains nil' or 'x not contains nil'.
-func (em *emitter) emitContains(not, ky bool, x, y int8, tx, ty reflect.Type, pos *ast.Position) {
+func (em *emitter) emitContains(not, ky bool, x, y int16, tx, ty reflect.Type, pos *ast.Position) {
 	var condition runtime.Condition
 	var t reflect.Type
 	switch tx.Kind() {
@@ -503,3 +504,69 @@ func convertPosition(pos *ast.Position) *runtime.Position {
 		End:    pos.End,
 	}
 }
+
+// emitCoverStatement emits a Cover instruction for the statement at the
+// given position. Its block goes from the start of the statement to its end,
+// if it ends on the same line, or to the end of the line.
+func (em *emitter) emitCoverStatement(pos *ast.Position) {
+	em.emitCover(pos.Line, pos.Column, pos.Column+pos.End-pos.Start+1)
+}
+
+// emitCoverText emits a Cover instruction for each line of text, excluding
+// the cut and the white space, that is not empty.
+func (em *emitter) emitCoverText(text *ast.Text) {
+	line, column := text.Line, text.Column
+	start, end := 0, 0 // columns of the first and after the last non-space character of the line.
+	txt := text.Text[:len(text.Text)-text.Cut.Right]
+	for i, r := range string(txt) {
+		if r == '\n' {
+			if start > 0 {
+				em.emitCover(line, start, end)
+			}
+			line, column = line+1, 1
+			start = 0
+			continue
+		}
+		if i >= text.Cut.Left && !unicode.IsSpace(r) {
+			if start == 0 {
+				start = column
+			}
+			end = column + 1
+		}
+		column++
+	}
+	if start > 0 {
+		em.emitCover(line, start, end)
+	}
+}
+
+// textPos returns the position of the first byte of text after the cut.
+func textPos(text *ast.Text) *ast.Position {
+	if text.Cut.Left == 0 {
+		return text.Pos()
+	}
+	pos := &ast.Position{
+		Line:   text.Line,
+		Column: text.Column,
+		Start:  text.Start + text.Cut.Left,
+		End:    text.End,
+	}
+	for _, r := range string(text.Text[:text.Cut.Left]) {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
+// emitCover emits a Cover instruction for a new block that, in the current
+// path, is on the given line and goes from column start to column end
+// excluded.
+func (em *emitter) emitCover(line, start, end int) {
+	block := len(em.coverBlocks)
+	em.fb.emitCover(block)
+	em.coverBlocks = append(em.coverBlocks, CoverBlock{Path: em.fb.path, Line: line, Column: start, EndColumn: end})
+}
@@ -0,0 +1,1318 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	goruntime "runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/compiler/types"
+	"github.com/open2b/scriggo/internal/runtime"
+	"github.com/open2b/scriggo/native"
+)
+
+// encodingVersion is the version of the encoding. It must be incremented
+// every time the encoding, the instruction set or the semantic of an
+// instruction changes.
+const encodingVersion = 4
+
+// encodingMagic is the prefix of every encoded code.
+const encodingMagic = "\x00scriggo"
+
+// ErrIncompatibleEncoding is returned by Decode when the code has been
+// encoded by a different version of Scriggo or Go, or when a native
+// declaration referenced by the code does not exist anymore or has changed.
+var ErrIncompatibleEncoding = errors.New("scriggo: incompatible encoding")
+
+// encodingHeader is the header of an encoded code. Decode rejects the code if
+// its header is not equal to currentEncodingHeader.
+type encodingHeader struct {
+	Version int    // encoding version.
+	Go      string // Go version.
+	Scriggo string // Scriggo module version.
+}
+
+var currentEncodingHeader = encodingHeader{
+	Version: encodingVersion,
+	Go:      goruntime.Version(),
+	Scriggo: scriggoVersion(),
+}
+
+// scriggoVersion returns the version of the Scriggo module the executable
+// has been built with or, if it is not available, the empty string.
+func scriggoVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	const path = "github.com/open2b/scriggo"
+	mod := &info.Main
+	if mod.Path != path {
+		mod = nil
+		for _, dep := range info.Deps {
+			if dep.Path == path {
+				mod = dep
+				break
+			}
+		}
+		if mod == nil {
+			return ""
+		}
+	}
+	if mod.Replace != nil {
+		mod = mod.Replace
+	}
+	version := mod.Version
+	if mod.Sum != "" {
+		version += " " + mod.Sum
+	}
+	if mod == &info.Main {
+		// Scriggo is the main module; also use the revision, if available, so
+		// that development builds are distinguished.
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.time" {
+				version += " " + s.Value
+			}
+		}
+	}
+	return version
+}
+
+// encodedCode is the encoded form of a Code. In the encoded form, types and
+// functions are referenced by their index in Types and Functions plus one,
+// so zero represents a nil type or function.
+type encodedCode struct {
+	Packages  []string
+	Types     []encodedType
+	Functions []encodedFunction
+	Main      int
+	Exported  []encodedExported
+	Globals   []encodedGlobal
+	Coverage  []CoverBlock
+}
+
+// encodedType is the encoded form of a type.
+//
+// Native types that cannot be created with the reflect package, as the
+// defined types, are looked up by Key in the native declarations.
+// Types defined in Scriggo have a Name and their underlying type is Elem.
+// All the other types are created from their kind and their elements.
+type encodedType struct {
+	Kind     reflect.Kind
+	Key      string
+	Name     string
+	Elem     int
+	MapKey   int
+	Len      int
+	Dir      reflect.ChanDir
+	In       []int
+	Out      []int
+	Variadic bool
+	Fields   []encodedField
+	Methods  []encodedMethod
+}
+
+// encodedField is the encoded form of a struct field.
+type encodedField struct {
+	Name      string
+	PkgPath   string
+	Type      int
+	Tag       reflect.StructTag
+	Anonymous bool
+}
+
+// encodedMethod is the encoded form of an interface method or of a method
+// declared in Scriggo on a defined type.
+type encodedMethod struct {
+	Name    string
+	PkgPath string
+	Type    int
+	Pointer bool
+	Func    int
+}
+
+// encodedFunction is the encoded form of a runtime.Function.
+type encodedFunction struct {
+	Pkg             string
+	Name            string
+	File            string
+	Pos             *runtime.Position
+	Type            int
+	Parent          int
+	VarRefs         []int16
+	Types           []int
+	NumReg          [4]int16
+	FinalRegs       [][2]int16
+	Macro           bool
+	Format          ast.Format
+	Ints            []int64
+	Floats          []float64
+	Strings         []string
+	Generals        []encodedValue
+	FieldIndexes    [][]int
+	Functions       []int
+	NativeFunctions []encodedNativeFunction
+	Body            []byte // eight bytes for each instruction.
+	Text            [][]byte
+	InstructionInfo []encodedInstructionInfo
+	Statements      []runtime.Statement
+	LocalVars       []encodedLocalVar
+}
+
+// encodedValue is the encoded form of a general value. If Type is zero, the
+// value is the invalid value, otherwise it is a complex value, if Type is a
+// complex type, or the zero value of Type.
+type encodedValue struct {
+	Type    int
+	Complex complex128
+}
+
+// encodedInstructionInfo is the encoded form of the information of the
+// instruction at address Addr.
+type encodedInstructionInfo struct {
+	Addr        runtime.Addr
+	Position    runtime.Position
+	Path        string
+	OperandKind [3]reflect.Kind
+	FuncType    int
+}
+
+// encodedLocalVar is the encoded form of a runtime.LocalVar.
+type encodedLocalVar struct {
+	Name  string
+	Type  int
+	Reg   int16
+	Start runtime.Addr
+	End   runtime.Addr
+}
+
+// encodedNativeFunction is the encoded form of a runtime.NativeFunction.
+type encodedNativeFunction struct {
+	Pkg  string
+	Name string
+	Func nativeRef
+	Type int
+}
+
+// encodedExported is the encoded form of an exported function of a program.
+type encodedExported struct {
+	Name string
+	Func int
+}
+
+// encodedGlobal is the encoded form of a Global. Value, if not nil,
+// references the native variable of the global.
+type encodedGlobal struct {
+	Pkg   string
+	Name  string
+	Type  int
+	Value *nativeRef
+}
+
+// A nativeRefKind is the kind of reference to a native value.
+type nativeRefKind int8
+
+const (
+	declarationRef nativeRefKind = iota // native declaration
+	methodRef                           // method of a native type
+	complexRef                          // function of a complex operation
+	builtinRef                          // builtin called by the defer and go statements
+	nilRef                              // nil function
+)
+
+// nativeRef references a native value.
+//
+// A declaration is referenced by the path of its package, or by the empty
+// string if it is a global, and by its name. Pkg is the name of the package
+// in the globals that contains the declaration, if there is one. A method is
+// referenced by the receiver type and the method name.
+type nativeRef struct {
+	Kind nativeRefKind
+	Path string
+	Pkg  string
+	Name string
+	Type int
+}
+
+func (ref nativeRef) String() string {
+	s := ref.Name
+	if ref.Pkg != "" {
+		s = ref.Pkg + "." + s
+	}
+	if ref.Path != "" {
+		s = ref.Path + "." + s
+	}
+	return s
+}
+
+// less reports whether ref is less than ref2, so that the choice among
+// different references to the same value is deterministic.
+func (ref nativeRef) less(ref2 nativeRef) bool {
+	if ref.Path != ref2.Path {
+		return ref.Path < ref2.Path
+	}
+	if ref.Pkg != ref2.Pkg {
+		return ref.Pkg < ref2.Pkg
+	}
+	return ref.Name < ref2.Name
+}
+
+// complexFunctions contains the functions of the complex operations, indexed
+// by name. See the complexOperationIndex method.
+var complexFunctions = map[string]interface{}{
+	"neg": negComplex,
+	"add": addComplex,
+	"sub": subComplex,
+	"mul": mulComplex,
+	"div": divComplex,
+}
+
+// deferGoBuiltins contains, sorted, the names of the builtins that can be
+// called by the defer and go statements. See the deferGoBuiltin function.
+var deferGoBuiltins = []string{"clear", "close", "copy", "delete", "panic", "print", "println", "recover"}
+
+// builtinTypes contains the native types that are not declared by the
+// packages but can be referenced by the code.
+var builtinTypes = []reflect.Type{
+	boolType,
+	intType,
+	reflect.TypeOf(int8(0)),
+	reflect.TypeOf(int16(0)),
+	int32Type,
+	reflect.TypeOf(int64(0)),
+	uintType,
+	uint8Type,
+	reflect.TypeOf(uint16(0)),
+	reflect.TypeOf(uint32(0)),
+	reflect.TypeOf(uint64(0)),
+	reflect.TypeOf(uintptr(0)),
+	float32Type,
+	float64Type,
+	complex64Type,
+	complex128Type,
+	stringType,
+	errorType,
+	envType,
+	timeType,
+	stringerType,
+	envStringerType,
+	htmlStringerType,
+	htmlEnvStringerType,
+	cssStringerType,
+	cssEnvStringerType,
+	jsStringerType,
+	jsEnvStringerType,
+	jsonStringerType,
+	jsonEnvStringerType,
+	mdStringerType,
+	mdEnvStringerType,
+	reflect.TypeOf(native.HTML("")),
+	reflect.TypeOf(native.CSS("")),
+	reflect.TypeOf(native.JS("")),
+	reflect.TypeOf(native.JSON("")),
+	reflect.TypeOf(native.Markdown("")),
+}
+
+// nativeTypeKey returns the key used to look up the native type t in the
+// native declarations. If t can be created with the reflect package, it
+// returns false.
+func nativeTypeKey(t reflect.Type) (string, bool) {
+	if name := t.Name(); name != "" {
+		return t.PkgPath() + "." + name, true
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return t.String(), true
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				return t.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// nativeDecl is a native declaration with its reference.
+type nativeDecl struct {
+	ref   nativeRef
+	value reflect.Value
+}
+
+// nativeIndex indexes the native declarations that can be referenced by a
+// code: the declarations of the imported packages and the globals.
+//
+// The packages are read only the first time a declaration is looked up.
+type nativeIndex struct {
+	importer native.Importer
+	globals  native.Declarations
+	packages []string
+
+	built     bool
+	imported  map[string]native.ImportablePackage
+	types     map[string]reflect.Type
+	ambiguous map[string]bool
+	visited   map[reflect.Type]bool
+	funcs     map[uintptr][]nativeDecl
+	vars      map[uintptr][]nativeDecl
+}
+
+// newNativeIndex returns a new native index for the given packages, imported
+// with importer, and globals.
+func newNativeIndex(importer native.Importer, globals native.Declarations, packages []string) *nativeIndex {
+	idx := &nativeIndex{
+		importer:  importer,
+		globals:   globals,
+		packages:  packages,
+		imported:  map[string]native.ImportablePackage{},
+		types:     map[string]reflect.Type{},
+		ambiguous: map[string]bool{},
+		visited:   map[reflect.Type]bool{},
+	}
+	for _, t := range builtinTypes {
+		idx.addType(t)
+	}
+	return idx
+}
+
+// importPackage imports the package with the given path.
+func (idx *nativeIndex) importPackage(path string) (native.ImportablePackage, error) {
+	if pkg, ok := idx.imported[path]; ok {
+		return pkg, nil
+	}
+	var pkg native.ImportablePackage
+	if idx.importer != nil {
+		var err error
+		pkg, err = idx.importer.Import(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("%w: cannot find package %q", ErrIncompatibleEncoding, path)
+	}
+	idx.imported[path] = pkg
+	return pkg, nil
+}
+
+// build builds the index reading the declarations of the packages and the
+// globals. It does nothing if the index has already been built.
+func (idx *nativeIndex) build() error {
+	if idx.built {
+		return nil
+	}
+	idx.built = true
+	idx.funcs = map[uintptr][]nativeDecl{}
+	idx.vars = map[uintptr][]nativeDecl{}
+	for _, path := range idx.packages {
+		pkg, err := idx.importPackage(path)
+		if err != nil {
+			return err
+		}
+		err = pkg.LookupFunc(func(name string, decl native.Declaration) error {
+			idx.addDecl(nativeRef{Path: path, Name: name}, decl)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for name, decl := range idx.globals {
+		if pkg, ok := decl.(native.ImportablePackage); ok {
+			err := pkg.LookupFunc(func(n string, decl native.Declaration) error {
+				idx.addDecl(nativeRef{Pkg: name, Name: n}, decl)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		idx.addDecl(nativeRef{Name: name}, decl)
+	}
+	return nil
+}
+
+// addDecl adds the declaration decl with reference ref to the index.
+func (idx *nativeIndex) addDecl(ref nativeRef, decl native.Declaration) {
+	switch d := decl.(type) {
+	case nil, native.ImportablePackage,
+		native.UntypedBooleanConst, native.UntypedStringConst, native.UntypedNumericConst:
+	case reflect.Type:
+		idx.addType(d)
+	default:
+		v := reflect.ValueOf(d)
+		switch v.Kind() {
+		case reflect.Func:
+			p := v.Pointer()
+			idx.funcs[p] = append(idx.funcs[p], nativeDecl{ref, v})
+			idx.addType(v.Type())
+		case reflect.Ptr:
+			if !v.IsNil() {
+				p := v.Pointer()
+				idx.vars[p] = append(idx.vars[p], nativeDecl{ref, v})
+			}
+			idx.addType(v.Type().Elem())
+		default:
+			idx.addType(v.Type())
+		}
+	}
+}
+
+// addType adds t, and the types reachable from t, to the index.
+func (idx *nativeIndex) addType(t reflect.Type) {
+	if idx.visited[t] {
+		return
+	}
+	idx.visited[t] = true
+	if key, ok := nativeTypeKey(t); ok {
+		if t2, ok := idx.types[key]; ok && t2 != t {
+			idx.ambiguous[key] = true
+		}
+		idx.types[key] = t
+	}
+	switch t.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Ptr, reflect.Slice:
+		idx.addType(t.Elem())
+	case reflect.Map:
+		idx.addType(t.Key())
+		idx.addType(t.Elem())
+	case reflect.Func:
+		for i := 0; i < t.NumIn(); i++ {
+			idx.addType(t.In(i))
+		}
+		for i := 0; i < t.NumOut(); i++ {
+			idx.addType(t.Out(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			idx.addType(t.Field(i).Type)
+		}
+	}
+	if t.Name() != "" {
+		for i := 0; i < t.NumMethod(); i++ {
+			idx.addType(t.Method(i).Type)
+		}
+		if t.Kind() != reflect.Interface && t.Kind() != reflect.Ptr {
+			idx.addType(reflect.PointerTo(t))
+		}
+	}
+}
+
+// lookupType looks up the native type with the given key.
+func (idx *nativeIndex) lookupType(key string) (reflect.Type, error) {
+	t, ok := idx.types[key]
+	if !ok {
+		err := idx.build()
+		if err != nil {
+			return nil, err
+		}
+		t, ok = idx.types[key]
+		if !ok {
+			return nil, fmt.Errorf("type %s is not reachable from the native declarations", key)
+		}
+	}
+	if idx.ambiguous[key] {
+		return nil, fmt.Errorf("type %s is ambiguous in the native declarations", key)
+	}
+	return t, nil
+}
+
+// lookupDecl looks up the declaration referenced by ref.
+func (idx *nativeIndex) lookupDecl(ref nativeRef) (native.Declaration, error) {
+	var decl native.Declaration
+	switch {
+	case ref.Path != "":
+		pkg, err := idx.importPackage(ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		decl = pkg.Lookup(ref.Name)
+	case ref.Pkg != "":
+		if pkg, ok := idx.globals[ref.Pkg].(native.ImportablePackage); ok {
+			decl = pkg.Lookup(ref.Name)
+		}
+	default:
+		decl = idx.globals[ref.Name]
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("%w: %s is not declared", ErrIncompatibleEncoding, ref)
+	}
+	return decl, nil
+}
+
+// encodingError is used to panic with an error during the encoding.
+type encodingError struct {
+	err error
+}
+
+// Encode encodes code. The native declarations referenced by code must be
+// declared in the imported packages, read from importer, or in globals.
+//
+// The returned data can be decoded by Decode with the same importer and
+// globals and, as Go and Scriggo versions are encoded, by an executable built
+// with the same versions.
+func Encode(code *Code, importer native.Importer, globals native.Declarations) (_ []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(encodingError); ok {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+	e := &encoder{
+		natives: newNativeIndex(importer, globals, code.Packages),
+		types:   map[reflect.Type]int{},
+		funcs:   map[*runtime.Function]int{},
+	}
+	e.code.Packages = code.Packages
+	e.code.Coverage = code.Coverage
+	e.code.Main = e.encodeFunction(code.Main)
+	names := make([]string, 0, len(code.Functions))
+	for name := range code.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e.code.Exported = append(e.code.Exported, encodedExported{
+			Name: name,
+			Func: e.encodeFunction(code.Functions[name]),
+		})
+	}
+	e.code.Globals = make([]encodedGlobal, len(code.Globals))
+	for i, global := range code.Globals {
+		e.code.Globals[i] = e.encodeGlobal(global)
+	}
+	var b bytes.Buffer
+	b.WriteString(encodingMagic)
+	enc := gob.NewEncoder(&b)
+	err = enc.Encode(currentEncodingHeader)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Encode(&e.code)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encoder encodes a code.
+type encoder struct {
+	natives *nativeIndex
+	types   map[reflect.Type]int
+	funcs   map[*runtime.Function]int
+	code    encodedCode
+}
+
+// errorf panics with an encoding error.
+func (e *encoder) errorf(format string, a ...interface{}) {
+	panic(encodingError{fmt.Errorf("scriggo: cannot encode: "+format, a...)})
+}
+
+// encodeFunction encodes fn, if it has not already been encoded, and
+// returns its reference.
+func (e *encoder) encodeFunction(fn *runtime.Function) int {
+	if fn == nil {
+		return 0
+	}
+	if id, ok := e.funcs[fn]; ok {
+		return id
+	}
+	e.code.Functions = append(e.code.Functions, encodedFunction{})
+	id := len(e.code.Functions)
+	e.funcs[fn] = id
+	ef := encodedFunction{
+		Pkg:          fn.Pkg,
+		Name:         fn.Name,
+		File:         fn.File,
+		Pos:          fn.Pos,
+		Type:         e.encodeType(fn.Type),
+		Parent:       e.encodeFunction(fn.Parent),
+		VarRefs:      fn.VarRefs,
+		NumReg:       fn.NumReg,
+		FinalRegs:    fn.FinalRegs,
+		Macro:        fn.Macro,
+		Format:       fn.Format,
+		Ints:         fn.Values.Int,
+		Floats:       fn.Values.Float,
+		Strings:      fn.Values.String,
+		FieldIndexes: fn.FieldIndexes,
+		Text:         fn.Text,
+	}
+	if fn.Types != nil {
+		ef.Types = make([]int, len(fn.Types))
+		for i, t := range fn.Types {
+			ef.Types[i] = e.encodeType(t)
+		}
+	}
+	if fn.Values.General != nil {
+		ef.Generals = make([]encodedValue, len(fn.Values.General))
+		for i, v := range fn.Values.General {
+			ef.Generals[i] = e.encodeValue(v)
+		}
+	}
+	if fn.Functions != nil {
+		ef.Functions = make([]int, len(fn.Functions))
+		for i, f := range fn.Functions {
+			ef.Functions[i] = e.encodeFunction(f)
+		}
+	}
+	if fn.NativeFunctions != nil {
+		ef.NativeFunctions = make([]encodedNativeFunction, len(fn.NativeFunctions))
+		for i, f := range fn.NativeFunctions {
+			ef.NativeFunctions[i] = e.encodeNativeFunction(f)
+		}
+	}
+	ef.Body = make([]byte, 8*len(fn.Body))
+	for i, in := range fn.Body {
+		b := ef.Body[8*i:]
+		binary.LittleEndian.PutUint16(b, uint16(in.Op))
+		binary.LittleEndian.PutUint16(b[2:], uint16(in.A))
+		binary.LittleEndian.PutUint16(b[4:], uint16(in.B))
+		binary.LittleEndian.PutUint16(b[6:], uint16(in.C))
+	}
+	if len(fn.InstructionInfo) > 0 {
+		ef.InstructionInfo = make([]encodedInstructionInfo, 0, len(fn.InstructionInfo))
+		for addr, info := range fn.InstructionInfo {
+			ef.InstructionInfo = append(ef.InstructionInfo, encodedInstructionInfo{
+				Addr:        addr,
+				Position:    info.Position,
+				Path:        info.Path,
+				OperandKind: info.OperandKind,
+				FuncType:    e.encodeType(info.FuncType),
+			})
+		}
+		sort.Slice(ef.InstructionInfo, func(i, j int) bool {
+			return ef.InstructionInfo[i].Addr < ef.InstructionInfo[j].Addr
+		})
+	}
+	ef.Statements = fn.Statements
+	if fn.LocalVars != nil {
+		ef.LocalVars = make([]encodedLocalVar, len(fn.LocalVars))
+		for i, v := range fn.LocalVars {
+			ef.LocalVars[i] = encodedLocalVar{
+				Name:  v.Name,
+				Type:  e.encodeType(v.Type),
+				Reg:   v.Reg,
+				Start: v.Start,
+				End:   v.End,
+			}
+		}
+	}
+	e.code.Functions[id-1] = ef
+	return id
+}
+
+// encodeType encodes t, if it has not already been encoded, and returns its
+// reference.
+func (e *encoder) encodeType(t reflect.Type) int {
+	if t == nil {
+		return 0
+	}
+	if id, ok := e.types[t]; ok {
+		return id
+	}
+	et := encodedType{Kind: t.Kind()}
+	_, isScriggo := t.(runtime.ScriggoType)
+	switch {
+	case !isScriggo:
+		if key, ok := nativeTypeKey(t); ok {
+			t2, err := e.natives.lookupType(key)
+			if err != nil {
+				e.errorf("%s", err)
+			}
+			if t2 != t {
+				e.errorf("type %s is not the type declared by the native declarations", t)
+			}
+			et.Key = key
+			break
+		}
+		e.encodeElements(t, &et)
+	case types.IsDefined(t):
+		// The methods are encoded after the type because they can refer to it.
+		et.Name = t.Name()
+		et.Elem = e.encodeType(types.Underlying(t))
+		e.code.Types = append(e.code.Types, et)
+		id := len(e.code.Types)
+		e.types[t] = id
+		var methods []encodedMethod
+		for _, m := range types.Methods(t) {
+			methods = append(methods, encodedMethod{
+				Name:    m.Name,
+				Type:    e.encodeType(m.Type),
+				Pointer: m.Pointer,
+				Func:    e.encodeFunction(m.Func),
+			})
+		}
+		e.code.Types[id-1].Methods = methods
+		return id
+	default:
+		e.encodeElements(t, &et)
+	}
+	e.code.Types = append(e.code.Types, et)
+	id := len(e.code.Types)
+	e.types[t] = id
+	return id
+}
+
+// encodeElements encodes in et the elements of the type t.
+func (e *encoder) encodeElements(t reflect.Type, et *encodedType) {
+	switch t.Kind() {
+	case reflect.Array:
+		et.Len = t.Len()
+		et.Elem = e.encodeType(t.Elem())
+	case reflect.Chan:
+		et.Dir = t.ChanDir()
+		et.Elem = e.encodeType(t.Elem())
+	case reflect.Func:
+		et.In = make([]int, t.NumIn())
+		for i := range et.In {
+			et.In[i] = e.encodeType(t.In(i))
+		}
+		et.Out = make([]int, t.NumOut())
+		for i := range et.Out {
+			et.Out[i] = e.encodeType(t.Out(i))
+		}
+		et.Variadic = t.IsVariadic()
+	case reflect.Interface:
+		et.Methods = make([]encodedMethod, t.NumMethod())
+		for i := range et.Methods {
+			m := t.Method(i)
+			et.Methods[i] = encodedMethod{Name: m.Name, PkgPath: m.PkgPath, Type: e.encodeType(m.Type)}
+		}
+	case reflect.Map:
+		et.MapKey = e.encodeType(t.Key())
+		et.Elem = e.encodeType(t.Elem())
+	case reflect.Ptr, reflect.Slice:
+		et.Elem = e.encodeType(t.Elem())
+	case reflect.Struct:
+		et.Fields = make([]encodedField, t.NumField())
+		for i := range et.Fields {
+			f := t.Field(i)
+			et.Fields[i] = encodedField{
+				Name:      f.Name,
+				PkgPath:   f.PkgPath,
+				Type:      e.encodeType(f.Type),
+				Tag:       f.Tag,
+				Anonymous: f.Anonymous,
+			}
+		}
+	default:
+		e.errorf("unexpected type %s", t)
+	}
+}
+
+// encodeValue encodes the general value v.
+func (e *encoder) encodeValue(v reflect.Value) encodedValue {
+	if !v.IsValid() {
+		return encodedValue{}
+	}
+	ev := encodedValue{Type: e.encodeType(v.Type())}
+	switch {
+	case v.Kind() == reflect.Complex64 || v.Kind() == reflect.Complex128:
+		ev.Complex = v.Complex()
+	case !v.IsZero():
+		e.errorf("unexpected value of type %s", v.Type())
+	}
+	return ev
+}
+
+// encodeNativeFunction encodes the native function fn.
+func (e *encoder) encodeNativeFunction(fn *runtime.NativeFunction) encodedNativeFunction {
+	v := reflect.ValueOf(fn.Func())
+	enf := encodedNativeFunction{
+		Pkg:  fn.Package(),
+		Name: fn.Name(),
+		Type: e.encodeType(v.Type()),
+	}
+	if v.IsNil() {
+		enf.Func = nativeRef{Kind: nilRef}
+		return enf
+	}
+	p := v.Pointer()
+	if f, ok := complexFunctions[fn.Name()]; ok && fn.Package() == "scriggo.complex" && reflect.ValueOf(f).Pointer() == p {
+		enf.Func = nativeRef{Kind: complexRef, Name: fn.Name()}
+		return enf
+	}
+	if fn.Package() == "" {
+		for _, name := range deferGoBuiltins {
+			if name == fn.Name() && deferGoBuiltin(name).value.(reflect.Value).Pointer() == p {
+				enf.Func = nativeRef{Kind: builtinRef, Name: name}
+				return enf
+			}
+		}
+	}
+	err := e.natives.build()
+	if err != nil {
+		e.errorf("%s", err)
+	}
+	// Look for the declaration of the function. Different functions may have
+	// the same pointer, as the method values, so the name is also used.
+	if ref, ok := chooseDecl(e.natives.funcs[p], fn.Name(), v.Type()); ok {
+		enf.Func = ref
+		return enf
+	}
+	// Look for a method of the type of the first parameter, as it happens
+	// for method expressions and calls of methods of native types.
+	if t := v.Type(); t.NumIn() > 0 {
+		recv := t.In(0)
+		if recv.Kind() == reflect.Interface {
+			// The functions of the method expressions of an interface type
+			// have all the same pointer, so the method is chosen by name or,
+			// if the name is not available, by type.
+			var name string
+			n := 0
+			for i := 0; i < recv.NumMethod(); i++ {
+				m := recv.Method(i)
+				if interfaceMethodExpr(recv, m).Type() != t {
+					continue
+				}
+				if m.Name == fn.Name() {
+					name, n = m.Name, 1
+					break
+				}
+				name = m.Name
+				n++
+			}
+			if n == 1 {
+				enf.Func = nativeRef{Kind: methodRef, Name: name, Type: e.encodeType(recv)}
+				return enf
+			}
+		} else {
+			for i := 0; i < recv.NumMethod(); i++ {
+				if m := recv.Method(i); m.Func.Pointer() == p && m.Func.Type() == t {
+					enf.Func = nativeRef{Kind: methodRef, Name: m.Name, Type: e.encodeType(recv)}
+					return enf
+				}
+			}
+		}
+	}
+	name := fn.Name()
+	if fn.Package() != "" {
+		name = fn.Package() + "." + name
+	}
+	e.errorf("native function %s is not declared in the native declarations", name)
+	return enf
+}
+
+// encodeGlobal encodes a global.
+func (e *encoder) encodeGlobal(global Global) encodedGlobal {
+	eg := encodedGlobal{
+		Pkg:  global.Pkg,
+		Name: global.Name,
+		Type: e.encodeType(global.Type),
+	}
+	if !global.Value.IsValid() {
+		return eg
+	}
+	if !global.Value.CanAddr() {
+		e.errorf("value of variable %s.%s is not addressable", global.Pkg, global.Name)
+	}
+	err := e.natives.build()
+	if err != nil {
+		e.errorf("%s", err)
+	}
+	ref, ok := chooseDecl(e.natives.vars[global.Value.Addr().Pointer()], global.Name, reflect.PointerTo(global.Value.Type()))
+	if !ok {
+		e.errorf("variable %s.%s is not declared in the native declarations", global.Pkg, global.Name)
+	}
+	eg.Value = &ref
+	return eg
+}
+
+// chooseDecl chooses, among decls, the declaration with type t and, if there
+// is more than one, with the given name. It returns false if there is no such
+// declaration or the choice is ambiguous.
+func chooseDecl(decls []nativeDecl, name string, t reflect.Type) (nativeRef, bool) {
+	var ref nativeRef
+	var named bool
+	n := 0
+	for _, decl := range decls {
+		if decl.value.Type() != t {
+			continue
+		}
+		if decl.ref.Name == name {
+			if !named || decl.ref.less(ref) {
+				ref = decl.ref
+			}
+			named = true
+		} else if !named {
+			ref = decl.ref
+		}
+		n++
+	}
+	return ref, named || n == 1
+}
+
+// Decode decodes a code encoded by Encode. importer and globals must provide
+// the native declarations referenced by the code.
+//
+// If the code has been encoded by a different version of Go or Scriggo, or a
+// referenced native declaration does not exist or has a different type,
+// Decode returns an error that wraps ErrIncompatibleEncoding.
+func Decode(data []byte, importer native.Importer, globals native.Declarations) (_ *Code, err error) {
+	if !bytes.HasPrefix(data, []byte(encodingMagic)) {
+		return nil, fmt.Errorf("%w: invalid data", ErrIncompatibleEncoding)
+	}
+	dec := gob.NewDecoder(bytes.NewReader(data[len(encodingMagic):]))
+	var header encodingHeader
+	err = dec.Decode(&header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrIncompatibleEncoding, err)
+	}
+	if header != currentEncodingHeader {
+		return nil, fmt.Errorf("%w: encoded with version %d, Go %s and Scriggo %q",
+			ErrIncompatibleEncoding, header.Version, header.Go, header.Scriggo)
+	}
+	d := &decoder{types: types.NewTypes()}
+	err = dec.Decode(&d.code)
+	if err != nil {
+		return nil, err
+	}
+	d.natives = newNativeIndex(importer, globals, d.code.Packages)
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(encodingError); ok {
+				err = e.err
+				return
+			}
+			err = fmt.Errorf("scriggo: cannot decode: %v", r)
+		}
+	}()
+	return d.decode(), nil
+}
+
+// decoder decodes a code.
+type decoder struct {
+	code      encodedCode
+	types     *types.Types
+	natives   *nativeIndex
+	typeList  []reflect.Type
+	functions []*runtime.Function
+}
+
+// errorf panics with a decoding error.
+func (d *decoder) errorf(format string, a ...interface{}) {
+	panic(encodingError{fmt.Errorf("scriggo: cannot decode: "+format, a...)})
+}
+
+// incompatible panics with an error that wraps ErrIncompatibleEncoding.
+func (d *decoder) incompatible(err error) {
+	if !errors.Is(err, ErrIncompatibleEncoding) {
+		err = fmt.Errorf("%w: %s", ErrIncompatibleEncoding, err)
+	}
+	panic(encodingError{err})
+}
+
+// decode decodes the code.
+func (d *decoder) decode() *Code {
+	// Allocate the functions so that they can be referenced by the types.
+	d.functions = make([]*runtime.Function, len(d.code.Functions))
+	for i := range d.functions {
+		d.functions[i] = &runtime.Function{}
+	}
+	d.typeList = make([]reflect.Type, 0, len(d.code.Types))
+	for _, et := range d.code.Types {
+		d.typeList = append(d.typeList, d.decodeType(et))
+	}
+	// Add the methods to the defined types, now that the types of the
+	// methods, that can follow the defined types, have been decoded.
+	for i, et := range d.code.Types {
+		if et.Name == "" {
+			continue
+		}
+		for _, m := range et.Methods {
+			d.types.AddMethod(d.typeList[i], &types.Method{
+				Name:    m.Name,
+				Type:    d.typ(m.Type),
+				Pointer: m.Pointer,
+				Func:    d.function(m.Func),
+			})
+		}
+	}
+	for i, ef := range d.code.Functions {
+		d.decodeFunction(d.functions[i], ef)
+	}
+	code := &Code{
+		Main:     d.function(d.code.Main),
+		TypeOf:   d.types.TypeOf,
+		Packages: d.code.Packages,
+		Coverage: d.code.Coverage,
+	}
+	if code.Main == nil {
+		d.errorf("missing main function")
+	}
+	if d.code.Exported != nil {
+		code.Functions = make(map[string]*runtime.Function, len(d.code.Exported))
+		for _, e := range d.code.Exported {
+			code.Functions[e.Name] = d.function(e.Func)
+		}
+	}
+	if len(d.code.Globals) > 0 {
+		code.Globals = make([]Global, len(d.code.Globals))
+		for i, eg := range d.code.Globals {
+			code.Globals[i] = d.decodeGlobal(eg)
+		}
+	}
+	return code
+}
+
+// typ returns the type with reference id.
+func (d *decoder) typ(id int) reflect.Type {
+	if id < 0 || id > len(d.typeList) {
+		d.errorf("invalid type reference %d", id)
+	}
+	if id == 0 {
+		return nil
+	}
+	return d.typeList[id-1]
+}
+
+// function returns the function with reference id.
+func (d *decoder) function(id int) *runtime.Function {
+	if id < 0 || id > len(d.functions) {
+		d.errorf("invalid function reference %d", id)
+	}
+	if id == 0 {
+		return nil
+	}
+	return d.functions[id-1]
+}
+
+// decodeType decodes a type.
+func (d *decoder) decodeType(et encodedType) reflect.Type {
+	if et.Key != "" {
+		t, err := d.natives.lookupType(et.Key)
+		if err != nil {
+			d.incompatible(err)
+		}
+		return t
+	}
+	if et.Name != "" {
+		return d.types.DefinedOf(et.Name, d.typ(et.Elem))
+	}
+	switch et.Kind {
+	case reflect.Array:
+		return d.types.ArrayOf(et.Len, d.typ(et.Elem))
+	case reflect.Chan:
+		return d.types.ChanOf(et.Dir, d.typ(et.Elem))
+	case reflect.Func:
+		in := make([]reflect.Type, len(et.In))
+		for i, id := range et.In {
+			in[i] = d.typ(id)
+		}
+		out := make([]reflect.Type, len(et.Out))
+		for i, id := range et.Out {
+			out[i] = d.typ(id)
+		}
+		return d.types.FuncOf(in, out, et.Variadic)
+	case reflect.Interface:
+		var methods []reflect.Method
+		for _, m := range et.Methods {
+			methods = append(methods, reflect.Method{Name: m.Name, PkgPath: m.PkgPath, Type: d.typ(m.Type)})
+		}
+		return d.types.InterfaceOf(methods)
+	case reflect.Map:
+		return d.types.MapOf(d.typ(et.MapKey), d.typ(et.Elem))
+	case reflect.Ptr:
+		return d.types.PointerTo(d.typ(et.Elem))
+	case reflect.Slice:
+		return d.types.SliceOf(d.typ(et.Elem))
+	case reflect.Struct:
+		fields := make([]reflect.StructField, len(et.Fields))
+		for i, f := range et.Fields {
+			fields[i] = reflect.StructField{
+				Name:      f.Name,
+				PkgPath:   f.PkgPath,
+				Type:      d.typ(f.Type),
+				Tag:       f.Tag,
+				Anonymous: f.Anonymous,
+			}
+		}
+		return d.types.StructOf(fields)
+	}
+	d.errorf("unexpected type kind %s", et.Kind)
+	return nil
+}
+
+// decodeFunction decodes ef into fn.
+func (d *decoder) decodeFunction(fn *runtime.Function, ef encodedFunction) {
+	fn.Pkg = ef.Pkg
+	fn.Name = ef.Name
+	fn.File = ef.File
+	fn.Pos = ef.Pos
+	fn.Type = d.typ(ef.Type)
+	fn.Parent = d.function(ef.Parent)
+	fn.VarRefs = ef.VarRefs
+	fn.NumReg = ef.NumReg
+	fn.FinalRegs = ef.FinalRegs
+	fn.Macro = ef.Macro
+	fn.Format = ef.Format
+	fn.Values.Int = ef.Ints
+	fn.Values.Float = ef.Floats
+	fn.Values.String = ef.Strings
+	fn.FieldIndexes = ef.FieldIndexes
+	fn.Text = ef.Text
+	if ef.Types != nil {
+		fn.Types = make([]reflect.Type, len(ef.Types))
+		for i, id := range ef.Types {
+			fn.Types[i] = d.typ(id)
+		}
+	}
+	if ef.Generals != nil {
+		fn.Values.General = make([]reflect.Value, len(ef.Generals))
+		for i, ev := range ef.Generals {
+			fn.Values.General[i] = d.decodeValue(ev)
+		}
+	}
+	if ef.Functions != nil {
+		fn.Functions = make([]*runtime.Function, len(ef.Functions))
+		for i, id := range ef.Functions {
+			fn.Functions[i] = d.function(id)
+		}
+	}
+	if ef.NativeFunctions != nil {
+		fn.NativeFunctions = make([]*runtime.NativeFunction, len(ef.NativeFunctions))
+		for i, enf := range ef.NativeFunctions {
+			fn.NativeFunctions[i] = d.decodeNativeFunction(enf)
+		}
+	}
+	if len(ef.Body)%8 != 0 {
+		d.errorf("invalid body of function %s", ef.Name)
+	}
+	fn.Body = make([]runtime.Instruction, len(ef.Body)/8)
+	for i := range fn.Body {
+		b := ef.Body[8*i:]
+		fn.Body[i] = runtime.Instruction{
+			Op: runtime.Operation(binary.LittleEndian.Uint16(b)),
+			A:  int16(binary.LittleEndian.Uint16(b[2:])),
+			B:  int16(binary.LittleEndian.Uint16(b[4:])),
+			C:  int16(binary.LittleEndian.Uint16(b[6:])),
+		}
+	}
+	if ef.InstructionInfo != nil {
+		fn.InstructionInfo = make(map[runtime.Addr]runtime.InstructionInfo, len(ef.InstructionInfo))
+		for _, info := range ef.InstructionInfo {
+			fn.InstructionInfo[info.Addr] = runtime.InstructionInfo{
+				Position:    info.Position,
+				Path:        info.Path,
+				OperandKind: info.OperandKind,
+				FuncType:    d.typ(info.FuncType),
+			}
+		}
+	}
+	fn.Statements = ef.Statements
+	if ef.LocalVars != nil {
+		fn.LocalVars = make([]runtime.LocalVar, len(ef.LocalVars))
+		for i, v := range ef.LocalVars {
+			fn.LocalVars[i] = runtime.LocalVar{
+				Name:  v.Name,
+				Type:  d.typ(v.Type),
+				Reg:   v.Reg,
+				Start: v.Start,
+				End:   v.End,
+			}
+		}
+	}
+}
+
+// decodeValue decodes a general value.
+func (d *decoder) decodeValue(ev encodedValue) reflect.Value {
+	t := d.typ(ev.Type)
+	if t == nil {
+		return reflect.Value{}
+	}
+	if _, ok := t.(runtime.ScriggoType); ok {
+		d.errorf("unexpected value of type %s", t)
+	}
+	if k := t.Kind(); k == reflect.Complex64 || k == reflect.Complex128 {
+		v := reflect.New(t).Elem()
+		v.SetComplex(ev.Complex)
+		return v
+	}
+	return reflect.Zero(t)
+}
+
+// decodeNativeFunction decodes a native function.
+func (d *decoder) decodeNativeFunction(enf encodedNativeFunction) *runtime.NativeFunction {
+	var v reflect.Value
+	switch ref := enf.Func; ref.Kind {
+	case declarationRef:
+		decl, err := d.natives.lookupDecl(ref)
+		if err != nil {
+			d.incompatible(err)
+		}
+		v = reflect.ValueOf(decl)
+		if v.Kind() != reflect.Func {
+			d.incompatible(fmt.Errorf("%s is not a function", ref))
+		}
+	case methodRef:
+		recv := d.typ(ref.Type)
+		m, ok := recv.MethodByName(ref.Name)
+		if !ok {
+			d.incompatible(fmt.Errorf("type %s has no method %s", recv, ref.Name))
+		}
+		if recv.Kind() == reflect.Interface {
+			v = interfaceMethodExpr(recv, m)
+		} else {
+			v = m.Func
+		}
+	case complexRef:
+		f, ok := complexFunctions[ref.Name]
+		if !ok {
+			d.errorf("unknown complex operation %q", ref.Name)
+		}
+		v = reflect.ValueOf(f)
+	case builtinRef:
+		i := sort.SearchStrings(deferGoBuiltins, ref.Name)
+		if i == len(deferGoBuiltins) || deferGoBuiltins[i] != ref.Name {
+			d.errorf("unknown builtin %q", ref.Name)
+		}
+		v = deferGoBuiltin(ref.Name).value.(reflect.Value)
+	case nilRef:
+		v = reflect.Zero(d.typ(enf.Type))
+	default:
+		d.errorf("invalid native function reference")
+	}
+	if t := d.typ(enf.Type); v.Type() != t {
+		d.incompatible(fmt.Errorf("%s has type %s, expected %s", enf.Func, v.Type(), t))
+	}
+	return runtime.NewNativeFunction(enf.Pkg, enf.Name, v)
+}
+
+// decodeGlobal decodes a global.
+func (d *decoder) decodeGlobal(eg encodedGlobal) Global {
+	global := Global{
+		Pkg:  eg.Pkg,
+		Name: eg.Name,
+		Type: d.typ(eg.Type),
+	}
+	if eg.Value == nil {
+		return global
+	}
+	decl, err := d.natives.lookupDecl(*eg.Value)
+	if err != nil {
+		d.incompatible(err)
+	}
+	v := reflect.ValueOf(decl)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Type().Elem() != global.Type {
+		d.incompatible(fmt.Errorf("%s is not a variable of type %s", eg.Value, global.Type))
+	}
+	global.Value = v.Elem()
+	return global
+}
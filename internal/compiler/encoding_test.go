@@ -0,0 +1,46 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/open2b/scriggo/internal/fstest"
+)
+
+func TestEncodingVersion(t *testing.T) {
+	fsys := fstest.Files{"main.go": "package main\n\nfunc main() { println(\"a\") }"}
+	code, err := BuildProgram(fsys, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := currentEncodingHeader
+	defer func() { currentEncodingHeader = header }()
+	for _, h := range []encodingHeader{
+		{Version: header.Version + 1, Go: header.Go, Scriggo: header.Scriggo},
+		{Version: header.Version, Go: "go1.0", Scriggo: header.Scriggo},
+		{Version: header.Version, Go: header.Go, Scriggo: "v0.1.0"},
+	} {
+		currentEncodingHeader = h
+		data, err := Encode(code, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		currentEncodingHeader = header
+		_, err = Decode(data, nil, nil)
+		if !errors.Is(err, ErrIncompatibleEncoding) {
+			t.Fatalf("header %v: expecting ErrIncompatibleEncoding, got %v", h, err)
+		}
+	}
+	data, err := Encode(code, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Decode(data, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,592 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/ast/astutil"
+)
+
+// FormatTemplateSource formats the source src of a template file in the
+// given format and returns the formatted source.
+//
+// The code in {{ }}, {% %} and {%% %%} is written with canonical spacing,
+// and the statements and comments that are alone in their lines are
+// indented, with tabs, according to their nesting. The text is preserved,
+// with the exception of the spaces around the statements alone in their
+// lines, that are not rendered anyway. So the formatted template renders
+// the same output of the original one.
+//
+// If src cannot be parsed, it returns the syntax error.
+func FormatTemplateSource(src []byte, format ast.Format) ([]byte, error) {
+
+	tree, _, err := ParseTemplateSource(src, format, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cuts of the Text nodes tell which spaces are not rendered.
+	texts := map[int]*ast.Text{}
+	astutil.Inspect(tree, func(node ast.Node) bool {
+		if text, ok := node.(*ast.Text); ok {
+			texts[text.Pos().Start] = text
+		}
+		return true
+	})
+
+	lex := scanTemplate(src, format, false)
+	var tokens []token
+	for tok := range lex.Tokens() {
+		// Skip the empty tokens, as the automatically inserted semicolons.
+		if len(tok.txt) > 0 {
+			tokens = append(tokens, tok)
+		}
+	}
+	if err := lex.error(); err != nil {
+		return nil, err
+	}
+
+	f := &formatter{src: src, tokens: tokens, texts: texts}
+	f.format()
+	formatted := f.buf.Bytes()
+
+	// Check that the formatted source has the same tree.
+	formattedTree, _, err := ParseTemplateSource(formatted, format, false, false)
+	if err != nil || !equalTrees(tree, formattedTree) {
+		return nil, errors.New("scriggo: formatting would change the template")
+	}
+
+	return formatted, nil
+}
+
+// formatter formats the tokens of a template source.
+type formatter struct {
+	src      []byte
+	tokens   []token
+	texts    map[int]*ast.Text
+	buf      bytes.Buffer
+	depth    int  // nesting depth of the statements
+	trimLeft bool // trim the leading spaces of the next text
+}
+
+// format formats the tokens writing the result to f.buf.
+func (f *formatter) format() {
+	for i := 0; i < len(f.tokens); i++ {
+		tok := f.tokens[i]
+		switch tok.typ {
+		case tokenText:
+			txt := tok.txt
+			if f.trimLeft {
+				txt = bytes.TrimLeft(txt, " \t")
+				f.trimLeft = false
+			}
+			f.buf.Write(txt)
+		case tokenComment:
+			f.align(i, i, f.depth, true)
+			f.buf.Write(tok.txt)
+		case tokenLeftBraces:
+			j := f.closing(i, tokenRightBraces)
+			f.writeCode(i, j, false, 0)
+			i = j
+		case tokenStartStatement:
+			j := f.closing(i, tokenEndStatement)
+			depth := f.depth
+			cuts := true
+			switch f.tokens[i+1].typ {
+			case tokenEnd:
+				if f.depth > 0 {
+					f.depth--
+				}
+				depth = f.depth
+			case tokenElse, tokenDefault:
+				if depth > 0 {
+					depth--
+				}
+			case tokenCase:
+				if depth > 0 {
+					depth--
+				}
+				cuts = false
+			case tokenIf, tokenFor, tokenSwitch, tokenSelect, tokenMacro, tokenRaw:
+				f.depth++
+			case tokenBreak, tokenContinue, tokenFallthrough, tokenImport:
+			default:
+				cuts = false
+				for _, t := range f.tokens[i+1 : j] {
+					if t.typ == tokenUsing {
+						f.depth++
+						cuts = true
+						break
+					}
+				}
+			}
+			f.align(i, j, depth, cuts)
+			f.writeCode(i, j, false, 0)
+			i = j
+		case tokenStartStatements:
+			j := f.closing(i, tokenEndStatements)
+			f.align(i, j, f.depth, false)
+			f.writeCode(i, j, true, f.depth)
+			i = j
+		default:
+			f.buf.Write(tok.txt)
+		}
+	}
+}
+
+// closing returns the index of the first token of type typ after the token
+// with index i.
+func (f *formatter) closing(i int, typ tokenTyp) int {
+	for j := i + 1; j < len(f.tokens); j++ {
+		if f.tokens[j].typ == typ {
+			return j
+		}
+	}
+	return len(f.tokens) - 1
+}
+
+// align indents, for the given depth, the tokens from index i to index j if
+// they are alone in their line, and removes the spaces that follow them in
+// the line. It only changes the spaces that are not rendered. cuts reports
+// whether the parser cuts the spaces in the line of the tokens.
+func (f *formatter) align(i, j, depth int, cuts bool) {
+	f.trimLeft = false
+	var first, last *ast.Text
+	var spaces, newline int
+	var firstIn, lastIn, hasLast bool
+	if i > 0 {
+		tok := f.tokens[i-1]
+		switch tok.typ {
+		case tokenShebangLine:
+		case tokenText:
+			k := bytes.LastIndexByte(tok.txt, '\n')
+			if k == -1 && tok.pos.Start > 0 || !containsOnlySpaces(tok.txt[k+1:]) {
+				return
+			}
+			spaces = len(tok.txt) - k - 1
+			first, firstIn = f.texts[tok.pos.Start]
+		default:
+			return
+		}
+	}
+	if j+1 < len(f.tokens) {
+		tok := f.tokens[j+1]
+		if tok.typ != tokenText {
+			return
+		}
+		k := bytes.IndexByte(tok.txt, '\n')
+		if k == -1 {
+			if j+2 < len(f.tokens) {
+				return
+			}
+			k = len(tok.txt) - 1
+		}
+		if !containsOnlySpaces(tok.txt[:k]) {
+			return
+		}
+		newline = k + 1
+		last, lastIn = f.texts[tok.pos.Start]
+		hasLast = true
+	}
+	// The spaces are not rendered if the Text nodes are not in the tree, or
+	// the parser has cut them, or it will cut them after the indentation.
+	if !firstIn || spaces > 0 && first.Cut.Right == spaces ||
+		spaces == 0 && cuts && hasLast && (!lastIn || last.Cut.Left == newline) {
+		f.indent(depth)
+	}
+	f.trimLeft = hasLast && (!lastIn || last.Cut.Left == newline)
+}
+
+// indent replaces the spaces written after the last newline with the
+// indentation for the given depth.
+func (f *formatter) indent(depth int) {
+	b := f.buf.Bytes()
+	n := len(b)
+	for n > 0 && (b[n-1] == ' ' || b[n-1] == '\t') {
+		n--
+	}
+	f.buf.Truncate(n)
+	for k := 0; k < depth; k++ {
+		f.buf.WriteByte('\t')
+	}
+}
+
+// writeCode writes the code from the opening token with index i to the
+// closing token with index j. statements reports whether the code is in a
+// {%% %%} block, in which case the lines are indented according to the
+// given depth of the block and the brackets.
+func (f *formatter) writeCode(i, j int, statements bool, depth int) {
+	var s codeState
+	prev := f.tokens[i]
+	f.buf.Write(prev.txt)
+	// The code that starts in the same line of {%% is not indented.
+	base := depth
+	if statements && bytes.IndexByte(f.src[prev.pos.End+1:f.tokens[i+1].pos.Start], '\n') >= 0 {
+		depth++
+	}
+	for k := i + 1; k <= j; k++ {
+		tok := f.tokens[k]
+		gap := f.src[prev.pos.End+1 : tok.pos.Start]
+		unary := s.isUnary(prev, tok, len(gap) > 0, f.spaceAfter(tok))
+		var indent string
+		if statements {
+			d := depth + len(s.brackets)
+			switch tok.typ {
+			case tokenRightParenthesis, tokenRightBracket, tokenRightBrace, tokenCase, tokenDefault:
+				d--
+			case tokenEndStatements:
+				d = base
+			}
+			if d < 0 {
+				d = 0
+			}
+			indent = strings.Repeat("\t", d)
+		}
+		sep := " "
+		if k > i+1 && k < j {
+			sep = s.spacing(prev, tok, len(gap) > 0)
+		}
+		f.writeGap(gap, sep, indent, !statements)
+		f.buf.Write(tok.txt)
+		s.update(prev, tok, unary, len(gap) > 0)
+		prev = tok
+	}
+}
+
+// spaceAfter reports whether tok is followed by a space in the source.
+func (f *formatter) spaceAfter(tok token) bool {
+	k := tok.pos.End + 1
+	return k < len(f.src) && isSpace(f.src[k])
+}
+
+// writeGap writes the spaces and the comments between two tokens of code.
+// The spaces in a line are replaced with sep, before a token, and with a
+// space, before and after a comment. The newlines are preserved, collapsing
+// the blank lines, and each new line is indented with indent or, if keep is
+// true, with the original indentation.
+func (f *formatter) writeGap(gap []byte, sep, indent string, keep bool) {
+	newlines := 0
+	line := 0 // index of the first byte of the current line in gap
+	space := func(i int, sep string) {
+		if newlines == 0 {
+			f.buf.WriteString(sep)
+			return
+		}
+		if newlines > 2 {
+			newlines = 2
+		}
+		for ; newlines > 0; newlines-- {
+			f.buf.WriteByte('\n')
+		}
+		if keep {
+			f.buf.Write(bytes.TrimLeft(gap[line:i], "\r"))
+		} else {
+			f.buf.WriteString(indent)
+		}
+	}
+	for i := 0; i < len(gap); {
+		switch gap[i] {
+		case '\n':
+			newlines++
+			i++
+			line = i
+		case ' ', '\t', '\r':
+			i++
+		default:
+			var n int
+			if gap[i+1] == '/' {
+				n = bytes.IndexByte(gap[i:], '\n')
+				if n == -1 {
+					n = len(gap) - i
+				}
+			} else {
+				n = bytes.Index(gap[i:], []byte("*/")) + 2
+			}
+			space(i, " ")
+			f.buf.Write(bytes.TrimRight(gap[i:i+n], " \t\r"))
+			i += n
+			sep = " "
+		}
+	}
+	space(len(gap), sep)
+}
+
+// bracket is an open bracket in code.
+type bracket struct {
+	typ    tokenTyp
+	isType bool // the bracket starts a type, as in []int and map[string]int
+}
+
+// codeState is the state of the code written by writeCode.
+type codeState struct {
+	brackets  []bracket
+	prevUnary bool // the previous token is a unary operator
+	typeClose bool // the previous token closes the brackets of a type
+}
+
+// isUnary reports whether tok, that follows prev, is a unary operator.
+// spaceBefore and spaceAfter report whether tok is preceded and followed
+// by a space in the source.
+func (s *codeState) isUnary(prev, tok token, spaceBefore, spaceAfter bool) bool {
+	switch tok.typ {
+	case tokenNot, tokenTilde:
+		return true
+	case tokenAddition, tokenSubtraction, tokenMultiplication, tokenAmpersand, tokenXor, tokenArrow:
+		if !isOperandEnd(prev) || s.typeClose {
+			return true
+		}
+		// A pointer type, as in "p *T".
+		return tok.typ == tokenMultiplication && spaceBefore && !spaceAfter
+	}
+	return false
+}
+
+// update updates the state after tok, that follows prev, has been written.
+func (s *codeState) update(prev, tok token, unary, spaceBefore bool) {
+	typeClose := false
+	switch tok.typ {
+	case tokenLeftParenthesis, tokenLeftBrace:
+		s.brackets = append(s.brackets, bracket{typ: tok.typ})
+	case tokenLeftBracket:
+		isType := !isOperandEnd(prev) || prev.typ == tokenMap || s.typeClose ||
+			prev.typ == tokenIdentifier && spaceBefore
+		s.brackets = append(s.brackets, bracket{typ: tok.typ, isType: isType})
+	case tokenRightParenthesis, tokenRightBracket, tokenRightBrace:
+		if n := len(s.brackets); n > 0 {
+			typeClose = tok.typ == tokenRightBracket && (s.brackets[n-1].isType || prev.typ == tokenLeftBracket)
+			s.brackets = s.brackets[:n-1]
+		}
+	}
+	s.typeClose = typeClose
+	s.prevUnary = unary
+}
+
+// inIndex reports whether the code is in the brackets of an index or a
+// slice expression.
+func (s *codeState) inIndex() bool {
+	n := len(s.brackets)
+	return n > 0 && s.brackets[n-1].typ == tokenLeftBracket && !s.brackets[n-1].isType
+}
+
+// spacing returns the spacing between the tokens prev and tok, in the same
+// line. spaced reports whether they are spaced in the source.
+func (s *codeState) spacing(prev, tok token, spaced bool) string {
+	sep := s.spacingOf(prev, tok, spaced)
+	if sep == "" && mergeable(prev, tok) {
+		sep = " "
+	}
+	return sep
+}
+
+func (s *codeState) spacingOf(prev, tok token, spaced bool) string {
+	switch tok.typ {
+	case tokenRightParenthesis, tokenRightBracket, tokenComma, tokenIncrement, tokenDecrement:
+		return ""
+	case tokenSemicolon:
+		// As in "for ; ; ".
+		if isKeyword(prev) || prev.typ == tokenSemicolon {
+			return " "
+		}
+		return ""
+	case tokenColon:
+		return ""
+	case tokenPeriod:
+		if prev.typ == tokenInt {
+			return " "
+		}
+		return ""
+	case tokenEllipsis:
+		// As in "f(s...)" and "s ...string".
+		if isOperandEnd(prev) {
+			if spaced {
+				return " "
+			}
+			return ""
+		}
+	}
+	switch prev.typ {
+	case tokenLeftParenthesis, tokenLeftBracket, tokenPeriod, tokenEllipsis:
+		return ""
+	case tokenComma, tokenSemicolon:
+		return " "
+	case tokenColon:
+		if s.inIndex() {
+			return ""
+		}
+		return " "
+	}
+	if s.prevUnary {
+		return ""
+	}
+	if tok.typ == tokenLeftBrace && isKeyword(prev) && prev.typ != tokenStruct && prev.typ != tokenInterface {
+		return " "
+	}
+	if tok.typ == tokenLeftBrace || tok.typ == tokenRightBrace || prev.typ == tokenLeftBrace {
+		if spaced {
+			return " "
+		}
+		return ""
+	}
+	if isUnaryOperator(tok) {
+		if s.typeClose || prev.typ == tokenChan && tok.typ == tokenArrow {
+			return ""
+		}
+		return " "
+	}
+	if isOperator(prev) || isOperator(tok) {
+		return " "
+	}
+	switch tok.typ {
+	case tokenLeftParenthesis:
+		switch prev.typ {
+		case tokenIdentifier, tokenRightBrace, tokenFunc, tokenMacro, tokenInterpretedString, tokenRawString:
+			return ""
+		case tokenRightParenthesis, tokenRightBracket:
+			if spaced {
+				return " "
+			}
+			return ""
+		}
+		if s.typeClose {
+			return ""
+		}
+		return " "
+	case tokenLeftBracket:
+		switch prev.typ {
+		case tokenMap:
+			return ""
+		case tokenIdentifier, tokenRightParenthesis, tokenRightBracket, tokenRightBrace:
+			if spaced && !s.typeClose {
+				return " "
+			}
+			return ""
+		}
+		return " "
+	}
+	if s.typeClose {
+		return ""
+	}
+	return " "
+}
+
+// isOperandEnd reports whether tok can be the last token of an operand.
+func isOperandEnd(tok token) bool {
+	switch tok.typ {
+	case tokenIdentifier, tokenInt, tokenFloat, tokenImaginary, tokenRune,
+		tokenInterpretedString, tokenRawString, tokenRightParenthesis,
+		tokenRightBracket, tokenRightBrace:
+		return true
+	}
+	return false
+}
+
+// isKeyword reports whether tok is a keyword.
+func isKeyword(tok token) bool {
+	if tok.typ == tokenIdentifier || tok.typ == tokenRune || tok.typ == tokenInterpretedString || tok.typ == tokenRawString {
+		return false
+	}
+	c := tok.txt[0]
+	return 'a' <= c && c <= 'z'
+}
+
+// isUnaryOperator reports whether tok is an operator that can be unary.
+func isUnaryOperator(tok token) bool {
+	switch tok.typ {
+	case tokenNot, tokenTilde, tokenAddition, tokenSubtraction, tokenMultiplication,
+		tokenAmpersand, tokenXor, tokenArrow:
+		return true
+	}
+	return false
+}
+
+// isOperator reports whether tok is a binary operator, an assignment or a
+// declaration operator that is written between spaces.
+func isOperator(tok token) bool {
+	if isAssignmentToken(tok) && tok.typ != tokenIncrement && tok.typ != tokenDecrement {
+		return true
+	}
+	switch tok.typ {
+	case tokenDeclaration, tokenEqual, tokenNotEqual, tokenLess, tokenLessOrEqual,
+		tokenGreater, tokenGreaterOrEqual, tokenAnd, tokenOr, tokenAddition,
+		tokenSubtraction, tokenMultiplication, tokenDivision, tokenModulo,
+		tokenAmpersand, tokenVerticalBar, tokenXor, tokenAndNot, tokenLeftShift,
+		tokenRightShift, tokenArrow:
+		return true
+	}
+	return false
+}
+
+// mergeable reports whether the tokens prev and tok would be scanned as
+// different tokens if they were not spaced.
+func mergeable(prev, tok token) bool {
+	a, b := prev.txt[len(prev.txt)-1], tok.txt[0]
+	switch string([]byte{a, b}) {
+	case "--", "++", "&&", "&^", "||", "<-", "//", "/*", "*/", "&=", "|=":
+		return true
+	}
+	return false
+}
+
+// equalTrees reports whether the trees t1 and t2 have the same nodes and
+// render the same text.
+func equalTrees(t1, t2 *ast.Tree) bool {
+	s1, s2 := treeSignature(t1), treeSignature(t2)
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// treeSignature returns the signature of a tree, a description of its nodes
+// that does not depend on their positions.
+func treeSignature(tree *ast.Tree) []string {
+	var signature []string
+	var inspect func(ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		var s string
+		if node == nil || reflect.ValueOf(node).IsNil() {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.Text:
+			text := n.Text[n.Cut.Left : len(n.Text)-n.Cut.Right]
+			if len(text) == 0 {
+				return true
+			}
+			s = "Text " + string(text)
+		case *ast.Comment:
+			s = "Comment " + n.Text
+		case *ast.Identifier:
+			s = "Identifier " + n.Name
+		case *ast.BasicLiteral:
+			s = "BasicLiteral " + n.Value
+		case *ast.BinaryOperator:
+			s = "BinaryOperator " + n.Op.String()
+		case *ast.UnaryOperator:
+			s = "UnaryOperator " + n.Op.String()
+		case *ast.Call:
+			signature = append(signature, "Call")
+			astutil.Inspect(n.Func, inspect)
+			return true
+		default:
+			s = fmt.Sprintf("%T", node)
+		}
+		signature = append(signature, s)
+		return true
+	}
+	astutil.Inspect(tree, inspect)
+	return signature
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/open2b/scriggo/ast"
+)
+
+var formatTests = []struct {
+	src      string
+	expected string
+}{
+	{"", ""},
+	{"a  b\n\n  c", "a  b\n\n  c"},
+	{"{{a+b}}", "{{ a + b }}"},
+	{"{{  f( -1,x ,*p)  }}", "{{ f(-1, x, *p) }}"},
+	{"{{a[1:3]}}{{s[:]}}{{ f(a...) }}{{ x.(T) }}", "{{ a[1:3] }}{{ s[:] }}{{ f(a...) }}{{ x.(T) }}"},
+	{"{{ []int{1,2}[0] }}{{ map[string]int{\"a\":1}}}", "{{ []int{1, 2}[0] }}{{ map[string]int{\"a\": 1} }}"},
+	{"{{ x default 1 }}{{ a and not b }}{{ !!x }}", "{{ x default 1 }}{{ a and not b }}{{ !!x }}"},
+	{"{{ -(-x) }}{{ - -x }}", "{{ -(-x) }}{{ - -x }}"},
+	{"{{ a /* b */ + c }}", "{{ a /* b */ + c }}"},
+	{"<b>{%if x>1%}a{%end%}</b>", "<b>{% if x > 1 %}a{% end %}</b>"},
+	{
+		"<ul>\n  {%if x>1%}\n  <li>{{x}}</li>\n      {% else if !y %}\n  {%for i,v:=range s%}\n  <li>{{v}}</li>  \n{%end%}  \n{%end if%}\n</ul>\n",
+		"<ul>\n{% if x > 1 %}\n  <li>{{ x }}</li>\n{% else if !y %}\n\t{% for i, v := range s %}\n  <li>{{ v }}</li>  \n\t{% end %}\n{% end if %}\n</ul>\n",
+	},
+	{
+		"{% macro M(s string,n int) html %}\n{% switch n %}\n{% case 1 %}\n{# one #}\n{% default %}\n{% end %}\n{% end macro %}\n",
+		"{% macro M(s string, n int) html %}\n\t{% switch n %}\n\t{% case 1 %}\n\t\t{# one #}\n\t{% default %}\n\t{% end %}\n{% end macro %}\n",
+	},
+	{
+		"{% show M(); using html %}\n  ab\n   {% end using %}\n",
+		"{% show M(); using html %}\n  ab\n{% end using %}\n",
+	},
+	{
+		"{% raw %}\n  {{ a+b }}\n  {% end raw %}\n",
+		"{% raw %}\n  {{ a+b }}\n{% end raw %}\n",
+	},
+	{
+		"{% var s = \"a\" // c\n  %}",
+		"{% var s = \"a\" // c\n  %}",
+	},
+	{
+		"{%%\nx:=1\nif x>1 {\nshow x\n}else{\n// comment\nx++  \n\n\n}\nvar f = func(p *int)[]*int{return nil}\n%%}",
+		"{%%\n\tx := 1\n\tif x > 1 {\n\t\tshow x\n\t} else {\n\t\t// comment\n\t\tx++\n\n\t}\n\tvar f = func(p *int)[]*int{return nil}\n%%}",
+	},
+	{
+		"{% if a %}\n  {%% for _, v := range s {\nshow v\n} %%}\n{% end %}\n",
+		"{% if a %}\n\t{%% for _, v := range s {\n\t\tshow v\n\t} %%}\n{% end %}\n",
+	},
+}
+
+func TestFormatTemplateSource(t *testing.T) {
+	for _, test := range formatTests {
+		got, err := FormatTemplateSource([]byte(test.src), ast.FormatHTML)
+		if err != nil {
+			t.Errorf("source: %q, unexpected error %q", test.src, err)
+			continue
+		}
+		if string(got) != test.expected {
+			t.Errorf("source: %q, expected %q, got %q", test.src, test.expected, got)
+			continue
+		}
+		again, err := FormatTemplateSource(got, ast.FormatHTML)
+		if err != nil {
+			t.Errorf("source: %q, unexpected error %q formatting again", test.src, err)
+			continue
+		}
+		if string(again) != string(got) {
+			t.Errorf("source: %q, formatting again got %q", test.src, again)
+		}
+	}
+}
+
+func TestFormatTemplateSourceError(t *testing.T) {
+	_, err := FormatTemplateSource([]byte("{% if %}"), ast.FormatHTML)
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/open2b/scriggo/ast"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder decodes data and stores the result in the value pointed to by v.
+type Decoder func(data []byte, v interface{}) error
+
+// FrontMatter is the front matter of a template file.
+type FrontMatter struct {
+	Value reflect.Value // decoded value, addressable
+	Len   int           // length in bytes of the front matter, delimiters included
+}
+
+// ParseFrontMatter parses the front matter at the beginning of src, the
+// source of the template file with the given path, and decodes it into a
+// new value of type typ. If src does not start with a front matter, it
+// returns nil.
+//
+// A front matter is a YAML document delimited by two lines "---", or a TOML
+// document delimited by two lines "+++" decoded by decodeTOML.
+//
+// If the front matter is not terminated or cannot be decoded, or if it is a
+// TOML front matter and decodeTOML is nil, it returns a *SyntaxError error.
+func ParseFrontMatter(path string, src []byte, typ reflect.Type, decodeTOML Decoder) (*FrontMatter, error) {
+
+	var delim []byte
+	switch {
+	case bytes.HasPrefix(src, []byte("---")):
+		delim = []byte("---")
+	case bytes.HasPrefix(src, []byte("+++")):
+		delim = []byte("+++")
+	default:
+		return nil, nil
+	}
+
+	// The first line must contain only the delimiter.
+	start := bytes.IndexByte(src, '\n') + 1
+	if start == 0 || !bytes.Equal(bytes.TrimRight(src[:start], " \t\r\n"), delim) {
+		return nil, nil
+	}
+
+	// Find the closing delimiter.
+	var data []byte
+	end := -1
+	for i := start; i < len(src); {
+		j := len(src)
+		if k := bytes.IndexByte(src[i:], '\n'); k >= 0 {
+			j = i + k + 1
+		}
+		if bytes.Equal(bytes.TrimRight(src[i:j], " \t\r\n"), delim) {
+			data, end = src[start:i], j
+			break
+		}
+		i = j
+	}
+	if end == -1 {
+		pos := ast.Position{Line: 1, Column: 1, Start: 0, End: len(delim) - 1}
+		return nil, &SyntaxError{path, pos, "unterminated front matter"}
+	}
+
+	// Decode the front matter.
+	v := reflect.New(typ)
+	var err error
+	if delim[0] == '-' {
+		err = yaml.Unmarshal(data, v.Interface())
+	} else {
+		if decodeTOML == nil {
+			pos := ast.Position{Line: 1, Column: 1, Start: 0, End: len(delim) - 1}
+			return nil, &SyntaxError{path, pos, "TOML front matter is not supported"}
+		}
+		err = decodeTOML(data, v.Interface())
+	}
+	if err != nil {
+		line, msg := frontMatterError(err)
+		// The data starts at the second line of the source.
+		line++
+		pos := ast.Position{Line: line, Column: 1}
+		for i, n := 0, 1; n < line; n++ {
+			i += bytes.IndexByte(src[i:], '\n') + 1
+			pos.Start, pos.End = i, i
+		}
+		return nil, &SyntaxError{path, pos, "invalid front matter: " + msg}
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Map && v.IsNil() {
+		v.Set(reflect.MakeMap(typ))
+	}
+
+	return &FrontMatter{Value: v, Len: end}, nil
+}
+
+// errorLine matches the line and the message of a decoding error, as the
+// errors of the yaml.v3 and BurntSushi/toml packages.
+var errorLine = regexp.MustCompile(`^(?:yaml: |toml: )?line (\d+)(?: \(last key "[^"]*"\))?: (.+)$`)
+
+// frontMatterError returns the line, relative to the front matter data, and
+// the message of a decoding error. If the line is not known, it returns 1.
+func frontMatterError(err error) (int, string) {
+	msg := err.Error()
+	var yamlErr *yaml.TypeError
+	if errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0 {
+		msg = yamlErr.Errors[0]
+	}
+	if m := errorLine.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return max(line, 1), m[2]
+	}
+	msg = strings.TrimPrefix(msg, "yaml: ")
+	msg = strings.TrimPrefix(msg, "toml: ")
+	return 1, msg
+}
@@ -1250,6 +1250,10 @@ LOOP:
 				l.column++
 			}
 			endLineAsSemicolon = false
+		case '~':
+			l.emit(tokenTilde, 1)
+			l.column++
+			endLineAsSemicolon = false
 		case ':':
 			if len(l.src) > 1 && l.src[1] == '=' {
 				l.emit(tokenDeclaration, 2)
@@ -0,0 +1,175 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"crypto/sha256"
+	"sort"
+	"sync"
+
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/ast/astutil"
+)
+
+// ParseCache caches the trees of the parsed template files, so that the
+// files shared by several templates, as extended layouts and imported
+// macros, are parsed only once.
+//
+// It also caches the type checked imported files, whose type checking does
+// not depend on the file that imports them, so that they are type checked
+// only once by the builds with the same options. See Options.CheckKey.
+//
+// A file is cached with the hash of its source, and only its last parsed
+// source is kept, so a changed file is parsed again and its old tree is
+// removed from the cache, together with the type checked files that use it.
+//
+// A ParseCache can be used concurrently by multiple goroutines.
+type ParseCache struct {
+	mu      sync.Mutex
+	entries map[parseCacheKey]*parseCacheEntry
+}
+
+// parseCacheKey is the key of a cached tree.
+type parseCacheKey struct {
+	path        string
+	format      ast.Format
+	imported    bool
+	noParseShow bool
+}
+
+// parseCacheEntry is a cached tree. tree is nil if the file could not be
+// parsed.
+type parseCacheEntry struct {
+	key     parseCacheKey
+	hash    [sha256.Size]byte // hash of the source
+	once    sync.Once
+	tree    *ast.Tree
+	checked *checkedImport // last type checked import of the file; guarded by ParseCache.mu
+}
+
+// NewParseCache returns a new empty parse cache.
+func NewParseCache() *ParseCache {
+	return &ParseCache{entries: map[parseCacheKey]*parseCacheEntry{}}
+}
+
+// Remove removes from the cache the trees of the file with the given path.
+func (c *ParseCache) Remove(path string) {
+	c.mu.Lock()
+	for key, entry := range c.entries {
+		if key.path == path {
+			delete(c.entries, key)
+			c.removeChecked(entry)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// removeChecked removes the type checked imports that use the file of the
+// removed entry. It must be called with c.mu held.
+func (c *ParseCache) removeChecked(removed *parseCacheEntry) {
+	for _, entry := range c.entries {
+		if entry.checked != nil {
+			if _, ok := entry.checked.files[removed]; ok {
+				entry.checked = nil
+			}
+		}
+	}
+}
+
+// checkedImport returns the type checked import of the file of entry, that
+// has been checked with the options with the given key and whose files are
+// the files of the given entries. It returns nil if there is no such import.
+func (c *ParseCache) checkedImport(entry *parseCacheEntry, key any, files map[*parseCacheEntry]struct{}) *checkedImport {
+	c.mu.Lock()
+	checked := entry.checked
+	c.mu.Unlock()
+	if checked == nil || checked.key != key || len(checked.files) != len(files) {
+		return nil
+	}
+	for file := range files {
+		if _, ok := checked.files[file]; !ok {
+			return nil
+		}
+	}
+	return checked
+}
+
+// storeChecked stores the type checked import of the file of entry,
+// replacing the previous one. It does nothing if the file, or a file used
+// by the import, is no longer cached.
+func (c *ParseCache) storeChecked(entry *parseCacheEntry, checked *checkedImport) {
+	c.mu.Lock()
+	for file := range checked.files {
+		if c.entries[file.key] != file {
+			c.mu.Unlock()
+			return
+		}
+	}
+	entry.checked = checked
+	c.mu.Unlock()
+}
+
+// parse is like ParseTemplateSource but it returns a copy of the cached tree
+// of the file with the given path, and the entry of the cached tree. The
+// tree, and its path, is cached only if src can be parsed; otherwise the
+// returned entry is nil.
+func (c *ParseCache) parse(src []byte, path string, format ast.Format, imported, noParseShow bool) (*ast.Tree, []ast.Node, *parseCacheEntry, error) {
+	key := parseCacheKey{path, format, imported, noParseShow}
+	hash := sha256.Sum256(src)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || entry.hash != hash {
+		// The file is not cached or its source has changed.
+		if ok {
+			c.removeChecked(entry)
+		}
+		entry = &parseCacheEntry{key: key, hash: hash}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+	entry.once.Do(func() {
+		tree, _, err := ParseTemplateSource(src, format, imported, noParseShow)
+		if err == nil {
+			tree.Path = path
+			entry.tree = tree
+		}
+	})
+	if entry.tree == nil {
+		// The errors are not cached because they are changed by the caller.
+		tree, unexpanded, err := ParseTemplateSource(src, format, imported, noParseShow)
+		return tree, unexpanded, nil, err
+	}
+	tree := astutil.CloneTree(entry.tree)
+	return tree, unexpandedNodes(tree), entry, nil
+}
+
+// unexpandedNodes returns the Extends, Import and Render nodes of a not
+// expanded tree, in the same order as ParseTemplateSource. As for
+// ParseTemplateSource, a Render node that is the left operand of a default
+// expression is returned as the Default node.
+func unexpandedNodes(tree *ast.Tree) []ast.Node {
+	nodes := []ast.Node{}
+	inDefault := map[*ast.Render]bool{}
+	astutil.Inspect(tree, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Extends, *ast.Import:
+			nodes = append(nodes, n)
+		case *ast.Default:
+			if r, ok := n.Expr1.(*ast.Render); ok {
+				nodes = append(nodes, n)
+				inDefault[r] = true
+			}
+		case *ast.Render:
+			if !inDefault[n] {
+				nodes = append(nodes, n)
+			}
+		}
+		return true
+	})
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Pos().Start < nodes[j].Pos().Start
+	})
+	return nodes
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/open2b/scriggo/ast"
+)
+
+var unexpandedNodesTests = []string{
+	``,
+	`{% extends "layout.html" %}{% macro M %}{{ render "a.html" }}{% end %}`,
+	`{% import "a.html" %}{% import m "b.html" %}{% import "c.html" for M %}`,
+	`{{ render "a.html" default render "b.html" }}{{ render "c.html" }}`,
+	`{{ render "a.html" default "" }}{% if render "b.html" default false %}{% end %}`,
+	`{% for i := range 5 %}{{ render "a.html" }}{% else %}{{ render "b.html" }}{% end %}`,
+	`{% switch %}{% case true %}{% f := func() html { return render "a.html" } %}{{ f() }}{% end %}`,
+	`{% macro M(s string) %}{% raw %}{{ render "a.html" }}{% end raw %}{{ s }}{% end %}{{ M(render "b.html") }}`,
+	`{{ render "a.html" default render "b.html" default "" }}`,
+}
+
+// TestUnexpandedNodes tests that unexpandedNodes returns the same nodes, in
+// the same order, of ParseTemplateSource.
+func TestUnexpandedNodes(t *testing.T) {
+	for _, src := range unexpandedNodesTests {
+		tree, expected, err := ParseTemplateSource([]byte(src), ast.FormatHTML, false, false)
+		if err != nil {
+			t.Errorf("source %q: unexpected error: %s", src, err)
+			continue
+		}
+		got := unexpandedNodes(tree)
+		if len(got) != len(expected) {
+			t.Errorf("source %q: expected %d nodes, got %d", src, len(expected), len(got))
+			continue
+		}
+		for i, node := range expected {
+			if got[i] != node {
+				t.Errorf("source %q: expected node %s (%T), got %s (%T)", src, node, node, got[i], got[i])
+				break
+			}
+		}
+	}
+}
+
+// TestParseCache tests that a ParseCache parses a file only once, returns a
+// copy of its tree and replaces the tree when the source changes.
+func TestParseCache(t *testing.T) {
+	cache := NewParseCache()
+	src := []byte(`{% extends "layout.html" %}{% macro M %}{% raw %}{% end raw %}{{ render "a.html" }}{% end %}`)
+	tree1, unexpanded1, entry1, err := cache.parse(src, "index.html", ast.FormatHTML, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree2, unexpanded2, entry2, err := cache.parse(src, "index.html", ast.FormatHTML, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree1 == tree2 || unexpanded1[0] == unexpanded2[0] {
+		t.Fatal("expected a copy of the tree")
+	}
+	if entry1 == nil || entry1 != entry2 {
+		t.Fatal("expected the entry of the cached tree")
+	}
+	if tree2.Path != "index.html" {
+		t.Fatalf("expected path %q, got %q", "index.html", tree2.Path)
+	}
+	if len(unexpanded2) != 2 {
+		t.Fatalf("expected 2 unexpanded nodes, got %d", len(unexpanded2))
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("expected 1 cached tree, got %d", len(cache.entries))
+	}
+	entry := cache.entries[parseCacheKey{"index.html", ast.FormatHTML, false, false}]
+	tree3, _, _, err := cache.parse([]byte(`{{ "changed" }}`), "index.html", ast.FormatHTML, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree3.Nodes) != 1 {
+		t.Fatalf("expected the tree of the changed source, got %d nodes", len(tree3.Nodes))
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("expected the changed source to replace the cached tree, got %d cached trees", len(cache.entries))
+	}
+	if cache.entries[parseCacheKey{"index.html", ast.FormatHTML, false, false}] == entry {
+		t.Fatal("expected a new cached tree")
+	}
+	_, _, _, err = cache.parse([]byte("{{ a"), "index.html", ast.FormatHTML, false, false)
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	cache.Remove("index.html")
+	if len(cache.entries) != 0 {
+		t.Fatalf("expected no cached trees, got %d", len(cache.entries))
+	}
+}
@@ -135,6 +135,9 @@ type parsing struct {
 
 	// Unexpanded Extends, Import and Render nodes.
 	unexpanded []ast.Node
+
+	// Tokens read but not yet consumed, in reverse order. See unread.
+	unread []token
 }
 
 // addToAncestors adds node to the ancestors.
@@ -165,6 +168,11 @@ func (p *parsing) inFunction() bool {
 // next returns the next token from the lexer. Panics if the lexer channel is
 // closed.
 func (p *parsing) next() token {
+	if n := len(p.unread); n > 0 {
+		tok := p.unread[n-1]
+		p.unread = p.unread[:n-1]
+		return tok
+	}
 	tok, ok := <-p.lex.Tokens()
 	if !ok {
 		if p.lex.err == nil {
@@ -175,6 +183,14 @@ func (p *parsing) next() token {
 	return tok
 }
 
+// back pushes back the given tokens, so that the next calls to next return
+// them in the same order before reading other tokens from the lexer.
+func (p *parsing) back(tokens ...token) {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		p.unread = append(p.unread, tokens[i])
+	}
+}
+
 // parseSource parses a program and returns its tree.
 // If noPackage is true, it does not expect a package statement.
 func parseSource(src []byte, noPackage bool) (tree *ast.Tree, err error) {
@@ -1594,8 +1610,29 @@ func (p *parsing) parseTypeDecl(tok token) (*ast.TypeDeclaration, token) {
 	}
 	ident := ast.NewIdentifier(tok.pos, string(tok.txt))
 	tok = p.next()
+	var typeParams []*ast.Field
+	if tok.typ == tokenLeftBracket {
+		// Distinguish a type parameter list from an array length, as in
+		// 'type T[P any] struct{}' and 'type T [N]int'.
+		next := p.next()
+		if next.typ == tokenIdentifier {
+			afterNext := p.next()
+			switch afterNext.typ {
+			case tokenIdentifier, tokenComma, tokenInterface, tokenTilde, tokenFunc, tokenMap, tokenChan, tokenStruct:
+				p.back(afterNext)
+				typeParams, tok = p.parseTypeParams(next)
+			default:
+				p.back(next, afterNext)
+			}
+		} else {
+			p.back(next)
+		}
+	}
 	alias := tok.typ == tokenSimpleAssignment
 	if alias {
+		if typeParams != nil {
+			panic(syntaxError(tok.pos, "generic type aliases are not supported in this release of Scriggo"))
+		}
 		tok = p.next()
 	}
 	var typ ast.Expression
@@ -1604,6 +1641,7 @@ func (p *parsing) parseTypeDecl(tok token) (*ast.TypeDeclaration, token) {
 		panic(syntaxError(tok.pos, "unexpected %s in type declaration", tok))
 	}
 	node := ast.NewTypeDeclaration(pos, ident, typ, alias)
+	node.TypeParams = typeParams
 	return node, tok
 }
 
@@ -97,15 +97,51 @@ func (p *parsing) parseExpr(tok token, canBeSwitchGuard, canElideType, mustBeTyp
 			if tok.typ != tokenLeftBrace {
 				panic(syntaxError(tok.pos, "unexpected %s, expecting {", tok))
 			}
+			var elements []*ast.Field
 			tok = p.next()
-			if tok.typ != tokenRightBrace {
+			for tok.typ != tokenRightBrace {
 				if tok.typ == tokenIdentifier {
-					panic(syntaxError(tok.pos, "non-empty interfaces are not supported in this release of Scriggo"))
+					next := p.next()
+					if next.typ == tokenLeftParenthesis {
+						// Method.
+						ident := ast.NewIdentifier(tok.pos, string(tok.txt))
+						params, isVariadic, last, next := p.parseFuncParameters(next, false, false)
+						end := last.End
+						result, _, last, next := p.parseFuncParameters(next, false, true)
+						if result != nil {
+							end = last.End
+						}
+						typ := ast.NewFuncType(tok.pos.WithEnd(end), false, params, result, isVariadic)
+						elements = append(elements, ast.NewField([]*ast.Identifier{ident}, typ, ""))
+						tok = next
+						switch tok.typ {
+						case tokenSemicolon:
+							tok = p.next()
+						case tokenRightBrace:
+						default:
+							panic(syntaxError(tok.pos, "unexpected %s, expecting semicolon or newline or }", tok))
+						}
+						continue
+					}
+					p.back(next)
+				}
+				var elem ast.Expression
+				elem, tok = p.parseExpr(tok, false, false, false, false)
+				if elem == nil {
+					panic(syntaxError(tok.pos, "unexpected %s, expecting }", tok))
+				}
+				elements = append(elements, ast.NewField(nil, elem, ""))
+				switch tok.typ {
+				case tokenSemicolon:
+					tok = p.next()
+				case tokenRightBrace:
+				default:
+					panic(syntaxError(tok.pos, "unexpected %s, expecting semicolon or newline or }", tok))
 				}
-				panic(syntaxError(tok.pos, "unexpected %s, expecting }", tok))
 			}
 			pos.End = tok.pos.End
 			operand = ast.NewInterface(pos)
+			operand.(*ast.Interface).Elements = elements
 			tok = p.next()
 		case tokenFunc: // func
 			var node ast.Node
@@ -155,6 +191,7 @@ func (p *parsing) parseExpr(tok token, canBeSwitchGuard, canElideType, mustBeTyp
 			tokenNot,            // !e
 			tokenExtendedNot,    // not e
 			tokenXor,            // ^e
+			tokenTilde,          // ~t
 			tokenMultiplication, // *t, *T
 			tokenAmpersand:      // &e
 			operator = ast.NewUnaryOperator(tok.pos, operatorFromTokenType(tok.typ, false), nil)
@@ -194,6 +231,15 @@ func (p *parsing) parseExpr(tok token, canBeSwitchGuard, canElideType, mustBeTyp
 					operand = ast.NewSelector(tok.pos, operand, ident.Name)
 					tok = p.next()
 				}
+				if tok.typ == tokenLeftBracket {
+					// T[A1, A2, ...]
+					next := p.next()
+					if next.typ == tokenRightBracket || next.typ == tokenEllipsis {
+						p.back(next)
+						break
+					}
+					operand, tok = p.parseTypeArgs(operand, next)
+				}
 			}
 		case tokenLeftBracket: // [
 			canCompositeLiteral = true
@@ -351,6 +397,21 @@ func (p *parsing) parseExpr(tok token, canBeSwitchGuard, canElideType, mustBeTyp
 					}
 					pos.End = tok.pos.End
 					operand = ast.NewSlicing(pos, operand, low, high, max, isFull)
+				} else if tok.typ == tokenComma && index != nil {
+					// e[A1, A2, ...]
+					indices := []ast.Expression{index}
+					for tok.typ == tokenComma {
+						index, tok = p.parseExpr(p.next(), false, false, false, false)
+						if index == nil {
+							break
+						}
+						indices = append(indices, index)
+					}
+					if tok.typ != tokenRightBracket {
+						panic(syntaxError(tok.pos, "unexpected %s, expecting comma or ]", tok))
+					}
+					pos.End = tok.pos.End
+					operand = ast.NewIndexList(pos, operand, indices)
 				} else {
 					if tok.typ != tokenRightBracket {
 						panic(syntaxError(tok.pos, "unexpected %s, expecting ]", tok))
@@ -644,6 +705,133 @@ func (p *parsing) parseExprListInParenthesis(tok token) ([]ast.Expression, token
 	}
 }
 
+// parseTypeArgs parses the type arguments of the generic type expr and
+// returns the instantiated type and the next token. tok is the first token
+// after the left bracket.
+func (p *parsing) parseTypeArgs(expr ast.Expression, tok token) (ast.Expression, token) {
+	var args []ast.Expression
+	for {
+		var arg ast.Expression
+		arg, tok = p.parseExpr(tok, false, false, true, false)
+		if arg == nil {
+			if args != nil && tok.typ == tokenRightBracket {
+				break
+			}
+			panic(syntaxError(tok.pos, "unexpected %s, expecting type", tok))
+		}
+		args = append(args, arg)
+		if tok.typ != tokenComma {
+			break
+		}
+		tok = p.next()
+	}
+	if tok.typ != tokenRightBracket {
+		panic(syntaxError(tok.pos, "unexpected %s, expecting comma or ]", tok))
+	}
+	pos := expr.Pos().WithEnd(tok.pos.End)
+	if len(args) == 1 {
+		return ast.NewIndex(pos, expr, args[0]), p.next()
+	}
+	return ast.NewIndexList(pos, expr, args), p.next()
+}
+
+// parseTypeArgsOrArrayType parses what follows the name ident of a parameter
+// or a field when it is followed by a left bracket. tok is the left bracket.
+//
+// If ident is followed by a list of types, as in 'T[int]', it returns the
+// instantiated type and a nil array type. Otherwise, as in 'a [3]int', it
+// returns a nil instantiated type and the array or slice type of the
+// parameter or field called ident.
+func (p *parsing) parseTypeArgsOrArrayType(ident *ast.Identifier, tok token) (ast.Expression, ast.Expression, token) {
+	next := p.next()
+	if next.typ == tokenRightBracket || next.typ == tokenEllipsis {
+		p.back(next)
+		var typ ast.Expression
+		typ, tok = p.parseExpr(tok, false, false, true, false)
+		return nil, typ, tok
+	}
+	pos := tok.pos
+	var indices []ast.Expression
+	tok = next
+	for {
+		var expr ast.Expression
+		expr, tok = p.parseExpr(tok, false, false, false, false)
+		if expr == nil {
+			if indices != nil && tok.typ == tokenRightBracket {
+				break
+			}
+			panic(syntaxError(tok.pos, "unexpected %s, expecting expression", tok))
+		}
+		indices = append(indices, expr)
+		if tok.typ != tokenComma {
+			break
+		}
+		tok = p.next()
+	}
+	if tok.typ != tokenRightBracket {
+		panic(syntaxError(tok.pos, "unexpected %s, expecting comma or ]", tok))
+	}
+	end := tok.pos.End
+	tok = p.next()
+	if len(indices) == 1 {
+		switch tok.typ {
+		case tokenIdentifier, tokenLeftBracket, tokenMultiplication, tokenLeftParenthesis, tokenFunc,
+			tokenMap, tokenChan, tokenArrow, tokenStruct, tokenInterface:
+			// Array type.
+			var elem ast.Expression
+			elem, tok = p.parseExpr(tok, false, false, true, false)
+			return nil, ast.NewArrayType(pos.WithEnd(elem.Pos().End), indices[0], elem), tok
+		}
+	}
+	pos = ident.Position.WithEnd(end)
+	if len(indices) == 1 {
+		return ast.NewIndex(pos, ident, indices[0]), nil, tok
+	}
+	return ast.NewIndexList(pos, ident, indices), nil, tok
+}
+
+// parseTypeParams parses a type parameter list and returns its fields and
+// the next token. tok is the first token after the left bracket.
+func (p *parsing) parseTypeParams(tok token) ([]*ast.Field, token) {
+	if tok.typ == tokenRightBracket {
+		panic(syntaxError(tok.pos, "empty type parameter list"))
+	}
+	var params []*ast.Field
+	for {
+		if tok.typ != tokenIdentifier {
+			panic(syntaxError(tok.pos, "unexpected %s, expecting name", tok))
+		}
+		field := ast.NewField(nil, nil, "")
+		for {
+			field.Idents = append(field.Idents, p.parseIdentifierNode(tok))
+			tok = p.next()
+			if tok.typ != tokenComma {
+				break
+			}
+			tok = p.next()
+			if tok.typ != tokenIdentifier {
+				panic(syntaxError(tok.pos, "unexpected %s, expecting name", tok))
+			}
+		}
+		field.Type, tok = p.parseExpr(tok, false, false, false, false)
+		if field.Type == nil {
+			panic(syntaxError(tok.pos, "missing type constraint"))
+		}
+		params = append(params, field)
+		if tok.typ == tokenComma {
+			tok = p.next()
+			if tok.typ != tokenRightBracket {
+				continue
+			}
+		}
+		if tok.typ != tokenRightBracket {
+			panic(syntaxError(tok.pos, "unexpected %s, expecting comma or ]", tok))
+		}
+		break
+	}
+	return params, p.next()
+}
+
 // parseField parses a field declaration and returns the parsed field and the
 // next token. The next token is the first token of the next field or a
 // tokenRightBrace token. tok is the first token of the field.
@@ -701,6 +889,13 @@ func (p *parsing) parseField(tok token) (*ast.Field, token) {
 			}
 		case tokenRawString, tokenInterpretedString:
 			field.Type = ident
+		case tokenLeftBracket:
+			var typ ast.Expression
+			field.Type, typ, tok = p.parseTypeArgsOrArrayType(ident, tok)
+			if typ != nil {
+				field.Idents = []*ast.Identifier{ident}
+				field.Type = typ
+			}
 		default:
 			field.Type, tok = p.parseExpr(tok, false, false, true, false)
 			if field.Type == nil {
@@ -782,6 +977,8 @@ func operatorFromTokenType(typ tokenTyp, binary bool) ast.OperatorType {
 		return ast.OperatorExtendedOr
 	case tokenExtendedNot:
 		return ast.OperatorExtendedNot
+	case tokenTilde:
+		return ast.OperatorTilde
 	case tokenContains:
 		return ast.OperatorContains
 	case tokenAddition:
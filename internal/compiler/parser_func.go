@@ -22,21 +22,43 @@ const (
 func (p *parsing) parseFunc(tok token, kind funcKindToParse) (ast.Node, token) {
 	isMacro := tok.typ == tokenMacro
 	pos := tok.pos
-	// Parses the function name if present.
+	// Parses the method receiver, the function name and the type parameters
+	// if present.
+	var recv *ast.Parameter
 	var ident *ast.Identifier
+	var typeParams []*ast.Field
 	tok = p.next()
+	if kind == parseFuncDecl && !isMacro && tok.typ == tokenLeftParenthesis {
+		recvPos := tok.pos
+		var params []*ast.Parameter
+		var isVariadic bool
+		params, isVariadic, _, tok = p.parseFuncParameters(tok, false, false)
+		switch {
+		case len(params) == 0:
+			panic(syntaxError(recvPos, "method has no receiver"))
+		case len(params) > 1:
+			panic(syntaxError(recvPos, "method has multiple receivers"))
+		case isVariadic:
+			panic(syntaxError(recvPos, "invalid use of ... in receiver"))
+		}
+		recv = params[0]
+		if tok.typ != tokenIdentifier {
+			panic(syntaxError(tok.pos, "unexpected %s, expecting name", tok.txt))
+		}
+	}
 	if tok.typ == tokenIdentifier {
 		if kind&parseFuncDecl == 0 {
 			panic(syntaxError(tok.pos, "unexpected %s, expecting (", tok))
 		}
 		ident = ast.NewIdentifier(tok.pos, string(tok.txt))
 		tok = p.next()
-	} else if kind == parseFuncDecl {
-		// This check could be avoided (the code panics anyway) but improves the
-		// readability of the error message.
-		if !isMacro && tok.typ == tokenLeftParenthesis {
-			panic(syntaxError(tok.pos, "method declarations are not supported in this release of Scriggo"))
+		if !isMacro && tok.typ == tokenLeftBracket {
+			if recv != nil {
+				panic(syntaxError(tok.pos, "method must have no type parameters"))
+			}
+			typeParams, tok = p.parseTypeParams(p.next())
 		}
+	} else if kind == parseFuncDecl {
 		// Node to parse must be a function declaration.
 		panic(syntaxError(tok.pos, "unexpected %s, expecting name", tok.txt))
 	}
@@ -66,6 +88,8 @@ func (p *parsing) parseFunc(tok token, kind funcKindToParse) (ast.Node, token) {
 		return typ, tok
 	}
 	node := ast.NewFunc(pos, ident, typ, nil, false, ast.Format(tok.ctx))
+	node.Recv = recv
+	node.TypeParams = typeParams
 	if !isMacro && tok.typ != tokenLeftBrace {
 		return node, tok
 	}
@@ -148,7 +172,23 @@ func (p *parsing) parseFuncParameters(tok token, isMacro, isResult bool) ([]*ast
 
 	for {
 		param := ast.NewParameter(nil, nil)
-		param.Type, tok = p.parseExpr(tok, false, false, true, false)
+		if tok.typ == tokenIdentifier {
+			// Look ahead to distinguish 'T[A]' from 'a [N]T'.
+			next := p.next()
+			p.back(next)
+			if next.typ == tokenLeftBracket {
+				ident := p.parseIdentifierNode(tok)
+				var typ ast.Expression
+				param.Type, typ, tok = p.parseTypeArgsOrArrayType(ident, p.next())
+				if typ != nil {
+					param.Ident = ident
+					param.Type = typ
+				}
+			}
+		}
+		if param.Type == nil {
+			param.Type, tok = p.parseExpr(tok, false, false, true, false)
+		}
 		if tok.typ == tokenEllipsis {
 			if ellipses.param == nil {
 				ellipses.param = param
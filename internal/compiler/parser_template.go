@@ -32,6 +32,13 @@ type FormatFS interface {
 // ParseTemplate expands the nodes Extends, Import and Render parsing the
 // relative trees.
 func ParseTemplate(fsys fs.FS, name string, noParseShow bool) (*ast.Tree, error) {
+	return parseTemplate(fsys, name, noParseShow, nil, nil)
+}
+
+// parseTemplate is like ParseTemplate but, if cache is not nil, it parses
+// the files using cache. If files is not nil, the files parsed using cache
+// are added to files.
+func parseTemplate(fsys fs.FS, name string, noParseShow bool, cache *ParseCache, files map[*ast.Tree]parsedFile) (*ast.Tree, error) {
 
 	if name == "." || strings.HasSuffix(name, "/") {
 		return nil, os.ErrInvalid
@@ -48,6 +55,8 @@ func ParseTemplate(fsys fs.FS, name string, noParseShow bool) (*ast.Tree, error)
 		paths:       []string{},
 		canExtend:   true,
 		noParseShow: noParseShow,
+		cache:       cache,
+		files:       files,
 	}
 
 	tree, err := pp.parseSource(src, name, format, false)
@@ -70,6 +79,14 @@ type templateExpansion struct {
 	paths       []string
 	canExtend   bool
 	noParseShow bool
+	cache       *ParseCache
+	files       map[*ast.Tree]parsedFile
+}
+
+// parsedFile is a file parsed using a parse cache.
+type parsedFile struct {
+	entry *parseCacheEntry // entry of the cached tree
+	trees []*ast.Tree      // trees of the files extended, imported and rendered
 }
 
 // parsedTree represents a parsed tree. parent is the file path and node that
@@ -181,7 +198,15 @@ func (pp *templateExpansion) parseNodeFile(node ast.Node) (*ast.Tree, error) {
 // the file is imported. path must be absolute and cleared.
 func (pp *templateExpansion) parseSource(src []byte, path string, format ast.Format, imported bool) (*ast.Tree, error) {
 
-	tree, unexpanded, err := ParseTemplateSource(src, format, imported, pp.noParseShow)
+	var tree *ast.Tree
+	var unexpanded []ast.Node
+	var entry *parseCacheEntry
+	var err error
+	if pp.cache == nil {
+		tree, unexpanded, err = ParseTemplateSource(src, format, imported, pp.noParseShow)
+	} else {
+		tree, unexpanded, entry, err = pp.cache.parse(src, path, format, imported, pp.noParseShow)
+	}
 	if err != nil {
 		if se, ok := err.(*SyntaxError); ok {
 			se.path = path
@@ -201,6 +226,27 @@ func (pp *templateExpansion) parseSource(src []byte, path string, format ast.For
 		return nil, err
 	}
 
+	if pp.files != nil && entry != nil {
+		file := parsedFile{entry: entry}
+		for _, node := range unexpanded {
+			var t *ast.Tree
+			switch n := node.(type) {
+			case *ast.Extends:
+				t = n.Tree
+			case *ast.Import:
+				t = n.Tree
+			case *ast.Render:
+				t = n.Tree
+			case *ast.Default:
+				t = n.Expr1.(*ast.Render).Tree
+			}
+			if t != nil {
+				file.trees = append(file.trees, t)
+			}
+		}
+		pp.files[tree] = file
+	}
+
 	return tree, nil
 }
 
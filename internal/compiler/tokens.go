@@ -106,6 +106,7 @@ const (
 	tokenDecrement                         // --
 	tokenArrow                             // <-
 	tokenXor                               // ^
+	tokenTilde                             // ~
 	tokenAndNot                            // &^
 	tokenLeftShift                         // <<
 	tokenRightShift                        // >>
@@ -212,6 +213,7 @@ var tokenString = map[tokenTyp]string{
 	tokenDecrement:                "--",
 	tokenArrow:                    "<-",
 	tokenXor:                      "^",
+	tokenTilde:                    "~",
 	tokenAndNot:                   "&^",
 	tokenLeftShift:                "<<",
 	tokenRightShift:               ">>",
@@ -26,6 +26,8 @@ const (
 	propertyIsMacroDeclaration                                    // is macro declaration
 	propertyMacroDeclaredInFileWithExtends                        // is macro declared in file with extends
 	propertyMapSelector                                           // is a map selector expression
+	propertyIsGeneric                                             // is a generic function or type
+	propertyIsConstraint                                          // is a type constraint
 )
 
 // A typeInfo holds the type checking information. For example, every expression
@@ -139,6 +141,16 @@ func (ti *typeInfo) IsMapSelector() bool {
 	return ti.Properties&propertyMapSelector != 0
 }
 
+// IsGeneric reports whether it is a generic function or type.
+func (ti *typeInfo) IsGeneric() bool {
+	return ti.Properties&propertyIsGeneric != 0
+}
+
+// IsConstraint reports whether it is a type constraint.
+func (ti *typeInfo) IsConstraint() bool {
+	return ti.Properties&propertyIsConstraint != 0
+}
+
 // TypeName returns the name of the type. If it is an alias, it returns the
 // name of the alias. Panics if it is not a type.
 func (ti *typeInfo) TypeName() string {
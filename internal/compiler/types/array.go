@@ -61,8 +61,15 @@ func (x arrayType) GoType() reflect.Type {
 	return x.Type
 }
 
+// MethodValue implements the interface runtime.ScriggoType.
+func (x arrayType) MethodValue(v reflect.Value, name string) (reflect.Value, bool) {
+	return methodValue(x, v, name)
+}
+
 // Unwrap implements the interface runtime.ScriggoType.
 func (x arrayType) Unwrap(v reflect.Value) (reflect.Value, bool) { return unwrap(x, v) }
 
 // Wrap implements the interface runtime.ScriggoType.
-func (x arrayType) Wrap(v reflect.Value) reflect.Value { return wrap(x, v) }
+func (x arrayType) Wrap(v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	return wrap(x, v, caller)
+}
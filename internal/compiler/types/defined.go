@@ -21,6 +21,10 @@ type definedType struct {
 
 	name string
 
+	// methods are the methods declared in Scriggo on the type. It is shared
+	// by all the copies of the type.
+	methods *methodSet
+
 	// sign ensures that a definedType returned by DefinedOf is always
 	// different from every other instance of definedType.
 	// By doing so, two reflect.Types are equal if and only if the type they
@@ -36,7 +40,17 @@ func (types *Types) DefinedOf(name string, underlyingType reflect.Type) reflect.
 	if name == "" {
 		panic(internalError("name cannot be empty"))
 	}
-	return definedType{Type: underlyingType, name: name, sign: new(byte)}
+	return definedType{Type: underlyingType, name: name, methods: &methodSet{}, sign: new(byte)}
+}
+
+// Underlying returns the underlying type of the defined type t. It panics if
+// t is not a type returned by DefinedOf.
+func Underlying(t reflect.Type) reflect.Type {
+	dt, ok := t.(definedType)
+	if !ok {
+		panic(internalError("%s is not a defined type", t))
+	}
+	return dt.Type
 }
 
 func (x definedType) Name() string {
@@ -55,7 +69,10 @@ func (x definedType) Implements(y reflect.Type) bool {
 	return Implements(x, y)
 }
 
-func (x definedType) MethodByName(string) (reflect.Method, bool) {
+func (x definedType) MethodByName(name string) (reflect.Method, bool) {
+	if x.Type.Kind() == reflect.Interface {
+		return x.Type.MethodByName(name)
+	}
 	// TODO.
 	return reflect.Method{}, false
 }
@@ -76,8 +93,15 @@ func (x definedType) GoType() reflect.Type {
 	return x.Type
 }
 
+// MethodValue implements the interface runtime.ScriggoType.
+func (x definedType) MethodValue(v reflect.Value, name string) (reflect.Value, bool) {
+	return methodValue(x, v, name)
+}
+
 // Unwrap implements the interface runtime.ScriggoType.
 func (x definedType) Unwrap(v reflect.Value) (reflect.Value, bool) { return unwrap(x, v) }
 
 // Wrap implements the interface runtime.ScriggoType.
-func (x definedType) Wrap(v reflect.Value) reflect.Value { return wrap(x, v) }
+func (x definedType) Wrap(v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	return wrap(x, v, caller)
+}
@@ -153,9 +153,16 @@ func (x funcType) String() string {
 		if i > 0 {
 			s += ", "
 		}
+		if x.IsVariadic() && i == len(*x.in)-1 {
+			s += "..." + t.Elem().String()
+			continue
+		}
 		s += t.String()
 	}
-	s += ") "
+	s += ")"
+	if len(*x.out) > 0 {
+		s += " "
+	}
 	if len(*x.out) >= 2 {
 		s += "("
 	}
@@ -177,8 +184,15 @@ func (x funcType) GoType() reflect.Type {
 	return x.Type
 }
 
+// MethodValue implements the interface runtime.ScriggoType.
+func (x funcType) MethodValue(v reflect.Value, name string) (reflect.Value, bool) {
+	return methodValue(x, v, name)
+}
+
 // Unwrap implements the interface runtime.ScriggoType.
 func (x funcType) Unwrap(v reflect.Value) (reflect.Value, bool) { return unwrap(x, v) }
 
 // Wrap implements the interface runtime.ScriggoType.
-func (x funcType) Wrap(v reflect.Value) reflect.Value { return wrap(x, v) }
+func (x funcType) Wrap(v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	return wrap(x, v, caller)
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+var emptyInterfaceType = reflect.TypeOf(&[]interface{}{nil}[0]).Elem()
+
+// InterfaceOf returns the interface type with the given methods. The type of
+// each method must be a function type without the receiver. If methods is
+// empty, it returns the empty interface type.
+//
+// As reflect does not allow to create interface types, the returned type is
+// always a Scriggo type, also if no method has a Scriggo type in its
+// signature. Values of the returned type are represented, as in Go, by an
+// empty interface.
+func (types *Types) InterfaceOf(methods []reflect.Method) reflect.Type {
+	if len(methods) == 0 {
+		return emptyInterfaceType
+	}
+	ms := make([]reflect.Method, len(methods))
+	copy(ms, methods)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+	for i := range ms {
+		ms[i].Index = i
+		ms[i].Func = reflect.Value{}
+	}
+	return interfaceType{
+		Type:    emptyInterfaceType,
+		methods: types.addMethods(ms),
+	}
+}
+
+// addMethods adds a list of interface methods to the cache if not already
+// present or returns the found one. As for addFields, two pointers returned
+// by this method are equal if and only if the methods are equal.
+func (types *Types) addMethods(methods []reflect.Method) *[]reflect.Method {
+	for _, stored := range types.interfaceMethodsLists {
+		if equalMethods(*stored, methods) {
+			return stored
+		}
+	}
+	types.interfaceMethodsLists = append(types.interfaceMethodsLists, &methods)
+	return &methods
+}
+
+func equalMethods(ms1, ms2 []reflect.Method) bool {
+	if len(ms1) != len(ms2) {
+		return false
+	}
+	for i := range ms1 {
+		if ms1[i].Name != ms2[i].Name || ms1[i].PkgPath != ms2[i].PkgPath || ms1[i].Type != ms2[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// interfaceType represents an interface type with at least one method,
+// declared in Scriggo.
+type interfaceType struct {
+	reflect.Type // always the empty interface type.
+	methods      *[]reflect.Method
+}
+
+func (x interfaceType) AssignableTo(y reflect.Type) bool {
+	return AssignableTo(x, y)
+}
+
+func (x interfaceType) ConvertibleTo(y reflect.Type) bool {
+	return ConvertibleTo(x, y)
+}
+
+func (x interfaceType) Implements(y reflect.Type) bool {
+	return Implements(x, y)
+}
+
+func (x interfaceType) Method(i int) reflect.Method {
+	return (*x.methods)[i]
+}
+
+func (x interfaceType) MethodByName(name string) (reflect.Method, bool) {
+	for _, m := range *x.methods {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return reflect.Method{}, false
+}
+
+func (x interfaceType) Name() string {
+	return "" // composite types do not have a name.
+}
+
+func (x interfaceType) NumMethod() int {
+	return len(*x.methods)
+}
+
+func (x interfaceType) String() string {
+	var s strings.Builder
+	s.WriteString("interface { ")
+	for i, m := range *x.methods {
+		if i > 0 {
+			s.WriteString("; ")
+		}
+		s.WriteString(m.Name)
+		s.WriteString(strings.TrimPrefix(m.Type.String(), "func"))
+	}
+	s.WriteString(" }")
+	return s.String()
+}
+
+// GoType implements the interface runtime.ScriggoType.
+func (x interfaceType) GoType() reflect.Type {
+	return x.Type
+}
+
+// MethodValue implements the interface runtime.ScriggoType.
+func (x interfaceType) MethodValue(v reflect.Value, name string) (reflect.Value, bool) {
+	return methodValue(x, v, name)
+}
+
+// Unwrap implements the interface runtime.ScriggoType.
+func (x interfaceType) Unwrap(v reflect.Value) (reflect.Value, bool) { return unwrap(x, v) }
+
+// Wrap implements the interface runtime.ScriggoType.
+func (x interfaceType) Wrap(v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	return wrap(x, v, caller)
+}
+
+// implementsInterface reports whether the type x has in its method set all
+// the methods of the interface type y, where at least one of x and y is a
+// Scriggo type.
+func implementsInterface(x, y reflect.Type) bool {
+	for i := 0; i < y.NumMethod(); i++ {
+		ym := y.Method(i)
+		t, ok := methodType(x, ym.Name)
+		if !ok || t != ym.Type {
+			return false
+		}
+	}
+	return true
+}
+
+// methodType returns the type, without the receiver, of the method with the
+// given name in the method set of the type t, and true. If t does not have
+// the method in its method set, it returns nil and false.
+func methodType(t reflect.Type, name string) (reflect.Type, bool) {
+	if t.Kind() == reflect.Interface {
+		m, ok := t.MethodByName(name)
+		return m.Type, ok
+	}
+	if _, ok := t.(runtime.ScriggoType); ok {
+		m, _, indirect, ok := LookupMethod(t, name)
+		if !ok || m.Pointer && t.Kind() != reflect.Ptr && !indirect {
+			return nil, false
+		}
+		return m.Type, true
+	}
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return nil, false
+	}
+	mt := m.Type
+	in := make([]reflect.Type, mt.NumIn()-1)
+	for i := range in {
+		in[i] = mt.In(i + 1)
+	}
+	out := make([]reflect.Type, mt.NumOut())
+	for i := range out {
+		out[i] = mt.Out(i)
+	}
+	return reflect.FuncOf(in, out, mt.IsVariadic()), true
+}
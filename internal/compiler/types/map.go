@@ -97,8 +97,15 @@ func (x mapType) GoType() reflect.Type {
 	return x.Type
 }
 
+// MethodValue implements the interface runtime.ScriggoType.
+func (x mapType) MethodValue(v reflect.Value, name string) (reflect.Value, bool) {
+	return methodValue(x, v, name)
+}
+
 // Unwrap implements the interface runtime.ScriggoType.
 func (x mapType) Unwrap(v reflect.Value) (reflect.Value, bool) { return unwrap(x, v) }
 
 // Wrap implements the interface runtime.ScriggoType.
-func (x mapType) Wrap(v reflect.Value) reflect.Value { return wrap(x, v) }
+func (x mapType) Wrap(v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	return wrap(x, v, caller)
+}
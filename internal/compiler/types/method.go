@@ -0,0 +1,187 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// Method represents a method declared in Scriggo on a defined type.
+type Method struct {
+	Name    string            // method name.
+	Type    reflect.Type      // method type, without the receiver.
+	Pointer bool              // reports whether the receiver is a pointer.
+	Func    *runtime.Function // function with the receiver as first parameter.
+}
+
+// methodSet is the set of the methods declared on a defined type, sorted by
+// name.
+type methodSet []*Method
+
+// AddMethod adds the method m to the defined type t. It panics if t is not a
+// type returned by DefinedOf or if t already has a method with the same name.
+func (types *Types) AddMethod(t reflect.Type, m *Method) {
+	dt, ok := t.(definedType)
+	if !ok {
+		panic(internalError("cannot add a method to the non-defined type %s", t))
+	}
+	methods := *dt.methods
+	i := sort.Search(len(methods), func(i int) bool { return methods[i].Name >= m.Name })
+	if i < len(methods) && methods[i].Name == m.Name {
+		panic(internalError("method %s.%s already added", t, m.Name))
+	}
+	methods = append(methods, nil)
+	copy(methods[i+1:], methods[i:])
+	methods[i] = m
+	*dt.methods = methods
+}
+
+// IsDefined reports whether t is a type returned by DefinedOf.
+func IsDefined(t reflect.Type) bool {
+	_, ok := t.(definedType)
+	return ok
+}
+
+// Methods returns the methods declared in Scriggo on the defined type t,
+// sorted by name. It panics if t is not a type returned by DefinedOf.
+func Methods(t reflect.Type) []*Method {
+	dt, ok := t.(definedType)
+	if !ok {
+		panic(internalError("%s is not a defined type", t))
+	}
+	methods := make([]*Method, len(*dt.methods))
+	copy(methods, *dt.methods)
+	return methods
+}
+
+// MethodOf returns the method with the given name declared in Scriggo on the
+// defined type t or, if t is a pointer type, on its element type. The method
+// is returned even if, t not being a pointer type, it has a pointer receiver.
+func MethodOf(t reflect.Type, name string) (*Method, bool) {
+	if p, ok := t.(ptrType); ok {
+		t = p.elem
+	}
+	dt, ok := t.(definedType)
+	if !ok {
+		return nil, false
+	}
+	methods := *dt.methods
+	i := sort.Search(len(methods), func(i int) bool { return methods[i].Name >= name })
+	if i < len(methods) && methods[i].Name == name {
+		return methods[i], true
+	}
+	return nil, false
+}
+
+// LookupMethod is like MethodOf but, if t, or its element type if t is a
+// pointer type, is a struct type, it also looks for the method promoted from
+// its embedded fields.
+//
+// If the method is promoted, it returns the index sequence of the embedded
+// field that declares it, and indirect reports whether one of the embedded
+// fields in the sequence is a pointer.
+func LookupMethod(t reflect.Type, name string) (m *Method, index []int, indirect bool, ok bool) {
+	if m, ok := MethodOf(t, name); ok {
+		return m, nil, false, true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, false, false
+	}
+	type embedded struct {
+		typ      reflect.Type
+		index    []int
+		indirect bool
+	}
+	current := []embedded{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []embedded
+		var found embedded
+		count := 0
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				field := e.typ.Field(i)
+				if fieldName(field.Name) == name {
+					// A field hides the methods with the same name.
+					count++
+					continue
+				}
+				if !field.Anonymous {
+					continue
+				}
+				index := append(append([]int{}, e.index...), i)
+				ft := field.Type
+				indirect := e.indirect
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+					indirect = true
+				}
+				if em, ok := MethodOf(ft, name); ok {
+					count++
+					m = em
+					found = embedded{typ: ft, index: index, indirect: indirect}
+					continue
+				}
+				if ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index, indirect: indirect})
+				}
+			}
+		}
+		if count > 0 {
+			if count > 1 || m == nil {
+				// The selector is ambiguous or it is a field.
+				return nil, nil, false, false
+			}
+			return m, found.index, found.indirect, true
+		}
+		current = next
+	}
+	return nil, nil, false, false
+}
+
+// fieldName returns the name of a struct field as declared in Scriggo. The
+// names of the unexported fields of the struct types declared in Scriggo are
+// prefixed with "𝗽" and a number.
+func fieldName(name string) string {
+	if !strings.HasPrefix(name, "𝗽") {
+		return name
+	}
+	return strings.TrimLeft(name[len("𝗽"):], "0123456789")
+}
+
+// implementsScriggo reports whether the type x, that is a Scriggo type, has
+// in its method set all the methods of the interface type y. As y is a Go
+// interface type, only the methods that a proxy exposes to Go are considered.
+func implementsScriggo(x, y reflect.Type) bool {
+	if y.NumMethod() == 0 {
+		return true
+	}
+	exposed := proxyMethodsOf(x.(runtime.ScriggoType))
+	for i := 0; i < y.NumMethod(); i++ {
+		ym := y.Method(i)
+		found := false
+		for _, name := range exposed {
+			if m, _, _, _ := LookupMethod(x, name); name == ym.Name && m.Type == ym.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
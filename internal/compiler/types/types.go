@@ -24,6 +24,10 @@ type Types struct {
 	// structFieldsLists avoid the creation of two different structTypes with
 	// the same struct fields.
 	structFieldsLists []*map[int]reflect.StructField
+
+	// interfaceMethodsLists avoid the creation of two different
+	// interfaceTypes with the same methods.
+	interfaceMethodsLists []*[]reflect.Method
 }
 
 // NewTypes returns a new instance of Types.
@@ -160,11 +164,14 @@ func ConvertibleTo(x, y reflect.Type) bool {
 
 // Implements reports whether x implements the interface type y.
 func Implements(x, y reflect.Type) bool {
-	if _, ok := x.(runtime.ScriggoType); ok {
-		return y.NumMethod() == 0
-	}
 	if _, ok := y.(runtime.ScriggoType); ok {
-		return true
+		return implementsInterface(x, y)
+	}
+	if _, ok := x.(runtime.ScriggoType); ok {
+		if x.Kind() == reflect.Interface {
+			return implementsInterface(x, y)
+		}
+		return implementsScriggo(x, y)
 	}
 	// If y has unexported methods, and x is not an interface type, it is not possible to check
 	// if x implements y using the x.NumMethod and x.Method methods, because they do not return
@@ -172,6 +179,12 @@ func Implements(x, y reflect.Type) bool {
 	return x.Implements(y)
 }
 
+// IdenticalUnderlying reports whether the types x and y have identical
+// underlying types.
+func IdenticalUnderlying(x, y reflect.Type) bool {
+	return identical(x, y, true, false)
+}
+
 // identical reports whether the types x and y, or their underlying types if
 // underlying is true, are identical. If ignoreTags is true, the tags are
 // ignored.
@@ -288,7 +301,7 @@ func (types *Types) TypeOf(v reflect.Value) reflect.Type {
 	if !v.IsValid() {
 		return nil
 	}
-	if p, ok := v.Interface().(emptyInterfaceProxy); ok {
+	if p, ok := proxyOf(v); ok {
 		return p.sign
 	}
 	return v.Type()
@@ -8,23 +8,76 @@ import (
 	"reflect"
 
 	"github.com/open2b/scriggo/internal/runtime"
+	"github.com/open2b/scriggo/native"
 )
 
 // wrap and unwrap are called by the methods Wrap and Unwrap of the types
 // defined in this package. These two methods and the GoType method
 // implement the runtime.ScriggoType interface.
 
-func wrap(t runtime.ScriggoType, v reflect.Value) reflect.Value {
-	return reflect.ValueOf(emptyInterfaceProxy{
-		value: v,
-		sign:  t,
-	})
+func wrap(t runtime.ScriggoType, v reflect.Value, caller runtime.FunctionCaller) reflect.Value {
+	if t.Kind() == reflect.Interface {
+		// A value of an interface type is already wrapped, if it has a
+		// Scriggo type, or it has a Go type.
+		return v
+	}
+	p := proxy{value: v, sign: t, caller: caller}
+	methods := proxyMethodsOf(t)
+	switch len(methods) {
+	case 0:
+		return reflect.ValueOf(emptyInterfaceProxy{p})
+	case 1:
+		switch methods[0] {
+		case "Error":
+			return reflect.ValueOf(errorProxy{p})
+		case "String":
+			return reflect.ValueOf(stringerProxy{p})
+		case "HTML":
+			return reflect.ValueOf(htmlStringerProxy{p})
+		case "CSS":
+			return reflect.ValueOf(cssStringerProxy{p})
+		case "JS":
+			return reflect.ValueOf(jsStringerProxy{p})
+		case "JSON":
+			return reflect.ValueOf(jsonStringerProxy{p})
+		case "Markdown":
+			return reflect.ValueOf(markdownStringerProxy{p})
+		}
+	case 2:
+		switch methods[1] {
+		case "String":
+			return reflect.ValueOf(errorStringerProxy{errorProxy{p}})
+		case "HTML":
+			return reflect.ValueOf(stringerHTMLStringerProxy{htmlStringerProxy{p}})
+		case "CSS":
+			return reflect.ValueOf(stringerCSSStringerProxy{cssStringerProxy{p}})
+		case "JS":
+			return reflect.ValueOf(stringerJSStringerProxy{jsStringerProxy{p}})
+		case "JSON":
+			return reflect.ValueOf(stringerJSONStringerProxy{jsonStringerProxy{p}})
+		case "Markdown":
+			return reflect.ValueOf(stringerMarkdownStringerProxy{markdownStringerProxy{p}})
+		}
+	}
+	panic("unexpected proxy methods")
 }
 
-// TODO: currently unwrap always returns an empty interface wrapper. This will
-// change when methods declaration will be implemented in Scriggo.
 func unwrap(x runtime.ScriggoType, v reflect.Value) (reflect.Value, bool) {
-	p, ok := v.Interface().(emptyInterfaceProxy)
+	if x.Kind() == reflect.Interface {
+		// v can be unwrapped if its dynamic type implements x.
+		if !v.IsValid() {
+			return reflect.Value{}, false
+		}
+		t := v.Type()
+		if p, ok := proxyOf(v); ok {
+			t = p.sign
+		}
+		if !Implements(t, x) {
+			return reflect.Value{}, false
+		}
+		return v, true
+	}
+	p, ok := proxyOf(v)
 	// Not a proxy.
 	if !ok {
 		return reflect.Value{}, false
@@ -36,9 +89,180 @@ func unwrap(x runtime.ScriggoType, v reflect.Value) (reflect.Value, bool) {
 	return p.value, true
 }
 
+// methodValue returns the method value, bound to the value v, of the method
+// with the given name declared in Scriggo, and true. v must be a proxy for a
+// value of type t. If v is not a proxy or its type does not have the method
+// in its method set, it returns false.
+func methodValue(t runtime.ScriggoType, v reflect.Value, name string) (reflect.Value, bool) {
+	p, ok := proxyOf(v)
+	if !ok || p.sign != t {
+		return reflect.Value{}, false
+	}
+	m, _, indirect, ok := LookupMethod(t, name)
+	if !ok || m.Pointer && t.Kind() != reflect.Ptr && !indirect {
+		return reflect.Value{}, false
+	}
+	typ := m.Type
+	if st, ok := typ.(runtime.ScriggoType); ok {
+		typ = st.GoType()
+	}
+	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		return p.call(name, args...)
+	}), true
+}
+
+// proxyOf returns the proxy of v and true, if v is a proxy. Otherwise it
+// returns false.
+func proxyOf(v reflect.Value) (proxy, bool) {
+	p, ok := v.Interface().(interface{ proxied() proxy })
+	if !ok {
+		return proxy{}, false
+	}
+	return p.proxied(), true
+}
+
+// proxyMethods are the methods, with their types, that a proxy can expose to
+// Go.
+var proxyMethods = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"Error", reflect.TypeOf((func() string)(nil))},
+	{"String", reflect.TypeOf((func() string)(nil))},
+	{"HTML", reflect.TypeOf((func() native.HTML)(nil))},
+	{"CSS", reflect.TypeOf((func() native.CSS)(nil))},
+	{"JS", reflect.TypeOf((func() native.JS)(nil))},
+	{"JSON", reflect.TypeOf((func() native.JSON)(nil))},
+	{"Markdown", reflect.TypeOf((func() native.Markdown)(nil))},
+}
+
+// proxyMethodsOf returns the names of the methods that a proxy for values of
+// type t exposes to Go. A proxy exposes the Error and String methods, or the
+// String method and the first of the HTML, CSS, JS, JSON and Markdown
+// methods, or only one of these methods, in order of precedence.
+func proxyMethodsOf(t runtime.ScriggoType) []string {
+	_, isPtr := t.(ptrType)
+	var methods []string
+	for _, pm := range proxyMethods {
+		m, _, indirect, ok := LookupMethod(t, pm.name)
+		if !ok || m.Pointer && !isPtr && !indirect || m.Type != pm.typ {
+			continue
+		}
+		switch {
+		case len(methods) == 0:
+			methods = append(methods, pm.name)
+		case len(methods) == 1 && (methods[0] == "Error" && pm.name == "String" || methods[0] == "String" && pm.name != "Error"):
+			methods = append(methods, pm.name)
+		}
+	}
+	return methods
+}
+
+// proxy is a proxy for a value with a Scriggo type. It is embedded in the
+// proxy types that are passed to Go.
+type proxy struct {
+	value  reflect.Value
+	sign   runtime.ScriggoType
+	caller runtime.FunctionCaller
+}
+
+func (p proxy) proxied() proxy {
+	return p
+}
+
+// call calls the method with the given name, declared in Scriggo, on the
+// proxied value with the arguments args and returns its results.
+func (p proxy) call(name string, args ...reflect.Value) []reflect.Value {
+	m, index, _, _ := LookupMethod(p.sign, name)
+	rcvr := p.value
+	for _, i := range index {
+		if rcvr.Kind() == reflect.Ptr {
+			rcvr = rcvr.Elem()
+		}
+		rcvr = rcvr.Field(i)
+	}
+	if isPtr := rcvr.Kind() == reflect.Ptr; isPtr && !m.Pointer {
+		rcvr = rcvr.Elem()
+	} else if !isPtr && m.Pointer {
+		rcvr = rcvr.Addr()
+	}
+	return p.caller.CallFunction(m.Func, append([]reflect.Value{rcvr}, args...))
+}
+
 // emptyInterfaceProxy is a proxy for values of types that have an empty
 // method set.
-type emptyInterfaceProxy struct {
-	value reflect.Value
-	sign  runtime.ScriggoType
+type emptyInterfaceProxy struct{ proxy }
+
+// errorProxy is a proxy for values of types with an Error method.
+type errorProxy struct{ proxy }
+
+func (p errorProxy) Error() string { return p.call("Error")[0].String() }
+
+// stringerProxy is a proxy for values of types with a String method.
+type stringerProxy struct{ proxy }
+
+func (p stringerProxy) String() string { return p.call("String")[0].String() }
+
+// errorStringerProxy is a proxy for values of types with the Error and String
+// methods.
+type errorStringerProxy struct{ errorProxy }
+
+func (p errorStringerProxy) String() string { return p.call("String")[0].String() }
+
+// htmlStringerProxy is a proxy for values of types with an HTML method.
+type htmlStringerProxy struct{ proxy }
+
+func (p htmlStringerProxy) HTML() native.HTML { return native.HTML(p.call("HTML")[0].String()) }
+
+// cssStringerProxy is a proxy for values of types with a CSS method.
+type cssStringerProxy struct{ proxy }
+
+func (p cssStringerProxy) CSS() native.CSS { return native.CSS(p.call("CSS")[0].String()) }
+
+// jsStringerProxy is a proxy for values of types with a JS method.
+type jsStringerProxy struct{ proxy }
+
+func (p jsStringerProxy) JS() native.JS { return native.JS(p.call("JS")[0].String()) }
+
+// jsonStringerProxy is a proxy for values of types with a JSON method.
+type jsonStringerProxy struct{ proxy }
+
+func (p jsonStringerProxy) JSON() native.JSON { return native.JSON(p.call("JSON")[0].String()) }
+
+// markdownStringerProxy is a proxy for values of types with a Markdown
+// method.
+type markdownStringerProxy struct{ proxy }
+
+func (p markdownStringerProxy) Markdown() native.Markdown {
+	return native.Markdown(p.call("Markdown")[0].String())
 }
+
+// stringerHTMLStringerProxy is a proxy for values of types with the String
+// and HTML methods.
+type stringerHTMLStringerProxy struct{ htmlStringerProxy }
+
+func (p stringerHTMLStringerProxy) String() string { return p.call("String")[0].String() }
+
+// stringerCSSStringerProxy is a proxy for values of types with the String and
+// CSS methods.
+type stringerCSSStringerProxy struct{ cssStringerProxy }
+
+func (p stringerCSSStringerProxy) String() string { return p.call("String")[0].String() }
+
+// stringerJSStringerProxy is a proxy for values of types with the String and
+// JS methods.
+type stringerJSStringerProxy struct{ jsStringerProxy }
+
+func (p stringerJSStringerProxy) String() string { return p.call("String")[0].String() }
+
+// stringerJSONStringerProxy is a proxy for values of types with the String
+// and JSON methods.
+type stringerJSONStringerProxy struct{ jsonStringerProxy }
+
+func (p stringerJSONStringerProxy) String() string { return p.call("String")[0].String() }
+
+// stringerMarkdownStringerProxy is a proxy for values of types with the
+// String and Markdown methods.
+type stringerMarkdownStringerProxy struct{ markdownStringerProxy }
+
+func (p stringerMarkdownStringerProxy) String() string { return p.call("String")[0].String() }
@@ -0,0 +1,390 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Statement represents the start of a statement in the body of a function.
+type Statement struct {
+	Addr     Addr     // address of the first instruction of the statement.
+	Path     string   // path of the source code where the statement is located in.
+	Position Position // position of the statement in the source code.
+}
+
+// LocalVar represents a local variable of a function, parameters included.
+type LocalVar struct {
+	Name  string       // name.
+	Type  reflect.Type // type.
+	Reg   int16        // register; it is negative if the register is indirect.
+	Start Addr         // address of the first instruction in the scope of the variable.
+	End   Addr         // address of the first instruction out of the scope of the variable.
+}
+
+// DebugStep indicates how the execution is resumed after a debugger stop.
+type DebugStep int8
+
+const (
+	DebugContinue DebugStep = iota // continue until a breakpoint or a pause
+	DebugStepOver                  // stop at the next line of the same function
+	DebugStepInto                  // stop at the next line, entering the called functions
+	DebugStepOut                   // stop after the current function has returned
+)
+
+// DebugReason is the reason why the execution has been stopped.
+type DebugReason int8
+
+const (
+	DebugBreakpoint DebugReason = iota // a breakpoint has been reached
+	DebugStepped                       // a step has been completed
+	DebugPaused                        // a pause has been requested
+)
+
+// Debugger holds the breakpoints and the pause requests of a debugged
+// execution. Its methods can be called concurrently, also while the execution
+// is running.
+type Debugger struct {
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool // breakpoint lines indexed by path.
+	version     uint32                  // incremented when breakpoints change; accessed atomically.
+	pause       int32                   // 1 if a pause has been requested; accessed atomically.
+}
+
+// NewDebugger returns a new debugger.
+func NewDebugger() *Debugger {
+	return &Debugger{breakpoints: map[string]map[int]bool{}}
+}
+
+// SetBreakpoints sets the breakpoints of the file with the given path,
+// replacing the previous ones. If lines is empty, the file has no
+// breakpoints.
+func (d *Debugger) SetBreakpoints(path string, lines []int) {
+	d.mu.Lock()
+	if len(lines) == 0 {
+		delete(d.breakpoints, path)
+	} else {
+		bp := make(map[int]bool, len(lines))
+		for _, line := range lines {
+			bp[line] = true
+		}
+		d.breakpoints[path] = bp
+	}
+	atomic.AddUint32(&d.version, 1)
+	d.mu.Unlock()
+}
+
+// Pause requests to stop the execution at the next statement.
+func (d *Debugger) Pause() {
+	atomic.StoreInt32(&d.pause, 1)
+}
+
+// debugSession is the state of a debugged execution. It is shared by the VM
+// that executes the main goroutine and by the VMs that execute the range
+// iterators called by it, so it is accessed by a single goroutine.
+type debugSession struct {
+	debugger *Debugger
+	stop     func(*DebugStop) DebugStep
+	version  uint32                   // version of the breakpoints in funcs.
+	funcs    map[*Function]*debugFunc // debug information indexed by function.
+	last     *Function                // function of lastInfo.
+	lastInfo *debugFunc
+
+	// step is the step of the last stop and depth its depth.
+	step  DebugStep
+	depth int
+
+	// path, line and depth of the last executed statement.
+	stmtPath  string
+	stmtLine  int
+	stmtDepth int
+}
+
+// debugFunc holds the debug information of a function.
+type debugFunc struct {
+	statements  []int32 // for each address, index plus one of the statement starting at it.
+	breakpoints map[Addr]bool
+}
+
+// SetDebugger sets the debugger of the execution. stop is called, in the
+// goroutine of the execution, every time the execution stops, and it returns
+// how the execution must be resumed. Goroutines started by the executed code
+// and functions called by native code are not debugged.
+//
+// SetDebugger must not be called after vm has been started.
+func (vm *VM) SetDebugger(d *Debugger, stop func(*DebugStop) DebugStep) {
+	vm.debug = &debugSession{
+		debugger: d,
+		stop:     stop,
+		funcs:    map[*Function]*debugFunc{},
+	}
+}
+
+// debugInfo returns the debug information of fn.
+func (s *debugSession) debugInfo(fn *Function) *debugFunc {
+	if v := atomic.LoadUint32(&s.debugger.version); v != s.version {
+		s.version = v
+		for _, f := range s.funcs {
+			f.breakpoints = nil
+		}
+		s.last = nil
+	}
+	if fn == s.last {
+		return s.lastInfo
+	}
+	f, ok := s.funcs[fn]
+	if !ok {
+		f = &debugFunc{statements: make([]int32, len(fn.Body))}
+		for i, st := range fn.Statements {
+			if int(st.Addr) < len(fn.Body) {
+				f.statements[st.Addr] = int32(i + 1)
+			}
+		}
+		s.funcs[fn] = f
+	}
+	if f.breakpoints == nil {
+		f.breakpoints = map[Addr]bool{}
+		d := s.debugger
+		d.mu.Lock()
+		for _, st := range fn.Statements {
+			if d.breakpoints[st.Path][st.Position.Line] {
+				f.breakpoints[st.Addr] = true
+			}
+		}
+		d.mu.Unlock()
+	}
+	s.last, s.lastInfo = fn, f
+	return f
+}
+
+// debugHook is called by the run method, before executing an instruction, if
+// the execution is debugged. It stops the execution, if it is at the start of
+// a statement and a breakpoint is reached, a step is completed or a pause
+// has been requested.
+func (vm *VM) debugHook() {
+	s := vm.debug
+	f := s.debugInfo(vm.fn)
+	i := f.statements[vm.pc]
+	if i == 0 {
+		return
+	}
+	st := vm.fn.Statements[i-1]
+	depth := vm.debugDepth()
+	newLine := depth != s.stmtDepth || st.Position.Line != s.stmtLine || st.Path != s.stmtPath
+	s.stmtPath, s.stmtLine, s.stmtDepth = st.Path, st.Position.Line, depth
+	var reason DebugReason
+	switch {
+	case atomic.CompareAndSwapInt32(&s.debugger.pause, 1, 0):
+		reason = DebugPaused
+	case newLine && f.breakpoints[vm.pc]:
+		reason = DebugBreakpoint
+	case newLine && (s.step == DebugStepInto ||
+		s.step == DebugStepOver && depth <= s.depth ||
+		s.step == DebugStepOut && depth < s.depth):
+		reason = DebugStepped
+	default:
+		return
+	}
+	stop := &DebugStop{vm: vm, reason: reason}
+	s.step = s.stop(stop)
+	s.depth = depth
+	stop.vm = nil
+}
+
+// debugDepth returns the number of frames of the call stack.
+func (vm *VM) debugDepth() int {
+	depth := 0
+	for ; vm != nil; vm = vm.parent {
+		depth++
+		for _, call := range vm.calls {
+			if call.status != deferred {
+				depth++
+			}
+		}
+	}
+	return depth
+}
+
+// DebugStop represents a stop of a debugged execution. It can only be used
+// during the call to the stop function passed to SetDebugger.
+type DebugStop struct {
+	vm     *VM
+	reason DebugReason
+}
+
+// DebugFrame represents a frame of the call stack of a stopped execution.
+type DebugFrame struct {
+	Function *Function // function.
+	Path     string    // path of the source code, if known.
+	Position Position  // current position, if known.
+
+	vm      *VM
+	fp      [4]Addr
+	pc      Addr
+	hasRegs bool // reports whether the registers of the frame can be read.
+}
+
+// DebugVar represents a variable read from a stopped execution.
+type DebugVar struct {
+	Name  string
+	Type  reflect.Type
+	Value reflect.Value
+}
+
+// Reason returns the reason of the stop.
+func (s *DebugStop) Reason() DebugReason {
+	return s.reason
+}
+
+// Frames returns the frames of the call stack, starting from the frame of
+// the running function.
+func (s *DebugStop) Frames() []DebugFrame {
+	var frames []DebugFrame
+	fn, pc := s.vm.fn, s.vm.pc
+	for vm := s.vm; vm != nil; vm = vm.parent {
+		frames = append(frames, newDebugFrame(vm, fn, vm.fp, pc, true))
+		for i := len(vm.calls) - 1; i >= 0; i-- {
+			call := vm.calls[i]
+			switch call.status {
+			case started:
+				frames = append(frames, newDebugFrame(vm, call.cl.fn, call.fp, call.pc-2, true))
+			case tailed:
+				frames = append(frames, newDebugFrame(vm, call.cl.fn, call.fp, call.pc-1, false))
+			case returned, panicked, recovered:
+				if call.cl.fn != nil {
+					frames = append(frames, DebugFrame{Function: call.cl.fn, Path: call.cl.fn.File})
+				}
+			}
+		}
+		if vm.parent != nil {
+			fn, pc = vm.parent.fn, vm.parentPC
+		}
+	}
+	return frames
+}
+
+// newDebugFrame returns a frame of fn at address pc. hasRegs reports whether
+// the registers of the frame, with frame pointers fp, can be read.
+func newDebugFrame(vm *VM, fn *Function, fp [4]Addr, pc Addr, hasRegs bool) DebugFrame {
+	path, pos := sourcePosition(fn, pc)
+	return DebugFrame{Function: fn, Path: path, Position: pos, vm: vm, fp: fp, pc: pc, hasRegs: hasRegs}
+}
+
+// sourcePosition returns the path and the position of the source code of
+// the instruction of fn at address pc. If the position is not known, it
+// returns the path of the file of fn and the zero Position.
+func sourcePosition(fn *Function, pc Addr) (string, Position) {
+	if info, ok := fn.InstructionInfo[pc]; ok && info.Position.Line > 0 {
+		return info.Path, info.Position
+	}
+	if i := sort.Search(len(fn.Statements), func(i int) bool { return fn.Statements[i].Addr > pc }); i > 0 {
+		st := fn.Statements[i-1]
+		return st.Path, st.Position
+	}
+	return fn.File, Position{}
+}
+
+// Locals returns the local variables, parameters included, that are in scope
+// in the frame. If more variables with the same name are in scope, only the
+// innermost is returned.
+func (s *DebugStop) Locals(frame DebugFrame) []DebugVar {
+	if !frame.hasRegs {
+		return nil
+	}
+	var vars []DebugVar
+	var starts []Addr
+	for _, v := range frame.Function.LocalVars {
+		if frame.pc < v.Start || frame.pc >= v.End {
+			continue
+		}
+		shadowed := false
+		for i := range vars {
+			if vars[i].Name == v.Name {
+				if starts[i] < v.Start {
+					vars[i] = frame.vm.debugVar(frame.fp, v)
+					starts[i] = v.Start
+				}
+				shadowed = true
+				break
+			}
+		}
+		if !shadowed {
+			vars = append(vars, frame.vm.debugVar(frame.fp, v))
+			starts = append(starts, v.Start)
+		}
+	}
+	return vars
+}
+
+// Globals returns the values of the global variables.
+func (s *DebugStop) Globals() []reflect.Value {
+	globals := make([]reflect.Value, len(s.vm.env.globals))
+	for i, v := range s.vm.env.globals {
+		globals[i] = s.vm.debugValue(v)
+	}
+	return globals
+}
+
+// debugVar reads the local variable v with frame pointers fp.
+func (vm *VM) debugVar(fp [4]Addr, v LocalVar) DebugVar {
+	t := v.Type
+	if st, ok := t.(ScriggoType); ok {
+		t = st.GoType()
+	}
+	var value reflect.Value
+	if v.Reg < 0 {
+		if ptr := vm.regs.general[fp[3]+Addr(-v.Reg)]; ptr.IsValid() && !ptr.IsNil() {
+			value = vm.debugValue(ptr.Elem())
+		}
+		return DebugVar{Name: v.Name, Type: v.Type, Value: value}
+	}
+	r := Addr(v.Reg)
+	switch kindToType[t.Kind()] {
+	case intRegister:
+		value = reflect.New(t).Elem()
+		n := vm.regs.int[fp[0]+r]
+		switch k := t.Kind(); {
+		case k == reflect.Bool:
+			value.SetBool(n != 0)
+		case reflect.Int <= k && k <= reflect.Int64:
+			value.SetInt(n)
+		default:
+			value.SetUint(uint64(n))
+		}
+	case floatRegister:
+		value = reflect.New(t).Elem()
+		value.SetFloat(vm.regs.float[fp[1]+r])
+	case stringRegister:
+		value = reflect.New(t).Elem()
+		value.SetString(vm.regs.string[fp[2]+r])
+	case generalRegister:
+		value = vm.debugValue(vm.regs.general[fp[3]+r])
+		if t.Kind() == reflect.Interface {
+			// A nil interface has the zero Value.
+			if value.IsValid() && value.Kind() == reflect.Interface {
+				value = reflect.Value{}
+			}
+		} else if !value.IsValid() {
+			value = reflect.Zero(t)
+		}
+	}
+	return DebugVar{Name: v.Name, Type: v.Type, Value: value}
+}
+
+// debugValue returns v as it can be read by a debugger.
+func (vm *VM) debugValue(v reflect.Value) reflect.Value {
+	if v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.IsValid() && v.Kind() == reflect.Ptr && v.CanInterface() {
+		if c, ok := v.Interface().(*callable); ok {
+			return c.Value(vm.env)
+		}
+	}
+	return v
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 type PrintFunc func(interface{})
@@ -23,9 +24,28 @@ type env struct {
 	typeof  TypeOfFunc      // typeof function.
 	conv    Converter       // Markdown converter
 
-	done     int32
-	doneChan <-chan struct{}
-	doneCase reflect.SelectCase
+	// The execution is halted when the context is canceled or a limit is
+	// exceeded. doneChan is closed, and done is set to 1, when the execution
+	// is halted. If the execution cannot be halted, doneChan is nil.
+	done      int32
+	doneChan  chan struct{}
+	doneCase  reflect.SelectCase
+	haltOnce  sync.Once
+	haltError error // error that halted the execution.
+
+	maxInstructions int64 // maximum number of instructions; zero means no limit.
+	instructions    int64 // number of executed instructions.
+	maxMemory       int64 // maximum number of allocated bytes; zero means no limit.
+	memory          int64 // number of allocated bytes.
+	accounting      bool  // reports whether the allocations are accounted.
+	maxOutput       int64 // maximum number of rendered bytes; zero means no limit.
+
+	coverage []uint32 // coverage counters, incremented by the Cover instructions.
+
+	profiler *Profiler // profiler, if the execution is profiled.
+	ticks    int32     // number of profiling ticks not yet sampled; accessed atomically.
+
+	sourceMap *SourceMap // source map of the template output, if it is recorded.
 
 	// Only the callPath field can be changed after the vm has been started
 	// and access to this field must be done with this mutex.
@@ -33,6 +53,11 @@ type env struct {
 	callPath string // path of the file where the main goroutine is in.
 }
 
+// CallFunction implements the FunctionCaller interface.
+func (env *env) CallFunction(fn *Function, args []reflect.Value) []reflect.Value {
+	return env.callFunction(fn, env.globals, args)
+}
+
 func (env *env) CallPath() string {
 	env.mu.Lock()
 	callPath := env.callPath
@@ -72,6 +97,30 @@ func (env *env) Stop(err error) {
 	panic(stopError{err})
 }
 
+// initHalt initializes env so that the execution can be halted, if there is
+// a context that can be canceled or a limit.
+func (env *env) initHalt() {
+	canceled := env.ctx != nil && env.ctx.Done() != nil
+	if !canceled && env.maxInstructions == 0 && env.maxMemory == 0 && env.maxOutput == 0 {
+		return
+	}
+	env.doneChan = make(chan struct{})
+	env.doneCase = reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(env.doneChan),
+	}
+}
+
+// halt halts the execution, of all goroutines, with the error err. Only the
+// first call has effect; the next calls leave the error unchanged.
+func (env *env) halt(err error) {
+	env.haltOnce.Do(func() {
+		env.haltError = err
+		atomic.StoreInt32(&env.done, 1)
+		close(env.doneChan)
+	})
+}
+
 func (env *env) TypeOf(v reflect.Value) reflect.Type {
 	return env.typeof(v)
 }
@@ -73,6 +73,13 @@ func errTypeAssertion(interfaceType, dynamicType, assertedType reflect.Type, mis
 	return runtimeError(s + "packages)")
 }
 
+// rangeBodyError represents an error, converted from a panic, raised by the
+// body of a range over a function iterator. It is raised again after the
+// iterator has returned.
+type rangeBodyError struct {
+	err error
+}
+
 // stopError represents a stop error.
 type stopError struct {
 	err error
@@ -118,6 +125,10 @@ func (vm *VM) convertPanic(msg interface{}) error {
 	switch err := msg.(type) {
 	case stopError:
 		return err
+	case rangeBodyError:
+		return err.err
+	case *LimitError:
+		return err
 	case outError:
 		return vm.newPanic(err)
 	}
@@ -214,6 +225,21 @@ func (vm *VM) convertPanic(msg interface{}) error {
 		}
 	case OpPanic:
 		return vm.newPanic(msg)
+	case OpRange:
+		in := vm.fn.Body[vm.pc-1]
+		if _, ok := vm.general(in.A).Interface().(*callable); !ok {
+			break
+		}
+		// The iterator panicked.
+		switch msg := msg.(type) {
+		case *PanicError:
+			return msg
+		case *fatalError:
+			return msg
+		case runtime.Error:
+		default:
+			return vm.newPanic(msg)
+		}
 	case OpSend, -OpSend:
 		switch err := msg.(type) {
 		case runtime.Error:
@@ -252,6 +278,57 @@ func (vm *VM) convertPanic(msg interface{}) error {
 	return &fatalError{msg: msg}
 }
 
+// LimitError represents the error that occurs when the execution exceeds a
+// limit set on the virtual machine. A LimitError cannot be recovered by the
+// running code.
+type LimitError struct {
+	message  string
+	path     string
+	position Position
+}
+
+// newLimitError returns a new *LimitError with the given message for the
+// currently running instruction. As not all instructions have a position, it
+// uses the position of the closest preceding instruction that has one, or the
+// position of the function if there is none.
+func (vm *VM) newLimitError(msg string) *LimitError {
+	err := &LimitError{message: msg, path: vm.fn.File}
+	for pc := vm.pc - 1; ; pc-- {
+		if info, ok := vm.fn.InstructionInfo[pc]; ok && info.Position.Line > 0 {
+			err.path = info.Path
+			err.position = info.Position
+			return err
+		}
+		if pc == 0 {
+			break
+		}
+	}
+	if vm.fn.Pos != nil {
+		err.position = *vm.fn.Pos
+	}
+	return err
+}
+
+// Error returns the message of the error with the path and position.
+func (err *LimitError) Error() string {
+	return err.path + ":" + err.position.String() + ": " + err.message
+}
+
+// Message returns the message of the error, without path and position.
+func (err *LimitError) Message() string {
+	return err.message
+}
+
+// Path returns the path of the file where the limit has been exceeded.
+func (err *LimitError) Path() string {
+	return err.path
+}
+
+// Position returns the position where the limit has been exceeded.
+func (err *LimitError) Position() Position {
+	return err.position
+}
+
 type PanicError struct {
 	message    interface{}
 	recovered  bool
@@ -259,6 +336,7 @@ type PanicError struct {
 	next       *PanicError
 	path       string
 	position   Position
+	traced     bool // the panic event has been traced
 }
 
 // Error returns all currently active panics as a string.
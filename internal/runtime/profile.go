@@ -0,0 +1,174 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProfilePeriod is the sampling period of the CPU time of a profiled
+// execution.
+const ProfilePeriod = 10 * time.Millisecond
+
+// Profiler collects the CPU and allocation samples of profiled executions.
+// Its methods can be called concurrently, also while the executions are
+// running.
+type Profiler struct {
+	mu   sync.Mutex
+	root profileNode
+}
+
+// NewProfiler returns a new profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// ProfileFrame represents a frame of the call stack of a profile sample.
+type ProfileFrame struct {
+	Function *Function // function.
+	Path     string    // path of the source code.
+	Line     int       // line in the source code; zero if it is not known.
+}
+
+// ProfileSample represents the samples with the same call stack.
+type ProfileSample struct {
+	Stack        []ProfileFrame // call stack, starting from the frame of the running function.
+	CPU          int64          // number of CPU samples, each one of ProfilePeriod.
+	AllocObjects int64          // number of allocations.
+	AllocBytes   int64          // number of allocated bytes.
+}
+
+// profileNode is a node of the tree of the call stacks of a profiler. The
+// children of the root are the outermost frames.
+type profileNode struct {
+	children     map[ProfileFrame]*profileNode
+	cpu          int64
+	allocObjects int64
+	allocBytes   int64
+}
+
+// Samples returns the samples collected by p.
+func (p *Profiler) Samples() []ProfileSample {
+	var samples []ProfileSample
+	var walk func(n *profileNode, stack []ProfileFrame)
+	walk = func(n *profileNode, stack []ProfileFrame) {
+		if n.cpu > 0 || n.allocObjects > 0 {
+			s := ProfileSample{
+				Stack:        make([]ProfileFrame, len(stack)),
+				CPU:          n.cpu,
+				AllocObjects: n.allocObjects,
+				AllocBytes:   n.allocBytes,
+			}
+			for i, frame := range stack {
+				s.Stack[len(stack)-1-i] = frame
+			}
+			samples = append(samples, s)
+		}
+		for frame, child := range n.children {
+			walk(child, append(stack, frame))
+		}
+	}
+	p.mu.Lock()
+	walk(&p.root, nil)
+	p.mu.Unlock()
+	return samples
+}
+
+// add adds to p a sample with the given call stack, number of CPU samples,
+// allocations and allocated bytes.
+func (p *Profiler) add(stack []ProfileFrame, cpu, objects, bytes int64) {
+	p.mu.Lock()
+	n := &p.root
+	for i := len(stack) - 1; i >= 0; i-- {
+		child, ok := n.children[stack[i]]
+		if !ok {
+			if n.children == nil {
+				n.children = map[ProfileFrame]*profileNode{}
+			}
+			child = &profileNode{}
+			n.children[stack[i]] = child
+		}
+		n = child
+	}
+	n.cpu += cpu
+	n.allocObjects += objects
+	n.allocBytes += bytes
+	p.mu.Unlock()
+}
+
+// SetProfiler sets the profiler of the execution. The CPU time and the
+// allocations of the execution, goroutines included, are sampled and added
+// to p. Allocations are sampled as they are accounted for the memory limit.
+//
+// SetProfiler must not be called after vm has been started.
+func (vm *VM) SetProfiler(p *Profiler) {
+	vm.env.profiler = p
+	vm.env.accounting = p != nil || vm.env.maxMemory > 0
+}
+
+// startProfiling starts the ticker of the CPU samples and returns a function
+// that stops it.
+func (vm *VM) startProfiling() func() {
+	ticker := time.NewTicker(ProfilePeriod)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				atomic.AddInt32(&vm.env.ticks, 1)
+			case <-stop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// profileHook is called by the run method, before executing an instruction,
+// if the execution is profiled and there are ticks not yet sampled. It adds
+// the CPU samples to the profiler.
+func (vm *VM) profileHook() {
+	if ticks := atomic.SwapInt32(&vm.env.ticks, 0); ticks > 0 {
+		vm.env.profiler.add(vm.profileStack(), int64(ticks), 0, 0)
+	}
+}
+
+// profileStack returns the call stack of the last executed instruction.
+func (vm *VM) profileStack() []ProfileFrame {
+	fn, pc := vm.fn, vm.pc
+	if pc > 0 {
+		pc--
+	}
+	var stack []ProfileFrame
+	for v := vm; v != nil; v = v.parent {
+		stack = append(stack, newProfileFrame(fn, pc))
+		for i := len(v.calls) - 1; i >= 0; i-- {
+			call := v.calls[i]
+			switch call.status {
+			case started:
+				stack = append(stack, newProfileFrame(call.cl.fn, call.pc-2))
+			case tailed:
+				stack = append(stack, newProfileFrame(call.cl.fn, call.pc-1))
+			case returned, panicked, recovered:
+				if call.cl.fn != nil {
+					stack = append(stack, ProfileFrame{Function: call.cl.fn, Path: call.cl.fn.File})
+				}
+			}
+		}
+		if v.parent != nil {
+			fn, pc = v.parent.fn, v.parentPC
+		}
+	}
+	return stack
+}
+
+// newProfileFrame returns a profile frame of fn at address pc.
+func newProfileFrame(fn *Function, pc Addr) ProfileFrame {
+	path, pos := sourcePosition(fn, pc)
+	return ProfileFrame{Function: fn, Path: path, Line: pos.Line}
+}
@@ -6,6 +6,7 @@ package runtime
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html"
 	"io"
@@ -23,6 +24,31 @@ import (
 
 var byteSliceType = reflect.TypeOf([]byte(nil))
 
+// errOutputLimit is the error returned by a limitedWriter when the limit is
+// exceeded.
+var errOutputLimit = errors.New("output limit exceeded")
+
+// limitedWriter writes to w at most n bytes. When a write exceeds the limit,
+// it writes only the bytes up to the limit and returns errOutputLimit.
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= lw.n {
+		n, err := lw.w.Write(p)
+		lw.n -= int64(n)
+		return n, err
+	}
+	n, err := lw.w.Write(p[:lw.n])
+	lw.n -= int64(n)
+	if err == nil {
+		err = errOutputLimit
+	}
+	return n, err
+}
+
 // renderer is used by te Show and Text instructions to render template files.
 type renderer struct {
 
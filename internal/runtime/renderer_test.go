@@ -4,7 +4,10 @@
 
 package runtime
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 var tagValues = []struct {
 	value     string
@@ -30,3 +33,21 @@ func TestParseTagValue(t *testing.T) {
 		}
 	}
 }
+
+// TestLimitedWriter tests the limitedWriter type.
+func TestLimitedWriter(t *testing.T) {
+	var b strings.Builder
+	w := &limitedWriter{w: &b, n: 5}
+	if n, err := w.Write([]byte("abc")); n != 3 || err != nil {
+		t.Fatalf("expecting 3 and no error, got %d and %v", n, err)
+	}
+	if n, err := w.Write([]byte("defg")); n != 2 || err != errOutputLimit {
+		t.Fatalf("expecting 2 and errOutputLimit, got %d and %v", n, err)
+	}
+	if n, err := w.Write([]byte("h")); n != 0 || err != errOutputLimit {
+		t.Fatalf("expecting 0 and errOutputLimit, got %d and %v", n, err)
+	}
+	if b.String() != "abcde" {
+		t.Fatalf("expecting \"abcde\", got %q", b.String())
+	}
+}
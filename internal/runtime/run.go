@@ -7,6 +7,7 @@ package runtime
 import (
 	"bytes"
 	"errors"
+	"io"
 	"reflect"
 	"strings"
 	"sync/atomic"
@@ -19,13 +20,13 @@ func (vm *VM) runFunc(fn *Function, vars []reflect.Value) error {
 	vm.fn = fn
 	vm.vars = vars
 	var stop chan struct{}
-	if vm.env.doneChan != nil {
+	if vm.env.doneChan != nil && vm.env.ctx != nil && vm.env.ctx.Done() != nil {
 		stop = make(chan struct{})
 		go func() {
 			select {
 			case <-stop:
 			case <-vm.env.ctx.Done():
-				atomic.StoreInt32(&vm.env.done, 1)
+				vm.env.halt(vm.env.ctx.Err())
 			}
 		}()
 	}
@@ -36,24 +37,38 @@ func (vm *VM) runFunc(fn *Function, vars []reflect.Value) error {
 		}
 		p, ok := err.(*PanicError)
 		if !ok {
+			if vm.trace != nil {
+				vm.traceReturns(0)
+			}
 			if stop != nil {
 				close(stop)
 			}
+			if _, ok := err.(*LimitError); ok && vm.env.doneChan != nil {
+				// Halt the other goroutines and return the first error.
+				vm.env.halt(err)
+				err = vm.env.haltError
+			}
 			return err
 		}
 		p.next = vm.panic
 		vm.panic = p
+		if vm.trace != nil && !p.traced {
+			vm.tracePanic(p)
+		}
 		if len(vm.calls) == 0 {
 			break
 		}
 		vm.calls = append(vm.calls, callFrame{cl: callable{fn: vm.fn}, renderer: vm.renderer, fp: vm.fp, status: panicked})
 		vm.fn = nil
 	}
+	if vm.trace != nil {
+		vm.traceReturns(0)
+	}
 	if stop != nil {
 		close(stop)
-		if atomic.LoadInt32(&vm.env.done) == 1 {
-			return vm.env.ctx.Err()
-		}
+	}
+	if vm.env.doneChan != nil && atomic.LoadInt32(&vm.env.done) == 1 {
+		return vm.env.haltError
 	}
 	if vm.panic != nil {
 		return vm.panic
@@ -82,9 +97,13 @@ func (vm *VM) run() (Addr, bool) {
 	var startNativeGoroutine bool
 
 	var op Operation
-	var a, b, c int8
+	var a, b, c int16
 
 	done := vm.env.doneChan
+	limited := vm.env.maxInstructions > 0
+	debugged := vm.debug != nil
+	profiled := vm.env.profiler != nil
+	traced := vm.trace != nil
 
 	for {
 
@@ -92,9 +111,25 @@ func (vm *VM) run() (Addr, bool) {
 			return vm.stop()
 		}
 
+		if debugged {
+			vm.debugHook()
+		}
+
+		if profiled && atomic.LoadInt32(&vm.env.ticks) > 0 {
+			vm.profileHook()
+		}
+
+		if traced && vm.traceChanged() {
+			vm.traceHook()
+		}
+
 		in := vm.fn.Body[vm.pc]
 
 		vm.pc++
+
+		if limited && atomic.AddInt64(&vm.env.instructions, 1) > vm.env.maxInstructions {
+			panic(vm.newLimitError("instruction limit exceeded"))
+		}
 		op, a, b, c = in.Op, in.A, in.B, in.C
 
 		// If an instruction needs to change the program counter,
@@ -142,7 +177,7 @@ func (vm *VM) run() (Addr, bool) {
 				i := int(vm.int(b))
 				vm.setGeneral(c, v.Index(i).Addr())
 			case reflect.Struct, reflect.Ptr:
-				vm.setGeneral(c, vm.fieldByIndex(v, uint8(b)).Addr())
+				vm.setGeneral(c, vm.fieldByIndex(v, uint16(b)).Addr())
 			}
 
 		// And
@@ -155,20 +190,29 @@ func (vm *VM) run() (Addr, bool) {
 
 		// Append
 		case OpAppend:
+			if vm.env.accounting {
+				vm.allocAppend(vm.general(c), int(b-a))
+			}
 			vm.setGeneral(c, vm.appendSlice(a, int(b-a), vm.general(c)))
 
 		// AppendSlice
 		case OpAppendSlice:
+			if vm.env.accounting {
+				vm.allocAppend(vm.general(c), vm.general(a).Len())
+			}
 			vm.setGeneral(c, reflect.AppendSlice(vm.general(c), vm.general(a)))
 
 		// Assert
 		case OpAssert:
 			v := vm.general(a)
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			var ok bool
 			if v.IsValid() {
 				if w, isScriggoType := t.(ScriggoType); isScriggoType {
-					v, ok = w.Unwrap(v)
+					var u reflect.Value
+					if u, ok = w.Unwrap(v); ok {
+						v = u
+					}
 				} else {
 					if t.Kind() == reflect.Interface {
 						ok = v.Type().Implements(t)
@@ -184,12 +228,12 @@ func (vm *VM) run() (Addr, bool) {
 					var concrete reflect.Type
 					var method string
 					if v.IsValid() {
-						concrete = v.Type()
+						concrete = vm.env.typeof(v)
 						if t.Kind() == reflect.Interface {
 							method = missingMethod(concrete, t)
 						}
 					}
-					panic(errTypeAssertion(vm.fn.Types[uint8(in.C)], concrete, t, method))
+					panic(errTypeAssertion(vm.fn.Types[uint16(in.C)], concrete, t, method))
 				}
 			}
 			if c != 0 {
@@ -240,23 +284,23 @@ func (vm *VM) run() (Addr, bool) {
 		// Call
 		case OpCallFunc:
 			call := callFrame{cl: callable{fn: vm.fn, vars: vm.vars}, fp: vm.fp, pc: vm.pc + 1}
-			fn := vm.fn.Functions[uint8(a)]
+			fn := vm.fn.Functions[uint16(a)]
 			off := vm.fn.Body[vm.pc]
 			vm.fp[0] += Addr(off.Op)
-			if vm.fp[0]+Addr(fn.NumReg[0]) > vm.st[0] {
-				vm.moreIntStack()
+			if vm.fp[0]+Addr(fn.NumReg[0]) >= vm.st[0] {
+				vm.moreIntStack(vm.fp[0] + Addr(fn.NumReg[0]))
 			}
 			vm.fp[1] += Addr(off.A)
-			if vm.fp[1]+Addr(fn.NumReg[1]) > vm.st[1] {
-				vm.moreFloatStack()
+			if vm.fp[1]+Addr(fn.NumReg[1]) >= vm.st[1] {
+				vm.moreFloatStack(vm.fp[1] + Addr(fn.NumReg[1]))
 			}
 			vm.fp[2] += Addr(off.B)
-			if vm.fp[2]+Addr(fn.NumReg[2]) > vm.st[2] {
-				vm.moreStringStack()
+			if vm.fp[2]+Addr(fn.NumReg[2]) >= vm.st[2] {
+				vm.moreStringStack(vm.fp[2] + Addr(fn.NumReg[2]))
 			}
 			vm.fp[3] += Addr(off.C)
-			if vm.fp[3]+Addr(fn.NumReg[3]) > vm.st[3] {
-				vm.moreGeneralStack()
+			if vm.fp[3]+Addr(fn.NumReg[3]) >= vm.st[3] {
+				vm.moreGeneralStack(vm.fp[3] + Addr(fn.NumReg[3]))
 			}
 			vm.fn = fn
 			vm.vars = vm.env.globals
@@ -266,7 +310,7 @@ func (vm *VM) run() (Addr, bool) {
 			f := vm.general(a).Interface().(*callable)
 			if f.fn == nil {
 				off := vm.fn.Body[vm.pc]
-				vm.callNative(f.Native(), c, StackShift{int8(off.Op), off.A, off.B, off.C}, startNativeGoroutine)
+				vm.callNative(f.Native(), c, StackShift{int16(off.Op), off.A, off.B, off.C}, startNativeGoroutine)
 				startNativeGoroutine = false
 				vm.pc++
 			} else {
@@ -274,20 +318,20 @@ func (vm *VM) run() (Addr, bool) {
 				fn := f.fn
 				off := vm.fn.Body[vm.pc]
 				vm.fp[0] += Addr(off.Op)
-				if vm.fp[0]+Addr(fn.NumReg[0]) > vm.st[0] {
-					vm.moreIntStack()
+				if vm.fp[0]+Addr(fn.NumReg[0]) >= vm.st[0] {
+					vm.moreIntStack(vm.fp[0] + Addr(fn.NumReg[0]))
 				}
 				vm.fp[1] += Addr(off.A)
-				if vm.fp[1]+Addr(fn.NumReg[1]) > vm.st[1] {
-					vm.moreFloatStack()
+				if vm.fp[1]+Addr(fn.NumReg[1]) >= vm.st[1] {
+					vm.moreFloatStack(vm.fp[1] + Addr(fn.NumReg[1]))
 				}
 				vm.fp[2] += Addr(off.B)
-				if vm.fp[2]+Addr(fn.NumReg[2]) > vm.st[2] {
-					vm.moreStringStack()
+				if vm.fp[2]+Addr(fn.NumReg[2]) >= vm.st[2] {
+					vm.moreStringStack(vm.fp[2] + Addr(fn.NumReg[2]))
 				}
 				vm.fp[3] += Addr(off.C)
-				if vm.fp[3]+Addr(fn.NumReg[3]) > vm.st[3] {
-					vm.moreGeneralStack()
+				if vm.fp[3]+Addr(fn.NumReg[3]) >= vm.st[3] {
+					vm.moreGeneralStack(vm.fp[3] + Addr(fn.NumReg[3]))
 				}
 				if fn.Macro {
 					call.renderer = vm.renderer
@@ -311,23 +355,23 @@ func (vm *VM) run() (Addr, bool) {
 			}
 		case OpCallMacro:
 			call := callFrame{cl: callable{fn: vm.fn, vars: vm.vars}, renderer: vm.renderer, fp: vm.fp, pc: vm.pc + 1}
-			fn := vm.fn.Functions[uint8(a)]
+			fn := vm.fn.Functions[uint16(a)]
 			off := vm.fn.Body[vm.pc]
 			vm.fp[0] += Addr(off.Op)
-			if vm.fp[0]+Addr(fn.NumReg[0]) > vm.st[0] {
-				vm.moreIntStack()
+			if vm.fp[0]+Addr(fn.NumReg[0]) >= vm.st[0] {
+				vm.moreIntStack(vm.fp[0] + Addr(fn.NumReg[0]))
 			}
 			vm.fp[1] += Addr(off.A)
-			if vm.fp[1]+Addr(fn.NumReg[1]) > vm.st[1] {
-				vm.moreFloatStack()
+			if vm.fp[1]+Addr(fn.NumReg[1]) >= vm.st[1] {
+				vm.moreFloatStack(vm.fp[1] + Addr(fn.NumReg[1]))
 			}
 			vm.fp[2] += Addr(off.B)
-			if vm.fp[2]+Addr(fn.NumReg[2]) > vm.st[2] {
-				vm.moreStringStack()
+			if vm.fp[2]+Addr(fn.NumReg[2]) >= vm.st[2] {
+				vm.moreStringStack(vm.fp[2] + Addr(fn.NumReg[2]))
 			}
 			vm.fp[3] += Addr(off.C)
-			if vm.fp[3]+Addr(fn.NumReg[3]) > vm.st[3] {
-				vm.moreGeneralStack()
+			if vm.fp[3]+Addr(fn.NumReg[3]) >= vm.st[3] {
+				vm.moreGeneralStack(vm.fp[3] + Addr(fn.NumReg[3]))
 			}
 			if b == ReturnString {
 				vm.renderer = newRenderer(&strings.Builder{})
@@ -346,9 +390,9 @@ func (vm *VM) run() (Addr, bool) {
 			vm.calls = append(vm.calls, call)
 			vm.pc = 0
 		case OpCallNative:
-			fn := vm.fn.NativeFunctions[uint8(a)]
+			fn := vm.fn.NativeFunctions[uint16(a)]
 			off := vm.fn.Body[vm.pc]
-			vm.callNative(fn, c, StackShift{int8(off.Op), off.A, off.B, off.C}, startNativeGoroutine)
+			vm.callNative(fn, c, StackShift{int16(off.Op), off.A, off.B, off.C}, startNativeGoroutine)
 			startNativeGoroutine = false
 			vm.pc++
 
@@ -385,6 +429,10 @@ func (vm *VM) run() (Addr, bool) {
 			}
 			vm.pc++
 
+		// Clear
+		case OpClear:
+			vm.general(a).Clear()
+
 		// Close
 		case OpClose:
 			vm.general(a).Close()
@@ -415,15 +463,19 @@ func (vm *VM) run() (Addr, bool) {
 
 		// Convert
 		case OpConvert:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			switch t.Kind() {
 			case reflect.String:
-				vm.setString(c, vm.general(a).Convert(t).String())
+				v := vm.general(a).Convert(t).String()
+				if vm.env.accounting {
+					vm.alloc(len(v), 1)
+				}
+				vm.setString(c, v)
 			default:
 				vm.setGeneral(c, vm.general(a).Convert(t))
 			}
 		case OpConvertInt:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			v := vm.int(a)
 			switch t.Kind() {
 			case reflect.Int:
@@ -458,7 +510,7 @@ func (vm *VM) run() (Addr, bool) {
 				vm.setString(c, s)
 			}
 		case OpConvertUint:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			v := uint64(vm.int(a))
 			switch t.Kind() {
 			case reflect.Int:
@@ -493,7 +545,7 @@ func (vm *VM) run() (Addr, bool) {
 				vm.setString(c, s)
 			}
 		case OpConvertFloat:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			v := vm.float(a)
 			switch t.Kind() {
 			case reflect.Int:
@@ -522,9 +574,12 @@ func (vm *VM) run() (Addr, bool) {
 				vm.setFloat(c, v)
 			}
 		case OpConvertString:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
 			v := reflect.ValueOf(vm.string(a))
 			if t.Kind() == reflect.Slice {
+				if vm.env.accounting {
+					vm.alloc(v.Len(), t.Elem().Size())
+				}
 				vm.setGeneral(c, v.Convert(t))
 			} else {
 				if vm.env.conv != nil {
@@ -536,6 +591,9 @@ func (vm *VM) run() (Addr, bool) {
 
 		// Concat
 		case OpConcat:
+			if vm.env.accounting {
+				vm.alloc(len(vm.string(a))+len(vm.string(b)), 1)
+			}
 			vm.setString(c, vm.string(a)+vm.string(b))
 
 		// Copy
@@ -545,6 +603,12 @@ func (vm *VM) run() (Addr, bool) {
 				vm.setInt(b, int64(n))
 			}
 
+		// Cover
+		case OpCover:
+			if counters := vm.env.coverage; counters != nil {
+				atomic.AddUint32(&counters[decodeUint24(a, b, c)], 1)
+			}
+
 		// Defer
 		case OpDefer:
 			cl := vm.general(a).Interface().(*callable)
@@ -556,7 +620,7 @@ func (vm *VM) run() (Addr, bool) {
 				vm.fp[2] + Addr(off.B),
 				vm.fp[3] + Addr(off.C),
 			}
-			vm.swapStack(&vm.fp, &fp, StackShift{int8(arg.Op), arg.A, arg.B, arg.C})
+			vm.swapStack(&vm.fp, &fp, StackShift{int16(arg.Op), arg.A, arg.B, arg.C})
 			vm.calls = append(vm.calls, callFrame{cl: *cl, renderer: vm.renderer, fp: fp, pc: 0, status: deferred, numVariadic: c})
 			vm.pc += 2
 
@@ -606,7 +670,7 @@ func (vm *VM) run() (Addr, bool) {
 		// Field
 		case OpField:
 			v := vm.general(a)
-			vm.setFromReflectValue(c, vm.fieldByIndex(v, uint8(b)))
+			vm.setFromReflectValue(c, vm.fieldByIndex(v, uint16(b)))
 
 		// GetVar
 		case OpGetVar:
@@ -984,10 +1048,10 @@ func (vm *VM) run() (Addr, bool) {
 		case OpLoadFunc:
 			if a == 1 {
 				fn := callable{}
-				fn.native = vm.fn.NativeFunctions[uint8(b)]
+				fn.native = vm.fn.NativeFunctions[uint16(b)]
 				vm.setGeneral(c, reflect.ValueOf(&fn))
 			} else {
-				fn := vm.fn.Functions[uint8(b)]
+				fn := vm.fn.Functions[uint16(b)]
 				var vars []reflect.Value
 				if fn.VarRefs != nil {
 					vars = make([]reflect.Value, len(fn.VarRefs))
@@ -996,7 +1060,7 @@ func (vm *VM) run() (Addr, bool) {
 							// Calling Elem() is necessary because the general
 							// register contains an indirect value, that is
 							// stored as a pointer to a value.
-							vars[i] = vm.general(int8(-ref)).Elem()
+							vars[i] = vm.general(int16(-ref)).Elem()
 						} else {
 							vars[i] = vm.vars[ref]
 						}
@@ -1009,13 +1073,19 @@ func (vm *VM) run() (Addr, bool) {
 
 		// MakeArray
 		case OpMakeArray:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
+			if vm.env.accounting {
+				vm.alloc(1, t.Size())
+			}
 			vm.setGeneral(c, reflect.New(t).Elem())
 
 		// MakeChan
 		case OpMakeChan, -OpMakeChan:
-			typ := vm.fn.Types[uint8(a)]
+			typ := vm.fn.Types[uint16(a)]
 			buffer := int(vm.intk(b, op < 0))
+			if vm.env.accounting {
+				vm.alloc(buffer, typ.Elem().Size())
+			}
 			var ch reflect.Value
 			if typ.ChanDir() == reflect.BothDir {
 				ch = reflect.MakeChan(typ, buffer)
@@ -1027,8 +1097,11 @@ func (vm *VM) run() (Addr, bool) {
 
 		// MakeMap
 		case OpMakeMap, -OpMakeMap:
-			typ := vm.fn.Types[uint8(a)]
+			typ := vm.fn.Types[uint16(a)]
 			n := int(vm.intk(b, op < 0))
+			if vm.env.accounting {
+				vm.alloc(n, typ.Key().Size()+typ.Elem().Size())
+			}
 			if n > 0 {
 				vm.setGeneral(c, reflect.MakeMapWithSize(typ, n))
 			} else {
@@ -1037,7 +1110,7 @@ func (vm *VM) run() (Addr, bool) {
 
 		// MakeSlice
 		case OpMakeSlice:
-			typ := vm.fn.Types[uint8(a)]
+			typ := vm.fn.Types[uint16(a)]
 			var len, cap int
 			if b > 0 {
 				next := vm.fn.Body[vm.pc]
@@ -1046,6 +1119,9 @@ func (vm *VM) run() (Addr, bool) {
 				capIsConst := (b & (1 << 2)) != 0
 				cap = int(vm.intk(next.B, capIsConst))
 			}
+			if vm.env.accounting && len <= cap {
+				vm.alloc(cap, typ.Elem().Size())
+			}
 			vm.setGeneral(c, reflect.MakeSlice(typ, len, cap))
 			if b > 0 {
 				vm.pc++
@@ -1053,7 +1129,10 @@ func (vm *VM) run() (Addr, bool) {
 
 		// MakeStruct
 		case OpMakeStruct:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
+			if vm.env.accounting {
+				vm.alloc(1, t.Size())
+			}
 			vm.setGeneral(c, reflect.New(t).Elem())
 
 		// MapIndex
@@ -1085,6 +1164,19 @@ func (vm *VM) run() (Addr, bool) {
 			}
 			vm.setFromReflectValue(c, elem)
 
+		// Max
+		case OpMax, -OpMax:
+			switch a := reflect.Kind(a); {
+			case a == reflect.Float32 || a == reflect.Float64:
+				vm.setFloat(c, max(vm.float(c), vm.floatk(b, op < 0)))
+			case a == reflect.String:
+				vm.setString(c, max(vm.string(c), vm.stringk(b, op < 0)))
+			case reflect.Uint <= a && a <= reflect.Uintptr:
+				vm.setInt(c, int64(max(uint64(vm.int(c)), uint64(vm.intk(b, op < 0)))))
+			default:
+				vm.setInt(c, max(vm.int(c), vm.intk(b, op < 0)))
+			}
+
 		// MethodValue
 		case OpMethodValue:
 			receiver := vm.general(a)
@@ -1092,8 +1184,27 @@ func (vm *VM) run() (Addr, bool) {
 				panic(errNilPointer)
 			}
 			method := vm.stringk(b, true)
+			if st, ok := vm.env.typeof(receiver).(ScriggoType); ok {
+				if m, ok := st.MethodValue(receiver, method); ok {
+					vm.setGeneral(c, reflect.ValueOf(&callable{value: m}))
+					break
+				}
+			}
 			vm.setGeneral(c, reflect.ValueOf(&callable{value: receiver.MethodByName(method)}))
 
+		// Min
+		case OpMin, -OpMin:
+			switch a := reflect.Kind(a); {
+			case a == reflect.Float32 || a == reflect.Float64:
+				vm.setFloat(c, min(vm.float(c), vm.floatk(b, op < 0)))
+			case a == reflect.String:
+				vm.setString(c, min(vm.string(c), vm.stringk(b, op < 0)))
+			case reflect.Uint <= a && a <= reflect.Uintptr:
+				vm.setInt(c, int64(min(uint64(vm.int(c)), uint64(vm.intk(b, op < 0)))))
+			default:
+				vm.setInt(c, min(vm.int(c), vm.intk(b, op < 0)))
+			}
+
 		// Move
 		case OpMove, -OpMove:
 			switch registerType(a) {
@@ -1177,7 +1288,10 @@ func (vm *VM) run() (Addr, bool) {
 
 		// New
 		case OpNew:
-			t := vm.fn.Types[uint8(b)]
+			t := vm.fn.Types[uint16(b)]
+			if vm.env.accounting {
+				vm.alloc(1, t.Size())
+			}
 			vm.setGeneral(c, reflect.New(t))
 
 		// Or
@@ -1379,6 +1493,10 @@ func (vm *VM) run() (Addr, bool) {
 						break
 					}
 				}
+			case *callable:
+				if addr, breakOut, ok := vm.rangeFunc(s, b, c, rangeAddress, bodyAddress); !ok {
+					return addr, breakOut
+				}
 			default:
 				switch kind := v.Kind(); kind {
 				case reflect.Map:
@@ -1536,6 +1654,9 @@ func (vm *VM) run() (Addr, bool) {
 					vm.calls[i].status = recovered
 					vm.panic.recovered = true
 					msg = reflect.ValueOf(vm.panic.message)
+					if vm.trace != nil {
+						vm.traceRecover(vm.panic)
+					}
 				}
 				break
 			}
@@ -1587,8 +1708,15 @@ func (vm *VM) run() (Addr, bool) {
 							vm.setString(1, out.String())
 						} else if fn.Format == ast.FormatMarkdown && ast.Format(b) == ast.FormatHTML {
 							out := vm.renderer.Out().(*bytes.Buffer)
+							if m := vm.env.sourceMap; m != nil && call.renderer.out == io.Writer(m) {
+								// The converted Markdown has no source.
+								m.unset()
+							}
 							err := vm.env.conv(out.Bytes(), call.renderer.out)
 							if err != nil {
+								if errors.Is(err, errOutputLimit) {
+									panic(vm.newLimitError(errOutputLimit.Error()))
+								}
 								panic(&fatalError{env: vm.env, msg: err})
 							}
 						}
@@ -1667,11 +1795,14 @@ func (vm *VM) run() (Addr, bool) {
 		// SetField
 		case OpSetField, -OpSetField:
 			v := vm.general(b)
-			vm.getIntoReflectValue(a, vm.fieldByIndex(v, uint8(c)), op < 0)
+			vm.getIntoReflectValue(a, vm.fieldByIndex(v, uint16(c)), op < 0)
 
 		// SetMap
 		case OpSetMap, -OpSetMap:
 			mv := vm.general(b)
+			if vm.env.accounting {
+				vm.allocMapEntry(mv, c)
+			}
 			switch m := mv.Interface().(type) {
 			case map[string]string:
 				k := vm.string(c)
@@ -1789,7 +1920,7 @@ func (vm *VM) run() (Addr, bool) {
 
 		// Show
 		case OpShow:
-			t := vm.fn.Types[uint8(a)]
+			t := vm.fn.Types[uint16(a)]
 			st, ok := t.(ScriggoType)
 			if ok {
 				t = st.GoType()
@@ -1797,14 +1928,20 @@ func (vm *VM) run() (Addr, bool) {
 			rv := reflect.New(t).Elem()
 			vm.getIntoReflectValue(b, rv, op < 0)
 			if st != nil {
-				rv = st.Wrap(rv)
+				rv = st.Wrap(rv, vm.env)
 			}
 			var v interface{}
 			if rv.IsValid() {
 				v = rv.Interface()
 			}
+			if vm.env.sourceMap != nil {
+				vm.mapOutput(vm.pc-1, false)
+			}
 			err := vm.renderer.Show(vm.env, v, Context(c))
 			if err != nil {
+				if err == errOutputLimit {
+					panic(vm.newLimitError(err.Error()))
+				}
 				panic(outError{err})
 			}
 
@@ -1914,20 +2051,20 @@ func (vm *VM) run() (Addr, bool) {
 					fn = closure.fn
 					vm.vars = closure.vars
 				} else {
-					fn = vm.fn.Functions[uint8(b)]
+					fn = vm.fn.Functions[uint16(b)]
 					vm.vars = vm.env.globals
 				}
-				if vm.fp[0]+Addr(fn.NumReg[0]) > vm.st[0] {
-					vm.moreIntStack()
+				if vm.fp[0]+Addr(fn.NumReg[0]) >= vm.st[0] {
+					vm.moreIntStack(vm.fp[0] + Addr(fn.NumReg[0]))
 				}
-				if vm.fp[1]+Addr(fn.NumReg[1]) > vm.st[1] {
-					vm.moreFloatStack()
+				if vm.fp[1]+Addr(fn.NumReg[1]) >= vm.st[1] {
+					vm.moreFloatStack(vm.fp[1] + Addr(fn.NumReg[1]))
 				}
-				if vm.fp[2]+Addr(fn.NumReg[2]) > vm.st[2] {
-					vm.moreStringStack()
+				if vm.fp[2]+Addr(fn.NumReg[2]) >= vm.st[2] {
+					vm.moreStringStack(vm.fp[2] + Addr(fn.NumReg[2]))
 				}
-				if vm.fp[3]+Addr(fn.NumReg[3]) > vm.st[3] {
-					vm.moreGeneralStack()
+				if vm.fp[3]+Addr(fn.NumReg[3]) >= vm.st[3] {
+					vm.moreGeneralStack(vm.fp[3] + Addr(fn.NumReg[3]))
 				}
 				vm.fn = fn
 			}
@@ -1937,14 +2074,20 @@ func (vm *VM) run() (Addr, bool) {
 		case OpText:
 			txt := vm.fn.Text[decodeUint16(a, b)]
 			inURL, isSet := c > 0, c == 2
+			if vm.env.sourceMap != nil {
+				vm.mapOutput(vm.pc-1, true)
+			}
 			err := vm.renderer.Text(txt, inURL, isSet)
 			if err != nil {
+				if err == errOutputLimit {
+					panic(vm.newLimitError(err.Error()))
+				}
 				panic(outError{err})
 			}
 
 		// Typify
 		case OpTypify, -OpTypify:
-			t := vm.fn.Types[uint8(a)]
+			t := vm.fn.Types[uint16(a)]
 			st, ok := t.(ScriggoType)
 			if ok {
 				t = st.GoType()
@@ -1952,7 +2095,7 @@ func (vm *VM) run() (Addr, bool) {
 			v := reflect.New(t).Elem()
 			vm.getIntoReflectValue(b, v, op < 0)
 			if st != nil {
-				v = st.Wrap(v)
+				v = st.Wrap(v, vm.env)
 			}
 			vm.setGeneral(c, v)
 
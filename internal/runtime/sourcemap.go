@@ -0,0 +1,100 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"io"
+)
+
+// SourceMapping maps a position of the output of a template to a position
+// in the source code. The mapping is valid up to the next mapping.
+type SourceMapping struct {
+	Line     int      // line of the output, starting from 1.
+	Column   int      // column of the output, in characters, starting from 1.
+	Path     string   // path of the source code; empty if the output has no source.
+	Position Position // position in the source code; only Line and Column are set.
+}
+
+// SourceMap records the source mappings of the output of a template. It is
+// the writer of the template output and it maps the written bytes to the
+// positions set by the Text and Show instructions.
+type SourceMap struct {
+	Mappings []SourceMapping
+
+	w        io.Writer // output.
+	line     int       // current line of the output, starting from 0.
+	column   int       // current column of the output, starting from 0.
+	path     string    // path of the source code of the written bytes.
+	pos      Position  // position of the source code of the next written byte.
+	verbatim bool      // reports whether the bytes are written as in the source code.
+	mapped   bool      // reports whether the next written byte is mapped.
+}
+
+// SetSourceMap sets the source map that records the mappings of the output.
+//
+// SetSourceMap must be called before SetRenderer and must not be called
+// after vm has been started.
+func (vm *VM) SetSourceMap(m *SourceMap) {
+	vm.env.sourceMap = m
+}
+
+// Write writes p to the output, adding the mappings of its bytes.
+func (m *SourceMap) Write(p []byte) (int, error) {
+	n, err := m.w.Write(p)
+	for _, c := range p[:n] {
+		if !m.mapped {
+			m.Mappings = append(m.Mappings, SourceMapping{
+				Line:     m.line + 1,
+				Column:   m.column + 1,
+				Path:     m.path,
+				Position: Position{Line: m.pos.Line, Column: m.pos.Column},
+			})
+			m.mapped = true
+		}
+		switch {
+		case c == '\n':
+			m.line++
+			m.column = 0
+			m.mapped = false
+			if m.verbatim {
+				m.pos.Line++
+				m.pos.Column = 1
+			}
+		case c < 0x80 || c >= 0xC0:
+			// First byte of a character.
+			m.column++
+			if m.verbatim {
+				m.pos.Column++
+			}
+		}
+	}
+	return n, err
+}
+
+// set sets the source of the next written bytes. verbatim reports whether
+// they are written as in the source code.
+func (m *SourceMap) set(path string, pos Position, verbatim bool) {
+	m.path = path
+	m.pos = pos
+	m.verbatim = verbatim
+	m.mapped = false
+}
+
+// unset sets the next written bytes as without a source.
+func (m *SourceMap) unset() {
+	m.set("", Position{}, false)
+}
+
+// mapOutput sets the source of the bytes written by the instruction at
+// address pc of the running function, if they are written directly to the
+// template output.
+func (vm *VM) mapOutput(pc Addr, verbatim bool) {
+	m := vm.env.sourceMap
+	if vm.renderer.out != io.Writer(m) {
+		return
+	}
+	info := vm.fn.InstructionInfo[pc]
+	m.set(info.Path, info.Position, verbatim)
+}
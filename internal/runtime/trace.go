@@ -0,0 +1,188 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+// TraceKind is the kind of a trace event.
+type TraceKind int
+
+const (
+	TraceCall         TraceKind = iota // call of a function or macro
+	TraceReturn                        // return from a function or macro
+	TraceNativeCall                    // call of a native function
+	TraceNativeReturn                  // return from a native function
+	TracePanic                         // panic
+	TraceRecover                       // recover of a panic
+)
+
+// TraceEvent represents an event of a traced execution.
+type TraceEvent struct {
+	Kind     TraceKind
+	Function *Function       // function, for call and return events.
+	Native   *NativeFunction // native function, for native call and return events.
+	Path     string          // path of the source code, if known.
+	Position Position        // position in the source code, if known.
+	Panic    *PanicError     // panic, for panic and recover events, and for returns caused by a panic.
+}
+
+// traceSession represents the tracing of the execution of a VM.
+type traceSession struct {
+	trace  func(TraceEvent)
+	stack  []traceFrame    // called functions, starting from the outermost.
+	fn     *Function       // running function when the stack has been updated.
+	depth  int             // length of the call stack of the VM when the stack has been updated.
+	status callStatus      // status of the last call frame when the stack has been updated.
+	native *NativeFunction // running native function.
+}
+
+// traceFrame represents a function called in a traced execution.
+type traceFrame struct {
+	fn   *Function
+	path string   // path of the call.
+	pos  Position // position of the call.
+}
+
+// SetTracer sets the tracer of the execution. trace is called, in the
+// goroutine of the execution, for every traced event. Goroutines started by
+// the executed code and functions called by native code are not traced.
+//
+// SetTracer must not be called after vm has been started.
+func (vm *VM) SetTracer(trace func(TraceEvent)) {
+	vm.trace = &traceSession{trace: trace}
+}
+
+// traceChanged reports whether the call stack may be changed since the last
+// time the stack of the trace session has been updated.
+func (vm *VM) traceChanged() bool {
+	s := vm.trace
+	if vm.fn != s.fn || len(vm.calls) != s.depth {
+		return true
+	}
+	return s.depth > 0 && vm.calls[s.depth-1].status != s.status
+}
+
+// traceHook is called by the run method, before executing an instruction,
+// if the execution is traced and the call stack may be changed.
+func (vm *VM) traceHook() {
+	s := vm.trace
+	s.fn = vm.fn
+	s.depth = len(vm.calls)
+	if s.depth > 0 {
+		s.status = vm.calls[s.depth-1].status
+	}
+	vm.traceUpdate(vm.calls, vm.fn)
+}
+
+// traceDeferredNative is called by the nextCall method before calling a
+// deferred native function. i is the index in the call stack of the
+// deferred call.
+func (vm *VM) traceDeferredNative(i int) {
+	// The frame of the function that is running its deferred calls is at
+	// index i-1.
+	vm.traceUpdate(vm.calls[:i], nil)
+	// Force the update of the stack before the next instruction.
+	vm.trace.fn = nil
+}
+
+// traceUpdate updates the stack of the trace session with the functions in
+// the call stack calls, followed by fn if it is not nil, sending the return
+// events of the returned functions and the call events of the called
+// functions.
+func (vm *VM) traceUpdate(calls []callFrame, fn *Function) {
+	s := vm.trace
+	// Compute the called functions and their calls.
+	var stack []traceFrame
+	var path string
+	var pos Position
+	if vm.parent != nil {
+		// vm executes the iterator of a range statement.
+		path, pos = sourcePosition(vm.parent.fn, vm.parentPC)
+	} else if len(calls) > 0 && calls[0].cl.fn != nil {
+		// The outermost function has no call, so use its file.
+		path = calls[0].cl.fn.File
+	} else if fn != nil {
+		path = fn.File
+	}
+	for _, call := range calls {
+		if call.cl.fn == nil || call.status == deferred {
+			continue
+		}
+		stack = append(stack, traceFrame{fn: call.cl.fn, path: path, pos: pos})
+		switch call.status {
+		case started:
+			path, pos = sourcePosition(call.cl.fn, call.pc-2)
+		case tailed:
+			path, pos = sourcePosition(call.cl.fn, call.pc-1)
+		default:
+			path, pos = call.cl.fn.File, Position{}
+		}
+	}
+	if fn != nil {
+		stack = append(stack, traceFrame{fn: fn, path: path, pos: pos})
+	}
+	// Compare it with the previous stack.
+	n := 0
+	for n < len(stack) && n < len(s.stack) && stack[n].fn == s.stack[n].fn {
+		n++
+	}
+	vm.traceReturns(n)
+	for _, frame := range stack[n:] {
+		s.stack = append(s.stack, frame)
+		s.trace(TraceEvent{Kind: TraceCall, Function: frame.fn, Path: frame.path, Position: frame.pos})
+	}
+}
+
+// traceReturns sends the return events of the functions of the stack of the
+// trace session, starting from the innermost, until the length of the stack
+// is n.
+func (vm *VM) traceReturns(n int) {
+	s := vm.trace
+	var p *PanicError
+	if vm.panic != nil && !vm.panic.recovered {
+		p = vm.panic
+	}
+	for i := len(s.stack) - 1; i >= n; i-- {
+		frame := s.stack[i]
+		s.stack = s.stack[:i]
+		s.trace(TraceEvent{Kind: TraceReturn, Function: frame.fn, Path: frame.path, Position: frame.pos, Panic: p})
+	}
+}
+
+// traceNativeCall calls the native function fn as the callNative method
+// does, sending the call and, if fn does not panic, the return events.
+func (vm *VM) traceNativeCall(fn *NativeFunction, numVariadic int16, shift StackShift) {
+	s := vm.trace
+	var path string
+	var pos Position
+	if vm.fn != nil && vm.pc > 0 {
+		path, pos = sourcePosition(vm.fn, vm.pc-1)
+	}
+	s.trace(TraceEvent{Kind: TraceNativeCall, Native: fn, Path: path, Position: pos})
+	s.native = fn
+	vm.callNative(fn, numVariadic, shift, false)
+	s.native = nil
+	s.trace(TraceEvent{Kind: TraceNativeReturn, Native: fn, Path: path, Position: pos})
+}
+
+// tracePanic sends the panic event of p and, if p has been raised by a
+// native function, the return event of the native function.
+func (vm *VM) tracePanic(p *PanicError) {
+	s := vm.trace
+	path, pos := p.path, p.position
+	if pos.Line == 0 && vm.fn != nil && vm.pc > 0 {
+		path, pos = sourcePosition(vm.fn, vm.pc-1)
+	}
+	s.trace(TraceEvent{Kind: TracePanic, Path: path, Position: pos, Panic: p})
+	p.traced = true
+	if fn := s.native; fn != nil {
+		s.native = nil
+		s.trace(TraceEvent{Kind: TraceNativeReturn, Native: fn, Path: path, Position: pos, Panic: p})
+	}
+}
+
+// traceRecover sends the recover event of the panic p.
+func (vm *VM) traceRecover(p *PanicError) {
+	path, pos := sourcePosition(vm.fn, vm.pc-1)
+	vm.trace.trace(TraceEvent{Kind: TraceRecover, Path: path, Position: pos, Panic: p})
+}
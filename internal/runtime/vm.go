@@ -42,14 +42,20 @@ type ScriggoType interface {
 	reflect.Type
 
 	// Wrap wraps a value with a Scriggo type putting into a proxy that exposes
-	// methods to Go.
-	Wrap(reflect.Value) reflect.Value
+	// methods to Go. caller is used by the proxy to call the methods declared
+	// in Scriggo.
+	Wrap(v reflect.Value, caller FunctionCaller) reflect.Value
 
 	// Unwrap unwraps a value that has been read from Go. If the value given as
 	// parameter can be unwrapped using the unwrapper's type, the unwrapped
 	// value is returned and the method returns true.
 	Unwrap(reflect.Value) (reflect.Value, bool)
 
+	// MethodValue returns the method value, bound to v, of the method with
+	// the given name declared in Scriggo. v must be a value wrapped by Wrap.
+	// If the method does not exist, the method returns false.
+	MethodValue(v reflect.Value, name string) (reflect.Value, bool)
+
 	// GoType returns the Go type of a Scriggo type. Note that the
 	// implementation of the reflect.Type returned by GoType is the
 	// implementation of the package 'reflect', so it's safe to pass the
@@ -57,26 +63,34 @@ type ScriggoType interface {
 	GoType() reflect.Type
 }
 
-type StackShift [4]int8
+// A FunctionCaller calls Scriggo functions from native code.
+type FunctionCaller interface {
+
+	// CallFunction calls the package level Scriggo function fn with arguments
+	// args and returns its results.
+	CallFunction(fn *Function, args []reflect.Value) []reflect.Value
+}
+
+type StackShift [4]int16
 
 type Instruction struct {
 	Op      Operation
-	A, B, C int8
+	A, B, C int16
 }
 
-func decodeInt16(a, b int8) int16 {
+func decodeInt16(a, b int16) int16 {
 	return int16(int(a)<<8 | int(uint8(b)))
 }
 
-func decodeUint16(a, b int8) uint16 {
+func decodeUint16(a, b int16) uint16 {
 	return uint16(uint8(a))<<8 | uint16(uint8(b))
 }
 
-func decodeUint24(a, b, c int8) uint32 {
+func decodeUint24(a, b, c int16) uint32 {
 	return uint32(uint8(a))<<16 | uint32(uint8(b))<<8 | uint32(uint8(c))
 }
 
-func decodeValueIndex(a, b int8) (t registerType, i int) {
+func decodeValueIndex(a, b int16) (t registerType, i int) {
 	return registerType(uint8(a) >> 6), int(decodeUint16(a, b) &^ (3 << 14))
 }
 
@@ -96,6 +110,10 @@ type VM struct {
 	cases    []reflect.SelectCase // select cases.
 	panic    *PanicError          // panic.
 	main     bool                 // reports whether this VM is executing the main goroutine.
+	debug    *debugSession        // debug session, if the execution is debugged.
+	trace    *traceSession        // trace session, if the execution is traced.
+	parent   *VM                  // VM that executes the range statement, if vm executes its iterator.
+	parentPC Addr                 // address of the Range instruction in parent.
 }
 
 // NewVM returns a new virtual machine.
@@ -126,6 +144,8 @@ func (vm *VM) Reset() {
 		vm.cases = vm.cases[:0]
 	}
 	vm.panic = nil
+	vm.debug = nil
+	vm.trace = nil
 }
 
 // stop is called in the vm.run method to stop the execution.
@@ -135,6 +155,45 @@ func (vm *VM) stop() (Addr, bool) {
 	return maxAddr, false
 }
 
+// alloc accounts for the allocation of n elements of size bytes each and
+// panics with a *LimitError if the memory limit is exceeded. If the
+// execution is profiled, it adds the allocation to the profile. It must be
+// called only if the allocations are accounted.
+func (vm *VM) alloc(n int, size uintptr) {
+	if n <= 0 || size == 0 {
+		return
+	}
+	if p := vm.env.profiler; p != nil {
+		p.add(vm.profileStack(), 0, 1, int64(n)*int64(size))
+	}
+	max := vm.env.maxMemory
+	if max == 0 {
+		return
+	}
+	if uint64(n) > uint64(max)/uint64(size) || atomic.AddInt64(&vm.env.memory, int64(n)*int64(size)) > max {
+		panic(vm.newLimitError("memory limit exceeded"))
+	}
+}
+
+// allocAppend accounts for the allocation of a new underlying array if the
+// capacity of the slice s is not enough to append n elements.
+func (vm *VM) allocAppend(s reflect.Value, n int) {
+	if l := s.Len() + n; l > s.Cap() {
+		vm.alloc(appendCap(s.Cap(), l), s.Type().Elem().Size())
+	}
+}
+
+// allocMapEntry accounts for the allocation of a new entry in the map m if
+// the key in the register k is not already in the map.
+func (vm *VM) allocMapEntry(m reflect.Value, k int16) {
+	t := m.Type()
+	key := reflect.New(t.Key()).Elem()
+	vm.getIntoReflectValue(k, key, false)
+	if !m.MapIndex(key).IsValid() {
+		vm.alloc(1, t.Key().Size()+t.Elem().Size())
+	}
+}
+
 // Run starts the execution of the function fn with the given global variables
 // and waits for it to complete.
 //
@@ -143,13 +202,21 @@ func (vm *VM) stop() (Addr, bool) {
 //
 // If a context has been set and the context is canceled, Run returns
 // as soon as possible with the error returned by the Err method of the
-// context.
+// context. If a limit is exceeded, also by a goroutine, Run returns as soon
+// as possible with a *LimitError. In both cases, the goroutines started by
+// the running code are stopped too.
 func (vm *VM) Run(fn *Function, typeof TypeOfFunc, globals []reflect.Value) error {
 	if typeof == nil {
 		typeof = typeOfFunc
 	}
 	vm.env.typeof = typeof
 	vm.env.globals = globals
+	vm.env.initHalt()
+	vm.reserveStacks(fn)
+	if vm.env.profiler != nil {
+		stop := vm.startProfiling()
+		defer stop()
+	}
 	err := vm.runFunc(fn, globals)
 	if err != nil {
 		switch e := err.(type) {
@@ -172,24 +239,59 @@ func (vm *VM) Run(fn *Function, typeof TypeOfFunc, globals []reflect.Value) erro
 // SetContext must not be called after vm has been started.
 func (vm *VM) SetContext(ctx context.Context) {
 	vm.env.ctx = ctx
-	if ctx != nil {
-		if done := ctx.Done(); done != nil {
-			vm.env.doneChan = done
-			vm.env.doneCase = reflect.SelectCase{
-				Dir:  reflect.SelectRecv,
-				Chan: reflect.ValueOf(done),
-			}
-			return
-		}
-	}
-	vm.env.doneChan = nil
-	vm.env.doneCase = reflect.SelectCase{}
+}
+
+// SetMaxInstructions sets the maximum number of instructions that can be
+// executed. If n is zero, there is no limit. Instructions executed by the
+// goroutines started by the running code are counted too.
+//
+// SetMaxInstructions must not be called after vm has been started.
+func (vm *VM) SetMaxInstructions(n int64) {
+	vm.env.maxInstructions = n
+}
+
+// SetMaxMemory sets the maximum number of bytes that can be allocated by
+// the instructions that make slices, maps, channels, strings and values and
+// by the instructions that grow them. If n is zero, there is no limit.
+// Freed memory is not given back, so n bounds the total allocated memory.
+// Memory allocated by native functions is not counted.
+//
+// SetMaxMemory must not be called after vm has been started.
+func (vm *VM) SetMaxMemory(n int64) {
+	vm.env.maxMemory = n
+	vm.env.accounting = n > 0 || vm.env.profiler != nil
+}
+
+// SetMaxOutput sets the maximum number of bytes that can be written to the
+// template output. If n is zero, there is no limit.
+//
+// SetMaxOutput must be called before SetRenderer and must not be called after
+// vm has been started.
+func (vm *VM) SetMaxOutput(n int64) {
+	vm.env.maxOutput = n
+}
+
+// SetCoverage sets the coverage counters. Every Cover instruction executed
+// increments, atomically, the counter with the index of its operand. If
+// counters is nil, the Cover instructions do nothing. counters must have an
+// element for each coverage block of the executed code.
+//
+// SetCoverage must not be called after vm has been started.
+func (vm *VM) SetCoverage(counters []uint32) {
+	vm.env.coverage = counters
 }
 
 // SetRenderer sets template output and markdown converter.
 //
 // SetRenderer must not be called after vm has been started.
 func (vm *VM) SetRenderer(out io.Writer, conv Converter) {
+	if n := vm.env.maxOutput; n > 0 {
+		out = &limitedWriter{w: out, n: n}
+	}
+	if m := vm.env.sourceMap; m != nil {
+		m.w = out
+		out = m
+	}
 	vm.renderer = newRenderer(out)
 	vm.env.conv = conv
 }
@@ -258,12 +360,17 @@ func (vm *VM) Stack(buf []byte, all bool) int {
 //
 // When callNative is called, vm.pc must be the address of the call
 // instruction plus one.
-func (vm *VM) callNative(fn *NativeFunction, numVariadic int8, shift StackShift, asGoroutine bool) {
+func (vm *VM) callNative(fn *NativeFunction, numVariadic int16, shift StackShift, asGoroutine bool) {
 
 	if fn.value.IsNil() {
 		panic(errNilPointer)
 	}
 
+	if vm.trace != nil && vm.trace.native == nil && !asGoroutine {
+		vm.traceNativeCall(fn, numVariadic, shift)
+		return
+	}
+
 	// Make a copy of the frame pointer.
 	fp := vm.fp
 
@@ -355,32 +462,32 @@ func (vm *VM) callNative(fn *NativeFunction, numVariadic int8, shift StackShift,
 				switch k {
 				case reflect.Bool:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).SetBool(vm.bool(int8(j + 1)))
+						slice.Index(j).SetBool(vm.bool(int16(j + 1)))
 					}
 				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).SetInt(vm.int(int8(j + 1)))
+						slice.Index(j).SetInt(vm.int(int16(j + 1)))
 					}
 				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).SetUint(uint64(vm.int(int8(j + 1))))
+						slice.Index(j).SetUint(uint64(vm.int(int16(j + 1))))
 					}
 				case reflect.Float32, reflect.Float64:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).SetFloat(vm.float(int8(j + 1)))
+						slice.Index(j).SetFloat(vm.float(int16(j + 1)))
 					}
 				case reflect.Func:
 					for j := 0; j < int(numVariadic); j++ {
-						f := vm.general(int8(j + 1)).Interface().(*callable)
+						f := vm.general(int16(j + 1)).Interface().(*callable)
 						slice.Index(j).Set(f.Value(vm.env))
 					}
 				case reflect.String:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).SetString(vm.string(int8(j + 1)))
+						slice.Index(j).SetString(vm.string(int16(j + 1)))
 					}
 				case reflect.Interface:
 					for j := 0; j < int(numVariadic); j++ {
-						if v := vm.general(int8(j + 1)); !v.IsValid() {
+						if v := vm.general(int16(j + 1)); !v.IsValid() {
 							if t := slice.Index(j).Type(); t == emptyInterfaceType {
 								slice.Index(j).Set(emptyInterfaceNil)
 							} else {
@@ -392,7 +499,7 @@ func (vm *VM) callNative(fn *NativeFunction, numVariadic int8, shift StackShift,
 					}
 				default:
 					for j := 0; j < int(numVariadic); j++ {
-						slice.Index(j).Set(vm.general(int8(j + 1)))
+						slice.Index(j).Set(vm.general(int16(j + 1)))
 					}
 				}
 				args[i].Set(slice)
@@ -473,7 +580,7 @@ func (vm *VM) equals(x, y reflect.Value) bool {
 //
 // It panics with errNilPointer if s represents a nil pointer or accessing
 // to a nil embedded struct.
-func (vm *VM) fieldByIndex(s reflect.Value, i uint8) reflect.Value {
+func (vm *VM) fieldByIndex(s reflect.Value, i uint16) reflect.Value {
 	v := s
 	for _, x := range vm.fn.FieldIndexes[i] {
 		if v.Kind() == reflect.Ptr {
@@ -487,38 +594,52 @@ func (vm *VM) fieldByIndex(s reflect.Value, i uint8) reflect.Value {
 	return v
 }
 
-func (vm *VM) finalize(regs [][2]int8) {
+func (vm *VM) finalize(regs [][2]int16) {
 	for _, reg := range regs {
 		vm.setFromReflectValue(reg[1], vm.generalIndirect(reg[0]))
 	}
 }
 
-func (vm *VM) moreIntStack() {
+// moreIntStack grows the int stack so that it has at least size+1 registers.
+// The other more*Stack methods do the same for the other stacks.
+func (vm *VM) moreIntStack(size Addr) {
 	top := len(vm.regs.int) * 2
+	for Addr(top) <= size {
+		top *= 2
+	}
 	stack := make([]int64, top)
 	copy(stack, vm.regs.int)
 	vm.regs.int = stack
 	vm.st[0] = Addr(top)
 }
 
-func (vm *VM) moreFloatStack() {
+func (vm *VM) moreFloatStack(size Addr) {
 	top := len(vm.regs.float) * 2
+	for Addr(top) <= size {
+		top *= 2
+	}
 	stack := make([]float64, top)
 	copy(stack, vm.regs.float)
 	vm.regs.float = stack
 	vm.st[1] = Addr(top)
 }
 
-func (vm *VM) moreStringStack() {
+func (vm *VM) moreStringStack(size Addr) {
 	top := len(vm.regs.string) * 2
+	for Addr(top) <= size {
+		top *= 2
+	}
 	stack := make([]string, top)
 	copy(stack, vm.regs.string)
 	vm.regs.string = stack
 	vm.st[2] = Addr(top)
 }
 
-func (vm *VM) moreGeneralStack() {
+func (vm *VM) moreGeneralStack(size Addr) {
 	top := len(vm.regs.general) * 2
+	for Addr(top) <= size {
+		top *= 2
+	}
 	stack := make([]reflect.Value, top)
 	copy(stack, vm.regs.general)
 	vm.regs.general = stack
@@ -582,16 +703,16 @@ func (vm *VM) nextCall() bool {
 				if call.status == deferred {
 					vm.calls[i] = vm.calls[i+1]
 					vm.calls[i].status = panicked
-					if call.cl.fn != nil {
-						i++
-					}
+					i++
 					break
 				}
 			}
 		}
 		if i >= 0 {
+			// The frame of the function that executes its deferred calls,
+			// if there is one, is now at index i-1.
+			vm.calls = vm.calls[:i]
 			if call.cl.fn != nil {
-				vm.calls = vm.calls[:i]
 				vm.fp = call.fp
 				vm.pc = call.pc
 				vm.fn = call.cl.fn
@@ -600,12 +721,206 @@ func (vm *VM) nextCall() bool {
 				return true
 			}
 			vm.fp = call.fp
+			if vm.trace != nil {
+				vm.traceDeferredNative(i)
+			}
 			vm.callNative(call.cl.Native(), call.numVariadic, StackShift{}, false)
 		}
 	}
 	return false
 }
 
+// rangeFunc executes a range over the function iterator fn. rangeAddress is
+// the address of the Range instruction and bodyAddress is the address of the
+// body. Every time the iterator calls the yield function, rangeFunc sets the
+// registers b and c, if not zero, with the yielded values and executes the
+// body.
+//
+// If the execution must continue out of the range statement, rangeFunc
+// returns the address and the break flag returned by the execution of the
+// body and false. Otherwise, it returns true.
+func (vm *VM) rangeFunc(fn *callable, b, c int16, rangeAddress, bodyAddress Addr) (Addr, bool, bool) {
+
+	var yieldType reflect.Type
+	if fn.fn == nil {
+		if fn.Native().value.IsNil() {
+			panic(errNilPointer)
+		}
+		yieldType = fn.Native().value.Type().In(0)
+	} else {
+		yieldType = fn.fn.Type.In(0)
+		if st, ok := yieldType.(ScriggoType); ok {
+			yieldType = st.GoType()
+		}
+	}
+
+	var addr Addr
+	var breakOut, exit, stopped bool
+	var panicking bool
+	var msg interface{}
+
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		if stopped {
+			panic(runtimeError("runtime error: range function continued iteration after function for loop body returned false"))
+		}
+		if b != 0 {
+			vm.setFromReflectValue(b, args[0])
+		}
+		if c != 0 {
+			vm.setFromReflectValue(c, args[1])
+		}
+		// A panic in the body stops the iteration and it is raised again
+		// after the iterator has returned.
+		panicking = true
+		func() {
+			defer func() {
+				if panicking {
+					msg = recover()
+				}
+			}()
+			vm.pc = bodyAddress
+			addr, breakOut = vm.runRangeFuncBody()
+			panicking = false
+		}()
+		exit = !panicking && addr != rangeAddress
+		stopped = panicking || exit || breakOut
+		return []reflect.Value{reflect.ValueOf(!stopped)}
+	})
+
+	// If the iterator panics, report the panic at the Range instruction.
+	iterating := true
+	defer func() {
+		if iterating {
+			vm.pc = rangeAddress + 1
+		}
+	}()
+
+	if fn.fn == nil {
+		fn.Native().value.Call([]reflect.Value{yield})
+	} else {
+		nvm := create(vm.env)
+		nvm.reserveStacks(fn.fn)
+		nvm.renderer = vm.renderer
+		nvm.debug = vm.debug
+		if vm.trace != nil {
+			nvm.trace = &traceSession{trace: vm.trace.trace}
+		}
+		nvm.parent = vm
+		nvm.parentPC = rangeAddress
+		nvm.setFromReflectValue(1, yield)
+		if err := nvm.runFunc(fn.fn, fn.vars); err != nil {
+			if atomic.LoadInt32(&vm.env.done) == 1 {
+				iterating = false
+				addr, breakOut = vm.stop()
+				return addr, breakOut, false
+			}
+			panic(err)
+		}
+	}
+	iterating = false
+
+	if panicking {
+		panic(msg)
+	}
+	if exit {
+		return addr, breakOut, false
+	}
+
+	return 0, false, true
+}
+
+// runRangeFuncBody executes the body of a range over a function iterator,
+// starting at the current program counter, and returns the address and the
+// break flag returned by run.
+//
+// A panic raised in a function called by the body is handled as runFunc
+// does, executing the deferred calls of the called functions, but not the
+// deferred calls of the function with the range statement, that are executed
+// after the iterator has returned. If the panic is not recovered, or it is
+// raised by the body itself, runRangeFuncBody panics with a rangeBodyError.
+func (vm *VM) runRangeFuncBody() (Addr, bool) {
+
+	depth := len(vm.calls)
+
+	// While a panic is handled, calls contains only the frames of the
+	// called function, starting with the frame of the call, and prefix
+	// contains the frames below it. outerPanic is the panic, if any, that
+	// was running before the call.
+	var unwinding bool
+	var prefix []callFrame
+	var outerPanic *PanicError
+
+	for {
+		addr, breakOut, err := vm.runBodyRecoverable()
+		var panics *PanicError
+		if unwinding {
+			if err == nil && vm.fn == nil {
+				// The panic has not been recovered by the called functions,
+				// so it is raised again by the body at the call.
+				call := vm.calls[0]
+				vm.fn = call.cl.fn
+				vm.vars = call.cl.vars
+				vm.fp = call.fp
+				vm.renderer = call.renderer
+				vm.calls = prefix
+				err, vm.panic = vm.panic, outerPanic
+				panic(rangeBodyError{err})
+			}
+			vm.calls = append(prefix, vm.calls...)
+			panics, vm.panic = vm.panic, outerPanic
+			unwinding = false
+		}
+		if err == nil {
+			return addr, breakOut
+		}
+		p, ok := err.(*PanicError)
+		if !ok {
+			panic(rangeBodyError{err})
+		}
+		// Look for the frame of the call, made by the body, in which the
+		// panic has been raised.
+		k := -1
+		for i := depth; i < len(vm.calls); i++ {
+			if vm.calls[i].status == started {
+				k = i
+				break
+			}
+		}
+		if k == -1 {
+			panic(rangeBodyError{p})
+		}
+		unwinding = true
+		prefix = vm.calls[:k]
+		vm.calls = vm.calls[k:]
+		outerPanic = vm.panic
+		p.next = panics
+		vm.panic = p
+		if vm.trace != nil {
+			vm.tracePanic(p)
+		}
+		vm.calls = append(vm.calls, callFrame{cl: callable{fn: vm.fn}, renderer: vm.renderer, fp: vm.fp, status: panicked})
+		vm.fn = nil
+	}
+
+}
+
+// runBodyRecoverable is like runRecoverable but it also returns the address
+// and the break flag returned by run.
+func (vm *VM) runBodyRecoverable() (addr Addr, breakOut bool, err error) {
+	panicking := true
+	defer func() {
+		if panicking {
+			msg := recover()
+			err = vm.convertPanic(msg)
+		}
+	}()
+	if vm.fn != nil || vm.nextCall() {
+		addr, breakOut = vm.run()
+	}
+	panicking = false
+	return addr, breakOut, nil
+}
+
 // create creates a new virtual machine with the execution environment env.
 func create(env *env) *VM {
 	vm := &VM{
@@ -624,6 +939,23 @@ func create(env *env) *VM {
 	return vm
 }
 
+// reserveStacks grows the stacks, if necessary, so that they can hold the
+// registers of fn when it is called with frame pointers at zero.
+func (vm *VM) reserveStacks(fn *Function) {
+	if Addr(fn.NumReg[0]) >= vm.st[0] {
+		vm.moreIntStack(Addr(fn.NumReg[0]))
+	}
+	if Addr(fn.NumReg[1]) >= vm.st[1] {
+		vm.moreFloatStack(Addr(fn.NumReg[1]))
+	}
+	if Addr(fn.NumReg[2]) >= vm.st[2] {
+		vm.moreStringStack(Addr(fn.NumReg[2]))
+	}
+	if Addr(fn.NumReg[3]) >= vm.st[3] {
+		vm.moreGeneralStack(Addr(fn.NumReg[3]))
+	}
+}
+
 // startGoroutine starts a new goroutine to execute a function call at program
 // counter pc. If the function is native, returns true.
 func (vm *VM) startGoroutine() bool {
@@ -632,12 +964,12 @@ func (vm *VM) startGoroutine() bool {
 	call := vm.fn.Body[vm.pc]
 	switch call.Op {
 	case OpCallFunc:
-		fn = vm.fn.Functions[uint8(call.A)]
+		fn = vm.fn.Functions[uint16(call.A)]
 		vars = vm.env.globals
 	case OpCallIndirect:
 		f := vm.general(call.A).Interface().(*callable)
 		if f.fn == nil {
-			if f.native.value.IsNil() {
+			if f.Native().value.IsNil() {
 				panic(errors.New("fatal error: go of nil func value"))
 			}
 			return true
@@ -648,12 +980,14 @@ func (vm *VM) startGoroutine() bool {
 		return true
 	}
 	nvm := create(vm.env)
+	nvm.reserveStacks(fn)
 	vm.pc++
 	off := vm.fn.Body[vm.pc]
-	copy(nvm.regs.int, vm.regs.int[vm.fp[0]+Addr(off.Op):vm.fp[0]+127])
-	copy(nvm.regs.float, vm.regs.float[vm.fp[1]+Addr(off.A):vm.fp[1]+127])
-	copy(nvm.regs.string, vm.regs.string[vm.fp[2]+Addr(off.B):vm.fp[2]+127])
-	copy(nvm.regs.general, vm.regs.general[vm.fp[3]+Addr(off.C):vm.fp[3]+127])
+	n := vm.fn.NumReg
+	copy(nvm.regs.int, vm.regs.int[vm.fp[0]+Addr(off.Op):vm.fp[0]+Addr(n[0])+1])
+	copy(nvm.regs.float, vm.regs.float[vm.fp[1]+Addr(off.A):vm.fp[1]+Addr(n[1])+1])
+	copy(nvm.regs.string, vm.regs.string[vm.fp[2]+Addr(off.B):vm.fp[2]+Addr(n[2])+1])
+	copy(nvm.regs.general, vm.regs.general[vm.fp[3]+Addr(off.C):vm.fp[3]+Addr(n[3])+1])
 	go nvm.runFunc(fn, vars)
 	vm.pc++
 	return false
@@ -670,8 +1004,8 @@ func (vm *VM) swapStack(a, b *[4]Addr, bSize StackShift) {
 	bs := Addr(bSize[0])
 	if as > 0 && bs > 0 {
 		tot := as + bs
-		if a[0]+tot+bs > vm.st[0] {
-			vm.moreIntStack()
+		if a[0]+tot+bs >= vm.st[0] {
+			vm.moreIntStack(a[0] + tot + bs)
 		}
 		s := vm.regs.int[a[0]+1:]
 		copy(s[bs:], s[:tot])
@@ -685,8 +1019,8 @@ func (vm *VM) swapStack(a, b *[4]Addr, bSize StackShift) {
 	bs = Addr(bSize[1])
 	if as > 0 && bs > 0 {
 		tot := as + bs
-		if a[1]+tot+bs > vm.st[1] {
-			vm.moreFloatStack()
+		if a[1]+tot+bs >= vm.st[1] {
+			vm.moreFloatStack(a[1] + tot + bs)
 		}
 		s := vm.regs.float[a[1]+1:]
 		copy(s[bs:], s[:tot])
@@ -700,8 +1034,8 @@ func (vm *VM) swapStack(a, b *[4]Addr, bSize StackShift) {
 	bs = Addr(bSize[2])
 	if as > 0 && bs > 0 {
 		tot := as + bs
-		if a[2]+tot+bs > vm.st[2] {
-			vm.moreStringStack()
+		if a[2]+tot+bs >= vm.st[2] {
+			vm.moreStringStack(a[2] + tot + bs)
 		}
 		s := vm.regs.string[a[2]+1:]
 		copy(s[bs:], s[:tot])
@@ -715,8 +1049,8 @@ func (vm *VM) swapStack(a, b *[4]Addr, bSize StackShift) {
 	bs = Addr(bSize[3])
 	if as > 0 && bs > 0 {
 		tot := as + bs
-		if a[3]+tot+bs > vm.st[3] {
-			vm.moreGeneralStack()
+		if a[3]+tot+bs >= vm.st[3] {
+			vm.moreGeneralStack(a[3] + tot + bs)
 		}
 		s := vm.regs.general[a[3]+1:]
 		copy(s[bs:], s[:tot])
@@ -738,7 +1072,7 @@ type NativeFunction struct {
 	pkg         string        // package.
 	name        string        // name.
 	function    interface{}   // value.
-	outOff      [4]int8       // offset of out arguments.
+	outOff      [4]int16      // offset of out arguments.
 	value       reflect.Value // reflect value.
 	reflectCall bool          // reports whether it can be called only with reflect.
 	argsPool    *sync.Pool    // pool of arguments for reflect.Call and reflect.CallSlice.
@@ -826,8 +1160,8 @@ type Function struct {
 	VarRefs []int16
 
 	Types           []reflect.Type
-	NumReg          [4]int8
-	FinalRegs       [][2]int8 // [indirect -> return parameter registers]
+	NumReg          [4]int16
+	FinalRegs       [][2]int16 // [indirect -> return parameter registers]
 	Macro           bool
 	Format          ast.Format
 	Values          Registers
@@ -837,6 +1171,11 @@ type Function struct {
 	Body            []Instruction
 	Text            [][]byte
 	InstructionInfo map[Addr]InstructionInfo
+
+	// Statements are the statements of the function ordered by address and
+	// LocalVars are its local variables. They are used by the debugger.
+	Statements []Statement
+	LocalVars  []LocalVar
 }
 
 // Position represents a source position.
@@ -880,7 +1219,7 @@ type callFrame struct {
 	fp          [4]Addr    // frame pointers.
 	pc          Addr       // program counter.
 	status      callStatus // status.
-	numVariadic int8       // number of variadic arguments.
+	numVariadic int16      // number of variadic arguments.
 }
 
 type callable struct {
@@ -914,55 +1253,65 @@ func (c *callable) Value(env *env) reflect.Value {
 	fn := c.fn
 	vars := c.vars
 	c.value = reflect.MakeFunc(fn.Type, func(args []reflect.Value) []reflect.Value {
-		nvm := create(env)
-		if fn.Macro {
-			nvm.renderer = newRenderer(&strings.Builder{})
-		}
-		nOut := fn.Type.NumOut()
-		results := make([]reflect.Value, nOut)
-		var r = [4]int8{1, 1, 1, 1}
-		for i := 0; i < nOut; i++ {
-			typ := fn.Type.Out(i)
-			results[i] = reflect.New(typ).Elem()
-			t := kindToType[typ.Kind()]
-			r[t]++
-		}
-		for _, arg := range args {
-			t := kindToType[arg.Kind()]
-			nvm.setFromReflectValue(r[t], arg)
-			r[t]++
+		return env.callFunction(fn, vars, args)
+	})
+	return c.value
+}
+
+// callFunction calls the Scriggo function fn, with non-local variables vars,
+// from a native code and returns its results.
+func (env *env) callFunction(fn *Function, vars []reflect.Value, args []reflect.Value) []reflect.Value {
+	nvm := create(env)
+	nvm.reserveStacks(fn)
+	if fn.Macro {
+		nvm.renderer = newRenderer(&strings.Builder{})
+	}
+	nOut := fn.Type.NumOut()
+	results := make([]reflect.Value, nOut)
+	var r = [4]int16{1, 1, 1, 1}
+	for i := 0; i < nOut; i++ {
+		typ := fn.Type.Out(i)
+		if st, ok := typ.(ScriggoType); ok {
+			typ = st.GoType()
 		}
-		err := nvm.runFunc(fn, vars)
-		if err != nil {
-			if p, ok := err.(*PanicError); ok {
-				var msg string
-				for ; p != nil; p = p.next {
-					msg = "\n" + msg
-					if p.recovered {
-						msg = " [recovered]" + msg
-					}
-					msg = p.String() + msg
-					if p.next != nil {
-						msg = "\tpanic: " + msg
-					}
+		results[i] = reflect.New(typ).Elem()
+		t := kindToType[typ.Kind()]
+		r[t]++
+	}
+	for _, arg := range args {
+		t := kindToType[arg.Kind()]
+		nvm.setFromReflectValue(r[t], arg)
+		r[t]++
+	}
+	err := nvm.runFunc(fn, vars)
+	if err != nil {
+		if p, ok := err.(*PanicError); ok {
+			var msg string
+			for ; p != nil; p = p.next {
+				msg = "\n" + msg
+				if p.recovered {
+					msg = " [recovered]" + msg
+				}
+				msg = p.String() + msg
+				if p.next != nil {
+					msg = "\tpanic: " + msg
 				}
-				err = &fatalError{msg: msg}
 			}
-			panic(err)
+			err = &fatalError{msg: msg}
 		}
-		if fn.Macro {
-			b := nvm.renderer.Out().(*strings.Builder)
-			nvm.setString(1, b.String())
-		}
-		r = [4]int8{1, 1, 1, 1}
-		for _, result := range results {
-			t := kindToType[result.Kind()]
-			nvm.getIntoReflectValue(r[t], result, false)
-			r[t]++
-		}
-		return results
-	})
-	return c.value
+		panic(err)
+	}
+	if fn.Macro {
+		b := nvm.renderer.Out().(*strings.Builder)
+		nvm.setString(1, b.String())
+	}
+	r = [4]int16{1, 1, 1, 1}
+	for _, result := range results {
+		t := kindToType[result.Kind()]
+		nvm.getIntoReflectValue(r[t], result, false)
+		r[t]++
+	}
+	return results
 }
 
 func packageName(pkg string) string {
@@ -1042,7 +1391,7 @@ const (
 	ConditionNotOK                                 // ![vm.ok]
 )
 
-type Operation int8
+type Operation int16
 
 const (
 	OpNone Operation = iota
@@ -1074,6 +1423,8 @@ const (
 
 	OpCase
 
+	OpClear
+
 	OpClose
 
 	OpComplex64
@@ -1091,6 +1442,8 @@ const (
 
 	OpCopy
 
+	OpCover
+
 	OpDefer
 
 	OpDelete
@@ -1138,8 +1491,12 @@ const (
 	OpMapIndex
 	OpMapIndexAny
 
+	OpMax
+
 	OpMethodValue
 
+	OpMin
+
 	OpMove
 
 	OpMul
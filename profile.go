@@ -0,0 +1,218 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"compress/gzip"
+	"io"
+	"time"
+
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// Profiler profiles the CPU time and the allocations of the executions of
+// programs and templates. To profile an execution, pass a Profiler to the
+// Run method with the Profiler field of RunOptions.
+//
+// The CPU time is sampled every 10 milliseconds, and it is attributed to the
+// last executed instruction, so the time spent in a native function call is
+// attributed to the line of the call. The allocations are the allocations of
+// slices, maps, channels, strings and values made by the executed code, as
+// they are accounted by the MaxMemory run option; allocations made by native
+// functions are not profiled.
+//
+// A Profiler can profile many executions, also of different programs and
+// templates and at the same time. Profiled executions run slower.
+type Profiler struct {
+	p     *runtime.Profiler
+	start time.Time
+}
+
+// NewProfiler returns a new profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{p: runtime.NewProfiler(), start: time.Now()}
+}
+
+// WriteProfile writes the profile to w in the gzip-compressed protocol buffer
+// format of pprof, so it can be read by the go tool pprof command. The
+// profile has the sample types "samples/count", "cpu/nanoseconds",
+// "alloc_objects/count" and "alloc_space/bytes", with "cpu" as default.
+//
+// The functions of the profile are named as the frames returned by the
+// Frames method of DebugStop, and their file names are the paths reported
+// by build errors.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	b := newProfileBuilder()
+	samples := p.p.Samples()
+	period := int64(runtime.ProfilePeriod)
+
+	var prof protobuf
+	for _, st := range [][2]string{
+		{"samples", "count"},
+		{"cpu", "nanoseconds"},
+		{"alloc_objects", "count"},
+		{"alloc_space", "bytes"},
+	} {
+		var vt protobuf
+		vt.int64(1, b.string(st[0]))
+		vt.int64(2, b.string(st[1]))
+		prof.message(1, vt)
+	}
+	for _, s := range samples {
+		var sample protobuf
+		ids := make([]uint64, len(s.Stack))
+		for i, frame := range s.Stack {
+			ids[i] = b.location(frame)
+		}
+		sample.packedUint64(1, ids)
+		sample.packedInt64(2, []int64{s.CPU, s.CPU * period, s.AllocObjects, s.AllocBytes})
+		prof.message(2, sample)
+	}
+	for _, loc := range b.locations {
+		prof.message(4, loc)
+	}
+	for _, fn := range b.functions {
+		prof.message(5, fn)
+	}
+	prof.int64(9, p.start.UnixNano())
+	var pt protobuf
+	pt.int64(1, b.string("cpu"))
+	pt.int64(2, b.string("nanoseconds"))
+	prof.message(11, pt)
+	prof.int64(12, period)
+	prof.int64(14, b.string("cpu"))
+	// The string table is written last, when all the strings have been added.
+	for _, s := range b.strings {
+		prof.bytes(6, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(prof.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// profileBuilder builds the string table, the functions and the locations
+// of a pprof profile.
+type profileBuilder struct {
+	strings   []string
+	stringIDs map[string]int64
+	functions []protobuf
+	funcIDs   map[profileFunction]uint64
+	locations []protobuf
+	locIDs    map[runtime.ProfileFrame]uint64
+}
+
+func newProfileBuilder() *profileBuilder {
+	return &profileBuilder{
+		strings:   []string{""},
+		stringIDs: map[string]int64{"": 0},
+		funcIDs:   map[profileFunction]uint64{},
+		locIDs:    map[runtime.ProfileFrame]uint64{},
+	}
+}
+
+// string returns the index of s in the string table.
+func (b *profileBuilder) string(s string) int64 {
+	id, ok := b.stringIDs[s]
+	if !ok {
+		id = int64(len(b.strings))
+		b.strings = append(b.strings, s)
+		b.stringIDs[s] = id
+	}
+	return id
+}
+
+// profileFunction is a function of a pprof profile. As the code of a
+// template function can be in different files, a function is identified by
+// both the function and the path.
+type profileFunction struct {
+	fn   *runtime.Function
+	path string
+}
+
+// function returns the identifier of the function of frame.
+func (b *profileBuilder) function(frame runtime.ProfileFrame) uint64 {
+	key := profileFunction{frame.Function, frame.Path}
+	id, ok := b.funcIDs[key]
+	if !ok {
+		id = uint64(len(b.functions) + 1)
+		name := b.string(debugFunctionName(frame.Function))
+		var fn protobuf
+		fn.uint64(1, id)
+		fn.int64(2, name)
+		fn.int64(3, name)
+		fn.int64(4, b.string(frame.Path))
+		b.functions = append(b.functions, fn)
+		b.funcIDs[key] = id
+	}
+	return id
+}
+
+// location returns the identifier of the location of frame.
+func (b *profileBuilder) location(frame runtime.ProfileFrame) uint64 {
+	id, ok := b.locIDs[frame]
+	if !ok {
+		id = uint64(len(b.locations) + 1)
+		var line protobuf
+		line.uint64(1, b.function(frame))
+		line.int64(2, int64(frame.Line))
+		var loc protobuf
+		loc.uint64(1, id)
+		loc.message(4, line)
+		b.locations = append(b.locations, loc)
+		b.locIDs[frame] = id
+	}
+	return id
+}
+
+// protobuf is a protocol buffer message encoder.
+type protobuf struct {
+	data []byte
+}
+
+func (pb *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		pb.data = append(pb.data, byte(x)|0x80)
+		x >>= 7
+	}
+	pb.data = append(pb.data, byte(x))
+}
+
+func (pb *protobuf) uint64(tag int, x uint64) {
+	pb.varint(uint64(tag) << 3)
+	pb.varint(x)
+}
+
+func (pb *protobuf) int64(tag int, x int64) {
+	pb.uint64(tag, uint64(x))
+}
+
+func (pb *protobuf) bytes(tag int, b []byte) {
+	pb.varint(uint64(tag)<<3 | 2)
+	pb.varint(uint64(len(b)))
+	pb.data = append(pb.data, b...)
+}
+
+func (pb *protobuf) message(tag int, m protobuf) {
+	pb.bytes(tag, m.data)
+}
+
+func (pb *protobuf) packedUint64(tag int, x []uint64) {
+	var p protobuf
+	for _, u := range x {
+		p.varint(u)
+	}
+	pb.bytes(tag, p.data)
+}
+
+func (pb *protobuf) packedInt64(tag int, x []int64) {
+	var p protobuf
+	for _, u := range x {
+		p.varint(uint64(u))
+	}
+	pb.bytes(tag, p.data)
+}
@@ -47,6 +47,17 @@ type BuildOptions struct {
 	//
 	// Used for templates only.
 	Globals native.Declarations
+
+	// FrontMatter, if not nil, parses the front matter of the template file
+	// and declares it as a global variable. See [FrontMatter] for details.
+	//
+	// Used for templates only.
+	FrontMatter *FrontMatter
+
+	// Coverage, when true, instruments the code to collect its coverage
+	// when it is run with the Coverage run option. Instrumented code runs
+	// slower, so it should be used only to collect the coverage.
+	Coverage bool
 }
 
 // PrintFunc represents a function that prints the arguments of the print and
@@ -65,13 +76,68 @@ type RunOptions struct {
 	// If it is nil, the print and println builtins format their arguments as
 	// expected and write the result to standard error.
 	Print PrintFunc
+
+	// MaxInstructions, if greater than zero, is the maximum number of virtual
+	// machine instructions that can be executed, including those executed by
+	// the started goroutines. When the limit is exceeded, the execution is
+	// terminated and the Run method returns a [*LimitError].
+	//
+	// Unlike Context, the limit does not depend on the wall clock, so for the
+	// same code and input the execution always stops at the same point.
+	MaxInstructions int64
+
+	// MaxMemory, if greater than zero, is the maximum number of bytes that
+	// can be allocated making and growing slices, maps, channels and strings
+	// and allocating new values, including the allocations of the started
+	// goroutines. When the limit is exceeded, the execution is terminated and
+	// the Run method returns a [*LimitError].
+	//
+	// Memory is accounted when it is allocated and it is not given back when
+	// it is freed, so MaxMemory bounds the total allocated memory. The size
+	// of an allocation is estimated from the sizes of the involved types and
+	// memory allocated by native functions is not accounted.
+	MaxMemory int64
+
+	// MaxOutput, if greater than zero, is the maximum number of bytes that
+	// can be written to the template output. When the limit is exceeded,
+	// the bytes up to the limit are written, the execution is terminated and
+	// the Run method returns a [*LimitError].
+	//
+	// Used for templates only.
+	MaxOutput int64
+
+	// SourceMap, if not nil, records the source map of the output. See
+	// [SourceMap] for details.
+	//
+	// Used for templates only.
+	SourceMap *SourceMap
+
+	// Debugger, if not nil, debugs the execution. See [Debugger] for
+	// details.
+	Debugger *Debugger
+
+	// Profiler, if not nil, profiles the execution. See [Profiler] for
+	// details.
+	Profiler *Profiler
+
+	// Tracer, if not nil, is called for the calls, returns, panics and
+	// recovers of the execution. See [Tracer] for details.
+	Tracer Tracer
+
+	// Coverage, if not nil, collects the coverage of the execution. The code
+	// must have been built with the Coverage build option, otherwise no
+	// coverage is collected. See [Coverage] for details.
+	Coverage *Coverage
 }
 
 // Program is a program compiled with the [Build] function.
 type Program struct {
-	fn      *runtime.Function
-	typeof  runtime.TypeOfFunc
-	globals []compiler.Global
+	fn       *runtime.Function
+	typeof   runtime.TypeOfFunc
+	globals  []compiler.Global
+	importer native.Importer
+	packages []string
+	coverage []compiler.CoverBlock
 }
 
 // Build builds a program from the package in the root of fsys with the given
@@ -79,12 +145,15 @@ type Program struct {
 //
 // Current limitation: fsys can contain only one Go file in its root.
 //
-// If a build error occurs, it returns a [*BuildError].
+// If a build error occurs, it returns a [*BuildError]. The type checking
+// continues after an error, so the Diagnostics method of the returned error
+// reports all the type checking errors.
 func Build(fsys fs.FS, options *BuildOptions) (*Program, error) {
 	co := compiler.Options{}
 	if options != nil {
 		co.AllowGoStmt = options.AllowGoStmt
 		co.Importer = options.Packages
+		co.Coverage = options.Coverage
 	}
 	code, err := compiler.BuildProgram(fsys, co)
 	if err != nil {
@@ -93,7 +162,44 @@ func Build(fsys fs.FS, options *BuildOptions) (*Program, error) {
 		}
 		return nil, err
 	}
-	return &Program{fn: code.Main, globals: code.Globals, typeof: code.TypeOf}, nil
+	return &Program{fn: code.Main, globals: code.Globals, typeof: code.TypeOf, importer: co.Importer, packages: code.Packages,
+		coverage: code.Coverage}, nil
+}
+
+// LoadProgram loads a program encoded by the MarshalBinary method of
+// [Program]. options.Packages must import the same native packages that have
+// been used to build the program.
+//
+// If data has been encoded by a different version of Scriggo or Go, or if a
+// native declaration used by the program does not exist anymore or has
+// changed, LoadProgram returns an error that satisfies
+// errors.Is(err, ErrIncompatibleEncoding). In this case the program should
+// be built again.
+func LoadProgram(data []byte, options *BuildOptions) (*Program, error) {
+	var importer native.Importer
+	if options != nil {
+		importer = options.Packages
+	}
+	code, err := compiler.Decode(data, importer, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code.Main.Macro {
+		return nil, errors.New("scriggo: data does not contain a program")
+	}
+	return &Program{fn: code.Main, globals: code.Globals, typeof: code.TypeOf, importer: importer, packages: code.Packages,
+		coverage: code.Coverage}, nil
+}
+
+// MarshalBinary encodes the program in a binary form that can be loaded by
+// the [LoadProgram] function. It implements the encoding.BinaryMarshaler
+// interface.
+//
+// The functions, variables and types of the native packages used by the
+// program are not encoded but are referenced by package path and name.
+func (p *Program) MarshalBinary() ([]byte, error) {
+	code := &compiler.Code{Main: p.fn, Globals: p.globals, Packages: p.packages, Coverage: p.coverage}
+	return compiler.Encode(code, p.importer, nil)
 }
 
 // Disassemble disassembles the package with the given path and returns its
@@ -121,8 +227,11 @@ func (p *Program) Disassemble(pkgPath string) ([]byte, error) {
 //
 // If the context has been canceled, Run returns the error returned by the Err
 // method of the context.
+//
+// If a limit set in the options is exceeded, Run returns a [*LimitError].
 func (p *Program) Run(options *RunOptions) error {
 	vm := runtime.NewVM()
+	var counters []uint32
 	if options != nil {
 		if options.Context != nil {
 			vm.SetContext(options.Context)
@@ -130,11 +239,36 @@ func (p *Program) Run(options *RunOptions) error {
 		if options.Print != nil {
 			vm.SetPrint(runtime.PrintFunc(options.Print))
 		}
+		if options.MaxInstructions > 0 {
+			vm.SetMaxInstructions(options.MaxInstructions)
+		}
+		if options.MaxMemory > 0 {
+			vm.SetMaxMemory(options.MaxMemory)
+		}
+		if options.Debugger != nil {
+			options.Debugger.debug(vm, p.globals)
+		}
+		if options.Profiler != nil {
+			vm.SetProfiler(options.Profiler.p)
+		}
+		if options.Tracer != nil {
+			setTracer(vm, options.Tracer)
+		}
+		if options.Coverage != nil && p.coverage != nil {
+			counters = make([]uint32, len(p.coverage))
+			vm.SetCoverage(counters)
+		}
 	}
 	err := vm.Run(p.fn, p.typeof, initPackageLevelVariables(p.globals))
+	if counters != nil {
+		options.Coverage.add(p.coverage, counters)
+	}
 	if err != nil {
-		if p, ok := err.(*runtime.PanicError); ok {
-			err = &PanicError{p}
+		switch e := err.(type) {
+		case *runtime.PanicError:
+			err = &PanicError{e}
+		case *runtime.LimitError:
+			err = &LimitError{e}
 		}
 		return err
 	}
@@ -0,0 +1,142 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"encoding/json"
+
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// SourceMap records the source positions of the output of a template
+// execution. To record the source map of an execution, pass a SourceMap to
+// the Run method of Template with the SourceMap field of RunOptions.
+//
+// The output written by a Text or a Show statement, as {{ v }}, is mapped
+// to the position of the statement, and the text is mapped line by line to
+// the lines of the source. The output of a macro, rendered or imported file
+// is mapped to its source, while the output of a Markdown conversion has no
+// source. A SourceMap records only the last execution.
+//
+// The zero value of SourceMap is ready to use.
+type SourceMap struct {
+
+	// File is the name of the generated file written in the "file" field of
+	// the JSON encoding. If it is empty, the field is omitted.
+	File string
+
+	// SourceRoot is the root of the source paths written in the "sourceRoot"
+	// field of the JSON encoding. If it is empty, the field is omitted.
+	SourceRoot string
+
+	m *runtime.SourceMap
+}
+
+// SourceMapping maps a position of the output of a template to a position
+// in the source code. The mapping applies to the output from its position,
+// up to the position of the next mapping.
+type SourceMapping struct {
+	Line     int      // line of the output, starting from 1.
+	Column   int      // column of the output, in characters, starting from 1.
+	Path     string   // path of the file; empty if the output has no source.
+	Position Position // position in the file; only Line and Column are set.
+}
+
+// Mappings returns the mappings, sorted by their position in the output.
+func (sm *SourceMap) Mappings() []SourceMapping {
+	if sm.m == nil {
+		return nil
+	}
+	mappings := make([]SourceMapping, 0, len(sm.m.Mappings))
+	for i, m := range sm.m.Mappings {
+		// A mapping with the same source of the previous mapping is omitted,
+		// unless it starts a new line.
+		if i > 0 {
+			prev := sm.m.Mappings[i-1]
+			if m.Line == prev.Line && m.Path == prev.Path && m.Position == prev.Position {
+				continue
+			}
+		}
+		mappings = append(mappings, SourceMapping{
+			Line:     m.Line,
+			Column:   m.Column,
+			Path:     m.Path,
+			Position: Position{Line: m.Position.Line, Column: m.Position.Column},
+		})
+	}
+	return mappings
+}
+
+// MarshalJSON returns the JSON encoding of the source map in the Source Map
+// Revision 3 format. The "sources" field contains the paths of the template
+// files and columns are counted in characters.
+func (sm *SourceMap) MarshalJSON() ([]byte, error) {
+	var sources []string
+	indexes := map[string]int{}
+	var mappings []byte
+	var line, column, source, srcLine, srcColumn int
+	for _, m := range sm.Mappings() {
+		for ; line < m.Line-1; line++ {
+			mappings = append(mappings, ';')
+			column = 0
+		}
+		if len(mappings) > 0 && mappings[len(mappings)-1] != ';' {
+			mappings = append(mappings, ',')
+		}
+		mappings = appendVLQ(mappings, m.Column-1-column)
+		column = m.Column - 1
+		if m.Path == "" {
+			continue
+		}
+		index, ok := indexes[m.Path]
+		if !ok {
+			index = len(sources)
+			sources = append(sources, m.Path)
+			indexes[m.Path] = index
+		}
+		mappings = appendVLQ(mappings, index-source)
+		mappings = appendVLQ(mappings, m.Position.Line-1-srcLine)
+		mappings = appendVLQ(mappings, m.Position.Column-1-srcColumn)
+		source, srcLine, srcColumn = index, m.Position.Line-1, m.Position.Column-1
+	}
+	if sources == nil {
+		sources = []string{}
+	}
+	return json.Marshal(struct {
+		Version    int      `json:"version"`
+		File       string   `json:"file,omitempty"`
+		SourceRoot string   `json:"sourceRoot,omitempty"`
+		Sources    []string `json:"sources"`
+		Names      []string `json:"names"`
+		Mappings   string   `json:"mappings"`
+	}{
+		Version:    3,
+		File:       sm.File,
+		SourceRoot: sm.SourceRoot,
+		Sources:    sources,
+		Names:      []string{},
+		Mappings:   string(mappings),
+	})
+}
+
+// appendVLQ appends n to b, encoded as a Base64 VLQ.
+func appendVLQ(b []byte, n int) []byte {
+	const base64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32
+		}
+		b = append(b, base64[digit])
+		if v == 0 {
+			return b
+		}
+	}
+}
@@ -41,10 +41,18 @@ type Converter func(src []byte, out io.Writer) error
 
 // Template is a template compiled with the BuildTemplate function.
 type Template struct {
-	fn      *runtime.Function
-	typeof  runtime.TypeOfFunc
-	globals []compiler.Global
-	conv    runtime.Converter
+	fn       *runtime.Function
+	typeof   runtime.TypeOfFunc
+	globals  []compiler.Global
+	conv     runtime.Converter
+	importer native.Importer
+	decls    native.Declarations
+	packages []string
+	coverage []compiler.CoverBlock
+
+	// frontMatter is the name of the front matter variable, if the template
+	// has been built with the FrontMatter option.
+	frontMatter string
 }
 
 // FormatFS is the interface implemented by a file system that can determine
@@ -90,13 +98,29 @@ var formatTypes = map[ast.Format]reflect.Type{
 // If the named file does not exist, BuildTemplate returns an error satisfying
 // errors.Is(err, fs.ErrNotExist).
 //
-// If a build error occurs, it returns a [*BuildError].
+// If a build error occurs, it returns a [*BuildError]. The type checking
+// continues after an error, so the Diagnostics method of the returned error
+// reports all the type checking errors.
 func BuildTemplate(fsys fs.FS, name string, options *BuildOptions) (*Template, error) {
+	return buildTemplate(fsys, name, options, nil)
+}
+
+// buildTemplate is like BuildTemplate but, if cache is not nil, it parses
+// the files using cache and it shares, through cache, the type checked
+// imported files with the other templates built with the same options.
+func buildTemplate(fsys fs.FS, name string, options *BuildOptions, cache *compiler.ParseCache) (*Template, error) {
 	if f, ok := fsys.(FormatFS); ok {
 		fsys = formatFS{f}
 	}
 	co := compiler.Options{
 		FormatTypes: formatTypes,
+		ParseCache:  cache,
+	}
+	// The front matter is declared in the universe block of every file, so
+	// the imported files of a template with a front matter are not shared.
+	// A nil options is stored as a non-nil CheckKey.
+	if cache != nil && (options == nil || options.FrontMatter == nil) {
+		co.CheckKey = options
 	}
 	var conv Converter
 	if options != nil {
@@ -106,7 +130,29 @@ func BuildTemplate(fsys fs.FS, name string, options *BuildOptions) (*Template, e
 		co.NoParseShortShowStmt = options.NoParseShortShowStmt
 		co.Importer = options.Packages
 		co.MDConverter = compiler.Converter(options.MarkdownConverter)
+		co.Coverage = options.Coverage
 		conv = options.MarkdownConverter
+		if options.FrontMatter != nil {
+			var n int
+			var err error
+			fsys, co.Globals, n, err = parseFrontMatter(fsys, name, options.FrontMatter, co.Globals)
+			if err != nil {
+				if e, ok := err.(compiler.Error); ok {
+					err = &BuildError{err: e}
+				}
+				return nil, err
+			}
+			if n > 0 {
+				transform := co.TreeTransformer
+				co.TreeTransformer = func(tree *ast.Tree) error {
+					cutFrontMatter(tree, n)
+					if transform != nil {
+						return transform(tree)
+					}
+					return nil
+				}
+			}
+		}
 	}
 	code, err := compiler.BuildTemplate(fsys, name, co)
 	if err != nil {
@@ -115,7 +161,54 @@ func BuildTemplate(fsys fs.FS, name string, options *BuildOptions) (*Template, e
 		}
 		return nil, err
 	}
-	return &Template{fn: code.Main, typeof: code.TypeOf, globals: code.Globals, conv: runtime.Converter(conv)}, nil
+	template := &Template{fn: code.Main, typeof: code.TypeOf, globals: code.Globals, conv: runtime.Converter(conv),
+		importer: co.Importer, decls: co.Globals, packages: code.Packages, coverage: code.Coverage}
+	if options != nil && options.FrontMatter != nil {
+		template.frontMatter = options.FrontMatter.Name
+	}
+	return template, nil
+}
+
+// LoadTemplate loads a template encoded by the MarshalBinary method of
+// [Template]. options.Packages and options.Globals must provide the same
+// native declarations that have been used to build the template, and
+// options.MarkdownConverter is the converter used to run it.
+//
+// If data has been encoded by a different version of Scriggo or Go, or if a
+// native declaration used by the template does not exist anymore or has
+// changed, LoadTemplate returns an error that satisfies
+// errors.Is(err, ErrIncompatibleEncoding). In this case the template should
+// be built again.
+func LoadTemplate(data []byte, options *BuildOptions) (*Template, error) {
+	var importer native.Importer
+	var decls native.Declarations
+	var conv Converter
+	if options != nil {
+		importer = options.Packages
+		decls = options.Globals
+		conv = options.MarkdownConverter
+	}
+	code, err := compiler.Decode(data, importer, decls)
+	if err != nil {
+		return nil, err
+	}
+	if !code.Main.Macro {
+		return nil, errors.New("scriggo: data does not contain a template")
+	}
+	return &Template{fn: code.Main, typeof: code.TypeOf, globals: code.Globals, conv: runtime.Converter(conv),
+		importer: importer, decls: decls, packages: code.Packages, coverage: code.Coverage}, nil
+}
+
+// MarshalBinary encodes the template in a binary form that can be loaded by
+// the [LoadTemplate] function. It implements the encoding.BinaryMarshaler
+// interface.
+//
+// The functions, variables and types of the native packages and of the
+// globals used by the template are not encoded but are referenced by package
+// path and name.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	code := &compiler.Code{Main: t.fn, Globals: t.globals, Packages: t.packages, Coverage: t.coverage}
+	return compiler.Encode(code, t.importer, t.decls)
 }
 
 // Run runs the template and write the rendered code to out. vars contains
@@ -134,6 +227,8 @@ func BuildTemplate(fsys fs.FS, name string, options *BuildOptions) (*Template, e
 // If the context has been canceled, Run returns the error returned by the Err
 // method of the context.
 //
+// If a limit set in the options is exceeded, Run returns a [*LimitError].
+//
 // If a call to out.Write returns an error, a panic occurs. If the executed
 // code does not recover the panic, Run returns the error returned by
 // out.Write.
@@ -142,6 +237,7 @@ func (t *Template) Run(out io.Writer, vars map[string]interface{}, options *RunO
 		return errors.New("invalid nil out")
 	}
 	vm := runtime.NewVM()
+	var counters []uint32
 	if options != nil {
 		if options.Context != nil {
 			vm.SetContext(options.Context)
@@ -149,12 +245,48 @@ func (t *Template) Run(out io.Writer, vars map[string]interface{}, options *RunO
 		if options.Print != nil {
 			vm.SetPrint(runtime.PrintFunc(options.Print))
 		}
+		if options.MaxInstructions > 0 {
+			vm.SetMaxInstructions(options.MaxInstructions)
+		}
+		if options.MaxMemory > 0 {
+			vm.SetMaxMemory(options.MaxMemory)
+		}
+		if options.MaxOutput > 0 {
+			vm.SetMaxOutput(options.MaxOutput)
+		}
+		if options.SourceMap != nil {
+			options.SourceMap.m = &runtime.SourceMap{}
+			vm.SetSourceMap(options.SourceMap.m)
+		}
+		if options.Debugger != nil {
+			options.Debugger.debug(vm, t.globals)
+		}
+		if options.Profiler != nil {
+			vm.SetProfiler(options.Profiler.p)
+		}
+		if options.Tracer != nil {
+			setTracer(vm, options.Tracer)
+		}
+		if options.Coverage != nil && t.coverage != nil {
+			counters = make([]uint32, len(t.coverage))
+			vm.SetCoverage(counters)
+		}
 	}
 	vm.SetRenderer(out, t.conv)
-	err := vm.Run(t.fn, t.typeof, initGlobalVariables(t.globals, vars))
+	globals := initGlobalVariables(t.globals, vars)
+	if t.frontMatter != "" {
+		copyFrontMatter(t.globals, globals, t.frontMatter)
+	}
+	err := vm.Run(t.fn, t.typeof, globals)
+	if counters != nil {
+		options.Coverage.add(t.coverage, counters)
+	}
 	if err != nil {
-		if p, ok := err.(*runtime.PanicError); ok {
-			err = &PanicError{p}
+		switch e := err.(type) {
+		case *runtime.PanicError:
+			err = &PanicError{e}
+		case *runtime.LimitError:
+			err = &LimitError{e}
 		}
 		return err
 	}
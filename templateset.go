@@ -0,0 +1,349 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open2b/scriggo/internal/compiler"
+)
+
+// TemplateSet is a set of templates built on demand from a file system. A
+// built template is cached, together with the files it depends on, that is
+// the template file and the files it extends, imports and renders, until one
+// of these files changes or is invalidated.
+//
+// The files shared by the templates of the set, as the extended layouts and
+// the imported macros, are parsed only once, unless they change. The
+// imported files are also type checked only once by the templates built with
+// the same options, that is when options returns the same *BuildOptions
+// value, or nil, and it has no front matter. The imported files that declare
+// generic functions or types are type checked by each template.
+//
+// Get checks that the files a cached template depends on have not changed
+// since the template was built, comparing their modification times and
+// sizes. A file that is modified without changing its modification time and
+// size is not detected as changed. So, if the file system does not report
+// the modification times, as embed.FS, a file that changes without changing
+// its size is detected as changed only if it is invalidated.
+//
+// The Invalidate method can be called to remove the templates that depend
+// on a changed file as soon as it changes. For example, with the fsnotify
+// package:
+//
+//	for event := range watcher.Events {
+//		if event.Has(fsnotify.Write) {
+//			set.Invalidate(filepath.ToSlash(event.Name))
+//		}
+//	}
+//
+// The methods of a TemplateSet can be called concurrently by multiple
+// goroutines.
+type TemplateSet struct {
+	fsys    fs.FS
+	options func(name string) *BuildOptions
+	parses  *compiler.ParseCache
+
+	mu           sync.Mutex
+	templates    map[string]*Template
+	dependencies map[string]map[string]fileVersion // template → files it depends on
+	dependents   map[string]map[string]struct{}    // file → templates that depend on it
+	builds       map[string]*templateBuild         // builds in progress
+}
+
+// templateBuild is a build of a template in progress.
+type templateBuild struct {
+	done     chan struct{}
+	template *Template
+	err      error
+	files    *recordingFS // files read by the build
+	stale    bool         // a read file has been invalidated during the build
+}
+
+// NewTemplateSet returns a new template set that builds the templates
+// reading the files from fsys. options is called to get the options to build
+// the named template; if options is nil, the templates are built with nil
+// options.
+func NewTemplateSet(fsys fs.FS, options func(name string) *BuildOptions) *TemplateSet {
+	return &TemplateSet{
+		fsys:         fsys,
+		options:      options,
+		parses:       compiler.NewParseCache(),
+		templates:    map[string]*Template{},
+		dependencies: map[string]map[string]fileVersion{},
+		dependents:   map[string]map[string]struct{}{},
+		builds:       map[string]*templateBuild{},
+	}
+}
+
+// Get returns the named template, building it if it is not in the set or if
+// a file it depends on has changed. If the template is already being built
+// by another goroutine, it waits for the build to complete. A template that
+// cannot be built is not cached, so it is built again by the next call.
+//
+// The errors are those returned by the BuildTemplate function.
+func (set *TemplateSet) Get(name string) (*Template, error) {
+	set.mu.Lock()
+	if template, ok := set.templates[name]; ok {
+		versions := set.dependencies[name]
+		set.mu.Unlock()
+		changed := changedFiles(set.fsys, versions)
+		if changed == nil {
+			return template, nil
+		}
+		for _, file := range changed {
+			set.Invalidate(file)
+		}
+		set.mu.Lock()
+		// Another goroutine may have built the template in the meantime.
+		if template, ok := set.templates[name]; ok {
+			set.mu.Unlock()
+			return template, nil
+		}
+	}
+	if b, ok := set.builds[name]; ok {
+		set.mu.Unlock()
+		<-b.done
+		return b.template, b.err
+	}
+	rec := &recordingFS{FS: set.fsys}
+	b := &templateBuild{done: make(chan struct{}), files: rec}
+	set.builds[name] = b
+	set.mu.Unlock()
+
+	var options *BuildOptions
+	if set.options != nil {
+		options = set.options(name)
+	}
+	var fsys fs.FS = rec
+	if ff, ok := set.fsys.(FormatFS); ok {
+		fsys = recordingFormatFS{rec, ff}
+	}
+	b.template, b.err = buildTemplate(fsys, name, options, set.parses)
+
+	set.mu.Lock()
+	delete(set.builds, name)
+	if b.err == nil && !b.stale {
+		set.templates[name] = b.template
+		dependencies := rec.recorded()
+		set.dependencies[name] = dependencies
+		for dependency := range dependencies {
+			if _, ok := set.dependents[dependency]; !ok {
+				set.dependents[dependency] = map[string]struct{}{}
+			}
+			set.dependents[dependency][name] = struct{}{}
+		}
+	}
+	set.mu.Unlock()
+	close(b.done)
+
+	return b.template, b.err
+}
+
+// GetAll returns the named templates, building in parallel, on multiple
+// goroutines, those that are not in the set or that depend on a changed
+// file. The returned map contains the templates that could be built.
+//
+// If some templates cannot be built, GetAll returns the errors, in the
+// same order as names, joined with errors.Join.
+func (set *TemplateSet) GetAll(names []string) (map[string]*Template, error) {
+	templates := make([]*Template, len(names))
+	errs := make([]error, len(names))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for n := min(runtime.GOMAXPROCS(0), len(names)); n > 0; n-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				templates[i], errs[i] = set.Get(names[i])
+			}
+		}()
+	}
+	for i := range names {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	built := make(map[string]*Template, len(names))
+	for i, name := range names {
+		if errs[i] == nil {
+			built[name] = templates[i]
+		}
+	}
+	return built, errors.Join(errs...)
+}
+
+// Lookup returns the named template if it is in the set, otherwise it
+// returns nil. Unlike Get, it never builds the template.
+func (set *TemplateSet) Lookup(name string) *Template {
+	set.mu.Lock()
+	template := set.templates[name]
+	set.mu.Unlock()
+	return template
+}
+
+// Dependencies returns the names, sorted, of the files the named template
+// depends on, including the template file. It returns nil if the template
+// is not in the set.
+//
+// The names include the files that the build tried to read but that did not
+// exist, so the template is invalidated also when one of them is created.
+func (set *TemplateSet) Dependencies(name string) []string {
+	set.mu.Lock()
+	var dependencies []string
+	for dependency := range set.dependencies[name] {
+		dependencies = append(dependencies, dependency)
+	}
+	set.mu.Unlock()
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// Invalidate invalidates the file with the given name, removing from the
+// set the templates that depend on it and its parsed tree. It returns the
+// names, sorted, of the removed templates.
+//
+// The templates being built when Invalidate is called are not added to the
+// set when their build is complete, if their build has already read the file.
+func (set *TemplateSet) Invalidate(name string) []string {
+	set.parses.Remove(name)
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for _, b := range set.builds {
+		if b.files.read(name) {
+			b.stale = true
+		}
+	}
+	var invalidated []string
+	for template := range set.dependents[name] {
+		for dependency := range set.dependencies[template] {
+			delete(set.dependents[dependency], template)
+			if len(set.dependents[dependency]) == 0 {
+				delete(set.dependents, dependency)
+			}
+		}
+		delete(set.dependencies, template)
+		delete(set.templates, template)
+		invalidated = append(invalidated, template)
+	}
+	sort.Strings(invalidated)
+	return invalidated
+}
+
+// fileVersion is the version of a file when it has been read.
+type fileVersion struct {
+	exists  bool
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+// equal reports whether v and w are the same version.
+func (v fileVersion) equal(w fileVersion) bool {
+	return v.exists == w.exists && v.dir == w.dir && v.size == w.size && v.modTime.Equal(w.modTime)
+}
+
+// readVersion returns the current version of the named file as reported by
+// fs.Stat. ok is false if the file could not be stat-ed for a reason other
+// than its non-existence.
+func readVersion(fsys fs.FS, name string) (v fileVersion, ok bool) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return fileVersion{}, errors.Is(err, fs.ErrNotExist)
+	}
+	return fileVersion{exists: true, dir: info.IsDir(), size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// changedFiles returns the names, sorted, of the files whose current version
+// is different from the given version. It returns nil if no file changed. A
+// file that cannot be read is considered changed.
+func changedFiles(fsys fs.FS, versions map[string]fileVersion) []string {
+	var changed []string
+	for name, version := range versions {
+		if v, ok := readVersion(fsys, name); !ok || !v.equal(version) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// recordingFS is a file system that records the names, and the versions, of
+// the files read from the wrapped file system.
+//
+// The version of a file is taken before it is read, so if the file changes
+// while it is read, the recorded version is an old version and the change
+// is detected later.
+type recordingFS struct {
+	fs.FS
+
+	mu       sync.Mutex
+	versions map[string]fileVersion
+}
+
+func (fsys *recordingFS) Open(name string) (fs.File, error) {
+	v, ok := readVersion(fsys.FS, name)
+	fsys.record(name, v, ok)
+	return fsys.FS.Open(name)
+}
+
+func (fsys *recordingFS) ReadFile(name string) ([]byte, error) {
+	v, ok := readVersion(fsys.FS, name)
+	fsys.record(name, v, ok)
+	return fs.ReadFile(fsys.FS, name)
+}
+
+// record records the version of a read file. If ok is false, the version
+// is not known, so the file is recorded as a not existent file; if the file
+// exists, it will be detected as changed.
+func (fsys *recordingFS) record(name string, v fileVersion, ok bool) {
+	if !ok {
+		v = fileVersion{}
+	}
+	fsys.mu.Lock()
+	if _, recorded := fsys.versions[name]; !recorded {
+		if fsys.versions == nil {
+			fsys.versions = map[string]fileVersion{}
+		}
+		fsys.versions[name] = v
+	}
+	fsys.mu.Unlock()
+}
+
+// read reports whether the named file has been read.
+func (fsys *recordingFS) read(name string) bool {
+	fsys.mu.Lock()
+	_, ok := fsys.versions[name]
+	fsys.mu.Unlock()
+	return ok
+}
+
+// recorded returns the names and the versions of the read files.
+func (fsys *recordingFS) recorded() map[string]fileVersion {
+	fsys.mu.Lock()
+	versions := maps.Clone(fsys.versions)
+	fsys.mu.Unlock()
+	if versions == nil {
+		versions = map[string]fileVersion{}
+	}
+	return versions
+}
+
+// recordingFormatFS is a recordingFS that implements FormatFS.
+type recordingFormatFS struct {
+	*recordingFS
+	format FormatFS
+}
+
+func (fsys recordingFormatFS) Format(name string) (Format, error) {
+	return fsys.format.Format(name)
+}
@@ -544,6 +544,7 @@ func runGc(path string) (int, []byte, []byte, error) {
 		}
 		goMod := strings.Join([]string{
 			`module github.com/open2b/scriggo/test/compare/testpkg`,
+			`go 1.23`,
 			`replace github.com/open2b/scriggo => ` + scriggoAbsPath,
 			`require github.com/open2b/scriggo v0.0.0`,
 		}, "\n")
@@ -556,6 +557,7 @@ func runGc(path string) (int, []byte, []byte, error) {
 	{
 		data := strings.Join([]string{
 			`module scriggo-gc-test`,
+			`go 1.23`,
 			`replace github.com/open2b/scriggo/test/compare/testpkg => ./testpkg`,
 			`replace github.com/open2b/scriggo => ` + scriggoAbsPath,
 		}, "\n")
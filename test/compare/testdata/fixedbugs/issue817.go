@@ -1,9 +1,18 @@
-// errorcheck
+// run
 
 package main
 
+import "fmt"
+
 type I interface {
-	M() // ERROR `non-empty interfaces are not supported in this release of Scriggo`
+	M()
 }
 
-func main() {}
+type T struct{}
+
+func (T) M() { fmt.Println("M called") }
+
+func main() {
+	var i I = T{}
+	i.M()
+}
@@ -0,0 +1,7 @@
+// errorcheck
+
+package main
+
+func F[]() {} // ERROR `empty type parameter list`
+
+func main() {}
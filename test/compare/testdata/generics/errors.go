@@ -0,0 +1,33 @@
+// errorcheck
+
+package main
+
+type Number interface {
+	~int | ~float64
+}
+
+type Set[K comparable] map[K]bool
+
+func Sum[T Number](values ...T) T {
+	var s T
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+func Zero[T any]() T {
+	var z T
+	return z
+}
+
+func main() {
+	_ = Sum("a", "b")           // ERROR `string does not satisfy Number (string missing in ~int | ~float64)`
+	_ = Zero()                  // ERROR `in call to Zero, cannot infer T`
+	_ = Sum                     // ERROR `cannot use generic function Sum without instantiation`
+	var _ Set                   // ERROR `cannot use generic type Set without instantiation`
+	var _ Set[[]int]            // ERROR `[]int does not satisfy comparable`
+	var _ Number                // ERROR `cannot use type Number outside a type constraint: interface contains type constraints`
+	_ = Zero[int, string]()     // ERROR `got 2 type arguments but Zero has 1 type parameters`
+	var _ interface{ ~int }     // ERROR `cannot use type interface{~int} outside a type constraint: interface contains type constraints`
+}
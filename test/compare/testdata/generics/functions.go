@@ -0,0 +1,92 @@
+// run
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+func Map[T, U any](s []T, f func(T) U) []U {
+	r := make([]U, len(s))
+	for i, v := range s {
+		r[i] = f(v)
+	}
+	return r
+}
+
+func Filter[T any](s []T, keep func(T) bool) []T {
+	var r []T
+	for _, v := range s {
+		if keep(v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+func Sum[T Number](values ...T) T {
+	var s T
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+func Keys[K comparable, V any](m map[K]V) int {
+	n := 0
+	for range m {
+		n++
+	}
+	return n
+}
+
+func Index[T comparable](s []T, x T) int {
+	for i, v := range s {
+		if v == x {
+			return i
+		}
+	}
+	return -1
+}
+
+func Zero[T any]() T {
+	var z T
+	return z
+}
+
+func Swap[A, B any](a A, b B) (B, A) {
+	return b, a
+}
+
+func Fact[T Number](n T) T {
+	if n <= 1 {
+		return 1
+	}
+	return n * Fact(n-1)
+}
+
+type Celsius float64
+
+func main() {
+	s := []int{1, 2, 3, 4, 5}
+	fmt.Println(Map(s, strconv.Itoa))
+	fmt.Println(Map(s, func(i int) float64 { return float64(i) / 2 }))
+	fmt.Println(Filter(s, func(i int) bool { return i%2 == 1 }))
+	fmt.Println(Sum(s...))
+	fmt.Println(Sum(1.5, 2, 3))
+	fmt.Println(Sum[int64](1, 2, 3))
+	fmt.Println(float64(Sum(Celsius(10), Celsius(20.5))))
+	fmt.Println(Keys(map[string]bool{"a": true, "b": false}))
+	fmt.Println(Index([]string{"a", "b", "c"}, "c"))
+	fmt.Println(Index(s, 10))
+	fmt.Printf("%q %v %v\n", Zero[string](), Zero[int](), Zero[[]int]() == nil)
+	fmt.Println(Swap("a", 1))
+	fmt.Println(Fact(5), Fact(5.0))
+	f := Map[int, string]
+	fmt.Println(f([]int{7}, func(i int) string { return strconv.Itoa(i * 2) }))
+}
@@ -0,0 +1,7 @@
+// errorcheck
+
+package main
+
+type A[T any] = []T // ERROR `generic type aliases are not supported in this release of Scriggo`
+
+func main() {}
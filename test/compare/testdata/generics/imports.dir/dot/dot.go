@@ -0,0 +1,5 @@
+package dot
+
+func First[T any](s []T) T {
+	return s[0]
+}
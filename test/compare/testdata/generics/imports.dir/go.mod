@@ -0,0 +1,3 @@
+module imports.dir
+
+go 1.23
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	. "imports.dir/dot"
+	"imports.dir/slices"
+)
+
+type Name string
+
+func main() {
+	fmt.Println(slices.Max(3, 7, 2))
+	fmt.Println(slices.Max("b", "c", "a"))
+	v := slices.Vector[int]{1, 2, 3}
+	fmt.Println([]int(slices.Reverse(v)))
+	fmt.Println(slices.Join([]Name{"x", "y"}))
+	fmt.Println(First([]string{"a", "b"}))
+	max := slices.Max[float64]
+	fmt.Println(max(1, 2.5))
+}
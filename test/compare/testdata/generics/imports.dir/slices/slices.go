@@ -0,0 +1,35 @@
+package slices
+
+import "strings"
+
+type Ordered interface {
+	~int | ~int64 | ~float64 | ~string
+}
+
+type Vector[T any] []T
+
+func Max[T Ordered](s ...T) T {
+	m := s[0]
+	for _, v := range s[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func Reverse[T any](s Vector[T]) Vector[T] {
+	r := make(Vector[T], len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
+
+func Join[T ~string](s []T) string {
+	parts := make([]string, len(s))
+	for i, v := range s {
+		parts[i] = string(v)
+	}
+	return strings.Join(parts, ",")
+}
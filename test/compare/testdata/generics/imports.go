@@ -0,0 +1 @@
+// rundir
@@ -0,0 +1,7 @@
+// errorcheck
+
+package main
+
+func F[T, U]() {} // ERROR `missing type constraint`
+
+func main() {}
@@ -0,0 +1,62 @@
+// run
+
+package main
+
+import "fmt"
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type Set[K comparable] map[K]struct{}
+
+type List[T any] []T
+
+type Stack[T any] struct {
+	items List[T]
+}
+
+func NewSet[K comparable](keys ...K) Set[K] {
+	s := Set[K]{}
+	for _, k := range keys {
+		s[k] = struct{}{}
+	}
+	return s
+}
+
+func Has[K comparable](s Set[K], k K) bool {
+	_, ok := s[k]
+	return ok
+}
+
+func Push[T any](s *Stack[T], v T) {
+	s.items = append(s.items, v)
+}
+
+func Pop[T any](s *Stack[T]) T {
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+func Second[K comparable, V any](p Pair[K, V]) V {
+	return p.Value
+}
+
+func main() {
+	p := Pair[string, int]{Key: "a", Value: 1}
+	fmt.Println(p.Key, p.Value)
+	fmt.Println(Second(p))
+	s := NewSet("a", "b")
+	fmt.Println(len(s), Has(s, "a"), Has(s, "c"))
+	var st Stack[string]
+	Push(&st, "x")
+	Push(&st, "y")
+	fmt.Println(Pop(&st), Pop(&st), len(st.items))
+	var l List[float64] = List[float64]{1.5}
+	fmt.Println([]float64(l), len(l))
+	var q Pair[string, int]
+	q = p
+	fmt.Println(q == p)
+}
@@ -1,5 +1,3 @@
-// skip : labels on breakable statements are not full implemented https://github.com/open2b/scriggo/issues/83
-
 // run
 
 // Copyright 2009 The Go Authors. All rights reserved.
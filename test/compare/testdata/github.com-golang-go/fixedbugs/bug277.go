@@ -1,5 +1,3 @@
-// skip : requires interface types https://github.com/open2b/scriggo/issues/218
-
 // compile
 
 // Copyright 2010 The Go Authors. All rights reserved.
@@ -1,5 +1,3 @@
-// skip : statement 'continue' with a label (https://github.com/open2b/scriggo/issues/83)
-
 // run
 
 // Copyright 2017 The Go Authors. All rights reserved.
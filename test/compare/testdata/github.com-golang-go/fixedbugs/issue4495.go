@@ -1,5 +1,3 @@
-// skip : interface definition
-
 // run
 
 // Copyright 2012 The Go Authors. All rights reserved.
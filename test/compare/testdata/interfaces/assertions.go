@@ -0,0 +1,94 @@
+// run
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type Shape interface {
+	Area() float64
+}
+
+type Named interface {
+	Name() string
+}
+
+type NamedShape interface {
+	Shape
+	Named
+}
+
+type Rect struct{ W, H float64 }
+
+func (r Rect) Area() float64 { return r.W * r.H }
+func (r Rect) Name() string   { return "rect" }
+
+type Circle struct{ R float64 }
+
+func (c *Circle) Area() float64 { return 3 * c.R * c.R }
+
+type Label string
+
+func (l Label) Name() string { return string(l) }
+
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case NamedShape:
+		return fmt.Sprintf("named shape %s with area %g", v.Name(), v.Area())
+	case Shape:
+		return fmt.Sprintf("shape with area %g", v.Area())
+	case Named:
+		return "named " + v.Name()
+	case fmt.Stringer:
+		return "stringer " + v.String()
+	default:
+		return "other"
+	}
+}
+
+func main() {
+
+	values := []interface{}{nil, Rect{2, 3}, &Circle{1}, Circle{1}, Label("label"), time.Second, 5}
+	for _, v := range values {
+		fmt.Println(describe(v))
+	}
+
+	// Type assertions to interface types.
+	var s Shape = Rect{1, 2}
+	if n, ok := s.(Named); ok {
+		fmt.Println("is named:", n.Name())
+	}
+	if _, ok := s.(NamedShape); ok {
+		fmt.Println("is a named shape")
+	}
+	var i interface{} = Label("x")
+	_, ok := i.(Shape)
+	fmt.Println(ok)
+
+	// Type assertions to concrete types.
+	if r, ok := s.(Rect); ok {
+		fmt.Println(r.W, r.H)
+	}
+	_, ok = s.(*Circle)
+	fmt.Println(ok)
+	s = &Circle{2}
+	c := s.(*Circle)
+	fmt.Println(c.R)
+
+	// Type assertions with a native dynamic type.
+	var n interface{ String() string } = time.Minute
+	if d, ok := n.(time.Duration); ok {
+		fmt.Println(d.Seconds())
+	}
+
+	// Failed type assertion.
+	defer func() {
+		fmt.Println(recover() != nil)
+	}()
+	var v interface{} = 5
+	_ = v.(Shape)
+}
@@ -0,0 +1,186 @@
+// run
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+type Named interface {
+	Name() string
+}
+
+// NamedShape embeds two interfaces declared in Scriggo.
+type NamedShape interface {
+	Shape
+	Named
+}
+
+type Rect struct{ W, H float64 }
+
+func (r Rect) Area() float64      { return r.W * r.H }
+func (r Rect) Perimeter() float64 { return 2 * (r.W + r.H) }
+func (r Rect) Name() string       { return "rect" }
+
+type Square struct{ Side float64 }
+
+func (s *Square) Area() float64      { return s.Side * s.Side }
+func (s *Square) Perimeter() float64 { return 4 * s.Side }
+func (s *Square) String() string     { return fmt.Sprintf("square(%g)", s.Side) }
+
+type Point struct{ X, Y int }
+
+// Mover has a method with an unexported name and methods with parameters
+// and results of types declared in Scriggo.
+type Mover interface {
+	Move(d Point) Point
+	position() Point
+}
+
+type Walker struct{ p Point }
+
+func (w *Walker) Move(d Point) Point {
+	w.p = Point{w.p.X + d.X, w.p.Y + d.Y}
+	return w.p
+}
+
+func (w *Walker) position() Point { return w.p }
+
+type Summer interface {
+	Sum(values ...int) int
+}
+
+type Base int
+
+func (b Base) Sum(values ...int) int {
+	s := int(b)
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+// Failer embeds the native interface error.
+type Failer interface {
+	error
+	Code() int
+}
+
+type failure struct{ code int }
+
+func (f failure) Error() string { return fmt.Sprintf("failure %d", f.code) }
+func (f failure) Code() int     { return f.code }
+
+type Box struct {
+	Shape Shape
+	Items map[string]Summer
+}
+
+// Measurable is used as a type constraint.
+type Measurable interface {
+	~int | ~float64
+	Unit() string
+}
+
+type Meters float64
+
+func (Meters) Unit() string { return "m" }
+
+func join[T Measurable](values []T) string {
+	s := ""
+	for _, v := range values {
+		s += fmt.Sprint(float64(v)) + v.Unit() + " "
+	}
+	return s
+}
+
+func largest[S Shape](shapes []S) S {
+	var max S
+	for i, s := range shapes {
+		if i == 0 || s.Area() > max.Area() {
+			max = s
+		}
+	}
+	return max
+}
+
+func totalArea(shapes ...Shape) float64 {
+	t := 0.0
+	for _, s := range shapes {
+		t += s.Area()
+	}
+	return t
+}
+
+func main() {
+
+	// Calls through an interface.
+	shapes := []Shape{Rect{2, 3}, &Square{2}}
+	fmt.Println(totalArea(shapes...))
+	for _, s := range shapes {
+		fmt.Println(s.Area(), s.Perimeter())
+	}
+
+	// Embedded interfaces.
+	var ns NamedShape = Rect{1, 2}
+	fmt.Println(ns.Name(), ns.Area())
+	var s Shape = ns
+	fmt.Println(s.Perimeter(), s == ns)
+
+	// Interface type literals.
+	var a interface{ Area() float64 } = s
+	fmt.Println(a.Area())
+	var st interface{ String() string } = &Square{3}
+	fmt.Println(st.String())
+	st = time.Second
+	fmt.Println(st.String())
+
+	// Method values and method expressions.
+	area := s.Area
+	fmt.Println(area())
+	perimeter := Shape.Perimeter
+	fmt.Println(perimeter(Rect{1, 1}))
+
+	// Unexported methods and types declared in Scriggo.
+	var m Mover = &Walker{}
+	m.Move(Point{1, 2})
+	p := m.Move(Point{3, 4})
+	fmt.Println(p.X, p.Y, m.position().X)
+
+	// Variadic methods.
+	var sum Summer = Base(10)
+	fmt.Println(sum.Sum(), sum.Sum(1, 2, 3), sum.Sum([]int{4, 5}...))
+
+	// Interfaces in composite types.
+	box := Box{Shape: Rect{3, 3}, Items: map[string]Summer{"a": Base(1)}}
+	fmt.Println(box.Shape.Area(), box.Items["a"].Sum(2))
+
+	// Comparison.
+	var x, y Summer = Base(1), Base(1)
+	fmt.Println(x == y, x != Base(2))
+	var nilShape Shape
+	fmt.Println(nilShape == nil)
+
+	// Type constraints with methods.
+	fmt.Println(join([]Meters{1, 2.5}))
+	fmt.Println(largest([]Rect{{1, 1}, {3, 2}, {2, 2}}).W)
+
+	// Interfaces and native code.
+	var err error = failure{404}
+	fmt.Println(err)
+	var f Failer = failure{500}
+	fmt.Println(f.Error(), f.Code())
+	err = f
+	fmt.Println(err.Error())
+	var e interface{ Error() string } = errors.New("native error")
+	fmt.Println(e.Error())
+	var str fmt.Stringer = &Square{4}
+	fmt.Println(str)
+}
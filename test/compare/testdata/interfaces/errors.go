@@ -0,0 +1,43 @@
+// errorcheck
+
+package main
+
+type Shape interface {
+	Area() float64
+}
+
+type T struct{}
+
+type P struct{}
+
+func (p *P) Area() float64 { return 0 }
+
+type W struct{}
+
+func (w W) Area() int { return 0 }
+
+type D interface {
+	M()
+	M()                          // ERROR `duplicate method M`
+}
+
+type E interface {
+	Shape
+	Area() int                   // ERROR `duplicate method Area`
+}
+
+type B interface {
+	_()                          // ERROR `methods must have a unique non-blank name`
+}
+
+func main() {
+	var _ Shape = T{}            // ERROR `cannot use T{} (type T) as type Shape in assignment`
+	var _ Shape = P{}            // ERROR `cannot use P{} (type P) as type Shape in assignment`
+	var _ Shape = W{}            // ERROR `cannot use W{} (type W) as type Shape in assignment`
+	var _ Shape = &P{}
+	var s Shape
+	_ = s
+	s.Volume()                   // ERROR `s.Volume undefined (type Shape has no field or method Volume)`
+	_ = s.(T)                    // ERROR `impossible type assertion:`
+	_ = s.(W)                    // ERROR `impossible type assertion:`
+}
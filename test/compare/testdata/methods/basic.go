@@ -0,0 +1,101 @@
+// run
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Celsius float64
+
+func (c Celsius) String() string { return fmt.Sprintf("%.1f°C", float64(c)) }
+
+func (c Celsius) Fahrenheit() float64 { return float64(c)*9/5 + 32 }
+
+type Counter struct {
+	n    int
+	name string
+}
+
+func (c *Counter) Inc() { c.n++ }
+
+func (c *Counter) Add(values ...int) int {
+	for _, v := range values {
+		c.n += v
+	}
+	return c.n
+}
+
+func (c Counter) Get() int { return c.n }
+
+type MyErr struct{ msg string }
+
+func (e *MyErr) Error() string { return "myerr: " + e.msg }
+
+type List []string
+
+func (l List) Join(sep string) string { return strings.Join([]string(l), sep) }
+
+func find(ok bool) error {
+	if ok {
+		return nil
+	}
+	return &MyErr{"not found"}
+}
+
+func main() {
+	c := Celsius(21.5)
+	fmt.Println(c.String(), c.Fahrenheit())
+	fmt.Println(c)
+	var s fmt.Stringer = c
+	fmt.Println(s.String())
+	var v interface{} = c
+	switch v := v.(type) {
+	case fmt.Stringer:
+		fmt.Println("stringer:", v.String())
+	}
+	if c2, ok := v.(Celsius); ok {
+		fmt.Println(c2.Fahrenheit())
+	}
+	if _, ok := v.(error); !ok {
+		fmt.Println("not an error")
+	}
+
+	var cnt Counter
+	cnt.Inc()
+	cnt.Inc()
+	fmt.Println(cnt.Get(), cnt.Add(1, 2, 3), cnt.Add([]int{10}...))
+	p := &cnt
+	p.Inc()
+	fmt.Println(p.Get())
+
+	err := find(false)
+	fmt.Println(err)
+	fmt.Println(errors.Unwrap(fmt.Errorf("wrapped: %w", err)) == err)
+	if e, ok := err.(*MyErr); ok {
+		fmt.Println("assert:", e.Error())
+	}
+
+	l := List{"a", "b", "c"}
+	fmt.Println(l.Join("-"))
+
+	// Method values and expressions.
+	inc := cnt.Inc
+	inc()
+	inc()
+	fmt.Println(cnt.Get())
+	get := Counter.Get
+	fmt.Println(get(cnt))
+	f := (*Counter).Get
+	fmt.Println(f(&cnt))
+	g := (*Counter).Add
+	fmt.Println(g(&cnt, 1))
+	fmt.Println(Celsius.Fahrenheit(100))
+	j := l.Join
+	l[0] = "z"
+	fmt.Println(j("+"))
+	defer cnt.Inc()
+	go c.String()
+}
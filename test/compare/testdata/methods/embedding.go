@@ -0,0 +1,67 @@
+// run
+
+package main
+
+import "fmt"
+
+type Animal struct {
+	name string
+}
+
+func (a Animal) Name() string { return a.name }
+
+func (a *Animal) Rename(name string) { a.name = name }
+
+func (a Animal) String() string { return "animal " + a.name }
+
+type Dog struct {
+	Animal
+	breed string
+}
+
+func (d Dog) Bark() string { return d.Name() + " barks" }
+
+type Puppy struct {
+	*Dog
+	age int
+}
+
+type Shadow struct {
+	Animal
+	Name string
+}
+
+func main() {
+	d := Dog{Animal{"rex"}, "beagle"}
+	fmt.Println(d.Name(), d.Bark())
+	d.Rename("max")
+	fmt.Println(d.Name())
+	fmt.Println(d.Animal.Name())
+
+	p := Puppy{&d, 1}
+	p.Rename("bob")
+	fmt.Println(p.Name(), d.Name(), p.Bark())
+
+	var s fmt.Stringer = d
+	fmt.Println(s.String())
+	s = p
+	fmt.Println(s.String())
+	fmt.Println(d)
+
+	name := d.Name
+	d.Rename("zed")
+	fmt.Println(name(), d.Name())
+	rename := p.Rename
+	rename("ted")
+	fmt.Println(d.Name())
+	fmt.Println(Dog.Name(d), (*Dog).Name(&d))
+	rn := (*Dog).Rename
+	rn(&d, "ned")
+	fmt.Println(d.Name())
+	prn := Puppy.Rename
+	prn(p, "fred")
+	fmt.Println(d.Name())
+
+	sh := Shadow{Animal{"a"}, "b"}
+	fmt.Println(sh.Name, sh.Animal.Name())
+}
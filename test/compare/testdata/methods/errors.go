@@ -0,0 +1,41 @@
+// errorcheck
+
+package main
+
+import "fmt"
+
+type T struct{ F int }
+
+func (t T) M() int { return t.F }
+
+func (t *T) P() {}
+
+type P *T
+
+type I interface{}
+
+var _ fmt.Stringer
+
+type S[E any] []E
+
+func (T) M() {}                  // ERROR `method T.M already declared`
+func (T) F() {}                  // ERROR `field and method with the same name F`
+func (int) M() {}                // ERROR `cannot define new methods on non-local type int`
+func (fmt.Stringer) M() {}       // ERROR `invalid receiver type fmt.Stringer`
+func (p P) M() {}                // ERROR `invalid receiver type P (pointer or interface type)`
+func (I) M() {}                  // ERROR `invalid receiver type I (pointer or interface type)`
+func (U) M() {}                  // ERROR `undefined: U`
+func (S) M() {}                  // ERROR `cannot use generic type S without instantiation`
+func (s S[E, F]) M() {}          // ERROR `got 2 type parameters, but receiver base type declares 1`
+func (T) G[E any]() {}           // ERROR `method must have no type parameters`
+func () M() {}                   // ERROR `method has no receiver`
+func (a, b T) M() {}             // ERROR `method has multiple receivers`
+
+func main() {
+	T{}.P()                      // ERROR `cannot call pointer method P on T`
+	_ = T.P                      // ERROR `invalid method expression T.P (needs pointer receiver: (*T).P)`
+	var _ fmt.Stringer = T{}     // ERROR `cannot use T{} (type T) as type fmt.Stringer in assignment`
+	var t T
+	_ = t
+	t.N()                        // ERROR `t.N undefined (type T has no field or method N)`
+}
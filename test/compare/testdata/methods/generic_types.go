@@ -0,0 +1,67 @@
+// run
+
+package main
+
+import "fmt"
+
+type Stack[T any] struct {
+	items []T
+}
+
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+func (s *Stack[E]) Pop() (E, bool) {
+	var zero E
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+func (s Stack[_]) Len() int { return len(s.items) }
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func (p Pair[K, V]) String() string {
+	return fmt.Sprintf("%v=%v", p.Key, p.Value)
+}
+
+type Ints = Stack[int]
+
+func Map[T, U any](s *Stack[T], f func(T) U) *Stack[U] {
+	r := &Stack[U]{}
+	for _, v := range s.items {
+		r.Push(f(v))
+	}
+	return r
+}
+
+func main() {
+	var s Ints
+	s.Push(1)
+	s.Push(2)
+	s.Push(3)
+	fmt.Println(s.Len())
+	v, ok := s.Pop()
+	fmt.Println(v, ok, s.Len())
+
+	ss := Map(&s, func(i int) string { return fmt.Sprint(i * 10) })
+	fmt.Println(ss.Len(), ss.items)
+
+	p := Pair[string, int]{"a", 1}
+	fmt.Println(p.String())
+	fmt.Println(p)
+	var st fmt.Stringer = Pair[int, bool]{2, true}
+	fmt.Println(st)
+
+	push := s.Push
+	push(42)
+	fmt.Println(s.items)
+}
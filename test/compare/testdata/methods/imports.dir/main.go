@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"imports.dir/shapes"
+)
+
+func main() {
+	r := shapes.Rect{W: 2, H: 3}
+	fmt.Println(r.Area())
+	r.Scale(2)
+	fmt.Println(r.Area(), r)
+	area := shapes.Rect.Area
+	fmt.Println(area(shapes.Rect{W: 1, H: 5}))
+	var s fmt.Stringer = &r
+	fmt.Println(s.String())
+
+	b := shapes.NewBox("hello")
+	b.Set(b.Get() + ", world")
+	fmt.Println(b.Get())
+	var ib shapes.Box[int]
+	ib.Set(5)
+	fmt.Println(ib.Get())
+}
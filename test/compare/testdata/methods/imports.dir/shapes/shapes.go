@@ -0,0 +1,26 @@
+package shapes
+
+import "fmt"
+
+type Rect struct {
+	W, H float64
+}
+
+func (r Rect) Area() float64 { return r.W * r.H }
+
+func (r *Rect) Scale(f float64) {
+	r.W *= f
+	r.H *= f
+}
+
+func (r Rect) String() string { return fmt.Sprintf("%gx%g", r.W, r.H) }
+
+type Box[T any] struct {
+	v T
+}
+
+func NewBox[T any](v T) *Box[T] { return &Box[T]{v} }
+
+func (b *Box[T]) Get() T { return b.v }
+
+func (b *Box[T]) Set(v T) { b.v = v }
@@ -0,0 +1,196 @@
+// run
+
+package main
+
+import "fmt"
+
+func seq(n int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func find(m [][]int, v int) (int, int) {
+	for i, row := range m {
+		for j, x := range row {
+			if x == v {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+func main() {
+	// continue in a for inside a range.
+	for _, s := range []string{"a", "b"} {
+		for i := 0; i < 3; i++ {
+			if i == 1 {
+				continue
+			}
+			fmt.Println(s, i)
+		}
+	}
+	// for without condition and continue.
+	n := 0
+	for i := 0; ; i++ {
+		if i < 3 {
+			continue
+		}
+		n = i
+		break
+	}
+	fmt.Println("n", n)
+outer1:
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if j == 2 {
+				continue outer1
+			}
+			if i == 2 {
+				break outer1
+			}
+			fmt.Println("f/f", i, j)
+		}
+	}
+outer2:
+	for i := range []int{0, 1, 2} {
+		for j := range []int{0, 1, 2} {
+			if j == 2 {
+				continue outer2
+			}
+			if i == 2 {
+				break outer2
+			}
+			fmt.Println("r/r", i, j)
+		}
+	}
+outer3:
+	for i := 0; i < 4; i++ {
+		for _, j := range []int{0, 1, 2} {
+			for k := range []int{0, 1} {
+				if j == 1 && k == 1 {
+					continue outer3
+				}
+				if i == 2 {
+					break outer3
+				}
+				fmt.Println("f/r/r", i, j, k)
+			}
+		}
+	}
+	fmt.Println("after outer3")
+outer4:
+	for i := range seq(4) {
+		for j := 0; j < 3; j++ {
+			switch {
+			case j == 1:
+				continue outer4
+			case i == 2:
+				break outer4
+			}
+			fmt.Println("seq/f", i, j)
+		}
+	}
+outer5:
+	for i := 0; i < 3; i++ {
+		for j := range seq(3) {
+			if j == 1 {
+				continue outer5
+			}
+			if i == 1 {
+				break outer5
+			}
+			fmt.Println("f/seq", i, j)
+		}
+	}
+sw:
+	switch x := 2; x {
+	case 2:
+		for _, v := range []int{1, 2, 3} {
+			if v == 2 {
+				break sw
+			}
+			fmt.Println("sw", v)
+		}
+		fmt.Println("not printed")
+	}
+	switch {
+	case true:
+		for _, v := range []int{1, 2, 3} {
+			if v == 2 {
+				break
+			}
+			fmt.Println("sw2", v)
+		}
+		fmt.Println("printed")
+	}
+	ch := make(chan int, 1)
+	ch <- 1
+sel:
+	select {
+	case v := <-ch:
+		for range []int{0, 1, 2} {
+			fmt.Println("sel", v)
+			break sel
+		}
+		fmt.Println("not printed")
+	}
+	ch <- 2
+	select {
+	case v := <-ch:
+		if v == 2 {
+			break
+		}
+		fmt.Println("not printed")
+	}
+	fmt.Println("after select")
+ts:
+	switch interface{}(1).(type) {
+	case int:
+		for i := 0; i < 3; i++ {
+			break ts
+		}
+		fmt.Println("not printed")
+	}
+	fmt.Println(find([][]int{{1, 2}, {3, 4}}, 4))
+	// labelled loops executed several times
+	for t := 0; t < 2; t++ {
+	loop:
+		for i := 0; i < 3; i++ {
+			for _, v := range []int{1, 2} {
+				if v == 2 {
+					continue loop
+				}
+				fmt.Println("rep", t, i, v)
+			}
+		}
+	}
+	// break to switch and for inside one range
+	total := 0
+L1:
+	for i := 0; i < 3; i++ {
+	L2:
+		switch i {
+		case 0, 1, 2:
+			for _, v := range []int{1, 2, 3} {
+				if v == 2 && i == 0 {
+					break L2
+				}
+				if v == 3 && i == 1 {
+					continue L1
+				}
+				if i == 2 {
+					break L1
+				}
+				total += v
+			}
+		}
+		total += 100
+	}
+	fmt.Println("total", total)
+}
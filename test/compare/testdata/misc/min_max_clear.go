@@ -0,0 +1,93 @@
+// run
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type Celsius float32
+type Name string
+
+var calls int
+
+func f(n int) int {
+	calls++
+	return n
+}
+
+const c1 = min(1, 2.5, 'a')
+const c2 = max(1, 2.5)
+const c3 = max("b", "abc", "a")
+const c4 int8 = min(3, -2)
+const c5 = max(1, 'a')
+
+func main() {
+	fmt.Println(c1, c2, c3, c4, c5)
+	fmt.Printf("%T %T %T %T %T\n", c1, c2, c3, c4, c5)
+	a, b, c := 3, -7, 12
+	fmt.Println(min(a), max(a), min(a, b), max(a, b, c), min(a, b, c, -100), max(c, 100))
+	var u8 uint8 = 200
+	var u8b uint8 = 10
+	fmt.Println(min(u8, u8b), max(u8, u8b, 255))
+	var u64 uint64 = math.MaxUint64
+	fmt.Println(min(u64, 1), max(u64, 1))
+	var i8 int8 = -128
+	fmt.Println(min(i8, 127), max(i8, -1))
+	x, y := 1.5, -2.25
+	fmt.Println(min(x, y), max(x, y), min(x, 0), max(y, 0))
+	nan := math.NaN()
+	fmt.Println(min(x, nan), max(nan, x), min(nan, x, y), max(x, y, nan))
+	negZero := math.Copysign(0, -1)
+	fmt.Println(math.Signbit(min(0, negZero)), math.Signbit(max(negZero, 0)), math.Signbit(min(negZero, 0.0)))
+	inf := math.Inf(1)
+	fmt.Println(min(inf, x), max(-inf, y), max(inf, x))
+	var f32 float32 = 1.25
+	fmt.Println(min(f32, 2), max(f32, 2.5))
+	s1, s2 := "banana", "apple"
+	fmt.Println(min(s1, s2), max(s1, s2), min(s1, s2, ""), max(s1, "cherry"))
+	t1, t2 := Celsius(20.5), Celsius(-3)
+	fmt.Println(float32(min(t1, t2)), float32(max(t1, t2, 100)))
+	n := Name("bob")
+	fmt.Println(string(max(n, "alice")), string(min(n, "alice")))
+	_ = min(f(1), f(2))
+	fmt.Println(calls)
+	fmt.Println(min(f(5), f(3)))
+	fmt.Println(calls)
+	var r rune = 'x'
+	fmt.Println(min(r, 'a'), max('a', r))
+	var iface interface{} = max(a, b)
+	fmt.Println(iface)
+	m := map[string]int{"a": 1, "b": 2}
+	clear(m)
+	fmt.Println(len(m), m)
+	s := []int{1, 2, 3}
+	clear(s)
+	fmt.Println(len(s), s)
+	var nm map[int]int
+	clear(nm)
+	var ns []string
+	clear(ns)
+	ss := []string{"a", "b"}
+	clear(ss[1:])
+	fmt.Printf("%q\n", ss)
+	type T struct{ A int }
+	ts := []*T{{1}, {2}}
+	clear(ts)
+	fmt.Println(ts[0] == nil, ts[1] == nil)
+	defer clear(m)
+	m["c"] = 3
+	fmt.Println(m)
+	func() {
+		mm := map[int]bool{1: true}
+		defer fmt.Println(len(mm))
+		defer clear(mm)
+	}()
+	ff := 2.0
+	ff = max(ff, 1, 3)
+	fmt.Println(ff)
+	arr := [3]int{4, 5, 6}
+	clear(arr[:])
+	fmt.Println(arr)
+}
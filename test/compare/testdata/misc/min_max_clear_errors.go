@@ -0,0 +1,24 @@
+// errorcheck
+
+package main
+
+var i int
+var f float64
+var m map[int]int
+var arr [2]int
+
+func main() {
+	_ = min()         // ERROR `not enough arguments for min() (expected 1, found 0)`
+	_ = max([]int{1}) // ERROR `invalid argument: []int{...} (type []int) cannot be ordered`
+	_ = min(1, "a")   // ERROR `invalid argument: mismatched types untyped int (previous argument) and untyped string (type of "a")`
+	_ = max(i, f)     // ERROR `invalid argument: mismatched types int (previous argument) and float64 (type of f)`
+	_ = min(i, 1.5)   // ERROR `constant 1.5 truncated to integer`
+	_ = max(nil)      // ERROR `use of untyped nil`
+	min(i, 2)         // ERROR `min(i, 2) evaluated but not used`
+	defer max(i, 2)   // ERROR `defer discards result of max(i, 2)`
+	clear()           // ERROR `not enough arguments for clear() (expected 1, found 0)`
+	clear(m, m)       // ERROR `too many arguments for clear(m, m) (expected 1, found 2)`
+	clear(i)          // ERROR `invalid argument: cannot clear i (type int): argument must be map or slice`
+	clear(arr)        // ERROR `invalid argument: cannot clear arr (type [2]int): argument must be map or slice`
+	clear(nil)        // ERROR `use of untyped nil`
+}
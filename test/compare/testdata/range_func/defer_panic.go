@@ -0,0 +1,77 @@
+// run
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/open2b/scriggo/test/compare/testpkg"
+)
+
+// Count returns an iterator over the integers from 0 to n-1.
+func Count(n int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		defer fmt.Println("count done")
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func nativeIterator() {
+	defer func() { fmt.Println("recovered:", recover()) }()
+	for range testpkg.Sequence(2) {
+		defer fmt.Println("d")
+		var m map[int]int
+		m[0] = 1
+	}
+}
+
+func scriggoIterator() {
+	defer func() { fmt.Println("recovered:", recover()) }()
+	for range Count(2) {
+		defer fmt.Println("d")
+		var m map[int]int
+		m[0] = 1
+	}
+}
+
+func notRecovered(i int) {
+	defer fmt.Println("not recovered", i)
+	panic(i)
+}
+
+func recovered(i int) {
+	defer func() { fmt.Println("recovered in call:", recover()) }()
+	defer fmt.Println("recovering", i)
+	notRecovered(i)
+}
+
+func recoveredInCall() {
+	defer func() { fmt.Println("recovered:", recover()) }()
+	for i := range Count(2) {
+		defer fmt.Println("d", i)
+		recovered(i)
+		fmt.Println("after call", i)
+	}
+	for i := range testpkg.Sequence(2) {
+		defer fmt.Println("d", i)
+		notRecovered(i)
+	}
+}
+
+func noIterator() {
+	defer func() { fmt.Println("recovered:", recover()) }()
+	defer fmt.Println("d")
+	var m map[int]int
+	m[0] = 1
+}
+
+func main() {
+	nativeIterator()
+	scriggoIterator()
+	recoveredInCall()
+	noIterator()
+}
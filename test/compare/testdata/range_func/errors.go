@@ -0,0 +1,33 @@
+// errorcheck
+
+package main
+
+type Bool bool
+
+var f0 func()
+var f1 func(func(int) bool) int
+var f2 func(int)
+var f3 func(func(int, int, int) bool)
+var f4 func(func(int))
+var f5 func(func(int) Bool)
+var f6 func(func(...int) bool)
+
+var g0 func(func() bool)
+var g1 func(func(int) bool)
+var g2 func(func(int, string) bool)
+
+func main() {
+
+	for range f0 { }                                   // ERROR `cannot range over f0 (type func()): func must be func(yield func(...) bool): wrong argument count`
+	for range f1 { }                                   // ERROR `cannot range over f1 (type func(func(int) bool) int): func must be func(yield func(...) bool): unexpected results`
+	for range f2 { }                                   // ERROR `cannot range over f2 (type func(int)): func must be func(yield func(...) bool): argument is not func`
+	for range f3 { }                                   // ERROR `cannot range over f3 (type func(func(int, int, int) bool)): func must be func(yield func(...) bool): yield func has too many parameters`
+	for range f4 { }                                   // ERROR `cannot range over f4 (type func(func(int))): func must be func(yield func(...) bool): yield func does not return bool`
+	for range f5 { }                                   // ERROR `cannot range over f5 (type func(func(int) Bool)): func must be func(yield func(...) bool): yield func returns user-defined boolean, not bool`
+	for range f6 { }                                   // ERROR `cannot range over f6 (type func(func(...int) bool)): yield func of type func(...int) bool cannot be variadic`
+
+	for i := range g0 { _ = i }                        // ERROR `range over g0 permits no iteration variables`
+	for i, j := range g1 { _, _ = i, j }               // ERROR `range over g1 permits only one iteration variable`
+	for i, s := range g2 { var _ string = i; _ = s }   // ERROR `cannot use i (type int) as type string in assignment`
+
+}
@@ -0,0 +1,197 @@
+// run
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/open2b/scriggo/test/compare/testpkg"
+)
+
+type Point struct{ X, Y int }
+
+// Seq is a function iterator with a defined type.
+type Seq func(yield func(string) bool)
+
+// Count returns an iterator over the integers from 0 to n-1.
+func Count(n int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		defer fmt.Println("count done")
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// Points is an iterator over a name and a point.
+func Points(yield func(string, Point) bool) {
+	_ = yield("a", Point{1, 2}) && yield("b", Point{3, 4})
+}
+
+// Twice is an iterator that does not produce values.
+func Twice(yield func() bool) {
+	_ = yield() && yield()
+}
+
+// Panicking is an iterator that panics after the first value.
+func Panicking(yield func(int) bool) {
+	yield(1)
+	panic("iterator panic")
+}
+
+// Misbehaving is an iterator that continues after yield returns false.
+func Misbehaving(yield func(int) bool) {
+	yield(1)
+	yield(2)
+}
+
+func find(n int) (int, string) {
+	for i := range Count(10) {
+		if i == n {
+			return i * 10, "found"
+		}
+	}
+	return -1, "not found"
+}
+
+func named() (r int, s string) {
+	for i := range Count(5) {
+		r = i
+		if i == 2 {
+			s = "two"
+			return
+		}
+	}
+	return
+}
+
+func nested() int {
+	for x := range Count(3) {
+		for _, y := range []int{1, 2} {
+			for z := range testpkg.Sequence(2) {
+				if x == 1 && y == 2 && z == 1 {
+					return x + y + z
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func deferInBody() {
+	for i := range Count(2) {
+		defer fmt.Println("deferred", i)
+	}
+	fmt.Println("end of deferInBody")
+}
+
+func main() {
+
+	// Iteration variables.
+	a := "a"
+	for i := range Count(3) {
+		b := "b"
+		fmt.Println(a, i, b)
+	}
+	for k, p := range Points {
+		fmt.Println(k, p.X, p.Y)
+	}
+	for range Twice {
+		fmt.Println("twice")
+	}
+	var i int
+	for i = range Count(2) {
+	}
+	fmt.Println("i", i)
+	var s Seq = func(yield func(string) bool) { yield("seq") }
+	for v := range s {
+		fmt.Println(v)
+	}
+	sum := 0
+	for i := range Count(4) {
+		func() { sum += i }()
+	}
+	fmt.Println("sum", sum)
+
+	// Break and continue.
+	for i := range Count(5) {
+		if i == 1 {
+			continue
+		}
+		if i == 3 {
+			break
+		}
+		fmt.Println("loop", i)
+	}
+	for i := range Count(3) {
+		switch i {
+		case 1:
+			continue
+		}
+		fmt.Println("switch", i)
+	}
+	for x := range Count(2) {
+		for y := range Count(2) {
+			if y == 1 {
+				break
+			}
+			fmt.Println(x, y)
+		}
+	}
+
+	// Return.
+	fmt.Println(find(4))
+	fmt.Println(find(20))
+	fmt.Println(named())
+	fmt.Println(nested())
+	deferInBody()
+
+	// Native iterators.
+	for i := range testpkg.Sequence(3) {
+		fmt.Println("sequence", i)
+	}
+	for i, e := range testpkg.Indexed([]string{"x", "y", "z"}) {
+		if i == 2 {
+			break
+		}
+		fmt.Println("indexed", i, e)
+	}
+
+	// Panics.
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		for i := range Count(3) {
+			if i == 1 {
+				panic("body panic")
+			}
+		}
+	}()
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		for range testpkg.Sequence(3) {
+			panic("native body panic")
+		}
+	}()
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		for i := range Panicking {
+			fmt.Println("got", i)
+		}
+	}()
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		for i := range Misbehaving {
+			fmt.Println("misbehaving", i)
+			break
+		}
+	}()
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		var f func(func(int) bool)
+		for range f {
+		}
+	}()
+
+}
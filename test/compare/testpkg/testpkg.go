@@ -3,6 +3,7 @@ package testpkg
 
 import (
 	"fmt"
+	"iter"
 
 	"github.com/open2b/scriggo/native"
 )
@@ -190,6 +191,30 @@ func ReturnFunction() func(int) int {
 	return func(i int) int { return i + 1 }
 }
 
+// Sequence is a function, used in tests, that returns an iterator over the
+// integers from 0 to n-1.
+func Sequence(n int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// Indexed is a function, used in tests, that returns an iterator over the
+// indexes and the elements of s.
+func Indexed(s []string) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		for i, e := range s {
+			if !yield(i, e) {
+				return
+			}
+		}
+	}
+}
+
 // RuntimeError is a function that causes a runtime error.
 func RuntimeError() {
 	var a = 0
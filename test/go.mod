@@ -5,8 +5,11 @@ go 1.23.0
 replace github.com/open2b/scriggo => ../
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/google/go-cmp v0.6.0
 	github.com/open2b/scriggo v0.0.0
 	github.com/rogpeppe/go-internal v1.13.1
 	golang.org/x/tools v0.30.0
 )
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,125 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+// coverLines returns the lines of cov in the form "path:line=count".
+func coverLines(cov *scriggo.Coverage) string {
+	var b strings.Builder
+	for i, l := range cov.Lines() {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		_, _ = fmt.Fprintf(&b, "%s:%d=%d", l.Path, l.Line, l.Count)
+	}
+	return b.String()
+}
+
+func TestCoverageTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":  "{% extends \"layout.html\" %}\n{% macro Body %}\n{% if big %}\n  big\n{% else %}\n  small\n{% end %}\n{% end %}",
+		"layout.html": "<body>\n  {{ Body() }}\n</body>",
+	}
+	opts := &scriggo.BuildOptions{
+		Globals:  native.Declarations{"big": (*bool)(nil)},
+		Coverage: true,
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cov scriggo.Coverage
+	for i := 0; i < 2; i++ {
+		err = template.Run(io.Discard, map[string]interface{}{"big": false}, &scriggo.RunOptions{Coverage: &cov})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "index.html:2=2 index.html:3=2 index.html:4=0 index.html:6=2 layout.html:1=2 layout.html:2=2 layout.html:3=2"
+	if got := coverLines(&cov); got != expected {
+		t.Fatalf("expecting %q, got %q", expected, got)
+	}
+	var b strings.Builder
+	err = cov.WriteProfile(&b, func(path string) string { return "./" + path })
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := "mode: count\n" +
+		"./index.html:2.4,2.72 1 2\n" +
+		"./index.html:3.4,3.45 1 2\n" +
+		"./index.html:4.3,4.6 1 0\n" +
+		"./index.html:6.3,6.8 1 2\n" +
+		"./layout.html:1.1,1.7 1 2\n" +
+		"./layout.html:2.3,2.15 1 2\n" +
+		"./layout.html:3.1,3.8 1 2\n"
+	if got := b.String(); got != profile {
+		t.Fatalf("expecting profile:\n%s\ngot:\n%s", profile, got)
+	}
+
+	// A template built without coverage does not collect it.
+	template, err = scriggo.BuildTemplate(fsys, "index.html", &scriggo.BuildOptions{Globals: opts.Globals})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cov = scriggo.Coverage{}
+	err = template.Run(io.Discard, nil, &scriggo.RunOptions{Coverage: &cov})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := cov.Lines(); len(lines) != 0 {
+		t.Fatalf("expecting no lines, got %v", lines)
+	}
+}
+
+func TestCoverageProgram(t *testing.T) {
+	src := `package main
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func main() {
+	for i := 0; i < 3; i++ {
+		_ = abs(i)
+	}
+}
+`
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, &scriggo.BuildOptions{Coverage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := scriggo.LoadProgram(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "main:4=3 main:5=0 main:7=3 main:11=3 main:12=3"
+	for _, p := range []*scriggo.Program{program, loaded} {
+		var cov scriggo.Coverage
+		err = p.Run(&scriggo.RunOptions{Coverage: &cov})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := coverLines(&cov); got != expected {
+			t.Fatalf("expecting %q, got %q", expected, got)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+)
+
+const debugProgram = `package main
+
+func add(a, b int) int {
+	c := a + b
+	return c
+}
+
+func main() {
+	x := 1
+	s := "hi"
+	y := add(x, 2)
+	for i := 0; i < 2; i++ {
+		x += i
+	}
+	println(s, y, x)
+}
+`
+
+// debugStops runs program with a debugger that, at every stop, appends to the
+// returned stops the line and the local variables of the running function,
+// and resumes the execution with the next step in steps.
+func debugStops(t *testing.T, program *scriggo.Program, breakpoints []int, pause bool, steps ...scriggo.DebugStep) []string {
+	var stops []string
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		frame := s.Frames()[0]
+		var b strings.Builder
+		_, _ = fmt.Fprintf(&b, "%s:%d", frame.Function, frame.Position.Line)
+		for _, v := range s.Locals(0) {
+			_, _ = fmt.Fprintf(&b, " %s=%v", v.Name, v.Value)
+		}
+		stops = append(stops, b.String())
+		if len(steps) == 0 {
+			return scriggo.DebugContinue
+		}
+		step := steps[0]
+		steps = steps[1:]
+		return step
+	})
+	debugger.SetBreakpoints("main", breakpoints)
+	if pause {
+		debugger.Pause()
+	}
+	print := func(interface{}) {}
+	err := program.Run(&scriggo.RunOptions{Print: print, Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stops
+}
+
+func TestDebugger(t *testing.T) {
+	program, err := scriggo.Build(fstest.Files{"main.go": debugProgram}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := scriggo.LoadProgram(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		breakpoints []int
+		pause       bool
+		steps       []scriggo.DebugStep
+		expected    []string
+	}{
+		{
+			breakpoints: []int{5, 13},
+			expected: []string{
+				"main.add:5 a=1 b=2 c=3",
+				"main.main:13 x=1 s=hi y=3 i=0",
+				"main.main:13 x=1 s=hi y=3 i=1",
+			},
+		},
+		{
+			pause: true,
+			steps: []scriggo.DebugStep{
+				scriggo.DebugStepInto, scriggo.DebugStepInto, scriggo.DebugStepInto,
+				scriggo.DebugStepOut, scriggo.DebugStepOver, scriggo.DebugStepOver,
+			},
+			expected: []string{
+				"main.main:9",
+				"main.main:10 x=1",
+				"main.main:11 x=1 s=hi",
+				"main.add:4 a=1 b=2",
+				"main.main:12 x=1 s=hi y=3",
+				"main.main:13 x=1 s=hi y=3 i=0",
+				"main.main:12 x=1 s=hi y=3 i=0",
+			},
+		},
+		{
+			breakpoints: []int{4},
+			steps:       []scriggo.DebugStep{scriggo.DebugStepOver, scriggo.DebugStepOver, scriggo.DebugStepOver},
+			expected: []string{
+				"main.add:4 a=1 b=2",
+				"main.add:5 a=1 b=2 c=3",
+				"main.main:12 x=1 s=hi y=3",
+				"main.main:13 x=1 s=hi y=3 i=0",
+			},
+		},
+	}
+	for _, p := range []*scriggo.Program{program, loaded} {
+		for _, test := range tests {
+			stops := debugStops(t, p, test.breakpoints, test.pause, test.steps...)
+			if got, expected := strings.Join(stops, "\n"), strings.Join(test.expected, "\n"); got != expected {
+				t.Fatalf("expecting stops:\n%s\ngot:\n%s", expected, got)
+			}
+		}
+	}
+}
+
+func TestDebuggerFrames(t *testing.T) {
+	program, err := scriggo.Build(fstest.Files{"main.go": debugProgram}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames []scriggo.DebugFrame
+	var local scriggo.DebugVariable
+	var ok bool
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		if s.Reason() != scriggo.DebugBreakpoint {
+			t.Fatalf("expecting reason DebugBreakpoint, got %d", s.Reason())
+		}
+		frames = s.Frames()
+		local, ok = s.Local(1, "s")
+		return scriggo.DebugContinue
+	})
+	debugger.SetBreakpoints("main", []int{4})
+	err = program.Run(&scriggo.RunOptions{Print: func(interface{}) {}, Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("expecting 2 frames, got %d", len(frames))
+	}
+	if f := frames[0]; f.Function != "main.add" || f.Path != "main" || f.Position.String() != "4:2" {
+		t.Fatalf("unexpected frame %v", f)
+	}
+	if f := frames[1]; f.Function != "main.main" || f.Path != "main" || f.Position.Line != 11 {
+		t.Fatalf("unexpected frame %v", f)
+	}
+	if !ok {
+		t.Fatal("expecting local variable s")
+	}
+	if local.Type.Kind() != reflect.String || local.Value.String() != "hi" {
+		t.Fatalf("unexpected local variable %s %s = %v", local.Name, local.Type, local.Value)
+	}
+}
+
+func TestDebuggerInterface(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tvar err error\n\tvar v any = 5\n\tprintln(err, v)\n}\n"
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locals []scriggo.DebugVariable
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		locals = s.Locals(0)
+		return scriggo.DebugContinue
+	})
+	debugger.SetBreakpoints("main", []int{6})
+	err = program.Run(&scriggo.RunOptions{Print: func(interface{}) {}, Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locals) != 2 {
+		t.Fatalf("expecting 2 local variables, got %d", len(locals))
+	}
+	if v := locals[0]; v.Name != "err" || v.Type != reflect.TypeOf((*error)(nil)).Elem() || v.Value.IsValid() {
+		t.Fatalf("unexpected local variable %s %s = %v", v.Name, v.Type, v.Value)
+	}
+	if v := locals[1]; v.Name != "v" || v.Type.Kind() != reflect.Interface || v.Value.Kind() != reflect.Int || v.Value.Int() != 5 {
+		t.Fatalf("unexpected local variable %s %s = %v", v.Name, v.Type, v.Value)
+	}
+}
+
+func TestDebuggerTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html": "{% var n = 2 %}\n{% for i := 0; i < n; i++ %}\n{{ i }}\n{% end %}",
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stops []string
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		frame := s.Frames()[0]
+		n, _ := s.Local(0, "n")
+		i, _ := s.Local(0, "i")
+		stops = append(stops, fmt.Sprintf("%s:%d n=%v i=%v", frame.Path, frame.Position.Line, n.Value, i.Value))
+		return scriggo.DebugContinue
+	})
+	debugger.SetBreakpoints("index.html", []int{3})
+	var b strings.Builder
+	err = template.Run(&b, nil, &scriggo.RunOptions{Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "index.html:3 n=2 i=0\nindex.html:3 n=2 i=1"
+	if got := strings.Join(stops, "\n"); got != expected {
+		t.Fatalf("expecting stops:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDebuggerRangeFunc(t *testing.T) {
+	src := `package main
+
+var g = "global"
+
+func seq(yield func(int) bool) {
+	for i := 0; i < 2; i++ {
+		if !yield(i) {
+			return
+		}
+	}
+}
+
+func main() {
+	sum := 0
+	inc := func(n int) { sum += n }
+	for v := range seq {
+		inc(v)
+	}
+	_ = sum
+}
+`
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stops []string
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		var b strings.Builder
+		for i, frame := range s.Frames() {
+			_, _ = fmt.Fprintf(&b, "%s:%d", frame.Function, frame.Position.Line)
+			for _, v := range s.Locals(i) {
+				if v.Type.Kind() != reflect.Func {
+					_, _ = fmt.Fprintf(&b, " %s=%v", v.Name, v.Value)
+				}
+			}
+			b.WriteString(" | ")
+		}
+		for _, v := range s.Globals() {
+			_, _ = fmt.Fprintf(&b, "%s=%v", v.Name, v.Value)
+		}
+		stops = append(stops, b.String())
+		return scriggo.DebugContinue
+	})
+	debugger.SetBreakpoints("main", []int{7, 17})
+	err = program.Run(&scriggo.RunOptions{Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"main.seq:7 i=0 | main.main:16 sum=0 v=0 | g=global",
+		"main.main:17 sum=0 v=0 | g=global",
+		"main.seq:7 i=1 | main.main:16 sum=0 v=0 | g=global",
+		"main.main:17 sum=0 v=1 | g=global",
+	}
+	if got := strings.Join(stops, "\n"); got != strings.Join(expected, "\n") {
+		t.Fatalf("expecting stops:\n%s\ngot:\n%s", strings.Join(expected, "\n"), got)
+	}
+}
+
+func TestDebuggerFunctionNames(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html": "{% macro A %}{% f := func() {\n_ = 1\n} %}{% f() %}{% end %}\n" +
+			"{% macro B %}{% f := func() {\n_ = 1\n} %}{% g := func() {\n_ = 2\n} %}{% f() %}{% g() %}{% end %}\n" +
+			"{{ A() }}{{ B() }}",
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stops []string
+	debugger := scriggo.NewDebugger(func(s *scriggo.DebugStop) scriggo.DebugStep {
+		var names []string
+		for _, frame := range s.Frames() {
+			names = append(names, frame.Function)
+		}
+		stops = append(stops, strings.Join(names, " "))
+		return scriggo.DebugContinue
+	})
+	debugger.SetBreakpoints("index.html", []int{2, 5, 7})
+	err = template.Run(io.Discard, nil, &scriggo.RunOptions{Debugger: debugger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "main.A.func1 main.A main.main\n" +
+		"main.B.func1 main.B main.main\n" +
+		"main.B.func2 main.B main.main"
+	if got := strings.Join(stops, "\n"); got != expected {
+		t.Fatalf("expecting stops:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+)
+
+// diagnostics returns the diagnostics of the build error err, one per line.
+func diagnostics(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected a build error, got no error")
+	}
+	e, ok := err.(*scriggo.BuildError)
+	if !ok {
+		t.Fatalf("expected a *BuildError value, got %T", err)
+	}
+	var b strings.Builder
+	for _, d := range e.Diagnostics() {
+		if d.Severity != scriggo.SeverityError {
+			t.Fatalf("expected severity error, got %s", d.Severity)
+		}
+		b.WriteString(d.Error())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestTemplateDiagnostics(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":  "{% extends \"layout.html\" %}\n{% import \"imp.html\" %}\n{% macro Body %}\n{{ a + 1 }}\n{{ Imp() }}\n{% end %}",
+		"layout.html": "{{ Body() }}\n{% var v int = \"\" %}\n{{ v + 1 }}\n{% for i := 0; i < 2; i++ %}{{ i.x }}{% end %}",
+		"imp.html":    "{% macro Imp %}{{ b }}{% end %}",
+	}
+	_, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	expected := "imp.html:1:19: undefined: b\n" +
+		"index.html:4:4: undefined: a\n" +
+		"layout.html:2:16: cannot use \"\" (type untyped string) as type int in assignment\n" +
+		"layout.html:4:33: i.x undefined (type int has no field or method x)\n"
+	if got := diagnostics(t, err); got != expected {
+		t.Fatalf("expected diagnostics:\n%s\ngot:\n%s", expected, got)
+	}
+	// The methods of BuildError refer to the first error.
+	e := err.(*scriggo.BuildError)
+	if e.Error() != "imp.html:1:19: undefined: b" {
+		t.Fatalf("unexpected error %q", e.Error())
+	}
+	if e.Path() != "imp.html" || e.Position().String() != "1:19" || e.Message() != "undefined: b" {
+		t.Fatalf("unexpected path %q, position %s and message %q", e.Path(), e.Position(), e.Message())
+	}
+}
+
+func TestProgramDiagnostics(t *testing.T) {
+	src := `package main
+
+func f() int {
+	x := 1
+	return "a"
+}
+
+func main() {
+	var a int = "s"
+	b := c
+	_ = b
+	for i := 0; i < 2; i++ {
+		i.x()
+	}
+}
+`
+	_, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	expected := "main:5:2: cannot use \"a\" (type untyped string) as type int in return argument\n" +
+		"main:9:14: cannot use \"s\" (type untyped string) as type int in assignment\n" +
+		"main:10:7: undefined: c\n" +
+		"main:13:4: i.x undefined (type int has no field or method x)\n"
+	if got := diagnostics(t, err); got != expected {
+		t.Fatalf("expected diagnostics:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestPackageDeclarationDiagnostics(t *testing.T) {
+	src := `package main
+
+var a int = "a"
+
+const b = c + 1
+
+var d = a + 1
+
+func main() {
+	_ = d
+	var e string = 5
+}
+`
+	_, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	// The constants are checked before the variables.
+	expected := "main:5:11: undefined: c\n" +
+		"main:3:13: cannot use \"a\" (type untyped string) as type int in assignment\n" +
+		"main:11:17: cannot use 5 (type untyped int) as type string in assignment\n"
+	if got := diagnostics(t, err); got != expected {
+		t.Fatalf("expected diagnostics:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestSyntaxErrorDiagnostics(t *testing.T) {
+	fsys := fstest.Files{"index.html": "{% if %}{{ a }}"}
+	_, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	expected := "index.html:1:7: missing condition in if statement\n"
+	if got := diagnostics(t, err); got != expected {
+		t.Fatalf("expected diagnostics:\n%s\ngot:\n%s", expected, got)
+	}
+}
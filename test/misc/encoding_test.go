@@ -0,0 +1,196 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+type EncodingPoint struct{ X, Y int }
+
+func (p EncodingPoint) String() string { return fmt.Sprintf("(%d,%d)", p.X, p.Y) }
+
+// encodingPackages returns the packages used by the encoding tests. The
+// output of the Print function is written to out.
+func encodingPackages(out *strings.Builder, counter *int) native.Packages {
+	return native.Packages{
+		"pkg": native.Package{
+			Name: "pkg",
+			Declarations: native.Declarations{
+				"Add":     func(a, b int) int { return a + b },
+				"Counter": counter,
+				"Point":   reflect.TypeOf(EncodingPoint{}),
+				"Print":   func(a ...interface{}) { out.WriteString(fmt.Sprintln(a...)) },
+			},
+		},
+	}
+}
+
+const encodingProgram = `package main
+
+import "pkg"
+
+type Shape interface{ Area() int }
+
+type Square struct{ side int }
+
+func (s Square) Area() int { return s.side * s.side }
+
+type Celsius int
+
+func (c *Celsius) Set(v int) { *c = Celsius(v) }
+
+func main() {
+	var s Shape = Square{3}
+	pkg.Print(s.Area())
+	p := pkg.Point{X: 1, Y: 2}
+	pkg.Print(p.String(), pkg.Point.String(p))
+	pkg.Counter++
+	add := func(x int) func(int) int {
+		return func(y int) int { return pkg.Add(x, y) }
+	}
+	c := 1 + 2i
+	c = c * c
+	var t Celsius
+	t.Set(21)
+	pkg.Print(add(2)(3), c, int(t), pkg.Counter)
+	ch := make(chan int, 1)
+	defer close(ch)
+	done := make(chan bool)
+	go func() { done <- true }()
+	<-done
+	_ = (func())(nil)
+}
+`
+
+func TestProgramEncoding(t *testing.T) {
+	var out strings.Builder
+	var counter int
+	options := &scriggo.BuildOptions{AllowGoStmt: true, Packages: encodingPackages(&out, &counter)}
+	program, err := scriggo.Build(fstest.Files{"main.go": encodingProgram}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatalf("cannot marshal: %s", err)
+	}
+	loaded, err := scriggo.LoadProgram(data, options)
+	if err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	expected := "9\n(1,2) (1,2)\n5 (-3+4i) 21 %d\n"
+	for i, p := range []*scriggo.Program{program, loaded} {
+		out.Reset()
+		err = p.Run(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := fmt.Sprintf(expected, i+1); out.String() != e {
+			t.Fatalf("expecting output %q, got %q", e, out.String())
+		}
+	}
+	data2, err := loaded.MarshalBinary()
+	if err != nil {
+		t.Fatalf("cannot marshal the loaded program: %s", err)
+	}
+	if string(data2) != string(data) {
+		t.Fatal("expecting the same encoding for the loaded program")
+	}
+}
+
+func TestTemplateEncoding(t *testing.T) {
+	var out strings.Builder
+	var counter int
+	title := "Encoding"
+	options := &scriggo.BuildOptions{
+		Globals: native.Declarations{
+			"title": &title,
+			"name":  (*string)(nil),
+			"upper": strings.ToUpper,
+			"pkg":   encodingPackages(&out, &counter)["pkg"],
+		},
+	}
+	files := fstest.Files{
+		"index.html":  `{% extends "layout.html" %}{% macro Body %}{% for i := 0; i < 3; i++ %}{{ pkg.Add(i, 1) }}{% end %} {{ upper(name) }} <b>{{ pkg.Point{1, 2} }}</b>{% end %}`,
+		"layout.html": `<h1>{{ title }}</h1>{{ Body() }}`,
+	}
+	template, err := scriggo.BuildTemplate(files, "index.html", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("cannot marshal: %s", err)
+	}
+	loaded, err := scriggo.LoadTemplate(data, options)
+	if err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	const expected = "<h1>Encoding</h1>123 SCRIGGO <b>(1,2)</b>"
+	for _, tmpl := range []*scriggo.Template{template, loaded} {
+		var b strings.Builder
+		err = tmpl.Run(&b, map[string]interface{}{"name": "scriggo"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != expected {
+			t.Fatalf("expecting output %q, got %q", expected, b.String())
+		}
+	}
+	if vars := loaded.UsedVars(); !reflect.DeepEqual(vars, template.UsedVars()) {
+		t.Fatalf("expecting used vars %v, got %v", template.UsedVars(), vars)
+	}
+}
+
+func TestIncompatibleEncoding(t *testing.T) {
+	var out strings.Builder
+	var counter int
+	packages := encodingPackages(&out, &counter)
+	program, err := scriggo.Build(fstest.Files{"main.go": encodingProgram}, &scriggo.BuildOptions{AllowGoStmt: true, Packages: packages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := native.Packages{"pkg": native.Package{Name: "pkg", Declarations: native.Declarations{}}}
+	for name, decl := range packages["pkg"].(native.Package).Declarations {
+		changed["pkg"].(native.Package).Declarations[name] = decl
+	}
+	changed["pkg"].(native.Package).Declarations["Add"] = func(a, b int64) int64 { return a + b }
+	tests := []struct {
+		name     string
+		data     []byte
+		packages native.Importer
+	}{
+		{"invalid data", []byte("program"), packages},
+		{"truncated data", data[:12], packages},
+		{"missing package", data, native.Packages{}},
+		{"missing declaration", data, native.Packages{"pkg": native.Package{Name: "pkg"}}},
+		{"changed declaration", data, changed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scriggo.LoadProgram(test.data, &scriggo.BuildOptions{Packages: test.packages})
+			if !errors.Is(err, scriggo.ErrIncompatibleEncoding) {
+				t.Fatalf("expecting ErrIncompatibleEncoding, got %v", err)
+			}
+		})
+	}
+	_, err = scriggo.LoadTemplate(data, &scriggo.BuildOptions{Packages: packages})
+	if err == nil {
+		t.Fatal("expecting error loading a program as a template, got no error")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+
+	"github.com/BurntSushi/toml"
+)
+
+type frontMatterPage struct {
+	Title string
+	Tags  []string
+	Draft bool
+}
+
+var frontMatterTests = []struct {
+	src      string
+	typ      reflect.Type
+	expected string
+}{
+	{"---\ntitle: Hello\n---\n<b>{{ page.title }}</b>", nil, "<b>Hello</b>"},
+	{"---\r\ntitle: Hello\r\n---\r\n{{ page.title }}", nil, "Hello"},
+	{"+++\ntitle = \"Hello\"\ncount = 3\n+++\n{{ page.title }} {{ page.count }}", nil, "Hello 3"},
+	{"---\n---\n{{ len(page) }}", nil, "0"},
+	{"{{ len(page) }}", nil, "0"},
+	{"---x\n---\n", nil, "---x\n---\n"},
+	{"----\n", nil, "----\n"},
+	{"---\ntitle: Hello\ntags: [a, b]\n---\n{{ page.Title }} {{ page.Tags[1] }} {{ page.Draft }}", reflect.TypeOf(frontMatterPage{}), "Hello b false"},
+	{"+++\ntitle = \"Hello\"\ndraft = true\n+++\n{{ page.Title }} {{ page.Draft }}", reflect.TypeOf(frontMatterPage{}), "Hello true"},
+	{"{{ page.Title == \"\" }}", reflect.TypeOf(frontMatterPage{}), "true"},
+	{"---\ntitle: Hello\n---\n\n{% if true %}\n{{ page.title }}\n{% end %}\n", nil, "\nHello\n"},
+	{"---\ntitle: Hello\n---\n{% extends \"layout.html\" %}\n{% macro Body %}body{% end %}", nil, "<title>Hello</title>body"},
+	{"---\ntitle: Hello\n---\n{{ render \"partial.html\" }}", nil, "<i>Hello</i>"},
+}
+
+// TestFrontMatter tests the FrontMatter build option.
+func TestFrontMatter(t *testing.T) {
+	for _, test := range frontMatterTests {
+		fsys := fstest.Files{
+			"index.html":   test.src,
+			"layout.html":  "<title>{{ page.title }}</title>{{ Body() }}",
+			"partial.html": "<i>{{ page.title }}</i>",
+		}
+		opts := &scriggo.BuildOptions{
+			FrontMatter: &scriggo.FrontMatter{Name: "page", Type: test.typ, DecodeTOML: toml.Unmarshal},
+		}
+		template, err := scriggo.BuildTemplate(fsys, "index.html", opts)
+		if err != nil {
+			t.Errorf("source %q: unexpected error: %s", test.src, err)
+			continue
+		}
+		var b strings.Builder
+		err = template.Run(&b, nil, nil)
+		if err != nil {
+			t.Errorf("source %q: unexpected error: %s", test.src, err)
+			continue
+		}
+		if b.String() != test.expected {
+			t.Errorf("source %q: expected output %q, got %q", test.src, test.expected, b.String())
+		}
+	}
+}
+
+var frontMatterErrorTests = []struct {
+	src        string
+	typ        reflect.Type
+	decodeTOML scriggo.Decoder
+	expected   string
+}{
+	{"---\ntitle: Hello\n", nil, nil, "index.html:1:1: syntax error: unterminated front matter"},
+	{"---\ntitle: Hello\n  a: b\n---\n", nil, nil, "index.html:3:1: syntax error: invalid front matter: mapping values are not allowed in this context"},
+	{"+++\ntitle = \"Hello\"\ntitle = 5\n+++\n", nil, toml.Unmarshal, "index.html:3:1: syntax error: invalid front matter: Key 'title' has already been defined."},
+	{"+++\ntitle = \"Hello\"\n+++\n", nil, nil, "index.html:1:1: syntax error: TOML front matter is not supported"},
+	{"---\ndraft: 5\n---\n", reflect.TypeOf(frontMatterPage{}), nil, "index.html:2:1: syntax error: invalid front matter: cannot unmarshal !!int `5` into bool"},
+	{"", reflect.TypeOf(0), nil, "scriggo: front matter type must be a struct or a map with string keys"},
+	{"", reflect.TypeOf(map[int]string{}), nil, "scriggo: front matter type must be a struct or a map with string keys"},
+}
+
+// TestFrontMatterErrors tests the errors of the FrontMatter build option.
+func TestFrontMatterErrors(t *testing.T) {
+	for _, test := range frontMatterErrorTests {
+		fsys := fstest.Files{"index.html": test.src}
+		opts := &scriggo.BuildOptions{
+			FrontMatter: &scriggo.FrontMatter{Name: "page", Type: test.typ, DecodeTOML: test.decodeTOML},
+		}
+		_, err := scriggo.BuildTemplate(fsys, "index.html", opts)
+		if err == nil {
+			t.Errorf("source %q: expected error %q, got no error", test.src, test.expected)
+			continue
+		}
+		if err.Error() != test.expected {
+			t.Errorf("source %q: expected error %q, got %q", test.src, test.expected, err)
+		}
+	}
+}
+
+// TestFrontMatterConcurrentRuns tests that every run of a template has its
+// own copy of the front matter.
+func TestFrontMatterConcurrentRuns(t *testing.T) {
+	tests := []struct {
+		src string
+		typ reflect.Type
+	}{
+		{"---\ntitle: Hello\ntags: [a]\n---\n{% tags := page.tags.([]interface{}) %}{{ page.title }} {{ tags[0] }}{% page.title = \"changed\" %}{% tags[0] = \"b\" %}", nil},
+		{"---\ntitle: Hello\ntags: [a]\n---\n{{ page.Title }} {{ page.Tags[0] }}{% page.Title = \"changed\" %}{% page.Tags[0] = \"b\" %}", reflect.TypeOf(frontMatterPage{})},
+	}
+	for _, test := range tests {
+		fsys := fstest.Files{"index.html": test.src}
+		opts := &scriggo.BuildOptions{
+			FrontMatter: &scriggo.FrontMatter{Name: "page", Type: test.typ},
+		}
+		template, err := scriggo.BuildTemplate(fsys, "index.html", opts)
+		if err != nil {
+			t.Fatalf("source %q: unexpected error: %s", test.src, err)
+		}
+		var wg sync.WaitGroup
+		outputs := make([]string, 10)
+		for i := range outputs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var b strings.Builder
+				err := template.Run(&b, nil, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				outputs[i] = b.String()
+			}()
+		}
+		wg.Wait()
+		for _, out := range outputs {
+			if out != "Hello a" {
+				t.Fatalf("source %q: expected output %q, got %q", test.src, "Hello a", out)
+			}
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"math"
 	"path"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -353,6 +354,34 @@ func TestMultiFileTemplate(t *testing.T) {
 			expectedOut: `a`,
 		},
 
+		"Label for in - continue": {
+			sources: fstest.Files{
+				"index.txt": `{% L: for x in []int{1, 2, 3} %}{% for y in []int{1, 2, 3} %}{% if y == 2 %}{% continue L %}{% end if %}{{ x }}{{ y }} {% end for %}{% end for %}`,
+			},
+			expectedOut: `11 21 31 `,
+		},
+
+		"Label for in - break": {
+			sources: fstest.Files{
+				"index.txt": `{% L: for x in []int{1, 2, 3} %}{% for y in []int{1, 2, 3} %}{% if x == 2 %}{% break L %}{% end if %}{{ x }}{{ y }} {% end for %}{% end for %}`,
+			},
+			expectedOut: `11 12 13 `,
+		},
+
+		"Label for - continue from a for in": {
+			sources: fstest.Files{
+				"index.txt": `{% L: for i := 0; i < 3; i++ %}{% for s in []string{"a", "b"} %}{% if s == "b" %}{% continue L %}{% end if %}{{ i }}{{ s }} {% end for %}{% end for %}`,
+			},
+			expectedOut: `0a 1a 2a `,
+		},
+
+		"Label switch - break from a for in": {
+			sources: fstest.Files{
+				"index.txt": `{% L: switch %}{% case true %}{% for s in []string{"a", "b"} %}{{ s }}{% break L %}{% end for %}b{% end switch %}`,
+			},
+			expectedOut: `a`,
+		},
+
 		"Render - Only text": {
 			sources: fstest.Files{
 				"index.txt":   `a{{ render "/partial.txt" }}c`,
@@ -3197,6 +3226,57 @@ func TestMultiFileTemplate(t *testing.T) {
 			expectedOut: "i'm the else block",
 		},
 
+		"For in over a function iterator": {
+			sources: fstest.Files{
+				"index.txt": `{% for v in values %}{{ v }} {% else %}NOT EXPECTED{% end for %}`,
+			},
+			main: native.Package{
+				Name: "main",
+				Declarations: native.Declarations{
+					"values": slices.Values([]string{"a", "b", "c"}),
+				},
+			},
+			expectedOut: "a b c ",
+		},
+
+		"For in over a function iterator with two values": {
+			sources: fstest.Files{
+				"index.txt": `{% for k in all %}{{ k }}{% end for %} {% for k, v := range all %}{{ k }}:{{ v }} {% end for %}`,
+			},
+			main: native.Package{
+				Name: "main",
+				Declarations: native.Declarations{
+					"all": slices.All([]string{"a", "b"}),
+				},
+			},
+			expectedOut: "01 0:a 1:b ",
+		},
+
+		"For-else function iterator -- else executed": {
+			sources: fstest.Files{
+				"index.txt": `{% for v in values %}NOT EXPECTED{% else %}i'm the else block{% end for %}`,
+			},
+			main: native.Package{
+				Name: "main",
+				Declarations: native.Declarations{
+					"values": slices.Values([]int(nil)),
+				},
+			},
+			expectedOut: "i'm the else block",
+		},
+
+		"For in over a function iterator declared in the template": {
+			sources: fstest.Files{
+				"index.txt": `{%%
+					count := func(yield func(int) bool) {
+						for i := 1; yield(i); i++ {
+						}
+					}
+				%%}{% macro M %}{% for i in count %}{% if i > 3 %}{% break %}{% end if %}{{ i }}{% end for %}{% end macro %}{{ M() }}`,
+			},
+			expectedOut: "123",
+		},
+
 		"Key selector": {
 			sources: fstest.Files{
 				"index.txt": `{%% 
@@ -0,0 +1,189 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+)
+
+// pprofProfile is a decoded pprof profile, limited to the string table and
+// to the values of the samples.
+type pprofProfile struct {
+	strings []string
+	values  [][]int64
+}
+
+// decodeProfile decodes a gzip-compressed pprof profile.
+func decodeProfile(data []byte) (*pprofProfile, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &pprofProfile{}
+	err = decodeProtobuf(data, func(tag int, value []byte) error {
+		switch tag {
+		case 2: // sample
+			return decodeProtobuf(value, func(tag int, value []byte) error {
+				if tag == 2 { // values
+					var values []int64
+					for len(value) > 0 {
+						v, n := binary.Uvarint(value)
+						if n <= 0 {
+							return io.ErrUnexpectedEOF
+						}
+						values = append(values, int64(v))
+						value = value[n:]
+					}
+					p.values = append(p.values, values)
+				}
+				return nil
+			})
+		case 6: // string table
+			p.strings = append(p.strings, string(value))
+		}
+		return nil
+	})
+	return p, err
+}
+
+// decodeProtobuf decodes the protobuf message data, calling f for each
+// length-delimited field.
+func decodeProtobuf(data []byte, f func(tag int, value []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return io.ErrUnexpectedEOF
+		}
+		data = data[n:]
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(data)
+			if n <= 0 {
+				return io.ErrUnexpectedEOF
+			}
+			data = data[n:]
+		case 2:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := f(int(key>>3), data[n:n+int(l)]); err != nil {
+				return err
+			}
+			data = data[n+int(l):]
+		default:
+			return io.ErrUnexpectedEOF
+		}
+	}
+	return nil
+}
+
+func TestProfileProgram(t *testing.T) {
+	src := `package main
+
+func alloc() []int {
+	return make([]int, 10)
+}
+
+func main() {
+	for i := 0; i < 100; i++ {
+		_ = alloc()
+	}
+}
+`
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiler := scriggo.NewProfiler()
+	err = program.Run(&scriggo.RunOptions{Profiler: profiler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	err = profiler.WriteProfile(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := decodeProfile(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := map[string]bool{}
+	for _, s := range p.strings {
+		has[s] = true
+	}
+	for _, s := range []string{"cpu", "alloc_objects", "alloc_space", "main.alloc", "main.main", "main"} {
+		if !has[s] {
+			t.Fatalf("expecting string %q in the string table", s)
+		}
+	}
+	var objects, allocated int64
+	for _, values := range p.values {
+		if len(values) != 4 {
+			t.Fatalf("expecting 4 values, got %d", len(values))
+		}
+		objects += values[2]
+		allocated += values[3]
+	}
+	if objects < 100 {
+		t.Fatalf("expecting at least 100 allocations, got %d", objects)
+	}
+	if allocated < 100*10*8 {
+		t.Fatalf("expecting at least %d allocated bytes, got %d", 100*10*8, allocated)
+	}
+}
+
+func TestProfileTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":  "{% extends \"layout.html\" %}\n{% macro Body %}{% for i := 0; i < 10; i++ %}{{ string(make([]byte, 100)) }}{% end %}{% end %}",
+		"layout.html": "<body>{{ Body() }}</body>",
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiler := scriggo.NewProfiler()
+	err = template.Run(io.Discard, nil, &scriggo.RunOptions{Profiler: profiler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	err = profiler.WriteProfile(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := decodeProfile(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := map[string]bool{}
+	for _, s := range p.strings {
+		has[s] = true
+	}
+	for _, s := range []string{"index.html", "layout.html"} {
+		if !has[s] {
+			t.Fatalf("expecting string %q in the string table, got %q", s, p.strings)
+		}
+	}
+	var objects int64
+	for _, values := range p.values {
+		objects += values[2]
+	}
+	if objects < 10 {
+		t.Fatalf("expecting at least 10 allocations, got %d", objects)
+	}
+}
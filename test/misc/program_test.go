@@ -517,6 +517,205 @@ func TestContextCancellation(t *testing.T) {
 	}
 }
 
+var maxInstructionsTests = []struct {
+	src string
+	pos string
+}{
+	{`
+		package main
+		func main() {
+			for {}
+		}`, "4:4"},
+	{`
+		package main
+		func main() {
+			defer func() {
+				recover()
+				for {}
+			}()
+			for {
+				panic("p")
+			}
+		}`, "6:5"},
+	{`
+		package main
+		func main() {
+			for _, v := range []int{1, 2, 3} {
+				for {
+					_ = v
+				}
+			}
+		}`, "5:5"},
+	{`
+		package main
+		import "test/callback"
+		func main() {
+			callback.Call(func() {
+				for {}
+			})
+		}`, "6:5"},
+}
+
+// TestMaxInstructions tests the MaxInstructions run option.
+func TestMaxInstructions(t *testing.T) {
+	for _, test := range maxInstructionsTests {
+		fsys := fstest.Files{"main.go": test.src}
+		options := &scriggo.BuildOptions{
+			Packages: native.Packages{
+				"test/callback": native.Package{
+					Name: "callback",
+					Declarations: native.Declarations{
+						"Call": func(f func()) { f() },
+					},
+				},
+			},
+		}
+		program, err := scriggo.Build(fsys, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = program.Run(&scriggo.RunOptions{MaxInstructions: 1000})
+		if err == nil {
+			t.Fatal("expected limit error, got no error")
+		}
+		e, ok := err.(*scriggo.LimitError)
+		if !ok {
+			t.Fatalf("expected *scriggo.LimitError, got %T (%s)", err, err)
+		}
+		if msg := e.Message(); msg != "instruction limit exceeded" {
+			t.Fatalf("unexpected message %q", msg)
+		}
+		if e.Path() != "main" {
+			t.Fatalf("expected path \"main\", got %q", e.Path())
+		}
+		if pos := e.Position().String(); pos != test.pos {
+			t.Fatalf("expected position %s, got %s", test.pos, pos)
+		}
+	}
+}
+
+// TestMaxInstructionsIsDeterministic tests that the same program exceeds the
+// instruction limit always at the same point.
+func TestMaxInstructionsIsDeterministic(t *testing.T) {
+	src := `
+		package main
+		func main() {
+			s := 0
+			for i := 0; ; i++ {
+				s += i
+				print(s)
+			}
+		}`
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs [2]strings.Builder
+	for i := range outputs {
+		out := &outputs[i]
+		err = program.Run(&scriggo.RunOptions{
+			Print:           func(v interface{}) { fmt.Fprint(out, v) },
+			MaxInstructions: 500,
+		})
+		if _, ok := err.(*scriggo.LimitError); !ok {
+			t.Fatalf("expected *scriggo.LimitError, got %T (%v)", err, err)
+		}
+	}
+	if outputs[0].Len() == 0 {
+		t.Fatal("expected output, got no output")
+	}
+	if outputs[0].String() != outputs[1].String() {
+		t.Fatalf("expected the same output, got %q and %q", outputs[0].String(), outputs[1].String())
+	}
+}
+
+var maxMemoryTests = []struct {
+	src string
+	pos string
+}{
+	{`
+		package main
+		func main() {
+			_ = make([]int, 10, 1000)
+		}`, "4:12"},
+	{`
+		package main
+		func main() {
+			var s []byte
+			for {
+				s = append(s, 'a')
+			}
+		}`, "6:15"},
+	{`
+		package main
+		func main() {
+			s := "a"
+			for {
+				s += s
+			}
+		}`, "6:5"},
+	{`
+		package main
+		func main() {
+			m := map[int]string{}
+			for i := 0; ; i++ {
+				m[i] = "a"
+			}
+		}`, "6:6"},
+	{`
+		package main
+		func main() {
+			defer func() { recover() }()
+			b := []byte("ab")
+			for {
+				b = append(b, b...)
+			}
+		}`, "7:15"},
+}
+
+// TestMaxMemory tests the MaxMemory run option.
+func TestMaxMemory(t *testing.T) {
+	for _, test := range maxMemoryTests {
+		program, err := scriggo.Build(fstest.Files{"main.go": test.src}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = program.Run(&scriggo.RunOptions{MaxMemory: 4096})
+		if err == nil {
+			t.Fatal("expected limit error, got no error")
+		}
+		e, ok := err.(*scriggo.LimitError)
+		if !ok {
+			t.Fatalf("expected *scriggo.LimitError, got %T (%s)", err, err)
+		}
+		if msg := e.Message(); msg != "memory limit exceeded" {
+			t.Fatalf("unexpected message %q", msg)
+		}
+		if pos := e.Position().String(); pos != test.pos {
+			t.Errorf("expected position %s, got %s", test.pos, pos)
+		}
+	}
+	// Limit not exceeded.
+	src := `
+		package main
+		func main() {
+			s := make([]int, 0, 10)
+			m := map[string]int{}
+			for i := 0; i < 10; i++ {
+				s = append(s, i)
+				m["a"] = i
+			}
+		}`
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = program.Run(&scriggo.RunOptions{MaxMemory: 4096})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+}
+
 // https://github.com/open2b/scriggo/issues/855
 func TestIssue855(t *testing.T) {
 	fsys := fstest.Files{
@@ -0,0 +1,67 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+func TestSourceMap(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":   "{% extends \"layout.html\" %}\n{% macro Body %}\n  <p>{{ title }}</p>\n  {{ render \"partial.html\" }}\n{% end %}",
+		"layout.html":  "<html>\n<body>{{ Body() }}</body>\n</html>",
+		"partial.html": "<b>a</b>\n<i>é</i>",
+	}
+	opts := &scriggo.BuildOptions{
+		Globals: native.Declarations{"title": "x\ny"},
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	sm := scriggo.SourceMap{File: "index.html"}
+	err = template.Run(&b, nil, &scriggo.RunOptions{SourceMap: &sm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "<html>\n<body>  <p>x\ny</p>\n<b>a</b>\n<i>é</i></body>\n</html>"
+	if b.String() != expectedOutput {
+		t.Fatalf("expected output %q, got %q", expectedOutput, b.String())
+	}
+	var got strings.Builder
+	for _, m := range sm.Mappings() {
+		_, _ = fmt.Fprintf(&got, "%d:%d %s:%d:%d\n", m.Line, m.Column, m.Path, m.Position.Line, m.Position.Column)
+	}
+	expectedMappings := `1:1 layout.html:1:1
+2:1 layout.html:2:1
+2:7 index.html:3:1
+2:12 index.html:3:9
+3:1 index.html:3:9
+3:2 index.html:3:17
+4:1 partial.html:1:1
+5:1 partial.html:2:1
+5:9 layout.html:2:19
+6:1 layout.html:3:1
+`
+	if got.String() != expectedMappings {
+		t.Fatalf("expected mappings:\n%s\ngot:\n%s", expectedMappings, got.String())
+	}
+	data, err := json.Marshal(&sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"version":3,"file":"index.html","sources":["layout.html","index.html","partial.html"],"names":[],"mappings":"AAAA;AACA,MCCA,KAAQ;AAAA,CAAQ;ACFhB;AACA,QFAkB;AAClB"}`
+	if string(data) != expectedJSON {
+		t.Fatalf("expected JSON %s, got %s", expectedJSON, data)
+	}
+}
@@ -251,6 +251,11 @@ var rendererExprTests = []struct {
 	// +
 	{"2 + 3", "5", nil},
 	{`"a" + "b"`, "ab", nil},
+
+	// min and max
+	{"min(3, 1, 2)", "1", nil},
+	{"max(3, 1.5, 2)", "3", nil},
+	{`max("b", "c", "a")`, "c", nil},
 	// {`a + "b"`, "ab", Vars{"a": "a"}},
 	// {`a + "b"`, "ab", Vars{"a": HTML("a")}},
 	// {`a + "b"`, "<a>b", Vars{"a": "<a>"}},
@@ -413,7 +418,7 @@ var rendererStmtTests = []struct {
 	{"{% for i := 0; i < 5; i++ %}{{ i }}{% end %}", "01234", nil},
 	{"{% for i := 0; i < 5; i++ %}{{ i }}{% break %}{% end %}", "0", nil},
 	{"{% for i := 0; ; i++ %}{{ i }}{% if i == 4 %}{% break %}{% end %}{% end %}", "01234", nil},
-	// {"{% for i := 0; i < 5; i++ %}{{ i }}{% if i == 4 %}{% continue %}{% end %},{% end %}", "0,1,2,3,4", nil},
+	{"{% for i := 0; i < 5; i++ %}{{ i }}{% if i == 4 %}{% continue %}{% end %},{% end %}", "0,1,2,3,4", nil},
 	{"{% switch %}{% end %}", "", nil},
 	{"{% switch %}{% case true %}ok{% end %}", "ok", nil},
 	{"{% switch ; %}{% case true %}ok{% end %}", "ok", nil},
@@ -903,3 +908,101 @@ func TestRecoveredOutError(t *testing.T) {
 		t.Fatalf("expecting no error, got error %v", err)
 	}
 }
+
+// TestMaxInstructionsTemplate tests that a template exceeding the instruction
+// limit stops with a limit error.
+func TestMaxInstructionsTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":    "{% import \"imported.html\" %}a{{ Loop() }}",
+		"imported.html": "{% macro Loop %}{% for i := 0; ; i++ %}{{ i }}{% end %}{% end %}",
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = template.Run(&b, nil, &scriggo.RunOptions{MaxInstructions: 100})
+	if err == nil {
+		t.Fatal("expecting limit error, got no error")
+	}
+	e, ok := err.(*scriggo.LimitError)
+	if !ok {
+		t.Fatalf("expecting *scriggo.LimitError, got %T (%s)", err, err)
+	}
+	const expected = "imported.html:1:20: instruction limit exceeded"
+	if e.Error() != expected {
+		t.Fatalf("expecting error %q, got %q", expected, e.Error())
+	}
+	// Limit not exceeded.
+	b.Reset()
+	fsys["index.html"] = "{% for i := 0; i < 3; i++ %}{{ i }}{% end %}"
+	template, err = scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = template.Run(&b, nil, &scriggo.RunOptions{MaxInstructions: 100})
+	if err != nil {
+		t.Fatalf("expecting no error, got error %q", err)
+	}
+	if b.String() != "012" {
+		t.Fatalf("expecting \"012\", got %q", b.String())
+	}
+}
+
+// TestMaxMemoryTemplate tests that a template exceeding the memory limit
+// stops with a limit error.
+func TestMaxMemoryTemplate(t *testing.T) {
+	fsys := fstest.Files{"index.txt": "{% s := \"abc\" %}{% for %}{% s += s %}{% end %}"}
+	template, err := scriggo.BuildTemplate(fsys, "index.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = template.Run(io.Discard, nil, &scriggo.RunOptions{MaxMemory: 1024})
+	if err == nil {
+		t.Fatal("expecting limit error, got no error")
+	}
+	e, ok := err.(*scriggo.LimitError)
+	if !ok {
+		t.Fatalf("expecting *scriggo.LimitError, got %T (%s)", err, err)
+	}
+	const expected = "index.txt:1:29: memory limit exceeded"
+	if e.Error() != expected {
+		t.Fatalf("expecting error %q, got %q", expected, e.Error())
+	}
+}
+
+// TestMaxOutput tests that a template exceeding the output limit stops with a
+// limit error after writing the bytes up to the limit.
+func TestMaxOutput(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+		out      string
+	}{
+		{"{% for i := 0; ; i++ %}ab{% end %}", "index.html:1:24: output limit exceeded", "ababa"},
+		{"{% for i := 0; ; i++ %}{{ i }}{% end %}", "index.html:1:27: output limit exceeded", "01234"},
+		{"{% defer func() { recover() }() %}{% macro M %}abc{% end %}{% for %}{{ M() }}{% end %}", "index.html:1:48: output limit exceeded", "abcab"},
+	}
+	for _, test := range tests {
+		fsys := fstest.Files{"index.html": test.src}
+		template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		err = template.Run(&b, nil, &scriggo.RunOptions{MaxOutput: 5})
+		if err == nil {
+			t.Fatal("expecting limit error, got no error")
+		}
+		e, ok := err.(*scriggo.LimitError)
+		if !ok {
+			t.Fatalf("expecting *scriggo.LimitError, got %T (%s)", err, err)
+		}
+		if e.Error() != test.expected {
+			t.Fatalf("expecting error %q, got %q", test.expected, e.Error())
+		}
+		if b.String() != test.out {
+			t.Fatalf("expecting output %q, got %q", test.out, b.String())
+		}
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	testfs "testing/fstest"
+	"time"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/ast"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+func TestTemplateSet(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":   `{% extends "layout.html" %}{% macro Body %}{{ render "partial.html" }}{% end %}`,
+		"about.html":   `{% import "macros.html" %}{{ Hello() }}`,
+		"layout.html":  "<b>{{ Body() }}</b>",
+		"partial.html": "a",
+		"macros.html":  "{% macro Hello %}hello{% end %}",
+	}
+	var builds atomic.Int32
+	set := scriggo.NewTemplateSet(fsys, func(name string) *scriggo.BuildOptions {
+		return &scriggo.BuildOptions{
+			TreeTransformer: func(*ast.Tree) error {
+				builds.Add(1)
+				return nil
+			},
+		}
+	})
+	run := func(name, expected string) *scriggo.Template {
+		t.Helper()
+		template, err := set.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		err = template.Run(&b, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != expected {
+			t.Fatalf("expected output %q, got %q", expected, b.String())
+		}
+		return template
+	}
+
+	// Build the templates concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = set.Get("index.html")
+		}()
+	}
+	wg.Wait()
+	if n := builds.Load(); n != 1 {
+		t.Fatalf("expected 1 build, got %d", n)
+	}
+	index := run("index.html", "<b>a</b>")
+	about := run("about.html", "hello")
+	if n := builds.Load(); n != 2 {
+		t.Fatalf("expected 2 builds, got %d", n)
+	}
+	if set.Lookup("index.html") != index {
+		t.Fatal("expected cached template")
+	}
+	if set.Lookup("layout.html") != nil {
+		t.Fatal("unexpected cached template")
+	}
+	expected := []string{"index.html", "layout.html", "partial.html"}
+	if got := set.Dependencies("index.html"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected dependencies %v, got %v", expected, got)
+	}
+	if got := set.Dependencies("layout.html"); got != nil {
+		t.Fatalf("expected no dependencies, got %v", got)
+	}
+
+	// Invalidate a file with no dependents.
+	if got := set.Invalidate("style.css"); got != nil {
+		t.Fatalf("expected no invalidated templates, got %v", got)
+	}
+
+	// Invalidate a file rendered by a template.
+	fsys["partial.html"] = "b"
+	if got := set.Invalidate("partial.html"); !reflect.DeepEqual(got, []string{"index.html"}) {
+		t.Fatalf("expected invalidated templates [index.html], got %v", got)
+	}
+	if set.Lookup("index.html") != nil {
+		t.Fatal("expected invalidated template")
+	}
+	if set.Lookup("about.html") != about {
+		t.Fatal("expected cached template")
+	}
+	run("index.html", "<b>b</b>")
+	if set.Invalidate("partial.html") == nil || set.Invalidate("layout.html") != nil {
+		t.Fatal("expected the dependencies to be removed with the invalidated template")
+	}
+
+	// A file changed without calling Invalidate.
+	index = run("index.html", "<b>b</b>")
+	if n := builds.Load(); n != 4 {
+		t.Fatalf("expected 4 builds, got %d", n)
+	}
+	fsys["layout.html"] = "<em>{{ Body() }}</em>"
+	index = run("index.html", "<em>b</em>")
+	if n := builds.Load(); n != 5 {
+		t.Fatalf("expected 5 builds, got %d", n)
+	}
+	run("index.html", "<em>b</em>")
+	if n := builds.Load(); n != 5 {
+		t.Fatalf("expected 5 builds, got %d", n)
+	}
+
+	// A file changed without changing its size, with no modification time,
+	// is detected as changed only if it is invalidated.
+	fsys["layout.html"] = "<EM>{{ Body() }}</EM>"
+	if run("index.html", "<em>b</em>") != index {
+		t.Fatal("expected cached template")
+	}
+	set.Invalidate("layout.html")
+	run("index.html", "<EM>b</EM>")
+
+	// A template that cannot be built is not cached.
+	fsys["macros.html"] = "{% macro Hello %}"
+	set.Invalidate("macros.html")
+	_, err := set.Get("about.html")
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	if set.Lookup("about.html") != nil {
+		t.Fatal("unexpected cached template")
+	}
+	fsys["macros.html"] = "{% macro Hello %}hi{% end %}"
+	run("about.html", "hi")
+
+	// A not existent file.
+	_, err = set.Get("missing.html")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func TestTemplateSetGetAll(t *testing.T) {
+	fsys := fstest.Files{
+		"layout.html": `{% import "macros.html" %}<title>{{ Title }}</title>{{ Hello() }} {{ Body() }}`,
+		"macros.html": `{% macro Hello %}hello{% end %}`,
+		"broken.html": `{% extends "layout.html" %}{% macro Body %}{{ a }}{% end %}`,
+	}
+	var names []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("page%d.html", i)
+		fsys[name] = fmt.Sprintf(`{%% extends "layout.html" %%}{%% var Title = "%d" %%}{%% macro Body %%}{{ render "partial.html" default "%d" }}{%% end %%}`, i, i)
+		names = append(names, name)
+	}
+	set := scriggo.NewTemplateSet(fsys, nil)
+	templates, err := set.GetAll(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != len(names) {
+		t.Fatalf("expected %d templates, got %d", len(names), len(templates))
+	}
+	for i, name := range names {
+		var b strings.Builder
+		err = templates[name].Run(&b, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := fmt.Sprintf("<title>%d</title>hello %d", i, i)
+		if b.String() != expected {
+			t.Fatalf("%s: expected output %q, got %q", name, expected, b.String())
+		}
+		if set.Lookup(name) != templates[name] {
+			t.Fatalf("%s: expected cached template", name)
+		}
+	}
+
+	// A changed shared file.
+	fsys["macros.html"] = `{% macro Hello %}hi{% end %}`
+	set.Invalidate("macros.html")
+	templates, err = set.GetAll([]string{"page0.html", "broken.html", "missing.html"})
+	if err == nil {
+		t.Fatal("expected error, got no error")
+	}
+	if len(templates) != 1 {
+		t.Fatalf("expected 1 template, got %d", len(templates))
+	}
+	var b strings.Builder
+	err2 := templates["page0.html"].Run(&b, nil, nil)
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	if expected := "<title>0</title>hi 0"; b.String() != expected {
+		t.Fatalf("expected output %q, got %q", expected, b.String())
+	}
+	var buildErr *scriggo.BuildError
+	if !errors.As(err, &buildErr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a build error and a not exist error, got %v", err)
+	}
+	expected := "broken.html:1:47: undefined: a\nopen missing.html: file does not exist"
+	if err.Error() != expected {
+		t.Fatalf("expected error %q, got %q", expected, err)
+	}
+}
+
+// TestTemplateSetInvalidateBuild tests that a template being built is not
+// added to a TemplateSet only if a file read by its build is invalidated.
+func TestTemplateSetInvalidateBuild(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":  `{% import "macros.html" %}{{ Hello() }}`,
+		"macros.html": `{% macro Hello %}hello{% end %}`,
+	}
+	started := make(chan string)
+	resume := make(chan struct{})
+	set := scriggo.NewTemplateSet(fsys, func(name string) *scriggo.BuildOptions {
+		started <- "options"
+		<-resume
+		return &scriggo.BuildOptions{
+			TreeTransformer: func(tree *ast.Tree) error {
+				if tree.Path == "index.html" {
+					started <- "parsed"
+					<-resume
+				}
+				return nil
+			},
+		}
+	})
+	build := func(invalidate, step string) *scriggo.Template {
+		t.Helper()
+		done := make(chan struct{})
+		go func() {
+			_, _ = set.Get("index.html")
+			close(done)
+		}()
+		for s := range started {
+			if s == step {
+				set.Invalidate(invalidate)
+			}
+			resume <- struct{}{}
+			if s == "parsed" {
+				break
+			}
+		}
+		<-done
+		return set.Lookup("index.html")
+	}
+
+	// Files not yet read by the build.
+	if build("macros.html", "options") == nil {
+		t.Fatal("expected cached template")
+	}
+	set.Invalidate("index.html")
+	if build("other.html", "parsed") == nil {
+		t.Fatal("expected cached template")
+	}
+	set.Invalidate("index.html")
+
+	// A file read by the build.
+	if build("index.html", "parsed") != nil {
+		t.Fatal("unexpected cached template")
+	}
+}
+
+// TestTemplateSetSharedImports tests the templates of a TemplateSet that
+// share the type checked imported files.
+func TestTemplateSetSharedImports(t *testing.T) {
+	fsys := fstest.Files{
+		"macros.html": `{% import "strings" %}{% import "helpers.html" %}` +
+			`{% macro M(s string) %}{% r := strings.NewReplacer("a", "A") %}` +
+			`{% switch %}{% case s == "" %}-{% default %}{{ r.Replace(s) }}{% end %}` +
+			`{% b := append([]byte("<"), s...) %}{{ string(b) }} {{ H(s) }} {{ render "partial.html" }}{% end %}`,
+		"helpers.html": `{% import "strings" %}{% macro H(s string) %}{{ strings.ToUpper(s) }}{% end %}`,
+		"partial.html": `p`,
+	}
+	var names []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("page%d.html", i)
+		fsys[name] = fmt.Sprintf(`{%% import "macros.html" %%}{{ M("a%d") }}`, i)
+		names = append(names, name)
+	}
+	fsys["page0.html"] = `{% import h "helpers.html" %}{% import "macros.html" %}{{ h.H("b") }} {{ M("a0") }}`
+	options := &scriggo.BuildOptions{
+		Packages: native.Packages{
+			"strings": native.Package{
+				Name: "strings",
+				Declarations: native.Declarations{
+					"NewReplacer": strings.NewReplacer,
+					"ToUpper":     strings.ToUpper,
+				},
+			},
+		},
+	}
+	set := scriggo.NewTemplateSet(fsys, func(string) *scriggo.BuildOptions { return options })
+	check := func(p string) {
+		t.Helper()
+		templates, err := set.GetAll(names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range names {
+			var b strings.Builder
+			err = templates[name].Run(&b, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := fmt.Sprintf("A%d&lt;a%d A%d %s", i, i, i, p)
+			if i == 0 {
+				expected = "B " + expected
+			}
+			if b.String() != expected {
+				t.Fatalf("%s: expected output %q, got %q", name, expected, b.String())
+			}
+		}
+	}
+	check("p")
+
+	// A changed file rendered by an imported file.
+	fsys["partial.html"] = `q`
+	set.Invalidate("partial.html")
+	check("q")
+}
+
+// TestTemplateSetModTime tests that a TemplateSet detects the changed files
+// by their modification times, and the created files that did not exist.
+func TestTemplateSetModTime(t *testing.T) {
+	modTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := testfs.MapFS{
+		"index.html":  {Data: []byte(`{% import "macros.html" %}{{ Hello() }}{{ render "partial.html" default "" }}`), ModTime: modTime},
+		"macros.html": {Data: []byte(`{% macro Hello %}hello{% end %}`), ModTime: modTime},
+	}
+	set := scriggo.NewTemplateSet(fsys, nil)
+	run := func(expected string) *scriggo.Template {
+		t.Helper()
+		template, err := set.Get("index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		err = template.Run(&b, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != expected {
+			t.Fatalf("expected output %q, got %q", expected, b.String())
+		}
+		return template
+	}
+	index := run("hello")
+
+	// A file with the same modification time and size is not changed.
+	fsys["macros.html"] = &testfs.MapFile{Data: []byte(`{% macro Hello %}HELLO{% end %}`), ModTime: modTime}
+	if run("hello") != index {
+		t.Fatal("expected cached template")
+	}
+
+	// A file with a new modification time is changed.
+	fsys["macros.html"].ModTime = modTime.Add(time.Second)
+	index = run("HELLO")
+
+	// A created file.
+	fsys["partial.html"] = &testfs.MapFile{Data: []byte(" world"), ModTime: modTime}
+	if run("HELLO world") == index {
+		t.Fatal("expected a new template")
+	}
+}
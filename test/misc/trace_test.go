@@ -0,0 +1,125 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/internal/fstest"
+	"github.com/open2b/scriggo/native"
+)
+
+// traceEvents returns the events recorded by r, one per line.
+func traceEvents(r *scriggo.TraceRecorder) string {
+	var b strings.Builder
+	for _, e := range r.Events {
+		_, _ = fmt.Fprintf(&b, "%s %s %s:%d", e.Kind, e.Function, e.Path, e.Position.Line)
+		if e.Panic != nil {
+			_, _ = fmt.Fprintf(&b, " panic=%s", e.Panic.String())
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestTraceProgram(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func fail(s string) {
+	panic(s)
+}
+
+func safe() {
+	defer func() {
+		recover()
+	}()
+	fail(strings.ToUpper("a"))
+}
+
+func main() {
+	safe()
+	defer strings.ToLower("B")
+	fail("b")
+}
+`
+	packages := native.Packages{
+		"strings": native.Package{
+			Name: "strings",
+			Declarations: native.Declarations{
+				"ToLower": strings.ToLower,
+				"ToUpper": strings.ToUpper,
+			},
+		},
+	}
+	program, err := scriggo.Build(fstest.Files{"main.go": src}, &scriggo.BuildOptions{Packages: packages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r scriggo.TraceRecorder
+	err = program.Run(&scriggo.RunOptions{Tracer: &r})
+	if _, ok := err.(*scriggo.PanicError); !ok {
+		t.Fatalf("expecting a panic error, got %v", err)
+	}
+	expected := "Call main.main main:0\n" +
+		"Call main.safe main:17\n" +
+		"NativeCall strings.ToUpper main:13\n" +
+		"NativeReturn strings.ToUpper main:13\n" +
+		"Call main.fail main:13\n" +
+		"Panic  main:6 panic=A\n" +
+		"Return main.fail main:13 panic=A\n" +
+		"Call main.safe.func1 main:0\n" +
+		"Recover  main:11 panic=A\n" +
+		"Return main.safe.func1 main:0\n" +
+		"Return main.safe main:17\n" +
+		"Call main.fail main:19\n" +
+		"Panic  main:6 panic=b\n" +
+		"Return main.fail main:19 panic=b\n" +
+		"NativeCall strings.ToLower :0\n" +
+		"NativeReturn strings.ToLower :0\n" +
+		"Return main.main main:0 panic=b\n"
+	if got := traceEvents(&r); got != expected {
+		t.Fatalf("expecting events:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestTraceTemplate(t *testing.T) {
+	fsys := fstest.Files{
+		"index.html":   "{% extends \"layout.html\" %}\n{% import \"imp.html\" %}\n{% macro Body %}{{ render \"partial.html\" }}{{ M() }}{% end %}",
+		"layout.html":  "<body>{{ Body() }}</body>",
+		"partial.html": "partial",
+		"imp.html":     "{% macro M %}m{% end %}",
+	}
+	template, err := scriggo.BuildTemplate(fsys, "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r scriggo.TraceRecorder
+	err = template.Run(io.Discard, nil, &scriggo.RunOptions{Tracer: &r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range r.Events {
+		if !e.Macro {
+			t.Fatalf("expecting a macro, got %s", e.Function)
+		}
+	}
+	expected := "Call main.main layout.html:0\n" +
+		"Call main.Body layout.html:1\n" +
+		"Call render partial.html partial.html:0\n" +
+		"Return render partial.html partial.html:0\n" +
+		"Call main.M index.html:3\n" +
+		"Return main.M index.html:3\n" +
+		"Return main.Body layout.html:1\n" +
+		"Return main.main layout.html:0\n"
+	if got := traceEvents(&r); got != expected {
+		t.Fatalf("expecting events:\n%s\ngot:\n%s", expected, got)
+	}
+}
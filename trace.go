@@ -0,0 +1,130 @@
+// Copyright 2026 The Scriggo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scriggo
+
+import (
+	"github.com/open2b/scriggo/internal/runtime"
+)
+
+// TraceKind is the kind of a trace event.
+type TraceKind int
+
+const (
+	TraceCall         TraceKind = iota // call of a function or macro
+	TraceReturn                        // return from a function or macro
+	TraceNativeCall                    // call of a native function
+	TraceNativeReturn                  // return from a native function
+	TracePanic                         // panic
+	TraceRecover                       // recover of a panic
+)
+
+// String returns the name of the kind, for example "Call".
+func (k TraceKind) String() string {
+	switch k {
+	case TraceCall:
+		return "Call"
+	case TraceReturn:
+		return "Return"
+	case TraceNativeCall:
+		return "NativeCall"
+	case TraceNativeReturn:
+		return "NativeReturn"
+	case TracePanic:
+		return "Panic"
+	case TraceRecover:
+		return "Recover"
+	}
+	panic("invalid trace kind")
+}
+
+// TraceEvent represents an event of a traced execution.
+//
+// For call and return events, Path and Position are the position of the
+// call, and Function is the name of the called function as it is reported by
+// the Frames method of DebugStop, for example "main.f". The outermost
+// function has no call, so Path is the path of its file. The render of a
+// file in a template is traced as a call of "render" followed by the path of
+// the file, for example "render partial.html", and Path is the path of the
+// rendered file.
+//
+// For native call and return events, Function is the package name and the
+// name of the native function, for example "strings.ToUpper", or only its
+// name if it does not belong to a package, and it is empty for native
+// functions without a name, as the yield function of a range-over-func
+// statement. For panic and recover events, Path and Position are the
+// position of the panic and of the recover call.
+//
+// Panic is the panic of panic and recover events and, for return events, the
+// panic that caused the return, if the function did not return normally.
+type TraceEvent struct {
+	Kind     TraceKind
+	Function string      // function name.
+	Macro    bool        // reports whether the function is a macro.
+	Path     string      // path of the file, if known.
+	Position Position    // position in the file, if known.
+	Panic    *PanicError // panic.
+}
+
+// Tracer traces the execution of a program or template. To trace an
+// execution, pass a Tracer to the Run method with the Tracer field of
+// RunOptions.
+//
+// The Trace method is called, in the goroutine of the execution, for every
+// call and return of functions, macros and native functions, and for every
+// panic and recover. Every call event is followed by the return event of the
+// same function, also if the function is terminated by a panic, so the
+// events can be used to build spans of the calls.
+//
+// Only the main goroutine is traced. Goroutines started by the executed code
+// and functions called by native functions are not traced. The statements
+// that import and render files in templates are traced as calls of the
+// functions of the imported and rendered files.
+type Tracer interface {
+	Trace(e TraceEvent)
+}
+
+// TraceRecorder is a Tracer that records the events.
+type TraceRecorder struct {
+	Events []TraceEvent
+}
+
+// Trace records e.
+func (r *TraceRecorder) Trace(e TraceEvent) {
+	r.Events = append(r.Events, e)
+}
+
+// setTracer sets t as the tracer of vm.
+func setTracer(vm *runtime.VM, t Tracer) {
+	vm.SetTracer(func(e runtime.TraceEvent) {
+		event := TraceEvent{
+			Kind: TraceKind(e.Kind),
+			Path: e.Path,
+			Position: Position{
+				Line:   e.Position.Line,
+				Column: e.Position.Column,
+				Start:  e.Position.Start,
+				End:    e.Position.End,
+			},
+		}
+		switch {
+		case e.Function != nil:
+			event.Function = debugFunctionName(e.Function)
+			event.Macro = e.Function.Macro
+			if path, ok := renderedFile(e.Function); ok {
+				event.Path = path
+				event.Position = Position{}
+			}
+		case e.Native != nil:
+			event.Function = e.Native.Name()
+			if pkg := e.Native.Package(); pkg != "" {
+				event.Function = pkg + "." + event.Function
+			}
+		}
+		if e.Panic != nil {
+			event.Panic = &PanicError{e.Panic}
+		}
+		t.Trace(event)
+	})
+}